@@ -2,28 +2,89 @@ package api
 
 import (
 	"database/sql"
+	"errors"
+	"fmt"
 	db "github.com/PFefe/simplebank/db/sqlc"
+	"github.com/PFefe/simplebank/util"
 	"github.com/gin-gonic/gin"
 	"net/http"
+	"time"
 )
 
+// accountResponse is the public view of an account, with its amounts in the format of the request
+type accountResponse struct {
+	ID               int64            `json:"id"`
+	Owner            string           `json:"owner"`
+	Balance          amountOutput     `json:"balance"`
+	AvailableBalance amountOutput     `json:"available_balance"`
+	Currency         string           `json:"currency"`
+	OverdraftLimit   amountOutput     `json:"overdraft_limit"`
+	Status           db.AccountStatus `json:"status"`
+	Nickname         string           `json:"nickname"`
+	AccountType      db.AccountType   `json:"account_type"`
+	Version          int64            `json:"version"`
+	CreatedAt        time.Time        `json:"created_at"`
+}
+
+func newAccountResponse(w amountWriter, account db.Account) accountResponse {
+	return accountResponse{
+		ID:    account.ID,
+		Owner: account.Owner,
+		Balance: w.amount(
+			account.Balance,
+			account.Currency,
+		),
+		AvailableBalance: w.amount(
+			account.AvailableBalance,
+			account.Currency,
+		),
+		Currency: account.Currency,
+		OverdraftLimit: w.amount(
+			account.OverdraftLimit,
+			account.Currency,
+		),
+		Status:      account.Status,
+		Nickname:    account.Nickname,
+		AccountType: account.AccountType,
+		Version:     account.Version,
+		CreatedAt:   account.CreatedAt,
+	}
+}
+
+func newAccountResponses(w amountWriter, accounts []db.Account) []accountResponse {
+	rsp := make([]accountResponse, 0, len(accounts))
+	for _, account := range accounts {
+		rsp = append(
+			rsp,
+			newAccountResponse(
+				w,
+				account,
+			),
+		)
+	}
+	return rsp
+}
+
 type createAccountRequest struct {
-	Owner    string `json:"owner" binding:"required"`
 	Currency string `json:"currency" binding:"required,currency"`
 }
 
 func (server *Server) createAccount(ctx *gin.Context) {
 	var req createAccountRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(
-			http.StatusBadRequest,
-			errorResponse(err),
+		abortWithError(
+			ctx,
+			invalidRequestError(
+				ctx,
+				err,
+			),
 		)
 		return
 	}
 
+	authPayload := authPayload(ctx)
 	arg := db.CreateAccountParams{
-		Owner:    req.Owner,
+		Owner:    authPayload.Username,
 		Currency: req.Currency,
 		Balance:  0,
 	}
@@ -33,16 +94,23 @@ func (server *Server) createAccount(ctx *gin.Context) {
 		arg,
 	)
 	if err != nil {
-		ctx.JSON(
-			http.StatusInternalServerError,
-			errorResponse(err),
+		abortWithError(
+			ctx,
+			err,
 		)
 		return
 	}
 
+	ctx.Header(
+		"ETag",
+		accountETag(account),
+	)
 	ctx.JSON(
 		http.StatusOK,
-		account,
+		newAccountResponse(
+			server.amountWriter(ctx),
+			account,
+		),
 	)
 }
 
@@ -53,9 +121,12 @@ type getAccountRequest struct {
 func (server *Server) getAccount(ctx *gin.Context) {
 	var req getAccountRequest
 	if err := ctx.ShouldBindUri(&req); err != nil {
-		ctx.JSON(
-			http.StatusBadRequest,
-			errorResponse(err),
+		abortWithError(
+			ctx,
+			invalidRequestError(
+				ctx,
+				err,
+			),
 		)
 		return
 	}
@@ -65,61 +136,396 @@ func (server *Server) getAccount(ctx *gin.Context) {
 		req.ID,
 	)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			ctx.JSON(
-				http.StatusNotFound,
-				errorResponse(err),
+		abortWithError(
+			ctx,
+			err,
+		)
+		return
+	}
+
+	if !authorizeAccount(
+		ctx,
+		account,
+		staffRoles...,
+	) {
+		return
+	}
+
+	ctx.Header(
+		"ETag",
+		accountETag(account),
+	)
+	ctx.JSON(
+		http.StatusOK,
+		newAccountResponse(
+			server.amountWriter(ctx),
+			account,
+		),
+	)
+}
+
+type updateAccountRequest struct {
+	Nickname       *string      `json:"nickname" binding:"omitempty,max=64"`
+	AccountType    *string      `json:"account_type" binding:"omitempty,oneof=checking savings"`
+	OverdraftLimit *amountInput `json:"overdraft_limit"`
+}
+
+// updateAccount changes the non-monetary fields of an account. The client must send the
+// ETag it last saw in If-Match, so that it cannot overwrite a change it has not seen.
+func (server *Server) updateAccount(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		abortWithError(
+			ctx,
+			invalidRequestError(
+				ctx,
+				err,
+			),
+		)
+		return
+	}
+
+	var req updateAccountRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		abortWithError(
+			ctx,
+			invalidRequestError(
+				ctx,
+				err,
+			),
+		)
+		return
+	}
+	if req.Nickname == nil && req.AccountType == nil && req.OverdraftLimit == nil {
+		abortWithError(
+			ctx,
+			newAPIError(
+				http.StatusBadRequest,
+				codeInvalidRequest,
+				"request has no fields to update",
+			),
+		)
+		return
+	}
+
+	ifMatch := ctx.GetHeader("If-Match")
+	if ifMatch == "" {
+		abortWithError(
+			ctx,
+			newAPIError(
+				http.StatusPreconditionRequired,
+				codePreconditionFailed,
+				"If-Match header with the account ETag is required",
+			),
+		)
+		return
+	}
+
+	isBanker := hasRole(
+		authPayload(ctx),
+		util.BankerRole,
+	)
+	if req.OverdraftLimit != nil && !isBanker {
+		abortWithError(
+			ctx,
+			newAPIError(
+				http.StatusForbidden,
+				codeForbidden,
+				"only bankers can change the overdraft limit",
+			),
+		)
+		return
+	}
+
+	account, err := server.store.GetAccount(
+		ctx,
+		uri.ID,
+	)
+	if err != nil {
+		abortWithError(
+			ctx,
+			err,
+		)
+		return
+	}
+
+	if !authorizeAccount(
+		ctx,
+		account,
+		util.BankerRole,
+	) {
+		return
+	}
+
+	if ifMatch != accountETag(account) {
+		abortWithError(
+			ctx,
+			errAccountModified,
+		)
+		return
+	}
+
+	arg := db.UpdateAccountParams{
+		ID:      account.ID,
+		Version: account.Version,
+	}
+	if req.OverdraftLimit != nil {
+		overdraftLimit, apiErr := server.parseAmount(
+			ctx,
+			"overdraft_limit",
+			*req.OverdraftLimit,
+			account.Currency,
+		)
+		if apiErr != nil {
+			abortWithError(
+				ctx,
+				apiErr,
 			)
 			return
 		}
+		arg.OverdraftLimit = sql.NullInt64{
+			Int64: overdraftLimit,
+			Valid: true,
+		}
+	}
+	if req.Nickname != nil {
+		arg.Nickname = sql.NullString{
+			String: *req.Nickname,
+			Valid:  true,
+		}
+	}
+	if req.AccountType != nil {
+		arg.AccountType = db.NullAccountType{
+			AccountType: db.AccountType(*req.AccountType),
+			Valid:       true,
+		}
+	}
 
-		ctx.JSON(
-			http.StatusInternalServerError,
-			errorResponse(err),
+	account, err = server.store.UpdateAccount(
+		ctx,
+		arg,
+	)
+	if err != nil {
+		// the version changed since the account was read
+		if errors.Is(
+			err,
+			sql.ErrNoRows,
+		) {
+			err = errAccountModified
+		}
+		abortWithError(
+			ctx,
+			err,
 		)
 		return
 	}
 
+	ctx.Header(
+		"ETag",
+		accountETag(account),
+	)
 	ctx.JSON(
 		http.StatusOK,
-		account,
+		newAccountResponse(
+			server.amountWriter(ctx),
+			account,
+		),
+	)
+}
+
+var errAccountModified = newAPIError(
+	http.StatusPreconditionFailed,
+	codePreconditionFailed,
+	"account was modified, fetch it again to get the current ETag",
+)
+
+// accountETag identifies the version of the account the client has seen
+func accountETag(account db.Account) string {
+	return fmt.Sprintf(
+		`"%d"`,
+		account.Version,
 	)
 }
 
 type listAccountRequest struct {
-	PageID   int32 `form:"page_id" binding:"required,min=1"`
-	PageSize int32 `form:"page_size" binding:"required,min=5,max=10"`
+	pageRequest
 }
 
 func (server *Server) listAccounts(ctx *gin.Context) {
 	var req listAccountRequest
 	if err := ctx.ShouldBindQuery(&req); err != nil {
+		abortWithError(
+			ctx,
+			invalidRequestError(
+				ctx,
+				err,
+			),
+		)
+		return
+	}
+
+	page, apiErr := server.page(req.pageRequest)
+	if apiErr != nil {
+		abortWithError(
+			ctx,
+			apiErr,
+		)
+		return
+	}
+
+	authPayload := authPayload(ctx)
+	if page.byOffset {
+		arg := db.ListAccountsParams{
+			Owner:  authPayload.Username,
+			Limit:  page.size,
+			Offset: page.offset,
+		}
+
+		accounts, err := server.store.ListAccounts(
+			ctx,
+			arg,
+		)
+		if err != nil {
+			abortWithError(
+				ctx,
+				err,
+			)
+			return
+		}
+
 		ctx.JSON(
-			http.StatusBadRequest,
-			errorResponse(err),
+			http.StatusOK,
+			newAccountResponses(
+				server.amountWriter(ctx),
+				accounts,
+			),
+		)
+		return
+	}
+
+	position := page.position()
+	arg := db.ListAccountsAfterParams{
+		Owner:           authPayload.Username,
+		CursorCreatedAt: position.CreatedAt,
+		CursorID:        position.ID,
+		Limit:           page.limit(),
+	}
+
+	var accounts []db.Account
+	var err error
+	if page.forward() {
+		accounts, err = server.store.ListAccountsAfter(
+			ctx,
+			arg,
+		)
+	} else {
+		accounts, err = server.store.ListAccountsBefore(
+			ctx,
+			db.ListAccountsBeforeParams(arg),
+		)
+	}
+	if err != nil {
+		abortWithError(
+			ctx,
+			err,
+		)
+		return
+	}
+
+	ctx.JSON(
+		http.StatusOK,
+		newPageResponse(
+			server,
+			page,
+			newAccountResponses(
+				server.amountWriter(ctx),
+				accounts,
+			),
+			func(account accountResponse) pageCursor {
+				return pageCursor{
+					CreatedAt: account.CreatedAt,
+					ID:        account.ID,
+				}
+			},
+		),
+	)
+}
+
+func (server *Server) freezeAccount(ctx *gin.Context) {
+	server.changeAccountStatus(
+		ctx,
+		db.AccountStatusFrozen,
+	)
+}
+
+func (server *Server) unfreezeAccount(ctx *gin.Context) {
+	server.changeAccountStatus(
+		ctx,
+		db.AccountStatusActive,
+	)
+}
+
+// closeAccount soft-closes the account so that its entries and transfers survive
+func (server *Server) closeAccount(ctx *gin.Context) {
+	server.changeAccountStatus(
+		ctx,
+		db.AccountStatusClosed,
+	)
+}
+
+func (server *Server) changeAccountStatus(ctx *gin.Context, status db.AccountStatus) {
+	var req getAccountRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		abortWithError(
+			ctx,
+			invalidRequestError(
+				ctx,
+				err,
+			),
 		)
 		return
 	}
 
-	arg := db.ListAccountsParams{
-		Limit:  req.PageSize,
-		Offset: (req.PageID - 1) * req.PageSize,
+	account, err := server.store.GetAccount(
+		ctx,
+		req.ID,
+	)
+	if err != nil {
+		abortWithError(
+			ctx,
+			err,
+		)
+		return
 	}
 
-	accounts, err := server.store.ListAccounts(
+	if !authorizeAccount(
 		ctx,
-		arg,
+		account,
+		util.BankerRole,
+	) {
+		return
+	}
+
+	account, err = server.store.ChangeAccountStatusTx(
+		ctx,
+		db.ChangeAccountStatusTxParams{
+			AccountID: account.ID,
+			Status:    status,
+		},
 	)
 	if err != nil {
-		ctx.JSON(
-			http.StatusInternalServerError,
-			errorResponse(err),
+		abortWithError(
+			ctx,
+			err,
 		)
 		return
 	}
 
 	ctx.JSON(
 		http.StatusOK,
-		accounts,
+		newAccountResponse(
+			server.amountWriter(ctx),
+			account,
+		),
 	)
 }
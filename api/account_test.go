@@ -7,27 +7,117 @@ import (
 	"fmt"
 	mockdb "github.com/PFefe/simplebank/db/mock"
 	db "github.com/PFefe/simplebank/db/sqlc"
+	"github.com/PFefe/simplebank/token"
 	"github.com/PFefe/simplebank/util"
+	"github.com/gin-gonic/gin"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/require"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
+	"time"
 )
 
 func TestGetAccountAPI(t *testing.T) {
-	account := RandomAccount()
+	user, _ := RandomUser(t)
+	account := RandomAccount(user.Username)
 
 	testCases := []struct {
 		name         string
 		accountID    int64
+		setupAuth    func(t *testing.T, request *http.Request, tokenMaker token.Maker)
 		buildStubs   func(store *mockdb.MockStore)
 		checkRespose func(t *testing.T, recorder *httptest.ResponseRecorder)
 	}{
 		{
 			name:      "OK",
 			accountID: account.ID,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(
+					t,
+					request,
+					tokenMaker,
+					authorizationTypeBearer,
+					user.Username,
+					util.DepositorRole,
+					time.Minute,
+				)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Eq(account.ID),
+					).
+					Times(1).
+					Return(
+						account,
+						nil,
+					)
+			},
+			checkRespose: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusOK,
+					recorder.Code,
+				)
+				requireBodyMatchAccount(
+					t,
+					recorder.Body,
+					account,
+				)
+			},
+		},
+		{
+			name:      "UnauthorizedUser",
+			accountID: account.ID,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(
+					t,
+					request,
+					tokenMaker,
+					authorizationTypeBearer,
+					"unauthorized_user",
+					util.DepositorRole,
+					time.Minute,
+				)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Eq(account.ID),
+					).
+					Times(1).
+					Return(
+						account,
+						nil,
+					)
+			},
+			checkRespose: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusForbidden,
+					recorder.Code,
+				)
+			},
+		},
+		{
+			name:      "TellerViewsAnyAccount",
+			accountID: account.ID,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(
+					t,
+					request,
+					tokenMaker,
+					authorizationTypeBearer,
+					"teller",
+					util.TellerRole,
+					time.Minute,
+				)
+			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
 					GetAccount(
@@ -53,9 +143,41 @@ func TestGetAccountAPI(t *testing.T) {
 				)
 			},
 		},
+		{
+			name:      "NoAuthorization",
+			accountID: account.ID,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(0)
+			},
+			checkRespose: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusUnauthorized,
+					recorder.Code,
+				)
+			},
+		},
 		{
 			name:      "NotFound",
 			accountID: account.ID,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(
+					t,
+					request,
+					tokenMaker,
+					authorizationTypeBearer,
+					user.Username,
+					util.DepositorRole,
+					time.Minute,
+				)
+			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
 					GetAccount(
@@ -79,6 +201,17 @@ func TestGetAccountAPI(t *testing.T) {
 		{
 			name:      "InternalError",
 			accountID: account.ID,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(
+					t,
+					request,
+					tokenMaker,
+					authorizationTypeBearer,
+					user.Username,
+					util.DepositorRole,
+					time.Minute,
+				)
+			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
 					GetAccount(
@@ -102,6 +235,17 @@ func TestGetAccountAPI(t *testing.T) {
 		{
 			name:      "InvalidID",
 			accountID: 0,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(
+					t,
+					request,
+					tokenMaker,
+					authorizationTypeBearer,
+					user.Username,
+					util.DepositorRole,
+					time.Minute,
+				)
+			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().GetAccount(
 					gomock.Any(),
@@ -129,7 +273,10 @@ func TestGetAccountAPI(t *testing.T) {
 				store := mockdb.NewMockStore(ctrl)
 				tc.buildStubs(store)
 
-				server := NewServer(store)
+				server := newTestServer(
+					t,
+					store,
+				)
 				recorder := httptest.NewRecorder()
 
 				url := fmt.Sprintf(
@@ -145,6 +292,12 @@ func TestGetAccountAPI(t *testing.T) {
 					t,
 					err,
 				)
+
+				tc.setupAuth(
+					t,
+					request,
+					server.tokenMaker,
+				)
 				server.router.ServeHTTP(
 					recorder,
 					request,
@@ -159,37 +312,1478 @@ func TestGetAccountAPI(t *testing.T) {
 	}
 }
 
-func RandomAccount() db.Account {
-	return db.Account{
-		ID: util.RandomInt(
-			1,
-			1000,
-		),
-		Owner:    util.RandomOwner(),
-		Balance:  util.RandomMoney(),
-		Currency: util.RandomCurrency(),
+func TestCreateAccountAPI(t *testing.T) {
+	user, _ := RandomUser(t)
+	account := RandomAccount(user.Username)
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{
+				"currency": account.Currency,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(
+					t,
+					request,
+					tokenMaker,
+					authorizationTypeBearer,
+					user.Username,
+					util.DepositorRole,
+					time.Minute,
+				)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.CreateAccountParams{
+					Owner:    account.Owner,
+					Currency: account.Currency,
+					Balance:  0,
+				}
+				store.EXPECT().
+					CreateAccount(
+						gomock.Any(),
+						gomock.Eq(arg),
+					).
+					Times(1).
+					Return(
+						account,
+						nil,
+					)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusOK,
+					recorder.Code,
+				)
+				requireBodyMatchAccount(
+					t,
+					recorder.Body,
+					account,
+				)
+			},
+		},
+		{
+			name: "NoAuthorization",
+			body: gin.H{
+				"currency": account.Currency,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateAccount(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusUnauthorized,
+					recorder.Code,
+				)
+			},
+		},
+		{
+			name: "InvalidCurrency",
+			body: gin.H{
+				"currency": "invalid",
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(
+					t,
+					request,
+					tokenMaker,
+					authorizationTypeBearer,
+					user.Username,
+					util.DepositorRole,
+					time.Minute,
+				)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateAccount(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusBadRequest,
+					recorder.Code,
+				)
+				requireBodyMatchFieldErrors(
+					t,
+					recorder.Body,
+					[]fieldError{
+						{
+							Field:   "currency",
+							Rule:    "currency",
+							Message: "must be a supported currency code",
+						},
+					},
+				)
+			},
+		},
+		{
+			name: "InternalError",
+			body: gin.H{
+				"currency": account.Currency,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(
+					t,
+					request,
+					tokenMaker,
+					authorizationTypeBearer,
+					user.Username,
+					util.DepositorRole,
+					time.Minute,
+				)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateAccount(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(1).
+					Return(
+						db.Account{},
+						sql.ErrConnDone,
+					)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusInternalServerError,
+					recorder.Code,
+				)
+			},
+		},
 	}
 
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(
+			tc.name,
+			func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+
+				store := mockdb.NewMockStore(ctrl)
+				tc.buildStubs(store)
+
+				server := newTestServer(
+					t,
+					store,
+				)
+				recorder := httptest.NewRecorder()
+
+				data, err := json.Marshal(tc.body)
+				require.NoError(
+					t,
+					err,
+				)
+
+				request, err := http.NewRequest(
+					http.MethodPost,
+					"/accounts",
+					bytes.NewReader(data),
+				)
+				require.NoError(
+					t,
+					err,
+				)
+
+				tc.setupAuth(
+					t,
+					request,
+					server.tokenMaker,
+				)
+				server.router.ServeHTTP(
+					recorder,
+					request,
+				)
+
+				tc.checkResponse(
+					t,
+					recorder,
+				)
+			},
+		)
+	}
 }
 
-func requireBodyMatchAccount(t *testing.T, body *bytes.Buffer, account db.Account) {
-	data, err := io.ReadAll(body)
-	require.NoError(
-		t,
-		err,
-	)
-	var gotAccount db.Account
-	err = json.Unmarshal(
-		data,
-		&gotAccount,
-	)
-	require.NoError(
-		t,
-		err,
-	)
-	require.Equal(
-		t,
-		account,
-		gotAccount,
+func TestListAccountsAPI(t *testing.T) {
+	user, _ := RandomUser(t)
+
+	n := 5
+	accounts := make(
+		[]db.Account,
+		n,
 	)
+	for i := 0; i < n; i++ {
+		accounts[i] = RandomAccount(user.Username)
+	}
+
+	type Query struct {
+		pageID   int
+		pageSize int
+	}
+
+	testCases := []struct {
+		name          string
+		query         Query
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			query: Query{
+				pageID:   1,
+				pageSize: n,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(
+					t,
+					request,
+					tokenMaker,
+					authorizationTypeBearer,
+					user.Username,
+					util.DepositorRole,
+					time.Minute,
+				)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.ListAccountsParams{
+					Owner:  user.Username,
+					Limit:  int32(n),
+					Offset: 0,
+				}
+				store.EXPECT().
+					ListAccounts(
+						gomock.Any(),
+						gomock.Eq(arg),
+					).
+					Times(1).
+					Return(
+						accounts,
+						nil,
+					)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusOK,
+					recorder.Code,
+				)
+				requireBodyMatchAccounts(
+					t,
+					recorder.Body,
+					accounts,
+				)
+			},
+		},
+		{
+			name: "NoAuthorization",
+			query: Query{
+				pageID:   1,
+				pageSize: n,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListAccounts(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusUnauthorized,
+					recorder.Code,
+				)
+			},
+		},
+		{
+			name: "InvalidPageSize",
+			query: Query{
+				pageID:   1,
+				pageSize: 100000,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(
+					t,
+					request,
+					tokenMaker,
+					authorizationTypeBearer,
+					user.Username,
+					util.DepositorRole,
+					time.Minute,
+				)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListAccounts(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusBadRequest,
+					recorder.Code,
+				)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(
+			tc.name,
+			func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+
+				store := mockdb.NewMockStore(ctrl)
+				tc.buildStubs(store)
+
+				server := newTestServer(
+					t,
+					store,
+				)
+				recorder := httptest.NewRecorder()
+
+				request, err := http.NewRequest(
+					http.MethodGet,
+					"/accounts",
+					nil,
+				)
+				require.NoError(
+					t,
+					err,
+				)
+
+				q := request.URL.Query()
+				q.Add(
+					"page_id",
+					fmt.Sprintf(
+						"%d",
+						tc.query.pageID,
+					),
+				)
+				q.Add(
+					"page_size",
+					fmt.Sprintf(
+						"%d",
+						tc.query.pageSize,
+					),
+				)
+				request.URL.RawQuery = q.Encode()
+
+				tc.setupAuth(
+					t,
+					request,
+					server.tokenMaker,
+				)
+				server.router.ServeHTTP(
+					recorder,
+					request,
+				)
+
+				tc.checkResponse(
+					t,
+					recorder,
+				)
+			},
+		)
+	}
+}
+
+func TestChangeAccountStatusAPI(t *testing.T) {
+	user, _ := RandomUser(t)
+	otherUser, _ := RandomUser(t)
+	banker, _ := RandomUser(t)
+	account := RandomAccount(user.Username)
+
+	testCases := []struct {
+		name          string
+		method        string
+		path          string
+		username      string
+		role          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "Freeze",
+			method:   http.MethodPost,
+			path:     "/freeze",
+			username: banker.Username,
+			role:     util.BankerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Eq(account.ID),
+					).
+					Times(1).
+					Return(
+						account,
+						nil,
+					)
+
+				frozen := account
+				frozen.Status = db.AccountStatusFrozen
+				arg := db.ChangeAccountStatusTxParams{
+					AccountID: account.ID,
+					Status:    db.AccountStatusFrozen,
+				}
+				store.EXPECT().
+					ChangeAccountStatusTx(
+						gomock.Any(),
+						gomock.Eq(arg),
+					).
+					Times(1).
+					Return(
+						frozen,
+						nil,
+					)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusOK,
+					recorder.Code,
+				)
+
+				frozen := account
+				frozen.Status = db.AccountStatusFrozen
+				requireBodyMatchAccount(
+					t,
+					recorder.Body,
+					frozen,
+				)
+			},
+		},
+		{
+			name:     "Unfreeze",
+			method:   http.MethodPost,
+			path:     "/unfreeze",
+			username: banker.Username,
+			role:     util.BankerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Eq(account.ID),
+					).
+					Times(1).
+					Return(
+						account,
+						nil,
+					)
+
+				arg := db.ChangeAccountStatusTxParams{
+					AccountID: account.ID,
+					Status:    db.AccountStatusActive,
+				}
+				store.EXPECT().
+					ChangeAccountStatusTx(
+						gomock.Any(),
+						gomock.Eq(arg),
+					).
+					Times(1).
+					Return(
+						db.Account{},
+						fmt.Errorf(
+							"account [%d] cannot go from active to active: %w",
+							account.ID,
+							db.ErrInvalidStatusTransition,
+						),
+					)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusConflict,
+					recorder.Code,
+				)
+			},
+		},
+		{
+			name:     "Delete",
+			method:   http.MethodDelete,
+			path:     "",
+			username: user.Username,
+			role:     util.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Eq(account.ID),
+					).
+					Times(1).
+					Return(
+						account,
+						nil,
+					)
+
+				closed := account
+				closed.Balance = 0
+				closed.Status = db.AccountStatusClosed
+				arg := db.ChangeAccountStatusTxParams{
+					AccountID: account.ID,
+					Status:    db.AccountStatusClosed,
+				}
+				store.EXPECT().
+					ChangeAccountStatusTx(
+						gomock.Any(),
+						gomock.Eq(arg),
+					).
+					Times(1).
+					Return(
+						closed,
+						nil,
+					)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusOK,
+					recorder.Code,
+				)
+			},
+		},
+		{
+			name:     "CloseWithBalance",
+			method:   http.MethodPost,
+			path:     "/close",
+			username: user.Username,
+			role:     util.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Eq(account.ID),
+					).
+					Times(1).
+					Return(
+						account,
+						nil,
+					)
+				store.EXPECT().
+					ChangeAccountStatusTx(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(1).
+					Return(
+						db.Account{},
+						db.ErrAccountBalanceNotZero,
+					)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusConflict,
+					recorder.Code,
+				)
+			},
+		},
+		{
+			name:     "UnauthorizedUser",
+			method:   http.MethodPost,
+			path:     "/close",
+			username: otherUser.Username,
+			role:     util.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Eq(account.ID),
+					).
+					Times(1).
+					Return(
+						account,
+						nil,
+					)
+				store.EXPECT().
+					ChangeAccountStatusTx(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusForbidden,
+					recorder.Code,
+				)
+			},
+		},
+		{
+			name:     "NotBanker",
+			method:   http.MethodPost,
+			path:     "/freeze",
+			username: user.Username,
+			role:     util.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(0)
+				store.EXPECT().
+					ChangeAccountStatusTx(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusForbidden,
+					recorder.Code,
+				)
+			},
+		},
+		{
+			name:     "NotFound",
+			method:   http.MethodPost,
+			path:     "/close",
+			username: user.Username,
+			role:     util.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Eq(account.ID),
+					).
+					Times(1).
+					Return(
+						db.Account{},
+						sql.ErrNoRows,
+					)
+				store.EXPECT().
+					ChangeAccountStatusTx(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusNotFound,
+					recorder.Code,
+				)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(
+			tc.name,
+			func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+
+				store := mockdb.NewMockStore(ctrl)
+				tc.buildStubs(store)
+
+				server := newTestServer(
+					t,
+					store,
+				)
+				recorder := httptest.NewRecorder()
+
+				url := fmt.Sprintf(
+					"/accounts/%d%s",
+					account.ID,
+					tc.path,
+				)
+				request, err := http.NewRequest(
+					tc.method,
+					url,
+					nil,
+				)
+				require.NoError(
+					t,
+					err,
+				)
+
+				addAuthorization(
+					t,
+					request,
+					server.tokenMaker,
+					authorizationTypeBearer,
+					tc.username,
+					tc.role,
+					time.Minute,
+				)
+				server.router.ServeHTTP(
+					recorder,
+					request,
+				)
+
+				tc.checkResponse(
+					t,
+					recorder,
+				)
+			},
+		)
+	}
+}
+
+func TestUpdateAccountAPI(t *testing.T) {
+	user, _ := RandomUser(t)
+	otherUser, _ := RandomUser(t)
+	banker, _ := RandomUser(t)
+	account := RandomAccount(user.Username)
+	nickname := util.RandomOwner()
+
+	updated := account
+	updated.Nickname = nickname
+	updated.Version++
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		ifMatch       string
+		username      string
+		role          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{
+				"nickname": nickname,
+			},
+			ifMatch:  accountETag(account),
+			username: user.Username,
+			role:     util.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Eq(account.ID),
+					).
+					Times(1).
+					Return(
+						account,
+						nil,
+					)
+
+				arg := db.UpdateAccountParams{
+					ID:      account.ID,
+					Version: account.Version,
+					Nickname: sql.NullString{
+						String: nickname,
+						Valid:  true,
+					},
+				}
+				store.EXPECT().
+					UpdateAccount(
+						gomock.Any(),
+						gomock.Eq(arg),
+					).
+					Times(1).
+					Return(
+						updated,
+						nil,
+					)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusOK,
+					recorder.Code,
+				)
+				require.Equal(
+					t,
+					accountETag(updated),
+					recorder.Header().Get("ETag"),
+				)
+				requireBodyMatchAccount(
+					t,
+					recorder.Body,
+					updated,
+				)
+			},
+		},
+		{
+			name: "BankerOverdraftLimit",
+			body: gin.H{
+				"overdraft_limit": "5.00",
+			},
+			ifMatch:  accountETag(account),
+			username: banker.Username,
+			role:     util.BankerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Eq(account.ID),
+					).
+					Times(1).
+					Return(
+						account,
+						nil,
+					)
+
+				arg := db.UpdateAccountParams{
+					ID:      account.ID,
+					Version: account.Version,
+					OverdraftLimit: sql.NullInt64{
+						Int64: 500,
+						Valid: true,
+					},
+				}
+				store.EXPECT().
+					UpdateAccount(
+						gomock.Any(),
+						gomock.Eq(arg),
+					).
+					Times(1).
+					Return(
+						account,
+						nil,
+					)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusOK,
+					recorder.Code,
+				)
+			},
+		},
+		{
+			name: "OverdraftLimitNotBanker",
+			body: gin.H{
+				"overdraft_limit": "5.00",
+			},
+			ifMatch:  accountETag(account),
+			username: user.Username,
+			role:     util.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpdateAccount(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusForbidden,
+					recorder.Code,
+				)
+			},
+		},
+		{
+			name: "NoFields",
+			body: gin.H{
+				"balance": 1000000,
+			},
+			ifMatch:  accountETag(account),
+			username: user.Username,
+			role:     util.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusBadRequest,
+					recorder.Code,
+				)
+			},
+		},
+		{
+			name: "InvalidAccountType",
+			body: gin.H{
+				"account_type": "brokerage",
+			},
+			ifMatch:  accountETag(account),
+			username: user.Username,
+			role:     util.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusBadRequest,
+					recorder.Code,
+				)
+			},
+		},
+		{
+			name: "MissingIfMatch",
+			body: gin.H{
+				"nickname": nickname,
+			},
+			ifMatch:  "",
+			username: user.Username,
+			role:     util.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusPreconditionRequired,
+					recorder.Code,
+				)
+			},
+		},
+		{
+			name: "StaleETag",
+			body: gin.H{
+				"nickname": nickname,
+			},
+			ifMatch:  `"0"`,
+			username: user.Username,
+			role:     util.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Eq(account.ID),
+					).
+					Times(1).
+					Return(
+						account,
+						nil,
+					)
+				store.EXPECT().
+					UpdateAccount(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusPreconditionFailed,
+					recorder.Code,
+				)
+			},
+		},
+		{
+			name: "ConcurrentUpdate",
+			body: gin.H{
+				"nickname": nickname,
+			},
+			ifMatch:  accountETag(account),
+			username: user.Username,
+			role:     util.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Eq(account.ID),
+					).
+					Times(1).
+					Return(
+						account,
+						nil,
+					)
+				store.EXPECT().
+					UpdateAccount(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(1).
+					Return(
+						db.Account{},
+						sql.ErrNoRows,
+					)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusPreconditionFailed,
+					recorder.Code,
+				)
+			},
+		},
+		{
+			name: "UnauthorizedUser",
+			body: gin.H{
+				"nickname": nickname,
+			},
+			ifMatch:  accountETag(account),
+			username: otherUser.Username,
+			role:     util.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Eq(account.ID),
+					).
+					Times(1).
+					Return(
+						account,
+						nil,
+					)
+				store.EXPECT().
+					UpdateAccount(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusForbidden,
+					recorder.Code,
+				)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(
+			tc.name,
+			func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+
+				store := mockdb.NewMockStore(ctrl)
+				tc.buildStubs(store)
+
+				server := newTestServer(
+					t,
+					store,
+				)
+				recorder := httptest.NewRecorder()
+
+				data, err := json.Marshal(tc.body)
+				require.NoError(
+					t,
+					err,
+				)
+
+				url := fmt.Sprintf(
+					"/accounts/%d",
+					account.ID,
+				)
+				request, err := http.NewRequest(
+					http.MethodPatch,
+					url,
+					bytes.NewReader(data),
+				)
+				require.NoError(
+					t,
+					err,
+				)
+				if tc.ifMatch != "" {
+					request.Header.Set(
+						"If-Match",
+						tc.ifMatch,
+					)
+				}
+
+				addAuthorization(
+					t,
+					request,
+					server.tokenMaker,
+					authorizationTypeBearer,
+					tc.username,
+					tc.role,
+					time.Minute,
+				)
+				server.router.ServeHTTP(
+					recorder,
+					request,
+				)
+
+				tc.checkResponse(
+					t,
+					recorder,
+				)
+			},
+		)
+	}
+}
+
+func RandomAccount(owner string) db.Account {
+	balance := util.RandomMoney()
+	return db.Account{
+		ID: util.RandomInt(
+			1,
+			1000,
+		),
+		Owner:            owner,
+		Balance:          balance,
+		AvailableBalance: balance,
+		Currency:         util.RandomCurrency(),
+		Status:           db.AccountStatusActive,
+		AccountType:      db.AccountTypeChecking,
+		Version:          1,
+	}
+
+}
+
+func requireBodyMatchAccount(t *testing.T, body *bytes.Buffer, account db.Account) {
+	data, err := io.ReadAll(body)
+	require.NoError(
+		t,
+		err,
+	)
+	requireBodyMatchJSON(
+		t,
+		data,
+		newAccountResponse(
+			decimalAmounts(t),
+			account,
+		),
+	)
+}
+
+func requireBodyMatchAccounts(t *testing.T, body *bytes.Buffer, accounts []db.Account) {
+	data, err := io.ReadAll(body)
+	require.NoError(
+		t,
+		err,
+	)
+	requireBodyMatchJSON(
+		t,
+		data,
+		newAccountResponses(
+			decimalAmounts(t),
+			accounts,
+		),
+	)
+}
+
+func TestListAccountsAPICursor(t *testing.T) {
+	user, _ := RandomUser(t)
+
+	n := 3
+	accounts := make(
+		[]db.Account,
+		n,
+	)
+	for i := 0; i < n; i++ {
+		accounts[i] = RandomAccount(user.Username)
+		accounts[i].ID = int64(i + 1)
+		accounts[i].CreatedAt = time.Now().UTC().Truncate(time.Second)
+	}
+	cursor := pageCursor{
+		CreatedAt: accounts[0].CreatedAt,
+		ID:        accounts[0].ID,
+	}
+
+	testCases := []struct {
+		name          string
+		query         func(server *Server) url.Values
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, server *Server, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "FirstPage",
+			query: func(server *Server) url.Values {
+				return url.Values{
+					"page_size": {"2"},
+				}
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.ListAccountsAfterParams{
+					Owner: user.Username,
+					Limit: 3,
+				}
+				store.EXPECT().
+					ListAccountsAfter(
+						gomock.Any(),
+						gomock.Eq(arg),
+					).
+					Times(1).
+					Return(
+						accounts,
+						nil,
+					)
+			},
+			checkResponse: func(t *testing.T, server *Server, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusOK,
+					recorder.Code,
+				)
+
+				var rsp pageResponse[json.RawMessage]
+				err := json.Unmarshal(
+					recorder.Body.Bytes(),
+					&rsp,
+				)
+				require.NoError(
+					t,
+					err,
+				)
+				data, err := json.Marshal(rsp.Data)
+				require.NoError(
+					t,
+					err,
+				)
+				requireBodyMatchJSON(
+					t,
+					data,
+					newAccountResponses(
+						decimalAmounts(t),
+						accounts[:2],
+					),
+				)
+				require.Empty(
+					t,
+					rsp.PrevCursor,
+				)
+
+				next, err := server.decodeCursor(rsp.NextCursor)
+				require.NoError(
+					t,
+					err,
+				)
+				require.Equal(
+					t,
+					accounts[1].ID,
+					next.ID,
+				)
+			},
+		},
+		{
+			name: "NextPage",
+			query: func(server *Server) url.Values {
+				return url.Values{
+					"page_size": {"2"},
+					"cursor":    {server.encodeCursor(cursor)},
+				}
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.ListAccountsAfterParams{
+					Owner:           user.Username,
+					CursorCreatedAt: cursor.CreatedAt,
+					CursorID:        cursor.ID,
+					Limit:           3,
+				}
+				store.EXPECT().
+					ListAccountsAfter(
+						gomock.Any(),
+						gomock.Eq(arg),
+					).
+					Times(1).
+					Return(
+						accounts[1:],
+						nil,
+					)
+			},
+			checkResponse: func(t *testing.T, server *Server, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusOK,
+					recorder.Code,
+				)
+
+				var rsp pageResponse[json.RawMessage]
+				err := json.Unmarshal(
+					recorder.Body.Bytes(),
+					&rsp,
+				)
+				require.NoError(
+					t,
+					err,
+				)
+				data, err := json.Marshal(rsp.Data)
+				require.NoError(
+					t,
+					err,
+				)
+				requireBodyMatchJSON(
+					t,
+					data,
+					newAccountResponses(
+						decimalAmounts(t),
+						accounts[1:],
+					),
+				)
+				require.Empty(
+					t,
+					rsp.NextCursor,
+				)
+				require.NotEmpty(
+					t,
+					rsp.PrevCursor,
+				)
+			},
+		},
+		{
+			name: "PrevPage",
+			query: func(server *Server) url.Values {
+				backward := cursor
+				backward.Backward = true
+				return url.Values{
+					"page_size": {"2"},
+					"cursor":    {server.encodeCursor(backward)},
+				}
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.ListAccountsBeforeParams{
+					Owner:           user.Username,
+					CursorCreatedAt: cursor.CreatedAt,
+					CursorID:        cursor.ID,
+					Limit:           3,
+				}
+				store.EXPECT().
+					ListAccountsBefore(
+						gomock.Any(),
+						gomock.Eq(arg),
+					).
+					Times(1).
+					Return(
+						[]db.Account{},
+						nil,
+					)
+			},
+			checkResponse: func(t *testing.T, server *Server, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusOK,
+					recorder.Code,
+				)
+				require.JSONEq(
+					t,
+					`{"data": []}`,
+					recorder.Body.String(),
+				)
+			},
+		},
+		{
+			name: "InvalidCursor",
+			query: func(server *Server) url.Values {
+				return url.Values{
+					"cursor": {"forged"},
+				}
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListAccountsAfter(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, server *Server, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusBadRequest,
+					recorder.Code,
+				)
+				requireBodyMatchFieldErrors(
+					t,
+					recorder.Body,
+					[]fieldError{
+						{
+							Field:   "cursor",
+							Rule:    "cursor",
+							Message: "is not a valid cursor",
+						},
+					},
+				)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(
+			tc.name,
+			func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+
+				store := mockdb.NewMockStore(ctrl)
+				tc.buildStubs(store)
+
+				server := newTestServer(
+					t,
+					store,
+				)
+				recorder := httptest.NewRecorder()
+
+				request, err := http.NewRequest(
+					http.MethodGet,
+					"/accounts?"+tc.query(server).Encode(),
+					nil,
+				)
+				require.NoError(
+					t,
+					err,
+				)
+
+				addAuthorization(
+					t,
+					request,
+					server.tokenMaker,
+					authorizationTypeBearer,
+					user.Username,
+					util.DepositorRole,
+					time.Minute,
+				)
+				server.router.ServeHTTP(
+					recorder,
+					request,
+				)
+
+				tc.checkResponse(
+					t,
+					server,
+					recorder,
+				)
+			},
+		)
+	}
 }
@@ -0,0 +1,257 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/PFefe/simplebank/util"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	amountFormatHeader = "Amount-Format"
+	amountFormatKey    = "amount_format"
+	// amountFormatDecimal reads and writes amounts as decimal strings in the major unit, e.g. "12.34"
+	amountFormatDecimal = "decimal"
+	// amountFormatMinor reads and writes amounts as integers of minor units, e.g. 1234
+	amountFormatMinor = "minor"
+)
+
+// amountFormatMiddleware reads the Amount-Format header of the request, defaulting to decimal amounts
+func amountFormatMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		format := strings.ToLower(ctx.GetHeader(amountFormatHeader))
+		switch format {
+		case "":
+			format = amountFormatDecimal
+		case amountFormatDecimal, amountFormatMinor:
+		default:
+			abortWithError(
+				ctx,
+				newAPIError(
+					http.StatusBadRequest,
+					codeInvalidRequest,
+					fmt.Sprintf(
+						"%s header must be %s or %s",
+						amountFormatHeader,
+						amountFormatDecimal,
+						amountFormatMinor,
+					),
+				),
+			)
+			return
+		}
+
+		ctx.Set(
+			amountFormatKey,
+			format,
+		)
+		ctx.Next()
+	}
+}
+
+func minorAmounts(ctx *gin.Context) bool {
+	return ctx.GetString(amountFormatKey) == amountFormatMinor
+}
+
+// amountInput is an amount exactly as the client sent it: a decimal string,
+// or an integer of minor units when the request uses the minor amount format.
+// It is kept raw, since the currency that gives it a meaning is only known later.
+type amountInput json.RawMessage
+
+// UnmarshalJSON keeps the raw amount; null leaves it unset, like a missing field
+func (a *amountInput) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*a = nil
+		return nil
+	}
+	*a = append(
+		(*a)[0:0],
+		data...,
+	)
+	return nil
+}
+
+func (a amountInput) MarshalJSON() ([]byte, error) {
+	if len(a) == 0 {
+		return []byte("null"), nil
+	}
+	return a, nil
+}
+
+// parseAmount converts an amount of the request body to minor units of the currency
+func (server *Server) parseAmount(ctx *gin.Context, field string, input amountInput, currency string) (int64, *apiError) {
+	quoted := len(input) > 0 && input[0] == '"'
+	if minorAmounts(ctx) {
+		if quoted {
+			return 0, invalidFieldError(
+				field,
+				amountFormatMinor,
+				"must be an integer of minor units",
+			)
+		}
+		return server.amountFromText(
+			ctx,
+			field,
+			string(input),
+			currency,
+		)
+	}
+
+	// a bare number is refused, since it is most likely minor units from a client that predates decimal amounts
+	var text string
+	if !quoted || json.Unmarshal(
+		input,
+		&text,
+	) != nil {
+		return 0, invalidFieldError(
+			field,
+			amountFormatDecimal,
+			`must be a decimal string like "12.34"`,
+		)
+	}
+	return server.amountFromText(
+		ctx,
+		field,
+		text,
+		currency,
+	)
+}
+
+// parsePositiveAmount is parseAmount for amounts that have to be greater than zero
+func (server *Server) parsePositiveAmount(ctx *gin.Context, field string, input amountInput, currency string) (int64, *apiError) {
+	amount, apiErr := server.parseAmount(
+		ctx,
+		field,
+		input,
+		currency,
+	)
+	if apiErr == nil && amount <= 0 {
+		apiErr = invalidFieldError(
+			field,
+			"gt",
+			"must be greater than 0",
+		)
+	}
+	return amount, apiErr
+}
+
+// amountFromText converts an amount written as text, in the body or the query string, to minor units
+func (server *Server) amountFromText(ctx *gin.Context, field string, text string, currency string) (int64, *apiError) {
+	if minorAmounts(ctx) {
+		amount, err := strconv.ParseInt(
+			text,
+			10,
+			64,
+		)
+		if err != nil || amount < 0 {
+			return 0, invalidFieldError(
+				field,
+				amountFormatMinor,
+				"must be an integer of minor units",
+			)
+		}
+		return amount, nil
+	}
+
+	c, ok := server.currencies.Lookup(currency)
+	if !ok {
+		return 0, invalidFieldError(
+			field,
+			"currency",
+			fmt.Sprintf(
+				"cannot be read in the unknown currency %s",
+				currency,
+			),
+		)
+	}
+
+	amount, err := c.ParseAmount(text)
+	if errors.Is(
+		err,
+		util.ErrAmountPrecision,
+	) {
+		return 0, invalidFieldError(
+			field,
+			"precision",
+			fmt.Sprintf(
+				"must have at most %d decimal places in %s",
+				c.MinorUnits,
+				c.Code,
+			),
+		)
+	}
+	if err != nil {
+		return 0, invalidFieldError(
+			field,
+			amountFormatDecimal,
+			`must be a decimal string like "12.34"`,
+		)
+	}
+	return amount, nil
+}
+
+// optionalAmount converts an optional amount of the query string, which has to be greater than zero when given
+func (server *Server) optionalAmount(ctx *gin.Context, field string, text *string, currency string) (*int64, *apiError) {
+	if text == nil {
+		return nil, nil
+	}
+
+	amount, apiErr := server.amountFromText(
+		ctx,
+		field,
+		*text,
+		currency,
+	)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if amount <= 0 {
+		return nil, invalidFieldError(
+			field,
+			"gt",
+			"must be greater than 0",
+		)
+	}
+	return &amount, nil
+}
+
+// amountOutput is an amount of minor units written in the amount format of the request
+type amountOutput struct {
+	amount   int64
+	currency util.Currency
+	minor    bool
+}
+
+func (a amountOutput) MarshalJSON() ([]byte, error) {
+	if a.minor {
+		return json.Marshal(a.amount)
+	}
+	return json.Marshal(a.currency.DecimalString(a.amount))
+}
+
+// amountWriter builds the amounts of one response
+type amountWriter struct {
+	currencies *util.CurrencyRegistry
+	minor      bool
+}
+
+func (server *Server) amountWriter(ctx *gin.Context) amountWriter {
+	return amountWriter{
+		currencies: server.currencies,
+		minor:      minorAmounts(ctx),
+	}
+}
+
+// amount writes an amount of the currency; a code missing from the ISO table is written without decimals
+func (w amountWriter) amount(amount int64, currency string) amountOutput {
+	c, _ := w.currencies.Lookup(currency)
+	return amountOutput{
+		amount:   amount,
+		currency: c,
+		minor:    w.minor,
+	}
+}
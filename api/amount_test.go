@@ -0,0 +1,329 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	mockdb "github.com/PFefe/simplebank/db/mock"
+	db "github.com/PFefe/simplebank/db/sqlc"
+	"github.com/PFefe/simplebank/util"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTransferAmountFormat(t *testing.T) {
+	user1, _ := RandomUser(t)
+	user2, _ := RandomUser(t)
+
+	account1 := RandomAccount(user1.Username)
+	account2 := RandomAccount(user2.Username)
+	account1.Currency = util.USD
+	account2.Currency = util.USD
+
+	result := db.TransferTxResult{
+		Transfer: db.Transfer{
+			ID:            1,
+			FromAccountID: account1.ID,
+			ToAccountID:   account2.ID,
+			Amount:        1234,
+			Currency:      util.USD,
+			ToAmount:      1234,
+			ToCurrency:    util.USD,
+			ExchangeRate:  "1",
+			Spread:        "0",
+		},
+		FromAccount: account1,
+		ToAccount:   account2,
+	}
+
+	expectTransfer := func(store *mockdb.MockStore) {
+		store.EXPECT().
+			GetAccount(
+				gomock.Any(),
+				gomock.Eq(account1.ID),
+			).
+			Times(1).
+			Return(
+				account1,
+				nil,
+			)
+		store.EXPECT().
+			GetAccount(
+				gomock.Any(),
+				gomock.Eq(account2.ID),
+			).
+			Times(1).
+			Return(
+				account2,
+				nil,
+			)
+		store.EXPECT().
+			TransferTx(
+				gomock.Any(),
+				gomock.Eq(db.TransferTxParams{
+					FromAccountID: account1.ID,
+					ToAccountID:   account2.ID,
+					Amount:        1234,
+				}),
+			).
+			Times(1).
+			Return(
+				result,
+				nil,
+			)
+	}
+
+	expectNoTransfer := func(store *mockdb.MockStore) {
+		store.EXPECT().
+			GetAccount(
+				gomock.Any(),
+				gomock.Any(),
+			).
+			Times(0)
+		store.EXPECT().
+			TransferTx(
+				gomock.Any(),
+				gomock.Any(),
+			).
+			Times(0)
+	}
+
+	testCases := []struct {
+		name          string
+		format        string
+		amount        interface{}
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:       "Decimal",
+			amount:     "12.34",
+			buildStubs: expectTransfer,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusOK,
+					recorder.Code,
+				)
+
+				var rsp struct {
+					Transfer struct {
+						Amount string `json:"amount"`
+					} `json:"transfer"`
+				}
+				err := json.Unmarshal(
+					recorder.Body.Bytes(),
+					&rsp,
+				)
+				require.NoError(
+					t,
+					err,
+				)
+				require.Equal(
+					t,
+					"12.34",
+					rsp.Transfer.Amount,
+				)
+			},
+		},
+		{
+			name:       "Minor",
+			format:     amountFormatMinor,
+			amount:     1234,
+			buildStubs: expectTransfer,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusOK,
+					recorder.Code,
+				)
+
+				var rsp struct {
+					Transfer struct {
+						Amount int64 `json:"amount"`
+					} `json:"transfer"`
+				}
+				err := json.Unmarshal(
+					recorder.Body.Bytes(),
+					&rsp,
+				)
+				require.NoError(
+					t,
+					err,
+				)
+				require.Equal(
+					t,
+					int64(1234),
+					rsp.Transfer.Amount,
+				)
+			},
+		},
+		{
+			name:       "ExcessPrecision",
+			amount:     "12.345",
+			buildStubs: expectNoTransfer,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusBadRequest,
+					recorder.Code,
+				)
+				requireBodyMatchFieldErrors(
+					t,
+					recorder.Body,
+					[]fieldError{
+						{
+							Field:   "amount",
+							Rule:    "precision",
+							Message: "must have at most 2 decimal places in USD",
+						},
+					},
+				)
+			},
+		},
+		{
+			name:       "BareNumber",
+			amount:     1234,
+			buildStubs: expectNoTransfer,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusBadRequest,
+					recorder.Code,
+				)
+				requireBodyMatchFieldErrors(
+					t,
+					recorder.Body,
+					[]fieldError{
+						{
+							Field:   "amount",
+							Rule:    amountFormatDecimal,
+							Message: `must be a decimal string like "12.34"`,
+						},
+					},
+				)
+			},
+		},
+		{
+			name:       "MinorString",
+			format:     amountFormatMinor,
+			amount:     "12.34",
+			buildStubs: expectNoTransfer,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusBadRequest,
+					recorder.Code,
+				)
+				requireBodyMatchFieldErrors(
+					t,
+					recorder.Body,
+					[]fieldError{
+						{
+							Field:   "amount",
+							Rule:    amountFormatMinor,
+							Message: "must be an integer of minor units",
+						},
+					},
+				)
+			},
+		},
+		{
+			name:       "Zero",
+			amount:     "0.00",
+			buildStubs: expectNoTransfer,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusBadRequest,
+					recorder.Code,
+				)
+			},
+		},
+		{
+			name:       "InvalidFormatHeader",
+			format:     "cents",
+			amount:     "12.34",
+			buildStubs: expectNoTransfer,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusBadRequest,
+					recorder.Code,
+				)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(
+			tc.name,
+			func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+
+				store := mockdb.NewMockStore(ctrl)
+				tc.buildStubs(store)
+
+				server := newTestServer(
+					t,
+					store,
+				)
+				recorder := httptest.NewRecorder()
+
+				data, err := json.Marshal(
+					gin.H{
+						"from_account_id": account1.ID,
+						"to_account_id":   account2.ID,
+						"amount":          tc.amount,
+						"currency":        util.USD,
+					},
+				)
+				require.NoError(
+					t,
+					err,
+				)
+
+				request, err := http.NewRequest(
+					http.MethodPost,
+					"/transfers",
+					bytes.NewReader(data),
+				)
+				require.NoError(
+					t,
+					err,
+				)
+				if tc.format != "" {
+					request.Header.Set(
+						amountFormatHeader,
+						tc.format,
+					)
+				}
+
+				addAuthorization(
+					t,
+					request,
+					server.tokenMaker,
+					authorizationTypeBearer,
+					user1.Username,
+					util.DepositorRole,
+					time.Minute,
+				)
+				server.router.ServeHTTP(
+					recorder,
+					request,
+				)
+
+				tc.checkResponse(
+					t,
+					recorder,
+				)
+			},
+		)
+	}
+}
@@ -0,0 +1,508 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	db "github.com/PFefe/simplebank/db/sqlc"
+	"github.com/PFefe/simplebank/util"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// approvalThresholds converts the configured thresholds, each written as CODE:amount in the major unit,
+// to minor units of their currencies. A currency without a threshold never needs approval.
+func approvalThresholds(entries []string, currencies *util.CurrencyRegistry) (map[string]int64, error) {
+	thresholds := make(map[string]int64, len(entries))
+	for _, entry := range entries {
+		code, threshold, found := strings.Cut(
+			entry,
+			":",
+		)
+		if !found {
+			return nil, fmt.Errorf(
+				"invalid approval threshold %q: must be CODE:amount",
+				entry,
+			)
+		}
+
+		if !currencies.IsSupported(code) {
+			return nil, fmt.Errorf(
+				"invalid approval threshold %q: currency is not enabled",
+				entry,
+			)
+		}
+		currency, _ := currencies.Lookup(code)
+		if _, ok := thresholds[code]; ok {
+			return nil, fmt.Errorf(
+				"duplicate approval threshold for %s",
+				code,
+			)
+		}
+
+		amount, err := currency.ParseAmount(threshold)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"invalid approval threshold for %s: %w",
+				code,
+				err,
+			)
+		}
+		thresholds[code] = amount
+	}
+	return thresholds, nil
+}
+
+// needsApproval reports whether a transfer of amount is above the approval threshold of its currency
+func (server *Server) needsApproval(amount int64, currency string) bool {
+	threshold, ok := server.approvalThresholds[currency]
+	return ok && amount > threshold
+}
+
+// transferRequestResponse is the public view of a transfer waiting for, or done with, its review
+type transferRequestResponse struct {
+	ID            int64                    `json:"id"`
+	RequestedBy   string                   `json:"requested_by"`
+	FromAccountID int64                    `json:"from_account_id"`
+	ToAccountID   int64                    `json:"to_account_id"`
+	Amount        amountOutput             `json:"amount"`
+	Currency      string                   `json:"currency"`
+	Status        db.TransferRequestStatus `json:"status"`
+	ReviewedBy    *string                  `json:"reviewed_by"`
+	ReviewedAt    *time.Time               `json:"reviewed_at"`
+	// TransferID is the transfer executed on approval
+	TransferID *int64    `json:"transfer_id"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func newTransferRequestResponse(w amountWriter, request db.TransferRequest) transferRequestResponse {
+	rsp := transferRequestResponse{
+		ID:            request.ID,
+		RequestedBy:   request.RequestedBy,
+		FromAccountID: request.FromAccountID,
+		ToAccountID:   request.ToAccountID,
+		Amount: w.amount(
+			request.Amount,
+			request.Currency,
+		),
+		Currency:  request.Currency,
+		Status:    request.Status,
+		ExpiresAt: request.ExpiresAt,
+		CreatedAt: request.CreatedAt,
+	}
+	if request.ReviewedBy.Valid {
+		rsp.ReviewedBy = &request.ReviewedBy.String
+	}
+	if request.ReviewedAt.Valid {
+		rsp.ReviewedAt = &request.ReviewedAt.Time
+	}
+	if request.TransferID.Valid {
+		rsp.TransferID = &request.TransferID.Int64
+	}
+	return rsp
+}
+
+func newTransferRequestResponses(w amountWriter, requests []db.TransferRequest) []transferRequestResponse {
+	rsp := make([]transferRequestResponse, 0, len(requests))
+	for _, request := range requests {
+		rsp = append(
+			rsp,
+			newTransferRequestResponse(
+				w,
+				request,
+			),
+		)
+	}
+	return rsp
+}
+
+// requestTransferApproval stores a transfer above the threshold for review instead of executing it.
+// Only the source amount is stored: a transfer to another currency is priced at the rate of the time
+// it is approved, so a quote, which expires long before the review, cannot lock in a rate.
+// An Idempotency-Key is stored against the request, so a retry gets the same request back.
+func (server *Server) requestTransferApproval(ctx *gin.Context, req transferRequest, amount int64, key string, hash string) {
+	if req.QuoteID != "" {
+		abortWithError(
+			ctx,
+			invalidFieldError(
+				"quote_id",
+				"approval",
+				"cannot be used for a transfer that needs approval, which is priced when it is approved",
+			),
+		)
+		return
+	}
+
+	username := authPayload(ctx).Username
+	arg := db.CreateTransferRequestTxParams{
+		CreateTransferRequestParams: db.CreateTransferRequestParams{
+			RequestedBy:   username,
+			FromAccountID: req.FromAccountID,
+			ToAccountID:   req.ToAccountID,
+			Amount:        amount,
+			Currency:      req.Currency,
+			ExpiresAt:     time.Now().Add(server.config.ApprovalTTL),
+		},
+	}
+	if key != "" {
+		arg.IdempotencyKey = &db.IdempotencyKeyParams{
+			Username:    username,
+			Key:         key,
+			RequestHash: hash,
+			ExpiresAt:   time.Now().Add(server.config.IdempotencyKeyTTL),
+		}
+	}
+
+	request, err := server.store.CreateTransferRequestTx(
+		ctx,
+		arg,
+	)
+	if err != nil {
+		// a concurrent request with the same key won the race
+		if errors.Is(
+			err,
+			db.ErrIdempotencyKeyInUse,
+		) && server.replayIdempotentRequest(
+			ctx,
+			username,
+			key,
+			hash,
+		) {
+			return
+		}
+		abortWithError(
+			ctx,
+			err,
+		)
+		return
+	}
+
+	ctx.JSON(
+		http.StatusAccepted,
+		newTransferRequestResponse(
+			server.amountWriter(ctx),
+			request,
+		),
+	)
+}
+
+type getTransferRequestRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// getTransferRequest shows a transfer request to its requester and the staff
+func (server *Server) getTransferRequest(ctx *gin.Context) {
+	var uri getTransferRequestRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		abortWithError(
+			ctx,
+			invalidRequestError(
+				ctx,
+				err,
+			),
+		)
+		return
+	}
+
+	request, err := server.store.GetTransferRequest(
+		ctx,
+		uri.ID,
+	)
+	if err != nil {
+		abortWithError(
+			ctx,
+			err,
+		)
+		return
+	}
+
+	authPayload := authPayload(ctx)
+	if request.RequestedBy != authPayload.Username && !hasRole(
+		authPayload,
+		staffRoles...,
+	) {
+		abortWithError(
+			ctx,
+			newAPIError(
+				http.StatusForbidden,
+				codeForbidden,
+				"transfer request was not made by the authenticated user",
+			),
+		)
+		return
+	}
+
+	ctx.JSON(
+		http.StatusOK,
+		newTransferRequestResponse(
+			server.amountWriter(ctx),
+			request,
+		),
+	)
+}
+
+type listTransferRequestsRequest struct {
+	pageRequest
+	Status string `form:"status" binding:"omitempty,oneof=pending_approval approved rejected expired"`
+}
+
+// listTransferRequests pages through the requests in a status, the ones waiting for review by default
+func (server *Server) listTransferRequests(ctx *gin.Context) {
+	var req listTransferRequestsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		abortWithError(
+			ctx,
+			invalidRequestError(
+				ctx,
+				err,
+			),
+		)
+		return
+	}
+
+	page, apiErr := server.page(req.pageRequest)
+	if apiErr != nil {
+		abortWithError(
+			ctx,
+			apiErr,
+		)
+		return
+	}
+
+	status := db.TransferRequestStatusPendingApproval
+	if req.Status != "" {
+		status = db.TransferRequestStatus(req.Status)
+	}
+
+	if page.byOffset {
+		requests, err := server.store.ListTransferRequests(
+			ctx,
+			db.ListTransferRequestsParams{
+				Status: status,
+				Limit:  page.size,
+				Offset: page.offset,
+			},
+		)
+		if err != nil {
+			abortWithError(
+				ctx,
+				err,
+			)
+			return
+		}
+
+		ctx.JSON(
+			http.StatusOK,
+			newTransferRequestResponses(
+				server.amountWriter(ctx),
+				requests,
+			),
+		)
+		return
+	}
+
+	position := page.position()
+	arg := db.ListTransferRequestsAfterParams{
+		Status:          status,
+		CursorCreatedAt: position.CreatedAt,
+		CursorID:        position.ID,
+		Limit:           page.limit(),
+	}
+
+	var requests []db.TransferRequest
+	var err error
+	if page.forward() {
+		requests, err = server.store.ListTransferRequestsAfter(
+			ctx,
+			arg,
+		)
+	} else {
+		requests, err = server.store.ListTransferRequestsBefore(
+			ctx,
+			db.ListTransferRequestsBeforeParams(arg),
+		)
+	}
+	if err != nil {
+		abortWithError(
+			ctx,
+			err,
+		)
+		return
+	}
+
+	ctx.JSON(
+		http.StatusOK,
+		newPageResponse(
+			server,
+			page,
+			newTransferRequestResponses(
+				server.amountWriter(ctx),
+				requests,
+			),
+			func(request transferRequestResponse) pageCursor {
+				return pageCursor{
+					CreatedAt: request.CreatedAt,
+					ID:        request.ID,
+				}
+			},
+		),
+	)
+}
+
+// reviewableTransferRequest loads the request of the uri for a review by the authenticated banker,
+// who must not be its requester
+func (server *Server) reviewableTransferRequest(ctx *gin.Context) (db.TransferRequest, bool) {
+	var uri getTransferRequestRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		abortWithError(
+			ctx,
+			invalidRequestError(
+				ctx,
+				err,
+			),
+		)
+		return db.TransferRequest{}, false
+	}
+
+	request, err := server.store.GetTransferRequest(
+		ctx,
+		uri.ID,
+	)
+	if err != nil {
+		abortWithError(
+			ctx,
+			err,
+		)
+		return db.TransferRequest{}, false
+	}
+
+	if request.RequestedBy == authPayload(ctx).Username {
+		abortWithError(
+			ctx,
+			newAPIError(
+				http.StatusForbidden,
+				codeForbidden,
+				"transfer request must be reviewed by someone other than its requester",
+			),
+		)
+		return db.TransferRequest{}, false
+	}
+
+	// the review query checks this again, this only saves pricing a transfer that cannot run
+	if request.Status != db.TransferRequestStatusPendingApproval || !time.Now().Before(request.ExpiresAt) {
+		abortWithError(
+			ctx,
+			db.ErrTransferRequestUnavailable,
+		)
+		return db.TransferRequest{}, false
+	}
+	return request, true
+}
+
+// approveTransferRequest executes a pending request through the transfer transaction.
+// A transfer to another currency converts at the current rate, the response shows the amount credited.
+func (server *Server) approveTransferRequest(ctx *gin.Context) {
+	request, ok := server.reviewableTransferRequest(ctx)
+	if !ok {
+		return
+	}
+
+	toAccount, err := server.store.GetAccount(
+		ctx,
+		request.ToAccountID,
+	)
+	if err != nil {
+		abortWithError(
+			ctx,
+			err,
+		)
+		return
+	}
+
+	terms, err := server.currentTerms(
+		ctx,
+		request.Amount,
+		request.Currency,
+		toAccount.Currency,
+	)
+	if err != nil {
+		abortWithError(
+			ctx,
+			err,
+		)
+		return
+	}
+
+	result, err := server.executeTransfer(
+		ctx,
+		db.TransferTxParams{
+			FromAccountID: request.FromAccountID,
+			ToAccountID:   request.ToAccountID,
+			Amount:        request.Amount,
+			Approval: &db.TransferApprovalParams{
+				RequestID:  request.ID,
+				ReviewedBy: authPayload(ctx).Username,
+			},
+		},
+		request.Currency,
+		terms,
+	)
+	if err != nil {
+		abortWithError(
+			ctx,
+			err,
+		)
+		return
+	}
+
+	ctx.JSON(
+		http.StatusOK,
+		newTransferTxResponse(
+			server.amountWriter(ctx),
+			result,
+		),
+	)
+}
+
+// rejectTransferRequest closes a pending request without moving any money
+func (server *Server) rejectTransferRequest(ctx *gin.Context) {
+	request, ok := server.reviewableTransferRequest(ctx)
+	if !ok {
+		return
+	}
+
+	request, err := server.store.RejectTransferRequest(
+		ctx,
+		db.RejectTransferRequestParams{
+			ReviewedBy: sql.NullString{
+				String: authPayload(ctx).Username,
+				Valid:  true,
+			},
+			ID: request.ID,
+		},
+	)
+	if err != nil {
+		if errors.Is(
+			err,
+			sql.ErrNoRows,
+		) {
+			err = db.ErrTransferRequestUnavailable
+		}
+		abortWithError(
+			ctx,
+			err,
+		)
+		return
+	}
+
+	ctx.JSON(
+		http.StatusOK,
+		newTransferRequestResponse(
+			server.amountWriter(ctx),
+			request,
+		),
+	)
+}
@@ -0,0 +1,1610 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	mockdb "github.com/PFefe/simplebank/db/mock"
+	db "github.com/PFefe/simplebank/db/sqlc"
+	"github.com/PFefe/simplebank/fx"
+	"github.com/PFefe/simplebank/util"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func randomTransferRequest(requestedBy string, fromAccount db.Account, toAccount db.Account) db.TransferRequest {
+	return db.TransferRequest{
+		ID: util.RandomInt(
+			1,
+			1000,
+		),
+		RequestedBy:   requestedBy,
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        5000000,
+		Currency:      fromAccount.Currency,
+		Status:        db.TransferRequestStatusPendingApproval,
+		ExpiresAt:     time.Now().Add(time.Hour).UTC().Truncate(time.Second),
+		CreatedAt:     time.Now().UTC().Truncate(time.Second),
+	}
+}
+
+func TestApprovalThresholds(t *testing.T) {
+	currencies, err := util.NewCurrencyRegistry([]string{util.USD, "JPY"})
+	require.NoError(
+		t,
+		err,
+	)
+
+	thresholds, err := approvalThresholds(
+		[]string{
+			"USD:10000.00",
+			"JPY:1500000",
+		},
+		currencies,
+	)
+	require.NoError(
+		t,
+		err,
+	)
+	require.Equal(
+		t,
+		map[string]int64{
+			util.USD: 1000000,
+			"JPY":    1500000,
+		},
+		thresholds,
+	)
+
+	for _, entries := range [][]string{
+		{"JPY:10000.00"},
+		{"EUR:10000.00"},
+		{"10000.00"},
+		{"USD:100", "USD:200"},
+	} {
+		_, err = approvalThresholds(
+			entries,
+			currencies,
+		)
+		require.Error(
+			t,
+			err,
+			entries,
+		)
+	}
+}
+
+func TestCreateTransferNeedsApprovalAPI(t *testing.T) {
+	user1, _ := RandomUser(t)
+	user2, _ := RandomUser(t)
+
+	account1 := RandomAccount(user1.Username)
+	account2 := RandomAccount(user2.Username)
+	account1.Currency = util.USD
+	account2.Currency = util.USD
+
+	request := randomTransferRequest(
+		user1.Username,
+		account1,
+		account2,
+	)
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "AboveThreshold",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          "50000.00",
+				"currency":        util.USD,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Eq(account1.ID),
+					).
+					Times(1).
+					Return(
+						account1,
+						nil,
+					)
+				store.EXPECT().
+					CreateTransferRequestTx(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateTransferRequestTxParams) (db.TransferRequest, error) {
+						require.Equal(
+							t,
+							user1.Username,
+							arg.RequestedBy,
+						)
+						require.Nil(
+							t,
+							arg.IdempotencyKey,
+						)
+						require.Equal(
+							t,
+							request.Amount,
+							arg.Amount,
+						)
+						require.WithinDuration(
+							t,
+							time.Now().Add(time.Hour),
+							arg.ExpiresAt,
+							time.Minute,
+						)
+						return request, nil
+					})
+				store.EXPECT().
+					TransferTx(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusAccepted,
+					recorder.Code,
+				)
+				requireBodyMatchJSON(
+					t,
+					recorder.Body.Bytes(),
+					newTransferRequestResponse(
+						decimalAmounts(t),
+						request,
+					),
+				)
+			},
+		},
+		{
+			name: "AtThreshold",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          "10000.00",
+				"currency":        util.USD,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Eq(account1.ID),
+					).
+					Times(1).
+					Return(
+						account1,
+						nil,
+					)
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Eq(account2.ID),
+					).
+					Times(1).
+					Return(
+						account2,
+						nil,
+					)
+				store.EXPECT().
+					CreateTransferRequestTx(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(0)
+				store.EXPECT().
+					TransferTx(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusOK,
+					recorder.Code,
+				)
+			},
+		},
+		{
+			name: "QuoteAboveThreshold",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          "50000.00",
+				"currency":        util.USD,
+				"quote_id":        "6f1c7a38-0d1b-4c8e-9a57-2f8b8f3c1d10",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Eq(account1.ID),
+					).
+					Times(1).
+					Return(
+						account1,
+						nil,
+					)
+				store.EXPECT().
+					CreateTransferRequestTx(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusBadRequest,
+					recorder.Code,
+				)
+				requireBodyMatchFieldErrors(
+					t,
+					recorder.Body,
+					[]fieldError{
+						{
+							Field:   "quote_id",
+							Rule:    "approval",
+							Message: "cannot be used for a transfer that needs approval, which is priced when it is approved",
+						},
+					},
+				)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(
+			tc.name,
+			func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+
+				store := mockdb.NewMockStore(ctrl)
+				tc.buildStubs(store)
+
+				server := newTestServer(
+					t,
+					store,
+				)
+				recorder := httptest.NewRecorder()
+
+				data, err := json.Marshal(tc.body)
+				require.NoError(
+					t,
+					err,
+				)
+
+				request, err := http.NewRequest(
+					http.MethodPost,
+					"/transfers",
+					bytes.NewReader(data),
+				)
+				require.NoError(
+					t,
+					err,
+				)
+
+				addAuthorization(
+					t,
+					request,
+					server.tokenMaker,
+					authorizationTypeBearer,
+					user1.Username,
+					util.DepositorRole,
+					time.Minute,
+				)
+				server.router.ServeHTTP(
+					recorder,
+					request,
+				)
+
+				tc.checkResponse(
+					t,
+					recorder,
+				)
+			},
+		)
+	}
+}
+
+func TestTransferRequestIdempotency(t *testing.T) {
+	key := util.RandomString(16)
+
+	user1, _ := RandomUser(t)
+	user2, _ := RandomUser(t)
+
+	account1 := RandomAccount(user1.Username)
+	account2 := RandomAccount(user2.Username)
+	account1.Currency = util.USD
+	account2.Currency = util.USD
+
+	pending := randomTransferRequest(
+		user1.Username,
+		account1,
+		account2,
+	)
+
+	req := transferRequest{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        amountInput(`"50000.00"`),
+		Currency:      util.USD,
+	}
+	hash, err := requestHash(req)
+	require.NoError(
+		t,
+		err,
+	)
+
+	testCases := []struct {
+		name          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "FirstRequest",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetIdempotencyKey(
+						gomock.Any(),
+						gomock.Eq(db.GetIdempotencyKeyParams{
+							Username: user1.Username,
+							Key:      key,
+						}),
+					).
+					Times(1).
+					Return(
+						db.IdempotencyKey{},
+						sql.ErrNoRows,
+					)
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Eq(account1.ID),
+					).
+					Times(1).
+					Return(
+						account1,
+						nil,
+					)
+				store.EXPECT().
+					CreateTransferRequestTx(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateTransferRequestTxParams) (db.TransferRequest, error) {
+						require.NotNil(
+							t,
+							arg.IdempotencyKey,
+						)
+						require.Equal(
+							t,
+							key,
+							arg.IdempotencyKey.Key,
+						)
+						require.Equal(
+							t,
+							hash,
+							arg.IdempotencyKey.RequestHash,
+						)
+						return pending, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusAccepted,
+					recorder.Code,
+				)
+				require.Empty(
+					t,
+					recorder.Header().Get(idempotentReplayedHeader),
+				)
+			},
+		},
+		{
+			name: "Replay",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetIdempotencyKey(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(1).
+					Return(
+						db.IdempotencyKey{
+							Username:    user1.Username,
+							Key:         key,
+							RequestHash: hash,
+							TransferRequestID: sql.NullInt64{
+								Int64: pending.ID,
+								Valid: true,
+							},
+						},
+						nil,
+					)
+				store.EXPECT().
+					GetTransferRequest(
+						gomock.Any(),
+						gomock.Eq(pending.ID),
+					).
+					Times(1).
+					Return(
+						pending,
+						nil,
+					)
+				store.EXPECT().
+					CreateTransferRequestTx(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusAccepted,
+					recorder.Code,
+				)
+				require.Equal(
+					t,
+					"true",
+					recorder.Header().Get(idempotentReplayedHeader),
+				)
+				requireBodyMatchJSON(
+					t,
+					recorder.Body.Bytes(),
+					newTransferRequestResponse(
+						decimalAmounts(t),
+						pending,
+					),
+				)
+			},
+		},
+		{
+			name: "ConcurrentRequest",
+			buildStubs: func(store *mockdb.MockStore) {
+				gomock.InOrder(
+					store.EXPECT().
+						GetIdempotencyKey(
+							gomock.Any(),
+							gomock.Any(),
+						).
+						Times(1).
+						Return(
+							db.IdempotencyKey{},
+							sql.ErrNoRows,
+						),
+					store.EXPECT().
+						GetIdempotencyKey(
+							gomock.Any(),
+							gomock.Any(),
+						).
+						Times(1).
+						Return(
+							db.IdempotencyKey{
+								Username:    user1.Username,
+								Key:         key,
+								RequestHash: hash,
+								TransferRequestID: sql.NullInt64{
+									Int64: pending.ID,
+									Valid: true,
+								},
+							},
+							nil,
+						),
+				)
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Eq(account1.ID),
+					).
+					Times(1).
+					Return(
+						account1,
+						nil,
+					)
+				store.EXPECT().
+					CreateTransferRequestTx(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(1).
+					Return(
+						db.TransferRequest{},
+						db.ErrIdempotencyKeyInUse,
+					)
+				store.EXPECT().
+					GetTransferRequest(
+						gomock.Any(),
+						gomock.Eq(pending.ID),
+					).
+					Times(1).
+					Return(
+						pending,
+						nil,
+					)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusAccepted,
+					recorder.Code,
+				)
+				require.Equal(
+					t,
+					"true",
+					recorder.Header().Get(idempotentReplayedHeader),
+				)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(
+			tc.name,
+			func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+
+				store := mockdb.NewMockStore(ctrl)
+				tc.buildStubs(store)
+
+				server := newTestServer(
+					t,
+					store,
+				)
+				recorder := httptest.NewRecorder()
+
+				data, err := json.Marshal(req)
+				require.NoError(
+					t,
+					err,
+				)
+
+				request, err := http.NewRequest(
+					http.MethodPost,
+					"/transfers",
+					bytes.NewReader(data),
+				)
+				require.NoError(
+					t,
+					err,
+				)
+				request.Header.Set(
+					idempotencyKeyHeader,
+					key,
+				)
+
+				addAuthorization(
+					t,
+					request,
+					server.tokenMaker,
+					authorizationTypeBearer,
+					user1.Username,
+					util.DepositorRole,
+					time.Minute,
+				)
+				server.router.ServeHTTP(
+					recorder,
+					request,
+				)
+
+				tc.checkResponse(
+					t,
+					recorder,
+				)
+			},
+		)
+	}
+}
+
+func TestListTransferRequestsAPI(t *testing.T) {
+	user1, _ := RandomUser(t)
+	user2, _ := RandomUser(t)
+	banker, _ := RandomUser(t)
+	teller, _ := RandomUser(t)
+
+	account1 := RandomAccount(user1.Username)
+	account2 := RandomAccount(user2.Username)
+	account1.Currency = util.USD
+
+	n := 3
+	requests := make(
+		[]db.TransferRequest,
+		n,
+	)
+	for i := 0; i < n; i++ {
+		requests[i] = randomTransferRequest(
+			user1.Username,
+			account1,
+			account2,
+		)
+		requests[i].ID = int64(i + 1)
+	}
+	cursor := pageCursor{
+		CreatedAt: requests[2].CreatedAt,
+		ID:        requests[2].ID,
+	}
+
+	testCases := []struct {
+		name          string
+		query         func(server *Server) url.Values
+		username      string
+		role          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, server *Server, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			// a page_id keeps the offset paging and its bare array
+			name: "OffsetPage",
+			query: func(server *Server) url.Values {
+				return url.Values{
+					"page_id":   {"2"},
+					"page_size": {"2"},
+				}
+			},
+			username: banker.Username,
+			role:     util.BankerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListTransferRequests(
+						gomock.Any(),
+						gomock.Eq(db.ListTransferRequestsParams{
+							Status: db.TransferRequestStatusPendingApproval,
+							Limit:  2,
+							Offset: 2,
+						}),
+					).
+					Times(1).
+					Return(
+						requests[:2],
+						nil,
+					)
+			},
+			checkResponse: func(t *testing.T, server *Server, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusOK,
+					recorder.Code,
+				)
+				requireBodyMatchJSON(
+					t,
+					recorder.Body.Bytes(),
+					newTransferRequestResponses(
+						decimalAmounts(t),
+						requests[:2],
+					),
+				)
+			},
+		},
+		{
+			name: "StatusFilter",
+			query: func(server *Server) url.Values {
+				return url.Values{
+					"page_id": {"1"},
+					"status":  {string(db.TransferRequestStatusRejected)},
+				}
+			},
+			username: banker.Username,
+			role:     util.BankerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListTransferRequests(
+						gomock.Any(),
+						gomock.Eq(db.ListTransferRequestsParams{
+							Status: db.TransferRequestStatusRejected,
+							Limit:  10,
+						}),
+					).
+					Times(1).
+					Return(
+						[]db.TransferRequest{},
+						nil,
+					)
+			},
+			checkResponse: func(t *testing.T, server *Server, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusOK,
+					recorder.Code,
+				)
+				require.JSONEq(
+					t,
+					"[]",
+					recorder.Body.String(),
+				)
+			},
+		},
+		{
+			// without a page_id the requests are paged by cursor, the pending ones by default
+			name: "FirstPage",
+			query: func(server *Server) url.Values {
+				return url.Values{
+					"page_size": {"2"},
+				}
+			},
+			username: banker.Username,
+			role:     util.BankerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListTransferRequestsAfter(
+						gomock.Any(),
+						gomock.Eq(db.ListTransferRequestsAfterParams{
+							Status: db.TransferRequestStatusPendingApproval,
+							Limit:  3,
+						}),
+					).
+					Times(1).
+					Return(
+						requests,
+						nil,
+					)
+			},
+			checkResponse: func(t *testing.T, server *Server, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusOK,
+					recorder.Code,
+				)
+
+				var rsp pageResponse[json.RawMessage]
+				err := json.Unmarshal(
+					recorder.Body.Bytes(),
+					&rsp,
+				)
+				require.NoError(
+					t,
+					err,
+				)
+				data, err := json.Marshal(rsp.Data)
+				require.NoError(
+					t,
+					err,
+				)
+				requireBodyMatchJSON(
+					t,
+					data,
+					newTransferRequestResponses(
+						decimalAmounts(t),
+						requests[:2],
+					),
+				)
+				require.Empty(
+					t,
+					rsp.PrevCursor,
+				)
+
+				next, err := server.decodeCursor(rsp.NextCursor)
+				require.NoError(
+					t,
+					err,
+				)
+				require.Equal(
+					t,
+					requests[1].ID,
+					next.ID,
+				)
+			},
+		},
+		{
+			name: "PrevPage",
+			query: func(server *Server) url.Values {
+				backward := cursor
+				backward.Backward = true
+				return url.Values{
+					"page_size": {"2"},
+					"cursor":    {server.encodeCursor(backward)},
+				}
+			},
+			username: banker.Username,
+			role:     util.BankerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListTransferRequestsBefore(
+						gomock.Any(),
+						gomock.Eq(db.ListTransferRequestsBeforeParams{
+							Status:          db.TransferRequestStatusPendingApproval,
+							CursorCreatedAt: cursor.CreatedAt,
+							CursorID:        cursor.ID,
+							Limit:           3,
+						}),
+					).
+					Times(1).
+					Return(
+						[]db.TransferRequest{requests[1], requests[0]},
+						nil,
+					)
+			},
+			checkResponse: func(t *testing.T, server *Server, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusOK,
+					recorder.Code,
+				)
+
+				var rsp pageResponse[json.RawMessage]
+				err := json.Unmarshal(
+					recorder.Body.Bytes(),
+					&rsp,
+				)
+				require.NoError(
+					t,
+					err,
+				)
+				data, err := json.Marshal(rsp.Data)
+				require.NoError(
+					t,
+					err,
+				)
+				requireBodyMatchJSON(
+					t,
+					data,
+					newTransferRequestResponses(
+						decimalAmounts(t),
+						requests[:2],
+					),
+				)
+			},
+		},
+		{
+			name: "InvalidStatus",
+			query: func(server *Server) url.Values {
+				return url.Values{
+					"status": {"unknown"},
+				}
+			},
+			username: banker.Username,
+			role:     util.BankerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListTransferRequests(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(0)
+				store.EXPECT().
+					ListTransferRequestsAfter(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, server *Server, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusBadRequest,
+					recorder.Code,
+				)
+			},
+		},
+		{
+			// reviewing large transfers is reserved for bankers
+			name: "NotBanker",
+			query: func(server *Server) url.Values {
+				return url.Values{}
+			},
+			username: teller.Username,
+			role:     util.TellerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListTransferRequests(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(0)
+				store.EXPECT().
+					ListTransferRequestsAfter(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, server *Server, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusForbidden,
+					recorder.Code,
+				)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(
+			tc.name,
+			func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+
+				store := mockdb.NewMockStore(ctrl)
+				tc.buildStubs(store)
+
+				server := newTestServer(
+					t,
+					store,
+				)
+				recorder := httptest.NewRecorder()
+
+				request, err := http.NewRequest(
+					http.MethodGet,
+					"/transfer_requests?"+tc.query(server).Encode(),
+					nil,
+				)
+				require.NoError(
+					t,
+					err,
+				)
+
+				addAuthorization(
+					t,
+					request,
+					server.tokenMaker,
+					authorizationTypeBearer,
+					tc.username,
+					tc.role,
+					time.Minute,
+				)
+				server.router.ServeHTTP(
+					recorder,
+					request,
+				)
+
+				tc.checkResponse(
+					t,
+					server,
+					recorder,
+				)
+			},
+		)
+	}
+}
+
+func TestApproveTransferRequestAPI(t *testing.T) {
+	user1, _ := RandomUser(t)
+	user2, _ := RandomUser(t)
+	banker, _ := RandomUser(t)
+
+	account1 := RandomAccount(user1.Username)
+	account2 := RandomAccount(user2.Username)
+	account3 := RandomAccount(user2.Username)
+	account1.Currency = util.USD
+	account2.Currency = util.USD
+	account3.Currency = util.EUR
+
+	rateProvider, err := fx.NewStaticRateProvider(
+		map[string]string{
+			"USD/EUR": "0.9",
+		},
+		"0.01",
+	)
+	require.NoError(
+		t,
+		err,
+	)
+
+	pending := randomTransferRequest(
+		user1.Username,
+		account1,
+		account2,
+	)
+
+	expired := pending
+	expired.ExpiresAt = time.Now().Add(-time.Minute)
+
+	rejected := pending
+	rejected.Status = db.TransferRequestStatusRejected
+
+	crossCurrency := pending
+	crossCurrency.ToAccountID = account3.ID
+
+	testCases := []struct {
+		name          string
+		username      string
+		role          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			username: banker.Username,
+			role:     util.BankerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetTransferRequest(
+						gomock.Any(),
+						gomock.Eq(pending.ID),
+					).
+					Times(1).
+					Return(
+						pending,
+						nil,
+					)
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Eq(account2.ID),
+					).
+					Times(1).
+					Return(
+						account2,
+						nil,
+					)
+
+				arg := db.TransferTxParams{
+					FromAccountID: account1.ID,
+					ToAccountID:   account2.ID,
+					Amount:        pending.Amount,
+					Approval: &db.TransferApprovalParams{
+						RequestID:  pending.ID,
+						ReviewedBy: banker.Username,
+					},
+				}
+				store.EXPECT().
+					TransferTx(
+						gomock.Any(),
+						gomock.Eq(arg),
+					).
+					Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusOK,
+					recorder.Code,
+				)
+			},
+		},
+		{
+			// a request is priced when it is approved, not when it was made
+			name:     "CrossCurrency",
+			username: banker.Username,
+			role:     util.BankerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetTransferRequest(
+						gomock.Any(),
+						gomock.Eq(pending.ID),
+					).
+					Times(1).
+					Return(
+						crossCurrency,
+						nil,
+					)
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Eq(account3.ID),
+					).
+					Times(1).
+					Return(
+						account3,
+						nil,
+					)
+
+				// 50000.00 USD at 0.9 less the 1% spread
+				arg := db.FXTransferTxParams{
+					TransferTxParams: db.TransferTxParams{
+						FromAccountID: account1.ID,
+						ToAccountID:   account3.ID,
+						Amount:        pending.Amount,
+						Approval: &db.TransferApprovalParams{
+							RequestID:  pending.ID,
+							ReviewedBy: banker.Username,
+						},
+					},
+					ToAmount:     4455000,
+					ExchangeRate: "0.9",
+					Spread:       "0.01",
+				}
+				store.EXPECT().
+					FXTransferTx(
+						gomock.Any(),
+						gomock.Eq(arg),
+					).
+					Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusOK,
+					recorder.Code,
+				)
+			},
+		},
+		{
+			name:     "Requester",
+			username: user1.Username,
+			role:     util.BankerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetTransferRequest(
+						gomock.Any(),
+						gomock.Eq(pending.ID),
+					).
+					Times(1).
+					Return(
+						pending,
+						nil,
+					)
+				store.EXPECT().
+					TransferTx(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusForbidden,
+					recorder.Code,
+				)
+			},
+		},
+		{
+			name:     "NotBanker",
+			username: user2.Username,
+			role:     util.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetTransferRequest(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusForbidden,
+					recorder.Code,
+				)
+			},
+		},
+		{
+			name:     "Expired",
+			username: banker.Username,
+			role:     util.BankerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetTransferRequest(
+						gomock.Any(),
+						gomock.Eq(pending.ID),
+					).
+					Times(1).
+					Return(
+						expired,
+						nil,
+					)
+				store.EXPECT().
+					TransferTx(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusConflict,
+					recorder.Code,
+				)
+			},
+		},
+		{
+			name:     "AlreadyReviewed",
+			username: banker.Username,
+			role:     util.BankerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetTransferRequest(
+						gomock.Any(),
+						gomock.Eq(pending.ID),
+					).
+					Times(1).
+					Return(
+						rejected,
+						nil,
+					)
+				store.EXPECT().
+					TransferTx(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusConflict,
+					recorder.Code,
+				)
+			},
+		},
+		{
+			name:     "ReviewedConcurrently",
+			username: banker.Username,
+			role:     util.BankerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetTransferRequest(
+						gomock.Any(),
+						gomock.Eq(pending.ID),
+					).
+					Times(1).
+					Return(
+						pending,
+						nil,
+					)
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Eq(account2.ID),
+					).
+					Times(1).
+					Return(
+						account2,
+						nil,
+					)
+				store.EXPECT().
+					TransferTx(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(1).
+					Return(
+						db.TransferTxResult{},
+						db.ErrTransferRequestUnavailable,
+					)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusConflict,
+					recorder.Code,
+				)
+			},
+		},
+		{
+			name:     "NotFound",
+			username: banker.Username,
+			role:     util.BankerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetTransferRequest(
+						gomock.Any(),
+						gomock.Eq(pending.ID),
+					).
+					Times(1).
+					Return(
+						db.TransferRequest{},
+						sql.ErrNoRows,
+					)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusNotFound,
+					recorder.Code,
+				)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(
+			tc.name,
+			func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+
+				store := mockdb.NewMockStore(ctrl)
+				tc.buildStubs(store)
+
+				server := newTestServer(
+					t,
+					store,
+				)
+				server.rateProvider = rateProvider
+				recorder := httptest.NewRecorder()
+
+				url := fmt.Sprintf(
+					"/transfer_requests/%d/approve",
+					pending.ID,
+				)
+				request, err := http.NewRequest(
+					http.MethodPost,
+					url,
+					nil,
+				)
+				require.NoError(
+					t,
+					err,
+				)
+
+				addAuthorization(
+					t,
+					request,
+					server.tokenMaker,
+					authorizationTypeBearer,
+					tc.username,
+					tc.role,
+					time.Minute,
+				)
+				server.router.ServeHTTP(
+					recorder,
+					request,
+				)
+
+				tc.checkResponse(
+					t,
+					recorder,
+				)
+			},
+		)
+	}
+}
+
+func TestRejectTransferRequestAPI(t *testing.T) {
+	user1, _ := RandomUser(t)
+	user2, _ := RandomUser(t)
+	banker, _ := RandomUser(t)
+
+	account1 := RandomAccount(user1.Username)
+	account2 := RandomAccount(user2.Username)
+	account1.Currency = util.EUR
+
+	pending := randomTransferRequest(
+		user1.Username,
+		account1,
+		account2,
+	)
+
+	rejected := pending
+	rejected.Status = db.TransferRequestStatusRejected
+	rejected.ReviewedBy = sql.NullString{
+		String: banker.Username,
+		Valid:  true,
+	}
+	rejected.ReviewedAt = sql.NullTime{
+		Time:  time.Now().UTC().Truncate(time.Second),
+		Valid: true,
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		GetTransferRequest(
+			gomock.Any(),
+			gomock.Eq(pending.ID),
+		).
+		Times(1).
+		Return(
+			pending,
+			nil,
+		)
+	store.EXPECT().
+		RejectTransferRequest(
+			gomock.Any(),
+			gomock.Eq(db.RejectTransferRequestParams{
+				ReviewedBy: rejected.ReviewedBy,
+				ID:         pending.ID,
+			}),
+		).
+		Times(1).
+		Return(
+			rejected,
+			nil,
+		)
+	store.EXPECT().
+		TransferTx(
+			gomock.Any(),
+			gomock.Any(),
+		).
+		Times(0)
+
+	server := newTestServer(
+		t,
+		store,
+	)
+	recorder := httptest.NewRecorder()
+
+	url := fmt.Sprintf(
+		"/transfer_requests/%d/reject",
+		pending.ID,
+	)
+	request, err := http.NewRequest(
+		http.MethodPost,
+		url,
+		nil,
+	)
+	require.NoError(
+		t,
+		err,
+	)
+
+	addAuthorization(
+		t,
+		request,
+		server.tokenMaker,
+		authorizationTypeBearer,
+		banker.Username,
+		util.BankerRole,
+		time.Minute,
+	)
+	server.router.ServeHTTP(
+		recorder,
+		request,
+	)
+
+	require.Equal(
+		t,
+		http.StatusOK,
+		recorder.Code,
+	)
+	requireBodyMatchJSON(
+		t,
+		recorder.Body.Bytes(),
+		newTransferRequestResponse(
+			decimalAmounts(t),
+			rejected,
+		),
+	)
+}
+
+func TestGetTransferRequestAPI(t *testing.T) {
+	user1, _ := RandomUser(t)
+	user2, _ := RandomUser(t)
+
+	account1 := RandomAccount(user1.Username)
+	account2 := RandomAccount(user2.Username)
+	account1.Currency = util.CAD
+
+	request := randomTransferRequest(
+		user1.Username,
+		account1,
+		account2,
+	)
+
+	testCases := []struct {
+		name          string
+		username      string
+		role          string
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "Requester",
+			username: user1.Username,
+			role:     util.DepositorRole,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusOK,
+					recorder.Code,
+				)
+				requireBodyMatchJSON(
+					t,
+					recorder.Body.Bytes(),
+					newTransferRequestResponse(
+						decimalAmounts(t),
+						request,
+					),
+				)
+			},
+		},
+		{
+			name:     "Teller",
+			username: user2.Username,
+			role:     util.TellerRole,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusOK,
+					recorder.Code,
+				)
+			},
+		},
+		{
+			name:     "OtherUser",
+			username: user2.Username,
+			role:     util.DepositorRole,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusForbidden,
+					recorder.Code,
+				)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(
+			tc.name,
+			func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+
+				store := mockdb.NewMockStore(ctrl)
+				store.EXPECT().
+					GetTransferRequest(
+						gomock.Any(),
+						gomock.Eq(request.ID),
+					).
+					Times(1).
+					Return(
+						request,
+						nil,
+					)
+
+				server := newTestServer(
+					t,
+					store,
+				)
+				recorder := httptest.NewRecorder()
+
+				url := fmt.Sprintf(
+					"/transfer_requests/%d",
+					request.ID,
+				)
+				httpRequest, err := http.NewRequest(
+					http.MethodGet,
+					url,
+					nil,
+				)
+				require.NoError(
+					t,
+					err,
+				)
+
+				addAuthorization(
+					t,
+					httpRequest,
+					server.tokenMaker,
+					authorizationTypeBearer,
+					tc.username,
+					tc.role,
+					time.Minute,
+				)
+				server.router.ServeHTTP(
+					recorder,
+					httpRequest,
+				)
+
+				tc.checkResponse(
+					t,
+					recorder,
+				)
+			},
+		)
+	}
+}
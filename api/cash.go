@@ -0,0 +1,126 @@
+package api
+
+import (
+	"context"
+	db "github.com/PFefe/simplebank/db/sqlc"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+type cashRequest struct {
+	Amount   amountInput `json:"amount" binding:"required"`
+	Currency string      `json:"currency" binding:"required,currency"`
+}
+
+// cashTxResponse is the result of a deposit or a withdrawal
+type cashTxResponse struct {
+	Transfer transferResponse `json:"transfer"`
+	Account  accountResponse  `json:"account"`
+	Entry    entryResponse    `json:"entry"`
+}
+
+func newCashTxResponse(w amountWriter, result db.CashTxResult) cashTxResponse {
+	return cashTxResponse{
+		Transfer: newTransferResponse(
+			w,
+			result.Transfer,
+		),
+		Account: newAccountResponse(
+			w,
+			result.Account,
+		),
+		Entry: newEntryResponse(
+			w,
+			result.Entry,
+			result.Account.Currency,
+		),
+	}
+}
+
+// createDeposit credits cash handed in at the counter to an account
+func (server *Server) createDeposit(ctx *gin.Context) {
+	server.moveCash(
+		ctx,
+		server.store.DepositTx,
+	)
+}
+
+// createWithdrawal debits cash handed out at the counter from an account
+func (server *Server) createWithdrawal(ctx *gin.Context) {
+	server.moveCash(
+		ctx,
+		server.store.WithdrawTx,
+	)
+}
+
+func (server *Server) moveCash(ctx *gin.Context, cashTx func(ctx context.Context, arg db.CashTxParams) (db.CashTxResult, error)) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		abortWithError(
+			ctx,
+			invalidRequestError(
+				ctx,
+				err,
+			),
+		)
+		return
+	}
+
+	var req cashRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		abortWithError(
+			ctx,
+			invalidRequestError(
+				ctx,
+				err,
+			),
+		)
+		return
+	}
+
+	amount, apiErr := server.parsePositiveAmount(
+		ctx,
+		"amount",
+		req.Amount,
+		req.Currency,
+	)
+	if apiErr != nil {
+		abortWithError(
+			ctx,
+			apiErr,
+		)
+		return
+	}
+
+	account, valid := server.validAccount(
+		ctx,
+		uri.ID,
+		req.Currency,
+	)
+	if !valid {
+		return
+	}
+
+	result, err := cashTx(
+		ctx,
+		db.CashTxParams{
+			AccountID: account.ID,
+			Amount:    amount,
+		},
+	)
+	if err != nil {
+		abortWithError(
+			ctx,
+			err,
+		)
+		return
+	}
+
+	ctx.JSON(
+		http.StatusOK,
+		newCashTxResponse(
+			server.amountWriter(ctx),
+			result,
+		),
+	)
+}
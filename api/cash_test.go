@@ -0,0 +1,356 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	mockdb "github.com/PFefe/simplebank/db/mock"
+	db "github.com/PFefe/simplebank/db/sqlc"
+	"github.com/PFefe/simplebank/util"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCashAPI(t *testing.T) {
+	user, _ := RandomUser(t)
+	teller, _ := RandomUser(t)
+	account := RandomAccount(user.Username)
+	amount := int64(100)
+	decimalAmount := "1.00"
+
+	testCases := []struct {
+		name          string
+		path          string
+		body          gin.H
+		username      string
+		role          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "Deposit",
+			path: "deposits",
+			body: gin.H{
+				"amount":   decimalAmount,
+				"currency": account.Currency,
+			},
+			username: teller.Username,
+			role:     util.TellerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Eq(account.ID),
+					).
+					Times(1).
+					Return(
+						account,
+						nil,
+					)
+
+				arg := db.CashTxParams{
+					AccountID: account.ID,
+					Amount:    amount,
+				}
+				store.EXPECT().
+					DepositTx(
+						gomock.Any(),
+						gomock.Eq(arg),
+					).
+					Times(1)
+				store.EXPECT().
+					WithdrawTx(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusOK,
+					recorder.Code,
+				)
+			},
+		},
+		{
+			name: "Withdrawal",
+			path: "withdrawals",
+			body: gin.H{
+				"amount":   decimalAmount,
+				"currency": account.Currency,
+			},
+			username: teller.Username,
+			role:     util.TellerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Eq(account.ID),
+					).
+					Times(1).
+					Return(
+						account,
+						nil,
+					)
+
+				arg := db.CashTxParams{
+					AccountID: account.ID,
+					Amount:    amount,
+				}
+				store.EXPECT().
+					WithdrawTx(
+						gomock.Any(),
+						gomock.Eq(arg),
+					).
+					Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusOK,
+					recorder.Code,
+				)
+			},
+		},
+		{
+			name: "InsufficientFunds",
+			path: "withdrawals",
+			body: gin.H{
+				"amount":   decimalAmount,
+				"currency": account.Currency,
+			},
+			username: teller.Username,
+			role:     util.TellerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Eq(account.ID),
+					).
+					Times(1).
+					Return(
+						account,
+						nil,
+					)
+				store.EXPECT().
+					WithdrawTx(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(1).
+					Return(
+						db.CashTxResult{},
+						&db.InsufficientFundsError{AccountID: account.ID},
+					)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusUnprocessableEntity,
+					recorder.Code,
+				)
+			},
+		},
+		{
+			name: "NotTeller",
+			path: "deposits",
+			body: gin.H{
+				"amount":   decimalAmount,
+				"currency": account.Currency,
+			},
+			username: user.Username,
+			role:     util.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(0)
+				store.EXPECT().
+					DepositTx(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusForbidden,
+					recorder.Code,
+				)
+			},
+		},
+		{
+			name: "CurrencyMismatch",
+			path: "deposits",
+			body: gin.H{
+				"amount":   decimalAmount,
+				"currency": otherCurrency(account.Currency),
+			},
+			username: teller.Username,
+			role:     util.TellerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Eq(account.ID),
+					).
+					Times(1).
+					Return(
+						account,
+						nil,
+					)
+				store.EXPECT().
+					DepositTx(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusBadRequest,
+					recorder.Code,
+				)
+			},
+		},
+		{
+			name: "AccountNotFound",
+			path: "deposits",
+			body: gin.H{
+				"amount":   decimalAmount,
+				"currency": account.Currency,
+			},
+			username: teller.Username,
+			role:     util.TellerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Eq(account.ID),
+					).
+					Times(1).
+					Return(
+						db.Account{},
+						sql.ErrNoRows,
+					)
+				store.EXPECT().
+					DepositTx(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusNotFound,
+					recorder.Code,
+				)
+			},
+		},
+		{
+			name: "NegativeAmount",
+			path: "deposits",
+			body: gin.H{
+				"amount":   "-" + decimalAmount,
+				"currency": account.Currency,
+			},
+			username: teller.Username,
+			role:     util.TellerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					DepositTx(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusBadRequest,
+					recorder.Code,
+				)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(
+			tc.name,
+			func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+
+				store := mockdb.NewMockStore(ctrl)
+				tc.buildStubs(store)
+
+				server := newTestServer(
+					t,
+					store,
+				)
+				recorder := httptest.NewRecorder()
+
+				data, err := json.Marshal(tc.body)
+				require.NoError(
+					t,
+					err,
+				)
+
+				url := fmt.Sprintf(
+					"/accounts/%d/%s",
+					account.ID,
+					tc.path,
+				)
+				request, err := http.NewRequest(
+					http.MethodPost,
+					url,
+					bytes.NewReader(data),
+				)
+				require.NoError(
+					t,
+					err,
+				)
+
+				addAuthorization(
+					t,
+					request,
+					server.tokenMaker,
+					authorizationTypeBearer,
+					tc.username,
+					tc.role,
+					time.Minute,
+				)
+				server.router.ServeHTTP(
+					recorder,
+					request,
+				)
+
+				tc.checkResponse(
+					t,
+					recorder,
+				)
+			},
+		)
+	}
+}
+
+// otherCurrency returns a supported currency different from currency
+func otherCurrency(currency string) string {
+	if currency == util.USD {
+		return util.EUR
+	}
+	return util.USD
+}
@@ -0,0 +1,14 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// listCurrencies returns the currencies accounts can be opened in
+func (server *Server) listCurrencies(ctx *gin.Context) {
+	ctx.JSON(
+		http.StatusOK,
+		server.currencies.List(),
+	)
+}
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"encoding/json"
+	mockdb "github.com/PFefe/simplebank/db/mock"
+	"github.com/PFefe/simplebank/util"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestListCurrenciesAPI(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	server := newTestServer(
+		t,
+		store,
+	)
+	recorder := httptest.NewRecorder()
+
+	request, err := http.NewRequest(
+		http.MethodGet,
+		"/currencies",
+		nil,
+	)
+	require.NoError(
+		t,
+		err,
+	)
+
+	server.router.ServeHTTP(
+		recorder,
+		request,
+	)
+	require.Equal(
+		t,
+		http.StatusOK,
+		recorder.Code,
+	)
+
+	var currencies []util.Currency
+	err = json.Unmarshal(
+		recorder.Body.Bytes(),
+		&currencies,
+	)
+	require.NoError(
+		t,
+		err,
+	)
+	require.Len(
+		t,
+		currencies,
+		len(util.DefaultCurrencies),
+	)
+	for i, currency := range currencies {
+		require.Equal(
+			t,
+			util.DefaultCurrencies[i],
+			currency.Code,
+		)
+		require.Equal(
+			t,
+			2,
+			currency.MinorUnits,
+		)
+	}
+}
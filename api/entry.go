@@ -0,0 +1,286 @@
+package api
+
+import (
+	db "github.com/PFefe/simplebank/db/sqlc"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"time"
+)
+
+// entryResponse is the public view of an entry, with its amounts in the format of the request
+type entryResponse struct {
+	ID        int64        `json:"id"`
+	AccountID int64        `json:"account_id"`
+	Amount    amountOutput `json:"amount"`
+	// RunningBalance is the account balance right after the entry, when it was queried
+	RunningBalance *amountOutput `json:"running_balance,omitempty"`
+	CreatedAt      time.Time     `json:"created_at"`
+}
+
+func newEntryResponse(w amountWriter, entry db.Entry, currency string) entryResponse {
+	return entryResponse{
+		ID:        entry.ID,
+		AccountID: entry.AccountID,
+		Amount: w.amount(
+			entry.Amount,
+			currency,
+		),
+		CreatedAt: entry.CreatedAt,
+	}
+}
+
+// newEntryBalanceResponse takes every entry query with a running balance, since their rows only differ by name
+func newEntryBalanceResponse(w amountWriter, entry db.GetEntryWithBalanceRow, currency string) entryResponse {
+	runningBalance := w.amount(
+		entry.RunningBalance,
+		currency,
+	)
+	return entryResponse{
+		ID:        entry.ID,
+		AccountID: entry.AccountID,
+		Amount: w.amount(
+			entry.Amount,
+			currency,
+		),
+		RunningBalance: &runningBalance,
+		CreatedAt:      entry.CreatedAt,
+	}
+}
+
+type listEntriesRequest struct {
+	pageRequest
+	FromTime *time.Time `form:"from_time" time_format:"2006-01-02T15:04:05Z07:00"`
+	ToTime   *time.Time `form:"to_time" time_format:"2006-01-02T15:04:05Z07:00"`
+}
+
+func (server *Server) listEntries(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		abortWithError(
+			ctx,
+			invalidRequestError(
+				ctx,
+				err,
+			),
+		)
+		return
+	}
+
+	var req listEntriesRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		abortWithError(
+			ctx,
+			invalidRequestError(
+				ctx,
+				err,
+			),
+		)
+		return
+	}
+
+	if apiErr := validTimeRange(
+		req.FromTime,
+		req.ToTime,
+	); apiErr != nil {
+		abortWithError(
+			ctx,
+			apiErr,
+		)
+		return
+	}
+	page, apiErr := server.page(req.pageRequest)
+	if apiErr != nil {
+		abortWithError(
+			ctx,
+			apiErr,
+		)
+		return
+	}
+
+	account, err := server.store.GetAccount(
+		ctx,
+		uri.ID,
+	)
+	if err != nil {
+		abortWithError(
+			ctx,
+			err,
+		)
+		return
+	}
+
+	if !authorizeAccount(
+		ctx,
+		account,
+		staffRoles...,
+	) {
+		return
+	}
+
+	if page.byOffset {
+		arg := db.ListAccountEntriesParams{
+			AccountID: account.ID,
+			FromTime:  nullTime(req.FromTime),
+			ToTime:    nullTime(req.ToTime),
+			Limit:     page.size,
+			Offset:    page.offset,
+		}
+
+		entries, err := server.store.ListAccountEntries(
+			ctx,
+			arg,
+		)
+		if err != nil {
+			abortWithError(
+				ctx,
+				err,
+			)
+			return
+		}
+
+		w := server.amountWriter(ctx)
+		rsp := make([]entryResponse, 0, len(entries))
+		for _, entry := range entries {
+			rsp = append(
+				rsp,
+				newEntryBalanceResponse(
+					w,
+					db.GetEntryWithBalanceRow(entry),
+					account.Currency,
+				),
+			)
+		}
+		ctx.JSON(
+			http.StatusOK,
+			rsp,
+		)
+		return
+	}
+
+	position := page.position()
+	arg := db.ListAccountEntriesAfterParams{
+		AccountID:       account.ID,
+		FromTime:        nullTime(req.FromTime),
+		ToTime:          nullTime(req.ToTime),
+		CursorCreatedAt: position.CreatedAt,
+		CursorID:        position.ID,
+		Limit:           page.limit(),
+	}
+
+	w := server.amountWriter(ctx)
+	var entries []entryResponse
+	if page.forward() {
+		var rows []db.ListAccountEntriesAfterRow
+		rows, err = server.store.ListAccountEntriesAfter(
+			ctx,
+			arg,
+		)
+		for _, row := range rows {
+			entries = append(
+				entries,
+				newEntryBalanceResponse(
+					w,
+					db.GetEntryWithBalanceRow(row),
+					account.Currency,
+				),
+			)
+		}
+	} else {
+		var rows []db.ListAccountEntriesBeforeRow
+		rows, err = server.store.ListAccountEntriesBefore(
+			ctx,
+			db.ListAccountEntriesBeforeParams(arg),
+		)
+		for _, row := range rows {
+			entries = append(
+				entries,
+				newEntryBalanceResponse(
+					w,
+					db.GetEntryWithBalanceRow(row),
+					account.Currency,
+				),
+			)
+		}
+	}
+	if err != nil {
+		abortWithError(
+			ctx,
+			err,
+		)
+		return
+	}
+
+	ctx.JSON(
+		http.StatusOK,
+		newPageResponse(
+			server,
+			page,
+			entries,
+			func(entry entryResponse) pageCursor {
+				return pageCursor{
+					CreatedAt: entry.CreatedAt,
+					ID:        entry.ID,
+				}
+			},
+		),
+	)
+}
+
+type getEntryRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+func (server *Server) getEntry(ctx *gin.Context) {
+	var req getEntryRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		abortWithError(
+			ctx,
+			invalidRequestError(
+				ctx,
+				err,
+			),
+		)
+		return
+	}
+
+	entry, err := server.store.GetEntryWithBalance(
+		ctx,
+		req.ID,
+	)
+	if err != nil {
+		abortWithError(
+			ctx,
+			err,
+		)
+		return
+	}
+
+	account, err := server.store.GetAccount(
+		ctx,
+		entry.AccountID,
+	)
+	if err != nil {
+		abortWithError(
+			ctx,
+			err,
+		)
+		return
+	}
+
+	if !authorizeAccount(
+		ctx,
+		account,
+		staffRoles...,
+	) {
+		return
+	}
+
+	ctx.JSON(
+		http.StatusOK,
+		newEntryBalanceResponse(
+			server.amountWriter(ctx),
+			entry,
+			account.Currency,
+		),
+	)
+}
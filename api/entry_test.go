@@ -0,0 +1,748 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	mockdb "github.com/PFefe/simplebank/db/mock"
+	db "github.com/PFefe/simplebank/db/sqlc"
+	"github.com/PFefe/simplebank/token"
+	"github.com/PFefe/simplebank/util"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestListEntriesAPI(t *testing.T) {
+	user, _ := RandomUser(t)
+	otherUser, _ := RandomUser(t)
+	account := RandomAccount(user.Username)
+
+	n := 5
+	entries := randomEntries(
+		account,
+		n,
+	)
+
+	fromTime := time.Date(
+		2024,
+		time.January,
+		1,
+		0,
+		0,
+		0,
+		0,
+		time.UTC,
+	)
+	toTime := fromTime.AddDate(
+		0,
+		1,
+		0,
+	)
+
+	type Query struct {
+		pageID   int
+		pageSize int
+		fromTime string
+		toTime   string
+	}
+
+	testCases := []struct {
+		name          string
+		accountID     int64
+		query         Query
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:      "OK",
+			accountID: account.ID,
+			query: Query{
+				pageID:   1,
+				pageSize: n,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(
+					t,
+					request,
+					tokenMaker,
+					authorizationTypeBearer,
+					user.Username,
+					util.DepositorRole,
+					time.Minute,
+				)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Eq(account.ID),
+					).
+					Times(1).
+					Return(
+						account,
+						nil,
+					)
+
+				arg := db.ListAccountEntriesParams{
+					AccountID: account.ID,
+					Limit:     int32(n),
+					Offset:    0,
+				}
+				store.EXPECT().
+					ListAccountEntries(
+						gomock.Any(),
+						gomock.Eq(arg),
+					).
+					Times(1).
+					Return(
+						entries,
+						nil,
+					)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusOK,
+					recorder.Code,
+				)
+				requireBodyMatchEntries(
+					t,
+					recorder.Body,
+					entries,
+					account.Currency,
+				)
+			},
+		},
+		{
+			name:      "TimeRange",
+			accountID: account.ID,
+			query: Query{
+				pageID:   2,
+				pageSize: n,
+				fromTime: fromTime.Format(time.RFC3339),
+				toTime:   toTime.Format(time.RFC3339),
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(
+					t,
+					request,
+					tokenMaker,
+					authorizationTypeBearer,
+					user.Username,
+					util.DepositorRole,
+					time.Minute,
+				)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Eq(account.ID),
+					).
+					Times(1).
+					Return(
+						account,
+						nil,
+					)
+
+				arg := db.ListAccountEntriesParams{
+					AccountID: account.ID,
+					FromTime: sql.NullTime{
+						Time:  fromTime,
+						Valid: true,
+					},
+					ToTime: sql.NullTime{
+						Time:  toTime,
+						Valid: true,
+					},
+					Limit:  int32(n),
+					Offset: int32(n),
+				}
+				store.EXPECT().
+					ListAccountEntries(
+						gomock.Any(),
+						gomock.Eq(arg),
+					).
+					Times(1).
+					Return(
+						[]db.ListAccountEntriesRow{},
+						nil,
+					)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusOK,
+					recorder.Code,
+				)
+			},
+		},
+		{
+			name:      "InvalidTimeRange",
+			accountID: account.ID,
+			query: Query{
+				pageID:   1,
+				pageSize: n,
+				fromTime: toTime.Format(time.RFC3339),
+				toTime:   fromTime.Format(time.RFC3339),
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(
+					t,
+					request,
+					tokenMaker,
+					authorizationTypeBearer,
+					user.Username,
+					util.DepositorRole,
+					time.Minute,
+				)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(0)
+				store.EXPECT().
+					ListAccountEntries(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusBadRequest,
+					recorder.Code,
+				)
+				requireBodyMatchFieldErrors(
+					t,
+					recorder.Body,
+					[]fieldError{
+						{
+							Field:   "to_time",
+							Rule:    "gtfield",
+							Message: "must be after from_time",
+						},
+					},
+				)
+			},
+		},
+		{
+			name:      "InvalidFromTime",
+			accountID: account.ID,
+			query: Query{
+				pageID:   1,
+				pageSize: n,
+				fromTime: "yesterday",
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(
+					t,
+					request,
+					tokenMaker,
+					authorizationTypeBearer,
+					user.Username,
+					util.DepositorRole,
+					time.Minute,
+				)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListAccountEntries(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusBadRequest,
+					recorder.Code,
+				)
+			},
+		},
+		{
+			name:      "UnauthorizedUser",
+			accountID: account.ID,
+			query: Query{
+				pageID:   1,
+				pageSize: n,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(
+					t,
+					request,
+					tokenMaker,
+					authorizationTypeBearer,
+					otherUser.Username,
+					util.DepositorRole,
+					time.Minute,
+				)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Eq(account.ID),
+					).
+					Times(1).
+					Return(
+						account,
+						nil,
+					)
+				store.EXPECT().
+					ListAccountEntries(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusForbidden,
+					recorder.Code,
+				)
+			},
+		},
+		{
+			name:      "NoAuthorization",
+			accountID: account.ID,
+			query: Query{
+				pageID:   1,
+				pageSize: n,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusUnauthorized,
+					recorder.Code,
+				)
+			},
+		},
+		{
+			name:      "AccountNotFound",
+			accountID: account.ID,
+			query: Query{
+				pageID:   1,
+				pageSize: n,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(
+					t,
+					request,
+					tokenMaker,
+					authorizationTypeBearer,
+					user.Username,
+					util.DepositorRole,
+					time.Minute,
+				)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Eq(account.ID),
+					).
+					Times(1).
+					Return(
+						db.Account{},
+						sql.ErrNoRows,
+					)
+				store.EXPECT().
+					ListAccountEntries(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusNotFound,
+					recorder.Code,
+				)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(
+			tc.name,
+			func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+
+				store := mockdb.NewMockStore(ctrl)
+				tc.buildStubs(store)
+
+				server := newTestServer(
+					t,
+					store,
+				)
+				recorder := httptest.NewRecorder()
+
+				url := fmt.Sprintf(
+					"/accounts/%d/entries",
+					tc.accountID,
+				)
+				request, err := http.NewRequest(
+					http.MethodGet,
+					url,
+					nil,
+				)
+				require.NoError(
+					t,
+					err,
+				)
+
+				q := request.URL.Query()
+				q.Add(
+					"page_id",
+					fmt.Sprintf(
+						"%d",
+						tc.query.pageID,
+					),
+				)
+				q.Add(
+					"page_size",
+					fmt.Sprintf(
+						"%d",
+						tc.query.pageSize,
+					),
+				)
+				if tc.query.fromTime != "" {
+					q.Add(
+						"from_time",
+						tc.query.fromTime,
+					)
+				}
+				if tc.query.toTime != "" {
+					q.Add(
+						"to_time",
+						tc.query.toTime,
+					)
+				}
+				request.URL.RawQuery = q.Encode()
+
+				tc.setupAuth(
+					t,
+					request,
+					server.tokenMaker,
+				)
+				server.router.ServeHTTP(
+					recorder,
+					request,
+				)
+
+				tc.checkResponse(
+					t,
+					recorder,
+				)
+			},
+		)
+	}
+}
+
+func TestGetEntryAPI(t *testing.T) {
+	user, _ := RandomUser(t)
+	otherUser, _ := RandomUser(t)
+	account := RandomAccount(user.Username)
+	entry := db.GetEntryWithBalanceRow(randomEntries(
+		account,
+		1,
+	)[0])
+
+	testCases := []struct {
+		name          string
+		entryID       int64
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:    "OK",
+			entryID: entry.ID,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(
+					t,
+					request,
+					tokenMaker,
+					authorizationTypeBearer,
+					user.Username,
+					util.DepositorRole,
+					time.Minute,
+				)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetEntryWithBalance(
+						gomock.Any(),
+						gomock.Eq(entry.ID),
+					).
+					Times(1).
+					Return(
+						entry,
+						nil,
+					)
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Eq(account.ID),
+					).
+					Times(1).
+					Return(
+						account,
+						nil,
+					)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusOK,
+					recorder.Code,
+				)
+
+				requireBodyMatchJSON(
+					t,
+					recorder.Body.Bytes(),
+					newEntryBalanceResponse(
+						decimalAmounts(t),
+						entry,
+						account.Currency,
+					),
+				)
+			},
+		},
+		{
+			name:    "UnauthorizedUser",
+			entryID: entry.ID,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(
+					t,
+					request,
+					tokenMaker,
+					authorizationTypeBearer,
+					otherUser.Username,
+					util.DepositorRole,
+					time.Minute,
+				)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetEntryWithBalance(
+						gomock.Any(),
+						gomock.Eq(entry.ID),
+					).
+					Times(1).
+					Return(
+						entry,
+						nil,
+					)
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Eq(account.ID),
+					).
+					Times(1).
+					Return(
+						account,
+						nil,
+					)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusForbidden,
+					recorder.Code,
+				)
+			},
+		},
+		{
+			name:    "NotFound",
+			entryID: entry.ID,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(
+					t,
+					request,
+					tokenMaker,
+					authorizationTypeBearer,
+					user.Username,
+					util.DepositorRole,
+					time.Minute,
+				)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetEntryWithBalance(
+						gomock.Any(),
+						gomock.Eq(entry.ID),
+					).
+					Times(1).
+					Return(
+						db.GetEntryWithBalanceRow{},
+						sql.ErrNoRows,
+					)
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusNotFound,
+					recorder.Code,
+				)
+			},
+		},
+		{
+			name:    "InvalidID",
+			entryID: 0,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(
+					t,
+					request,
+					tokenMaker,
+					authorizationTypeBearer,
+					user.Username,
+					util.DepositorRole,
+					time.Minute,
+				)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetEntryWithBalance(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusBadRequest,
+					recorder.Code,
+				)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(
+			tc.name,
+			func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+
+				store := mockdb.NewMockStore(ctrl)
+				tc.buildStubs(store)
+
+				server := newTestServer(
+					t,
+					store,
+				)
+				recorder := httptest.NewRecorder()
+
+				url := fmt.Sprintf(
+					"/entries/%d",
+					tc.entryID,
+				)
+				request, err := http.NewRequest(
+					http.MethodGet,
+					url,
+					nil,
+				)
+				require.NoError(
+					t,
+					err,
+				)
+
+				tc.setupAuth(
+					t,
+					request,
+					server.tokenMaker,
+				)
+				server.router.ServeHTTP(
+					recorder,
+					request,
+				)
+
+				tc.checkResponse(
+					t,
+					recorder,
+				)
+			},
+		)
+	}
+}
+
+// randomEntries returns n consecutive entries of account whose running balance ends at its balance
+func randomEntries(account db.Account, n int) []db.ListAccountEntriesRow {
+	entries := make(
+		[]db.ListAccountEntriesRow,
+		n,
+	)
+	balance := account.Balance
+	for i := n - 1; i >= 0; i-- {
+		entries[i] = db.ListAccountEntriesRow{
+			ID:             int64(i + 1),
+			AccountID:      account.ID,
+			Amount:         util.RandomMoney(),
+			CreatedAt:      time.Now().UTC().Truncate(time.Second),
+			RunningBalance: balance,
+		}
+		balance -= entries[i].Amount
+	}
+	return entries
+}
+
+func requireBodyMatchEntries(t *testing.T, body *bytes.Buffer, entries []db.ListAccountEntriesRow, currency string) {
+	data, err := io.ReadAll(body)
+	require.NoError(
+		t,
+		err,
+	)
+
+	w := decimalAmounts(t)
+	want := make([]entryResponse, 0, len(entries))
+	for _, entry := range entries {
+		want = append(
+			want,
+			newEntryBalanceResponse(
+				w,
+				db.GetEntryWithBalanceRow(entry),
+				currency,
+			),
+		)
+	}
+	requireBodyMatchJSON(
+		t,
+		data,
+		want,
+	)
+}
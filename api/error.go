@@ -0,0 +1,343 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	db "github.com/PFefe/simplebank/db/sqlc"
+	"github.com/PFefe/simplebank/fx"
+	"github.com/gin-gonic/gin"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// Stable machine-readable error codes sent to clients
+const (
+	codeInvalidRequest             = "invalid_request"
+	codeUnauthorized               = "unauthorized"
+	codeForbidden                  = "forbidden"
+	codeNotFound                   = "not_found"
+	codeAlreadyExists              = "already_exists"
+	codeInvalidReference           = "invalid_reference"
+	codeConstraintViolation        = "constraint_violation"
+	codeCurrencyMismatch           = "currency_mismatch"
+	codeSameCurrency               = "same_currency"
+	codeInsufficientFunds          = "insufficient_funds"
+	codeIdempotencyConflict        = "idempotency_conflict"
+	codeAccountNotActive           = "account_not_active"
+	codeInvalidTransition          = "invalid_status_transition"
+	codeBalanceNotZero             = "balance_not_zero"
+	codePreconditionFailed         = "precondition_failed"
+	codeRetryLater                 = "retry_later"
+	codeRateUnavailable            = "rate_unavailable"
+	codeQuoteUnavailable           = "quote_unavailable"
+	codeLimitExceeded              = "limit_exceeded"
+	codeTransferRequestUnavailable = "transfer_request_unavailable"
+	codeHoldUnavailable            = "hold_unavailable"
+	codeCaptureExceedsHold         = "capture_exceeds_hold"
+	codeInternal                   = "internal_error"
+)
+
+const problemContentType = "application/problem+json"
+
+// apiError is an error that is safe to show to the client
+type apiError struct {
+	Status  int
+	Code    string
+	Message string
+	Errors  []fieldError
+}
+
+// problemDetails is the RFC 7807 body of every error response
+type problemDetails struct {
+	Type     string       `json:"type"`
+	Title    string       `json:"title"`
+	Status   int          `json:"status"`
+	Detail   string       `json:"detail"`
+	Instance string       `json:"instance,omitempty"`
+	Code     string       `json:"code"`
+	Errors   []fieldError `json:"errors,omitempty"`
+}
+
+// fieldError describes a single invalid request field
+type fieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+func (e *apiError) Error() string {
+	return e.Message
+}
+
+func newAPIError(status int, code string, message string) *apiError {
+	return &apiError{
+		Status:  status,
+		Code:    code,
+		Message: message,
+	}
+}
+
+// invalidRequestError reports a request that failed binding or validation, listing every invalid field
+func invalidRequestError(ctx *gin.Context, err error) *apiError {
+	fieldErrors := bindingFieldErrors(
+		ctx,
+		err,
+	)
+	if fieldErrors == nil {
+		return newAPIError(
+			http.StatusBadRequest,
+			codeInvalidRequest,
+			"request could not be parsed",
+		)
+	}
+
+	apiErr := newAPIError(
+		http.StatusBadRequest,
+		codeInvalidRequest,
+		"request has invalid fields",
+	)
+	apiErr.Errors = fieldErrors
+	return apiErr
+}
+
+// invalidFieldError reports a single field that broke a rule the validator cannot express
+func invalidFieldError(field string, rule string, message string) *apiError {
+	apiErr := newAPIError(
+		http.StatusBadRequest,
+		codeInvalidRequest,
+		"request has invalid fields",
+	)
+	apiErr.Errors = []fieldError{
+		{
+			Field:   field,
+			Rule:    rule,
+			Message: message,
+		},
+	}
+	return apiErr
+}
+
+func (e *apiError) problem(instance string) problemDetails {
+	return problemDetails{
+		Type:     "/problems/" + strings.ReplaceAll(e.Code, "_", "-"),
+		Title:    http.StatusText(e.Status),
+		Status:   e.Status,
+		Detail:   e.Message,
+		Instance: instance,
+		Code:     e.Code,
+		Errors:   e.Errors,
+	}
+}
+
+// toAPIError maps err to the error sent to the client, hiding the text of unexpected errors
+func toAPIError(err error) *apiError {
+	var apiErr *apiError
+	if errors.As(
+		err,
+		&apiErr,
+	) {
+		return apiErr
+	}
+
+	err = db.ClassifyError(err)
+	var limitErr *db.LimitExceededError
+	switch {
+	case errors.Is(
+		err,
+		db.ErrRecordNotFound,
+	):
+		return newAPIError(
+			http.StatusNotFound,
+			codeNotFound,
+			"resource not found",
+		)
+	case errors.Is(
+		err,
+		db.ErrUniqueViolation,
+	):
+		return newAPIError(
+			http.StatusConflict,
+			codeAlreadyExists,
+			"resource already exists",
+		)
+	case errors.Is(
+		err,
+		db.ErrForeignKeyViolation,
+	):
+		return newAPIError(
+			http.StatusForbidden,
+			codeInvalidReference,
+			"referenced resource does not exist",
+		)
+	case errors.Is(
+		err,
+		db.ErrCheckViolation,
+	):
+		return newAPIError(
+			http.StatusUnprocessableEntity,
+			codeConstraintViolation,
+			"request violates a data constraint",
+		)
+	case errors.Is(
+		err,
+		db.ErrSerializationFailure,
+	):
+		return newAPIError(
+			http.StatusServiceUnavailable,
+			codeRetryLater,
+			"concurrent update, please retry",
+		)
+	case errors.Is(
+		err,
+		db.ErrInsufficientFunds,
+	):
+		return newAPIError(
+			http.StatusUnprocessableEntity,
+			codeInsufficientFunds,
+			"insufficient funds",
+		)
+	case errors.Is(
+		err,
+		db.ErrAccountNotActive,
+	):
+		return newAPIError(
+			http.StatusConflict,
+			codeAccountNotActive,
+			"account is frozen or closed",
+		)
+	case errors.Is(
+		err,
+		db.ErrInvalidStatusTransition,
+	):
+		return newAPIError(
+			http.StatusConflict,
+			codeInvalidTransition,
+			err.Error(),
+		)
+	case errors.Is(
+		err,
+		db.ErrInvalidTransferTransition,
+	):
+		return newAPIError(
+			http.StatusConflict,
+			codeInvalidTransition,
+			"transfer was already reversed or cannot be reversed",
+		)
+	case errors.Is(
+		err,
+		db.ErrAccountBalanceNotZero,
+	):
+		return newAPIError(
+			http.StatusConflict,
+			codeBalanceNotZero,
+			"only an account with a zero balance and no holds can be closed",
+		)
+	case errors.Is(
+		err,
+		fx.ErrRateNotFound,
+	):
+		return newAPIError(
+			http.StatusUnprocessableEntity,
+			codeRateUnavailable,
+			"no exchange rate between the account currencies",
+		)
+	case errors.Is(
+		err,
+		db.ErrSameCurrency,
+	):
+		return newAPIError(
+			http.StatusUnprocessableEntity,
+			codeSameCurrency,
+			"a currency conversion needs accounts in different currencies",
+		)
+	case errors.Is(
+		err,
+		db.ErrQuoteUnavailable,
+	):
+		return newAPIError(
+			http.StatusConflict,
+			codeQuoteUnavailable,
+			err.Error(),
+		)
+	case errors.As(
+		err,
+		&limitErr,
+	):
+		// the limit is named, but not how far the account is into it
+		return newAPIError(
+			http.StatusUnprocessableEntity,
+			codeLimitExceeded,
+			fmt.Sprintf(
+				"transfer would exceed the %s limit of the %s",
+				limitErr.Limit,
+				limitErr.Scope,
+			),
+		)
+	case errors.Is(
+		err,
+		db.ErrTransferRequestUnavailable,
+	):
+		return newAPIError(
+			http.StatusConflict,
+			codeTransferRequestUnavailable,
+			err.Error(),
+		)
+	case errors.Is(
+		err,
+		db.ErrHoldUnavailable,
+	):
+		return newAPIError(
+			http.StatusConflict,
+			codeHoldUnavailable,
+			"hold was already captured, voided or has expired",
+		)
+	case errors.Is(
+		err,
+		db.ErrCaptureExceedsHold,
+	):
+		return newAPIError(
+			http.StatusUnprocessableEntity,
+			codeCaptureExceedsHold,
+			"capture exceeds the authorized amount of the hold",
+		)
+	case errors.Is(
+		err,
+		db.ErrIdempotencyKeyInUse,
+	):
+		return newAPIError(
+			http.StatusConflict,
+			codeIdempotencyConflict,
+			err.Error(),
+		)
+	}
+
+	return newAPIError(
+		http.StatusInternalServerError,
+		codeInternal,
+		"internal server error",
+	)
+}
+
+// abortWithError writes err as the error response; internal details only go to the log
+func abortWithError(ctx *gin.Context, err error) {
+	apiErr := toAPIError(err)
+	if apiErr.Status >= http.StatusInternalServerError {
+		log.Printf(
+			"%s %s failed: %v",
+			ctx.Request.Method,
+			ctx.Request.URL.Path,
+			err,
+		)
+	}
+
+	// gin keeps a content type that is already set when rendering JSON
+	ctx.Header(
+		"Content-Type",
+		problemContentType,
+	)
+	ctx.AbortWithStatusJSON(
+		apiErr.Status,
+		apiErr.problem(ctx.Request.URL.Path),
+	)
+}
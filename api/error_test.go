@@ -0,0 +1,331 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	mockdb "github.com/PFefe/simplebank/db/mock"
+	db "github.com/PFefe/simplebank/db/sqlc"
+	"github.com/PFefe/simplebank/util"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestAbortWithError(t *testing.T) {
+	testCases := []struct {
+		name   string
+		err    error
+		status int
+		code   string
+	}{
+		{
+			name:   "NotFound",
+			err:    sql.ErrNoRows,
+			status: http.StatusNotFound,
+			code:   codeNotFound,
+		},
+		{
+			name:   "UniqueViolation",
+			err:    &pq.Error{Code: db.UniqueViolation, Constraint: "accounts_owner_currency_key"},
+			status: http.StatusConflict,
+			code:   codeAlreadyExists,
+		},
+		{
+			name:   "ForeignKeyViolation",
+			err:    &pq.Error{Code: db.ForeignKeyViolation, Constraint: "accounts_owner_fkey"},
+			status: http.StatusForbidden,
+			code:   codeInvalidReference,
+		},
+		{
+			name:   "CheckViolation",
+			err:    &pq.Error{Code: db.CheckViolation},
+			status: http.StatusUnprocessableEntity,
+			code:   codeConstraintViolation,
+		},
+		{
+			name:   "SerializationFailure",
+			err:    &pq.Error{Code: db.SerializationFailure},
+			status: http.StatusServiceUnavailable,
+			code:   codeRetryLater,
+		},
+		{
+			name:   "InsufficientFunds",
+			err:    &db.InsufficientFundsError{AccountID: 1},
+			status: http.StatusUnprocessableEntity,
+			code:   codeInsufficientFunds,
+		},
+		{
+			name:   "AccountNotActive",
+			err:    fmt.Errorf("account [1] is frozen: %w", db.ErrAccountNotActive),
+			status: http.StatusConflict,
+			code:   codeAccountNotActive,
+		},
+		{
+			name:   "SameCurrency",
+			err:    db.ErrSameCurrency,
+			status: http.StatusUnprocessableEntity,
+			code:   codeSameCurrency,
+		},
+		{
+			name:   "LimitExceeded",
+			err:    &db.LimitExceededError{Scope: db.LimitScopeAccount, Limit: db.LimitDailyAmount},
+			status: http.StatusUnprocessableEntity,
+			code:   codeLimitExceeded,
+		},
+		{
+			name:   "Internal",
+			err:    errors.New("pq: password authentication failed for user root"),
+			status: http.StatusInternalServerError,
+			code:   codeInternal,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(
+			tc.name,
+			func(t *testing.T) {
+				recorder := httptest.NewRecorder()
+				ctx, _ := gin.CreateTestContext(recorder)
+				ctx.Request = httptest.NewRequest(
+					http.MethodGet,
+					"/",
+					nil,
+				)
+
+				abortWithError(
+					ctx,
+					tc.err,
+				)
+				require.Equal(
+					t,
+					tc.status,
+					recorder.Code,
+				)
+
+				require.Equal(
+					t,
+					problemContentType,
+					recorder.Header().Get("Content-Type"),
+				)
+
+				var rsp problemDetails
+				err := json.Unmarshal(
+					recorder.Body.Bytes(),
+					&rsp,
+				)
+				require.NoError(
+					t,
+					err,
+				)
+				require.Equal(
+					t,
+					tc.code,
+					rsp.Code,
+				)
+				require.Equal(
+					t,
+					tc.status,
+					rsp.Status,
+				)
+				// the raw database error must never reach the client
+				require.NotContains(
+					t,
+					recorder.Body.String(),
+					tc.err.Error(),
+				)
+			},
+		)
+	}
+}
+
+func TestInvalidRequestError(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(recorder)
+	ctx.Request = httptest.NewRequest(
+		http.MethodPost,
+		"/users",
+		bytes.NewReader([]byte(`{"username": "bad user", "password": "abc"}`)),
+	)
+	// creating a server registers the json field names with the validator
+	_ = newTestServer(
+		t,
+		nil,
+	)
+
+	var req createUserRequest
+	err := ctx.ShouldBindJSON(&req)
+	require.Error(
+		t,
+		err,
+	)
+	abortWithError(
+		ctx,
+		invalidRequestError(
+			ctx,
+			err,
+		),
+	)
+
+	require.Equal(
+		t,
+		http.StatusBadRequest,
+		recorder.Code,
+	)
+	requireBodyMatchFieldErrors(
+		t,
+		recorder.Body,
+		[]fieldError{
+			{
+				Field:   "username",
+				Rule:    "alphanum",
+				Message: "must contain only letters and digits",
+			},
+			{
+				Field:   "password",
+				Rule:    "min",
+				Message: "must be at least 6 characters long",
+			},
+			{
+				Field:   "full_name",
+				Rule:    "required",
+				Message: "is required",
+			},
+			{
+				Field:   "email",
+				Rule:    "required",
+				Message: "is required",
+			},
+		},
+	)
+}
+
+func TestInvalidParamErrors(t *testing.T) {
+	user, _ := RandomUser(t)
+
+	testCases := []struct {
+		name       string
+		url        string
+		fieldError fieldError
+	}{
+		{
+			name: "QueryInteger",
+			url:  "/accounts?page_size=abc",
+			fieldError: fieldError{
+				Field:   "page_size",
+				Rule:    "type",
+				Message: "must be an integer",
+			},
+		},
+		{
+			name: "QueryOutOfRange",
+			url:  "/accounts?page_id=99999999999",
+			fieldError: fieldError{
+				Field:   "page_id",
+				Rule:    "type",
+				Message: "is out of range",
+			},
+		},
+		{
+			name: "URIInteger",
+			url:  "/accounts/abc",
+			fieldError: fieldError{
+				Field:   "id",
+				Rule:    "type",
+				Message: "must be an integer",
+			},
+		},
+		{
+			name: "QueryTime",
+			url:  "/accounts/1/entries?from_time=yesterday",
+			fieldError: fieldError{
+				Field:   "from_time",
+				Rule:    "type",
+				Message: "must be an RFC 3339 timestamp",
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(
+			tc.name,
+			func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+
+				// the request is rejected before the store is used
+				server := newTestServer(
+					t,
+					mockdb.NewMockStore(ctrl),
+				)
+				recorder := httptest.NewRecorder()
+
+				request, err := http.NewRequest(
+					http.MethodGet,
+					tc.url,
+					nil,
+				)
+				require.NoError(
+					t,
+					err,
+				)
+
+				addAuthorization(
+					t,
+					request,
+					server.tokenMaker,
+					authorizationTypeBearer,
+					user.Username,
+					util.DepositorRole,
+					time.Minute,
+				)
+				server.router.ServeHTTP(
+					recorder,
+					request,
+				)
+
+				require.Equal(
+					t,
+					http.StatusBadRequest,
+					recorder.Code,
+				)
+				requireBodyMatchFieldErrors(
+					t,
+					recorder.Body,
+					[]fieldError{tc.fieldError},
+				)
+			},
+		)
+	}
+}
+
+func requireBodyMatchFieldErrors(t *testing.T, body *bytes.Buffer, fieldErrors []fieldError) {
+	var rsp problemDetails
+	err := json.Unmarshal(
+		body.Bytes(),
+		&rsp,
+	)
+	require.NoError(
+		t,
+		err,
+	)
+	require.Equal(
+		t,
+		codeInvalidRequest,
+		rsp.Code,
+	)
+	require.Equal(
+		t,
+		fieldErrors,
+		rsp.Errors,
+	)
+}
@@ -0,0 +1,54 @@
+package api
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// expiryInterval is how often the transfer requests and holds that ran out of time are expired
+const expiryInterval = time.Minute
+
+// runExpiry expires stale transfer requests and holds on every tick, until ctx is done
+func (server *Server) runExpiry(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			server.expire(ctx)
+		}
+	}
+}
+
+// expire moves the requests nobody reviewed in time to expired, and releases the holds nobody settled in time
+func (server *Server) expire(ctx context.Context) {
+	expired, err := server.store.ExpireTransferRequests(ctx)
+	if err != nil {
+		log.Printf(
+			"Failed to expire transfer requests: %v",
+			err,
+		)
+	} else if expired > 0 {
+		log.Printf(
+			"Expired %d transfer requests",
+			expired,
+		)
+	}
+
+	released, err := server.store.ExpireHoldsTx(ctx)
+	if err != nil {
+		log.Printf(
+			"Failed to expire holds: %v",
+			err,
+		)
+	} else if released > 0 {
+		log.Printf(
+			"Released expired holds of %d accounts",
+			released,
+		)
+	}
+}
@@ -0,0 +1,55 @@
+package api
+
+import (
+	"context"
+	"errors"
+	mockdb "github.com/PFefe/simplebank/db/mock"
+	"github.com/golang/mock/gomock"
+	"testing"
+	"time"
+)
+
+func TestRunExpiry(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		ExpireTransferRequests(gomock.Any()).
+		MinTimes(1).
+		Return(
+			int64(0),
+			errors.New("connection refused"),
+		)
+	// a failure of one doesn't keep the other from running
+	store.EXPECT().
+		ExpireHoldsTx(gomock.Any()).
+		MinTimes(1).
+		DoAndReturn(func(context.Context) (int64, error) {
+			cancel()
+			return 1, nil
+		})
+
+	server := newTestServer(
+		t,
+		store,
+	)
+
+	done := make(chan struct{})
+	go func() {
+		server.runExpiry(
+			ctx,
+			time.Millisecond,
+		)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expiry loop did not stop")
+	}
+}
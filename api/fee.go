@@ -0,0 +1,211 @@
+package api
+
+import (
+	db "github.com/PFefe/simplebank/db/sqlc"
+	"github.com/gin-gonic/gin"
+	"math/big"
+	"net/http"
+	"time"
+)
+
+// feeScheduleRequest sets one tier of the fee schedule of a currency and account type.
+// The amounts are in the currency of the schedule and all default to zero.
+type feeScheduleRequest struct {
+	Currency    string      `json:"currency" binding:"required,currency"`
+	AccountType string      `json:"account_type" binding:"required,oneof=checking savings"`
+	MinAmount   amountInput `json:"min_amount"`
+	FlatFee     amountInput `json:"flat_fee"`
+	// Percentage is the fraction of the amount charged, e.g. "0.005" for half a percent
+	Percentage string      `json:"percentage" binding:"omitempty,numeric"`
+	MinFee     amountInput `json:"min_fee"`
+	// MaxFee caps the fee; the tier is uncapped without it
+	MaxFee amountInput `json:"max_fee"`
+}
+
+// feeScheduleResponse is the public view of a tier, with its amounts in the format of the request
+type feeScheduleResponse struct {
+	ID          int64          `json:"id"`
+	Currency    string         `json:"currency"`
+	AccountType db.AccountType `json:"account_type"`
+	MinAmount   amountOutput   `json:"min_amount"`
+	FlatFee     amountOutput   `json:"flat_fee"`
+	Percentage  string         `json:"percentage"`
+	MinFee      amountOutput   `json:"min_fee"`
+	MaxFee      *amountOutput  `json:"max_fee"`
+	CreatedAt   time.Time      `json:"created_at"`
+}
+
+func newFeeScheduleResponse(w amountWriter, schedule db.FeeSchedule) feeScheduleResponse {
+	rsp := feeScheduleResponse{
+		ID:          schedule.ID,
+		Currency:    schedule.Currency,
+		AccountType: schedule.AccountType,
+		MinAmount: w.amount(
+			schedule.MinAmount,
+			schedule.Currency,
+		),
+		FlatFee: w.amount(
+			schedule.FlatFee,
+			schedule.Currency,
+		),
+		Percentage: schedule.Percentage,
+		MinFee: w.amount(
+			schedule.MinFee,
+			schedule.Currency,
+		),
+		CreatedAt: schedule.CreatedAt,
+	}
+	if schedule.MaxFee.Valid {
+		maxFee := w.amount(
+			schedule.MaxFee.Int64,
+			schedule.Currency,
+		)
+		rsp.MaxFee = &maxFee
+	}
+	return rsp
+}
+
+// upsertFeeSchedule creates a tier, or replaces the tier of the schedule with the same min_amount
+func (server *Server) upsertFeeSchedule(ctx *gin.Context) {
+	var req feeScheduleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		abortWithError(
+			ctx,
+			invalidRequestError(
+				ctx,
+				err,
+			),
+		)
+		return
+	}
+
+	arg := db.UpsertFeeScheduleParams{
+		Currency:    req.Currency,
+		AccountType: db.AccountType(req.AccountType),
+		Percentage:  "0",
+	}
+
+	if req.Percentage != "" {
+		percentage, ok := new(big.Rat).SetString(req.Percentage)
+		if !ok || percentage.Sign() < 0 || percentage.Cmp(big.NewRat(1, 1)) >= 0 {
+			abortWithError(
+				ctx,
+				invalidFieldError(
+					"percentage",
+					"percentage",
+					"must be a fraction from 0 up to 1",
+				),
+			)
+			return
+		}
+		arg.Percentage = req.Percentage
+	}
+
+	amounts := []struct {
+		field string
+		input amountInput
+		value *int64
+	}{
+		{
+			field: "min_amount",
+			input: req.MinAmount,
+			value: &arg.MinAmount,
+		},
+		{
+			field: "flat_fee",
+			input: req.FlatFee,
+			value: &arg.FlatFee,
+		},
+		{
+			field: "min_fee",
+			input: req.MinFee,
+			value: &arg.MinFee,
+		},
+		{
+			field: "max_fee",
+			input: req.MaxFee,
+			value: &arg.MaxFee.Int64,
+		},
+	}
+	for _, amount := range amounts {
+		if len(amount.input) == 0 {
+			continue
+		}
+
+		var apiErr *apiError
+		*amount.value, apiErr = server.parseAmount(
+			ctx,
+			amount.field,
+			amount.input,
+			req.Currency,
+		)
+		if apiErr != nil {
+			abortWithError(
+				ctx,
+				apiErr,
+			)
+			return
+		}
+	}
+	arg.MaxFee.Valid = len(req.MaxFee) > 0
+
+	if arg.MaxFee.Valid && arg.MaxFee.Int64 < arg.MinFee {
+		abortWithError(
+			ctx,
+			invalidFieldError(
+				"max_fee",
+				"gtefield",
+				"must not be less than min_fee",
+			),
+		)
+		return
+	}
+
+	schedule, err := server.store.UpsertFeeSchedule(
+		ctx,
+		arg,
+	)
+	if err != nil {
+		abortWithError(
+			ctx,
+			err,
+		)
+		return
+	}
+
+	ctx.JSON(
+		http.StatusOK,
+		newFeeScheduleResponse(
+			server.amountWriter(ctx),
+			schedule,
+		),
+	)
+}
+
+// listFeeSchedules returns every tier of every schedule
+func (server *Server) listFeeSchedules(ctx *gin.Context) {
+	schedules, err := server.store.ListFeeSchedules(ctx)
+	if err != nil {
+		abortWithError(
+			ctx,
+			err,
+		)
+		return
+	}
+
+	w := server.amountWriter(ctx)
+	rsp := make([]feeScheduleResponse, 0, len(schedules))
+	for _, schedule := range schedules {
+		rsp = append(
+			rsp,
+			newFeeScheduleResponse(
+				w,
+				schedule,
+			),
+		)
+	}
+	ctx.JSON(
+		http.StatusOK,
+		rsp,
+	)
+}
@@ -0,0 +1,401 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	mockdb "github.com/PFefe/simplebank/db/mock"
+	db "github.com/PFefe/simplebank/db/sqlc"
+	"github.com/PFefe/simplebank/util"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestUpsertFeeScheduleAPI(t *testing.T) {
+	banker, _ := RandomUser(t)
+	teller, _ := RandomUser(t)
+
+	schedule := db.FeeSchedule{
+		ID: util.RandomInt(
+			1,
+			1000,
+		),
+		Currency:    util.USD,
+		AccountType: db.AccountTypeChecking,
+		MinAmount:   100000,
+		FlatFee:     25,
+		Percentage:  "0.005",
+		MinFee:      50,
+		MaxFee: sql.NullInt64{
+			Int64: 2500,
+			Valid: true,
+		},
+		CreatedAt: time.Now().UTC().Truncate(time.Second),
+	}
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		username      string
+		role          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{
+				"currency":     util.USD,
+				"account_type": "checking",
+				"min_amount":   "1000.00",
+				"flat_fee":     "0.25",
+				"percentage":   "0.005",
+				"min_fee":      "0.50",
+				"max_fee":      "25.00",
+			},
+			username: banker.Username,
+			role:     util.BankerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.UpsertFeeScheduleParams{
+					Currency:    schedule.Currency,
+					AccountType: schedule.AccountType,
+					MinAmount:   schedule.MinAmount,
+					FlatFee:     schedule.FlatFee,
+					Percentage:  schedule.Percentage,
+					MinFee:      schedule.MinFee,
+					MaxFee:      schedule.MaxFee,
+				}
+				store.EXPECT().
+					UpsertFeeSchedule(
+						gomock.Any(),
+						gomock.Eq(arg),
+					).
+					Times(1).
+					Return(
+						schedule,
+						nil,
+					)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusOK,
+					recorder.Code,
+				)
+				requireBodyMatchJSON(
+					t,
+					recorder.Body.Bytes(),
+					newFeeScheduleResponse(
+						decimalAmounts(t),
+						schedule,
+					),
+				)
+			},
+		},
+		{
+			name: "Defaults",
+			body: gin.H{
+				"currency":     util.EUR,
+				"account_type": "savings",
+			},
+			username: banker.Username,
+			role:     util.BankerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.UpsertFeeScheduleParams{
+					Currency:    util.EUR,
+					AccountType: db.AccountTypeSavings,
+					Percentage:  "0",
+				}
+				store.EXPECT().
+					UpsertFeeSchedule(
+						gomock.Any(),
+						gomock.Eq(arg),
+					).
+					Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusOK,
+					recorder.Code,
+				)
+			},
+		},
+		{
+			name: "NotBanker",
+			body: gin.H{
+				"currency":     util.USD,
+				"account_type": "checking",
+			},
+			username: teller.Username,
+			role:     util.TellerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpsertFeeSchedule(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusForbidden,
+					recorder.Code,
+				)
+			},
+		},
+		{
+			name: "InvalidPercentage",
+			body: gin.H{
+				"currency":     util.USD,
+				"account_type": "checking",
+				"percentage":   "1.5",
+			},
+			username: banker.Username,
+			role:     util.BankerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpsertFeeSchedule(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusBadRequest,
+					recorder.Code,
+				)
+			},
+		},
+		{
+			name: "MaxFeeBelowMinFee",
+			body: gin.H{
+				"currency":     util.USD,
+				"account_type": "checking",
+				"min_fee":      "1.00",
+				"max_fee":      "0.50",
+			},
+			username: banker.Username,
+			role:     util.BankerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpsertFeeSchedule(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusBadRequest,
+					recorder.Code,
+				)
+				requireBodyMatchFieldErrors(
+					t,
+					recorder.Body,
+					[]fieldError{
+						{
+							Field:   "max_fee",
+							Rule:    "gtefield",
+							Message: "must not be less than min_fee",
+						},
+					},
+				)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(
+			tc.name,
+			func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+
+				store := mockdb.NewMockStore(ctrl)
+				tc.buildStubs(store)
+
+				server := newTestServer(
+					t,
+					store,
+				)
+				recorder := httptest.NewRecorder()
+
+				data, err := json.Marshal(tc.body)
+				require.NoError(
+					t,
+					err,
+				)
+
+				request, err := http.NewRequest(
+					http.MethodPut,
+					"/fee_schedules",
+					bytes.NewReader(data),
+				)
+				require.NoError(
+					t,
+					err,
+				)
+
+				addAuthorization(
+					t,
+					request,
+					server.tokenMaker,
+					authorizationTypeBearer,
+					tc.username,
+					tc.role,
+					time.Minute,
+				)
+				server.router.ServeHTTP(
+					recorder,
+					request,
+				)
+
+				tc.checkResponse(
+					t,
+					recorder,
+				)
+			},
+		)
+	}
+}
+
+func TestTransferFeeResponse(t *testing.T) {
+	user1, _ := RandomUser(t)
+	user2, _ := RandomUser(t)
+
+	account1 := RandomAccount(user1.Username)
+	account2 := RandomAccount(user2.Username)
+	account1.Currency = util.USD
+	account2.Currency = util.USD
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		GetAccount(
+			gomock.Any(),
+			gomock.Eq(account1.ID),
+		).
+		Times(1).
+		Return(
+			account1,
+			nil,
+		)
+	store.EXPECT().
+		GetAccount(
+			gomock.Any(),
+			gomock.Eq(account2.ID),
+		).
+		Times(1).
+		Return(
+			account2,
+			nil,
+		)
+	store.EXPECT().
+		TransferTx(
+			gomock.Any(),
+			gomock.Any(),
+		).
+		Times(1).
+		Return(
+			db.TransferTxResult{
+				Transfer: db.Transfer{
+					FromAccountID: account1.ID,
+					ToAccountID:   account2.ID,
+					Amount:        1000,
+					Currency:      util.USD,
+					ToAmount:      1000,
+					ToCurrency:    util.USD,
+					Fee:           15,
+				},
+				FromAccount: account1,
+				ToAccount:   account2,
+				Fee:         15,
+			},
+			nil,
+		)
+
+	server := newTestServer(
+		t,
+		store,
+	)
+	recorder := httptest.NewRecorder()
+
+	data, err := json.Marshal(
+		gin.H{
+			"from_account_id": account1.ID,
+			"to_account_id":   account2.ID,
+			"amount":          "10.00",
+			"currency":        util.USD,
+		},
+	)
+	require.NoError(
+		t,
+		err,
+	)
+
+	request, err := http.NewRequest(
+		http.MethodPost,
+		"/transfers",
+		bytes.NewReader(data),
+	)
+	require.NoError(
+		t,
+		err,
+	)
+	addAuthorization(
+		t,
+		request,
+		server.tokenMaker,
+		authorizationTypeBearer,
+		user1.Username,
+		util.DepositorRole,
+		time.Minute,
+	)
+	server.router.ServeHTTP(
+		recorder,
+		request,
+	)
+
+	require.Equal(
+		t,
+		http.StatusOK,
+		recorder.Code,
+	)
+
+	var rsp struct {
+		Transfer struct {
+			Fee string `json:"fee"`
+		} `json:"transfer"`
+		Fee string `json:"fee"`
+	}
+	err = json.Unmarshal(
+		recorder.Body.Bytes(),
+		&rsp,
+	)
+	require.NoError(
+		t,
+		err,
+	)
+	require.Equal(
+		t,
+		"0.15",
+		rsp.Fee,
+	)
+	require.Equal(
+		t,
+		"0.15",
+		rsp.Transfer.Fee,
+	)
+}
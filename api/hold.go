@@ -0,0 +1,421 @@
+package api
+
+import (
+	"errors"
+	db "github.com/PFefe/simplebank/db/sqlc"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	"time"
+)
+
+type createHoldRequest struct {
+	AccountID   int64       `json:"account_id" binding:"required,min=1"`
+	ToAccountID int64       `json:"to_account_id" binding:"required,min=1"`
+	Amount      amountInput `json:"amount" binding:"required"`
+	Currency    string      `json:"currency" binding:"required,currency"`
+}
+
+// holdResponse is the public view of a hold, with its amounts in the format of the request
+type holdResponse struct {
+	ID             int64         `json:"id"`
+	AccountID      int64         `json:"account_id"`
+	ToAccountID    int64         `json:"to_account_id"`
+	Amount         amountOutput  `json:"amount"`
+	CapturedAmount amountOutput  `json:"captured_amount"`
+	Currency       string        `json:"currency"`
+	Status         db.HoldStatus `json:"status"`
+	// TransferID is the transfer of the captured amount
+	TransferID *int64    `json:"transfer_id"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func newHoldResponse(w amountWriter, hold db.Hold) holdResponse {
+	rsp := holdResponse{
+		ID:          hold.ID,
+		AccountID:   hold.AccountID,
+		ToAccountID: hold.ToAccountID,
+		Amount: w.amount(
+			hold.Amount,
+			hold.Currency,
+		),
+		CapturedAmount: w.amount(
+			hold.CapturedAmount,
+			hold.Currency,
+		),
+		Currency:  hold.Currency,
+		Status:    hold.Status,
+		ExpiresAt: hold.ExpiresAt,
+		CreatedAt: hold.CreatedAt,
+	}
+	if hold.TransferID.Valid {
+		rsp.TransferID = &hold.TransferID.Int64
+	}
+	return rsp
+}
+
+// holdTxResponse is a hold together with the account it reserves money of
+type holdTxResponse struct {
+	Hold    holdResponse    `json:"hold"`
+	Account accountResponse `json:"account"`
+}
+
+func newHoldTxResponse(w amountWriter, result db.HoldTxResult) holdTxResponse {
+	return holdTxResponse{
+		Hold: newHoldResponse(
+			w,
+			result.Hold,
+		),
+		Account: newAccountResponse(
+			w,
+			result.Account,
+		),
+	}
+}
+
+// createHold authorizes an amount against an account of the user, to be captured by a transfer to the destination later
+func (server *Server) createHold(ctx *gin.Context) {
+	var req createHoldRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		abortWithError(
+			ctx,
+			invalidRequestError(
+				ctx,
+				err,
+			),
+		)
+		return
+	}
+
+	amount, apiErr := server.parsePositiveAmount(
+		ctx,
+		"amount",
+		req.Amount,
+		req.Currency,
+	)
+	if apiErr != nil {
+		abortWithError(
+			ctx,
+			apiErr,
+		)
+		return
+	}
+
+	// the owner captures a hold without review, so it may not bypass the approval of a large transfer
+	if server.needsApproval(
+		amount,
+		req.Currency,
+	) {
+		abortWithError(
+			ctx,
+			holdApprovalError(),
+		)
+		return
+	}
+
+	account, valid := server.validAccount(
+		ctx,
+		req.AccountID,
+		req.Currency,
+	)
+	if !valid {
+		return
+	}
+
+	if !authorizeAccount(
+		ctx,
+		account,
+	) {
+		return
+	}
+
+	// a capture doesn't convert currencies, so the destination must hold the same one
+	_, valid = server.validAccount(
+		ctx,
+		req.ToAccountID,
+		req.Currency,
+	)
+	if !valid {
+		return
+	}
+
+	result, err := server.store.AuthorizeHoldTx(
+		ctx,
+		db.AuthorizeHoldTxParams{
+			AccountID:   account.ID,
+			ToAccountID: req.ToAccountID,
+			Amount:      amount,
+			ExpiresAt:   time.Now().Add(server.config.HoldTTL),
+		},
+	)
+	if err != nil {
+		abortWithError(
+			ctx,
+			err,
+		)
+		return
+	}
+
+	ctx.JSON(
+		http.StatusOK,
+		newHoldTxResponse(
+			server.amountWriter(ctx),
+			result,
+		),
+	)
+}
+
+// holdApprovalError rejects a hold above the approval threshold, which has to be a reviewed transfer instead
+func holdApprovalError() *apiError {
+	return invalidFieldError(
+		"amount",
+		"approval",
+		"is above the approval threshold; make a transfer to have it reviewed",
+	)
+}
+
+type getHoldRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// holdFromURI loads the hold of the uri
+func (server *Server) holdFromURI(ctx *gin.Context) (db.Hold, bool) {
+	var uri getHoldRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		abortWithError(
+			ctx,
+			invalidRequestError(
+				ctx,
+				err,
+			),
+		)
+		return db.Hold{}, false
+	}
+
+	hold, err := server.store.GetHold(
+		ctx,
+		uri.ID,
+	)
+	if err != nil {
+		abortWithError(
+			ctx,
+			err,
+		)
+		return db.Hold{}, false
+	}
+	return hold, true
+}
+
+// ownsHoldAccount checks that the authenticated user owns one of the accounts of the hold
+func (server *Server) ownsHoldAccount(ctx *gin.Context, accountIDs []int64, message string) bool {
+	username := authPayload(ctx).Username
+	for _, accountID := range accountIDs {
+		account, err := server.store.GetAccount(
+			ctx,
+			accountID,
+		)
+		if err != nil {
+			abortWithError(
+				ctx,
+				err,
+			)
+			return false
+		}
+		if account.Owner == username {
+			return true
+		}
+	}
+
+	abortWithError(
+		ctx,
+		newAPIError(
+			http.StatusForbidden,
+			codeForbidden,
+			message,
+		),
+	)
+	return false
+}
+
+// authorizedHold loads the hold of the uri for the owner of either of its accounts or the staff, to read it
+func (server *Server) authorizedHold(ctx *gin.Context) (db.Hold, bool) {
+	hold, ok := server.holdFromURI(ctx)
+	if !ok {
+		return db.Hold{}, false
+	}
+
+	if hasRole(
+		authPayload(ctx),
+		staffRoles...,
+	) {
+		return hold, true
+	}
+
+	if !server.ownsHoldAccount(
+		ctx,
+		[]int64{hold.AccountID, hold.ToAccountID},
+		"hold doesn't involve an account of the authenticated user",
+	) {
+		return db.Hold{}, false
+	}
+	return hold, true
+}
+
+func (server *Server) getHold(ctx *gin.Context) {
+	hold, ok := server.authorizedHold(ctx)
+	if !ok {
+		return
+	}
+
+	ctx.JSON(
+		http.StatusOK,
+		newHoldResponse(
+			server.amountWriter(ctx),
+			hold,
+		),
+	)
+}
+
+type captureHoldRequest struct {
+	// Amount defaults to the whole authorized amount
+	Amount amountInput `json:"amount"`
+}
+
+// captureHoldResponse is the captured hold and the transfer that settled it
+type captureHoldResponse struct {
+	Hold     holdResponse       `json:"hold"`
+	Transfer transferTxResponse `json:"transfer"`
+}
+
+// captureHold transfers all or part of the authorized amount to the destination and releases the rest.
+// Only the payee may capture, the staff can read holds but not settle them.
+func (server *Server) captureHold(ctx *gin.Context) {
+	hold, ok := server.holdFromURI(ctx)
+	if !ok {
+		return
+	}
+
+	if !server.ownsHoldAccount(
+		ctx,
+		[]int64{hold.ToAccountID},
+		"only the owner of the destination account may capture the hold",
+	) {
+		return
+	}
+
+	// the body is optional, since a full capture needs no amount
+	var req captureHoldRequest
+	if ctx.Request.ContentLength != 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil && !errors.Is(
+			err,
+			io.EOF,
+		) {
+			abortWithError(
+				ctx,
+				invalidRequestError(
+					ctx,
+					err,
+				),
+			)
+			return
+		}
+	}
+
+	amount := hold.Amount
+	if len(req.Amount) > 0 {
+		var apiErr *apiError
+		amount, apiErr = server.parsePositiveAmount(
+			ctx,
+			"amount",
+			req.Amount,
+			hold.Currency,
+		)
+		if apiErr != nil {
+			abortWithError(
+				ctx,
+				apiErr,
+			)
+			return
+		}
+	}
+
+	// the threshold may have been lowered since the hold was authorized
+	if server.needsApproval(
+		amount,
+		hold.Currency,
+	) {
+		abortWithError(
+			ctx,
+			holdApprovalError(),
+		)
+		return
+	}
+
+	result, err := server.store.CaptureHoldTx(
+		ctx,
+		db.CaptureHoldTxParams{
+			HoldID: hold.ID,
+			Amount: amount,
+		},
+	)
+	if err != nil {
+		abortWithError(
+			ctx,
+			err,
+		)
+		return
+	}
+
+	w := server.amountWriter(ctx)
+	ctx.JSON(
+		http.StatusOK,
+		captureHoldResponse{
+			Hold: newHoldResponse(
+				w,
+				result.Hold,
+			),
+			Transfer: newTransferTxResponse(
+				w,
+				result.Transfer,
+			),
+		},
+	)
+}
+
+// voidHold cancels a hold, making its whole amount available again.
+// Either party may void it, the staff can read holds but not settle them.
+func (server *Server) voidHold(ctx *gin.Context) {
+	hold, ok := server.holdFromURI(ctx)
+	if !ok {
+		return
+	}
+
+	if !server.ownsHoldAccount(
+		ctx,
+		[]int64{hold.AccountID, hold.ToAccountID},
+		"hold doesn't involve an account of the authenticated user",
+	) {
+		return
+	}
+
+	result, err := server.store.VoidHoldTx(
+		ctx,
+		hold.ID,
+	)
+	if err != nil {
+		abortWithError(
+			ctx,
+			err,
+		)
+		return
+	}
+
+	ctx.JSON(
+		http.StatusOK,
+		newHoldTxResponse(
+			server.amountWriter(ctx),
+			result,
+		),
+	)
+}
@@ -0,0 +1,1014 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	mockdb "github.com/PFefe/simplebank/db/mock"
+	db "github.com/PFefe/simplebank/db/sqlc"
+	"github.com/PFefe/simplebank/util"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func randomHold(account db.Account, toAccount db.Account) db.Hold {
+	return db.Hold{
+		ID: util.RandomInt(
+			1,
+			1000,
+		),
+		AccountID:   account.ID,
+		ToAccountID: toAccount.ID,
+		Amount:      2500,
+		Currency:    account.Currency,
+		Status:      db.HoldStatusAuthorized,
+		ExpiresAt:   time.Now().Add(time.Hour).UTC().Truncate(time.Second),
+		CreatedAt:   time.Now().UTC().Truncate(time.Second),
+	}
+}
+
+func TestCreateHoldAPI(t *testing.T) {
+	user1, _ := RandomUser(t)
+	user2, _ := RandomUser(t)
+
+	account1 := RandomAccount(user1.Username)
+	account2 := RandomAccount(user2.Username)
+	account1.Currency = util.USD
+	account2.Currency = util.USD
+
+	hold := randomHold(
+		account1,
+		account2,
+	)
+	heldAccount := account1
+	heldAccount.AvailableBalance -= hold.Amount
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		username      string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{
+				"account_id":    account1.ID,
+				"to_account_id": account2.ID,
+				"amount":        "25.00",
+				"currency":      util.USD,
+			},
+			username: user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Eq(account1.ID),
+					).
+					Times(1).
+					Return(
+						account1,
+						nil,
+					)
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Eq(account2.ID),
+					).
+					Times(1).
+					Return(
+						account2,
+						nil,
+					)
+				store.EXPECT().
+					AuthorizeHoldTx(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.AuthorizeHoldTxParams) (db.HoldTxResult, error) {
+						require.Equal(
+							t,
+							account1.ID,
+							arg.AccountID,
+						)
+						require.Equal(
+							t,
+							account2.ID,
+							arg.ToAccountID,
+						)
+						require.Equal(
+							t,
+							hold.Amount,
+							arg.Amount,
+						)
+						require.WithinDuration(
+							t,
+							time.Now().Add(time.Hour),
+							arg.ExpiresAt,
+							time.Minute,
+						)
+						return db.HoldTxResult{
+							Hold:    hold,
+							Account: heldAccount,
+						}, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusOK,
+					recorder.Code,
+				)
+				requireBodyMatchJSON(
+					t,
+					recorder.Body.Bytes(),
+					newHoldTxResponse(
+						decimalAmounts(t),
+						db.HoldTxResult{
+							Hold:    hold,
+							Account: heldAccount,
+						},
+					),
+				)
+			},
+		},
+		{
+			name: "InsufficientFunds",
+			body: gin.H{
+				"account_id":    account1.ID,
+				"to_account_id": account2.ID,
+				"amount":        "25.00",
+				"currency":      util.USD,
+			},
+			username: user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(2).
+					DoAndReturn(func(_ context.Context, id int64) (db.Account, error) {
+						if id == account1.ID {
+							return account1, nil
+						}
+						return account2, nil
+					})
+				store.EXPECT().
+					AuthorizeHoldTx(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(1).
+					Return(
+						db.HoldTxResult{},
+						&db.InsufficientFundsError{
+							AccountID: account1.ID,
+						},
+					)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusUnprocessableEntity,
+					recorder.Code,
+				)
+			},
+		},
+		{
+			name: "UnauthorizedUser",
+			body: gin.H{
+				"account_id":    account1.ID,
+				"to_account_id": account2.ID,
+				"amount":        "25.00",
+				"currency":      util.USD,
+			},
+			username: user2.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Eq(account1.ID),
+					).
+					Times(1).
+					Return(
+						account1,
+						nil,
+					)
+				store.EXPECT().
+					AuthorizeHoldTx(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusForbidden,
+					recorder.Code,
+				)
+			},
+		},
+		{
+			name: "AboveApprovalThreshold",
+			body: gin.H{
+				"account_id":    account1.ID,
+				"to_account_id": account2.ID,
+				"amount":        "10000.01",
+				"currency":      util.USD,
+			},
+			username: user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(0)
+				store.EXPECT().
+					AuthorizeHoldTx(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusBadRequest,
+					recorder.Code,
+				)
+				requireBodyMatchFieldErrors(
+					t,
+					recorder.Body,
+					[]fieldError{
+						{
+							Field:   "amount",
+							Rule:    "approval",
+							Message: "is above the approval threshold; make a transfer to have it reviewed",
+						},
+					},
+				)
+			},
+		},
+		{
+			name: "ToAccountCurrencyMismatch",
+			body: gin.H{
+				"account_id":    account1.ID,
+				"to_account_id": account2.ID,
+				"amount":        "25.00",
+				"currency":      util.USD,
+			},
+			username: user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Eq(account1.ID),
+					).
+					Times(1).
+					Return(
+						account1,
+						nil,
+					)
+
+				euroAccount := account2
+				euroAccount.Currency = util.EUR
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Eq(account2.ID),
+					).
+					Times(1).
+					Return(
+						euroAccount,
+						nil,
+					)
+				store.EXPECT().
+					AuthorizeHoldTx(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusBadRequest,
+					recorder.Code,
+				)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(
+			tc.name,
+			func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+
+				store := mockdb.NewMockStore(ctrl)
+				tc.buildStubs(store)
+
+				server := newTestServer(
+					t,
+					store,
+				)
+				recorder := httptest.NewRecorder()
+
+				data, err := json.Marshal(tc.body)
+				require.NoError(
+					t,
+					err,
+				)
+
+				request, err := http.NewRequest(
+					http.MethodPost,
+					"/holds",
+					bytes.NewReader(data),
+				)
+				require.NoError(
+					t,
+					err,
+				)
+
+				addAuthorization(
+					t,
+					request,
+					server.tokenMaker,
+					authorizationTypeBearer,
+					tc.username,
+					util.DepositorRole,
+					time.Minute,
+				)
+				server.router.ServeHTTP(
+					recorder,
+					request,
+				)
+
+				tc.checkResponse(
+					t,
+					recorder,
+				)
+			},
+		)
+	}
+}
+
+func TestCaptureHoldAPI(t *testing.T) {
+	user1, _ := RandomUser(t)
+	user2, _ := RandomUser(t)
+	user3, _ := RandomUser(t)
+	teller, _ := RandomUser(t)
+
+	account1 := RandomAccount(user1.Username)
+	account2 := RandomAccount(user2.Username)
+	account1.Currency = util.CAD
+	account2.Currency = util.CAD
+
+	hold := randomHold(
+		account1,
+		account2,
+	)
+
+	testCases := []struct {
+		name          string
+		body          io.Reader
+		username      string
+		role          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "FullCapture",
+			username: user2.Username,
+			role:     util.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Eq(account2.ID),
+					).
+					Times(1).
+					Return(
+						account2,
+						nil,
+					)
+				store.EXPECT().
+					CaptureHoldTx(
+						gomock.Any(),
+						gomock.Eq(db.CaptureHoldTxParams{
+							HoldID: hold.ID,
+							Amount: hold.Amount,
+						}),
+					).
+					Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusOK,
+					recorder.Code,
+				)
+			},
+		},
+		{
+			name:     "PartialCapture",
+			body:     bytes.NewBufferString(`{"amount":"10.50"}`),
+			username: user2.Username,
+			role:     util.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Eq(account2.ID),
+					).
+					Times(1).
+					Return(
+						account2,
+						nil,
+					)
+				store.EXPECT().
+					CaptureHoldTx(
+						gomock.Any(),
+						gomock.Eq(db.CaptureHoldTxParams{
+							HoldID: hold.ID,
+							Amount: 1050,
+						}),
+					).
+					Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusOK,
+					recorder.Code,
+				)
+			},
+		},
+		{
+			name:     "ExceedsHold",
+			body:     bytes.NewBufferString(`{"amount":"30.00"}`),
+			username: user2.Username,
+			role:     util.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Eq(account2.ID),
+					).
+					Times(1).
+					Return(
+						account2,
+						nil,
+					)
+				store.EXPECT().
+					CaptureHoldTx(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(1).
+					Return(
+						db.CaptureHoldTxResult{},
+						db.ErrCaptureExceedsHold,
+					)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusUnprocessableEntity,
+					recorder.Code,
+				)
+			},
+		},
+		{
+			name:     "AlreadySettled",
+			username: user2.Username,
+			role:     util.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Eq(account2.ID),
+					).
+					Times(1).
+					Return(
+						account2,
+						nil,
+					)
+				store.EXPECT().
+					CaptureHoldTx(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(1).
+					Return(
+						db.CaptureHoldTxResult{},
+						db.ErrHoldUnavailable,
+					)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusConflict,
+					recorder.Code,
+				)
+			},
+		},
+		{
+			// the payer may void the hold but not collect it
+			name:     "SourceOwner",
+			username: user1.Username,
+			role:     util.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Eq(account2.ID),
+					).
+					Times(1).
+					Return(
+						account2,
+						nil,
+					)
+				store.EXPECT().
+					CaptureHoldTx(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusForbidden,
+					recorder.Code,
+				)
+			},
+		},
+		{
+			// a user of neither account
+			name:     "UnauthorizedUser",
+			username: user3.Username,
+			role:     util.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Eq(account2.ID),
+					).
+					Times(1).
+					Return(
+						account2,
+						nil,
+					)
+				store.EXPECT().
+					CaptureHoldTx(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusForbidden,
+					recorder.Code,
+				)
+			},
+		},
+		{
+			// the staff may read holds but not settle them
+			name:     "Teller",
+			username: teller.Username,
+			role:     util.TellerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Eq(account2.ID),
+					).
+					Times(1).
+					Return(
+						account2,
+						nil,
+					)
+				store.EXPECT().
+					CaptureHoldTx(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusForbidden,
+					recorder.Code,
+				)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(
+			tc.name,
+			func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+
+				store := mockdb.NewMockStore(ctrl)
+				store.EXPECT().
+					GetHold(
+						gomock.Any(),
+						gomock.Eq(hold.ID),
+					).
+					Times(1).
+					Return(
+						hold,
+						nil,
+					)
+				tc.buildStubs(store)
+
+				server := newTestServer(
+					t,
+					store,
+				)
+				recorder := httptest.NewRecorder()
+
+				url := fmt.Sprintf(
+					"/holds/%d/capture",
+					hold.ID,
+				)
+				request, err := http.NewRequest(
+					http.MethodPost,
+					url,
+					tc.body,
+				)
+				require.NoError(
+					t,
+					err,
+				)
+
+				addAuthorization(
+					t,
+					request,
+					server.tokenMaker,
+					authorizationTypeBearer,
+					tc.username,
+					tc.role,
+					time.Minute,
+				)
+				server.router.ServeHTTP(
+					recorder,
+					request,
+				)
+
+				tc.checkResponse(
+					t,
+					recorder,
+				)
+			},
+		)
+	}
+}
+
+func TestVoidHoldAPI(t *testing.T) {
+	user1, _ := RandomUser(t)
+	user2, _ := RandomUser(t)
+	user3, _ := RandomUser(t)
+	teller, _ := RandomUser(t)
+
+	account1 := RandomAccount(user1.Username)
+	account2 := RandomAccount(user2.Username)
+
+	hold := randomHold(
+		account1,
+		account2,
+	)
+	voided := hold
+	voided.Status = db.HoldStatusVoided
+
+	testCases := []struct {
+		name          string
+		username      string
+		role          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			username: user1.Username,
+			role:     util.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetHold(
+						gomock.Any(),
+						gomock.Eq(hold.ID),
+					).
+					Times(1).
+					Return(
+						hold,
+						nil,
+					)
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Eq(account1.ID),
+					).
+					Times(1).
+					Return(
+						account1,
+						nil,
+					)
+				store.EXPECT().
+					VoidHoldTx(
+						gomock.Any(),
+						gomock.Eq(hold.ID),
+					).
+					Times(1).
+					Return(
+						db.HoldTxResult{
+							Hold:    voided,
+							Account: account1,
+						},
+						nil,
+					)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusOK,
+					recorder.Code,
+				)
+				requireBodyMatchJSON(
+					t,
+					recorder.Body.Bytes(),
+					newHoldTxResponse(
+						decimalAmounts(t),
+						db.HoldTxResult{
+							Hold:    voided,
+							Account: account1,
+						},
+					),
+				)
+			},
+		},
+		{
+			// the payee may give up the hold
+			name:     "Payee",
+			username: user2.Username,
+			role:     util.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetHold(
+						gomock.Any(),
+						gomock.Eq(hold.ID),
+					).
+					Times(1).
+					Return(
+						hold,
+						nil,
+					)
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Eq(account1.ID),
+					).
+					Times(1).
+					Return(
+						account1,
+						nil,
+					)
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Eq(account2.ID),
+					).
+					Times(1).
+					Return(
+						account2,
+						nil,
+					)
+				store.EXPECT().
+					VoidHoldTx(
+						gomock.Any(),
+						gomock.Eq(hold.ID),
+					).
+					Times(1).
+					Return(
+						db.HoldTxResult{
+							Hold:    voided,
+							Account: account1,
+						},
+						nil,
+					)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusOK,
+					recorder.Code,
+				)
+			},
+		},
+		{
+			// a user of neither account
+			name:     "UnauthorizedUser",
+			username: user3.Username,
+			role:     util.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetHold(
+						gomock.Any(),
+						gomock.Eq(hold.ID),
+					).
+					Times(1).
+					Return(
+						hold,
+						nil,
+					)
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Eq(account1.ID),
+					).
+					Times(1).
+					Return(
+						account1,
+						nil,
+					)
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Eq(account2.ID),
+					).
+					Times(1).
+					Return(
+						account2,
+						nil,
+					)
+				store.EXPECT().
+					VoidHoldTx(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusForbidden,
+					recorder.Code,
+				)
+			},
+		},
+		{
+			// the staff may read holds but not settle them
+			name:     "Teller",
+			username: teller.Username,
+			role:     util.TellerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetHold(
+						gomock.Any(),
+						gomock.Eq(hold.ID),
+					).
+					Times(1).
+					Return(
+						hold,
+						nil,
+					)
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Eq(account1.ID),
+					).
+					Times(1).
+					Return(
+						account1,
+						nil,
+					)
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Eq(account2.ID),
+					).
+					Times(1).
+					Return(
+						account2,
+						nil,
+					)
+				store.EXPECT().
+					VoidHoldTx(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusForbidden,
+					recorder.Code,
+				)
+			},
+		},
+		{
+			name:     "NotFound",
+			username: user1.Username,
+			role:     util.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetHold(
+						gomock.Any(),
+						gomock.Eq(hold.ID),
+					).
+					Times(1).
+					Return(
+						db.Hold{},
+						sql.ErrNoRows,
+					)
+				store.EXPECT().
+					VoidHoldTx(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusNotFound,
+					recorder.Code,
+				)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(
+			tc.name,
+			func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+
+				store := mockdb.NewMockStore(ctrl)
+				tc.buildStubs(store)
+
+				server := newTestServer(
+					t,
+					store,
+				)
+				recorder := httptest.NewRecorder()
+
+				url := fmt.Sprintf(
+					"/holds/%d/void",
+					hold.ID,
+				)
+				request, err := http.NewRequest(
+					http.MethodPost,
+					url,
+					nil,
+				)
+				require.NoError(
+					t,
+					err,
+				)
+
+				addAuthorization(
+					t,
+					request,
+					server.tokenMaker,
+					authorizationTypeBearer,
+					tc.username,
+					tc.role,
+					time.Minute,
+				)
+				server.router.ServeHTTP(
+					recorder,
+					request,
+				)
+
+				tc.checkResponse(
+					t,
+					recorder,
+				)
+			},
+		)
+	}
+}
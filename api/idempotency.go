@@ -0,0 +1,129 @@
+package api
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	db "github.com/PFefe/simplebank/db/sqlc"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+const (
+	idempotencyKeyHeader      = "Idempotency-Key"
+	idempotentReplayedHeader  = "Idempotent-Replayed"
+	maxIdempotencyKeyLength   = 255
+	idempotencyKeyMismatchMsg = "idempotency key was already used with a different request"
+)
+
+// idempotencyKey returns the Idempotency-Key header, or an error if it is malformed
+func idempotencyKey(ctx *gin.Context) (string, error) {
+	key := ctx.GetHeader(idempotencyKeyHeader)
+	if len(key) > maxIdempotencyKeyLength {
+		return "", errors.New("idempotency key is too long")
+	}
+	return key, nil
+}
+
+// requestHash fingerprints a request body so that a reused key can be told apart from a retry
+func requestHash(req interface{}) (string, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// replayIdempotentRequest writes the stored response for a known key and reports whether it handled the request
+func (server *Server) replayIdempotentRequest(ctx *gin.Context, username string, key string, hash string) bool {
+	stored, err := server.store.GetIdempotencyKey(
+		ctx,
+		db.GetIdempotencyKeyParams{
+			Username: username,
+			Key:      key,
+		},
+	)
+	if err != nil {
+		if errors.Is(
+			err,
+			sql.ErrNoRows,
+		) {
+			return false
+		}
+		abortWithError(
+			ctx,
+			err,
+		)
+		return true
+	}
+
+	if stored.RequestHash != hash {
+		abortWithError(
+			ctx,
+			newAPIError(
+				http.StatusConflict,
+				codeIdempotencyConflict,
+				idempotencyKeyMismatchMsg,
+			),
+		)
+		return true
+	}
+
+	// a transfer waiting for approval is replayed with the current state of its request
+	if stored.TransferRequestID.Valid {
+		request, err := server.store.GetTransferRequest(
+			ctx,
+			stored.TransferRequestID.Int64,
+		)
+		if err != nil {
+			abortWithError(
+				ctx,
+				err,
+			)
+			return true
+		}
+
+		ctx.Header(
+			idempotentReplayedHeader,
+			"true",
+		)
+		ctx.JSON(
+			http.StatusAccepted,
+			newTransferRequestResponse(
+				server.amountWriter(ctx),
+				request,
+			),
+		)
+		return true
+	}
+
+	// the stored result is rendered again, since the retry may ask for another amount format
+	var result db.TransferTxResult
+	err = json.Unmarshal(
+		stored.Response,
+		&result,
+	)
+	if err != nil {
+		abortWithError(
+			ctx,
+			err,
+		)
+		return true
+	}
+
+	ctx.Header(
+		idempotentReplayedHeader,
+		"true",
+	)
+	ctx.JSON(
+		http.StatusOK,
+		newTransferTxResponse(
+			server.amountWriter(ctx),
+			result,
+		),
+	)
+	return true
+}
@@ -0,0 +1,343 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	db "github.com/PFefe/simplebank/db/sqlc"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// transferLimitsRequest replaces every limit of an account or a user; a missing or null limit is lifted.
+// The amounts are in the currency the limits apply to.
+type transferLimitsRequest struct {
+	MaxAmount   amountInput `json:"max_amount"`
+	DailyAmount amountInput `json:"daily_amount"`
+	HourlyCount *int64      `json:"hourly_count" binding:"omitempty,min=1"`
+}
+
+// transferLimitsResponse shows the limits in the format of the request, null when a limit isn't set
+type transferLimitsResponse struct {
+	Currency    string        `json:"currency"`
+	MaxAmount   *amountOutput `json:"max_amount"`
+	DailyAmount *amountOutput `json:"daily_amount"`
+	HourlyCount *int64        `json:"hourly_count"`
+}
+
+func newTransferLimitsResponse(w amountWriter, currency string, maxAmount sql.NullInt64, dailyAmount sql.NullInt64, hourlyCount sql.NullInt64) transferLimitsResponse {
+	rsp := transferLimitsResponse{
+		Currency: currency,
+	}
+	if maxAmount.Valid {
+		amount := w.amount(
+			maxAmount.Int64,
+			currency,
+		)
+		rsp.MaxAmount = &amount
+	}
+	if dailyAmount.Valid {
+		amount := w.amount(
+			dailyAmount.Int64,
+			currency,
+		)
+		rsp.DailyAmount = &amount
+	}
+	if hourlyCount.Valid {
+		rsp.HourlyCount = &hourlyCount.Int64
+	}
+	return rsp
+}
+
+// parseLimits converts the limits of the request, leaving the missing and null ones unset
+func (server *Server) parseLimits(ctx *gin.Context, req transferLimitsRequest, currency string) (maxAmount sql.NullInt64, dailyAmount sql.NullInt64, hourlyCount sql.NullInt64, apiErr *apiError) {
+	if len(req.MaxAmount) > 0 {
+		maxAmount.Int64, apiErr = server.parsePositiveAmount(
+			ctx,
+			"max_amount",
+			req.MaxAmount,
+			currency,
+		)
+		if apiErr != nil {
+			return
+		}
+		maxAmount.Valid = true
+	}
+
+	if len(req.DailyAmount) > 0 {
+		dailyAmount.Int64, apiErr = server.parsePositiveAmount(
+			ctx,
+			"daily_amount",
+			req.DailyAmount,
+			currency,
+		)
+		if apiErr != nil {
+			return
+		}
+		dailyAmount.Valid = true
+	}
+
+	hourlyCount = nullInt64(req.HourlyCount)
+	return
+}
+
+// getAccountLimits shows the limits of an account to its owner and the staff
+func (server *Server) getAccountLimits(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		abortWithError(
+			ctx,
+			invalidRequestError(
+				ctx,
+				err,
+			),
+		)
+		return
+	}
+
+	account, err := server.store.GetAccount(
+		ctx,
+		uri.ID,
+	)
+	if err != nil {
+		abortWithError(
+			ctx,
+			err,
+		)
+		return
+	}
+
+	if !authorizeAccount(
+		ctx,
+		account,
+		staffRoles...,
+	) {
+		return
+	}
+
+	// an account without limits has none set, which is not an error
+	limits, err := server.store.GetAccountLimits(
+		ctx,
+		account.ID,
+	)
+	if err != nil && !errors.Is(
+		err,
+		sql.ErrNoRows,
+	) {
+		abortWithError(
+			ctx,
+			err,
+		)
+		return
+	}
+
+	ctx.JSON(
+		http.StatusOK,
+		newTransferLimitsResponse(
+			server.amountWriter(ctx),
+			account.Currency,
+			limits.MaxAmount,
+			limits.DailyAmount,
+			limits.HourlyCount,
+		),
+	)
+}
+
+// updateAccountLimits overrides the limits of an account, on top of the limits of its owner
+func (server *Server) updateAccountLimits(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		abortWithError(
+			ctx,
+			invalidRequestError(
+				ctx,
+				err,
+			),
+		)
+		return
+	}
+
+	var req transferLimitsRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		abortWithError(
+			ctx,
+			invalidRequestError(
+				ctx,
+				err,
+			),
+		)
+		return
+	}
+
+	account, err := server.store.GetAccount(
+		ctx,
+		uri.ID,
+	)
+	if err != nil {
+		abortWithError(
+			ctx,
+			err,
+		)
+		return
+	}
+
+	maxAmount, dailyAmount, hourlyCount, apiErr := server.parseLimits(
+		ctx,
+		req,
+		account.Currency,
+	)
+	if apiErr != nil {
+		abortWithError(
+			ctx,
+			apiErr,
+		)
+		return
+	}
+
+	limits, err := server.store.UpsertAccountLimits(
+		ctx,
+		db.UpsertAccountLimitsParams{
+			AccountID:   account.ID,
+			MaxAmount:   maxAmount,
+			DailyAmount: dailyAmount,
+			HourlyCount: hourlyCount,
+		},
+	)
+	if err != nil {
+		abortWithError(
+			ctx,
+			err,
+		)
+		return
+	}
+
+	ctx.JSON(
+		http.StatusOK,
+		newTransferLimitsResponse(
+			server.amountWriter(ctx),
+			account.Currency,
+			limits.MaxAmount,
+			limits.DailyAmount,
+			limits.HourlyCount,
+		),
+	)
+}
+
+// listUserLimits shows the limits of a user in every currency they were set for
+func (server *Server) listUserLimits(ctx *gin.Context) {
+	var uri getUserRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		abortWithError(
+			ctx,
+			invalidRequestError(
+				ctx,
+				err,
+			),
+		)
+		return
+	}
+
+	limits, err := server.store.ListUserLimits(
+		ctx,
+		uri.Username,
+	)
+	if err != nil {
+		abortWithError(
+			ctx,
+			err,
+		)
+		return
+	}
+
+	w := server.amountWriter(ctx)
+	rsp := make([]transferLimitsResponse, 0, len(limits))
+	for _, limit := range limits {
+		rsp = append(
+			rsp,
+			newTransferLimitsResponse(
+				w,
+				limit.Currency,
+				limit.MaxAmount,
+				limit.DailyAmount,
+				limit.HourlyCount,
+			),
+		)
+	}
+	ctx.JSON(
+		http.StatusOK,
+		rsp,
+	)
+}
+
+type userLimitsRequest struct {
+	transferLimitsRequest
+	Currency string `json:"currency" binding:"required,currency"`
+}
+
+// updateUserLimits sets the limits of a user over all their accounts in one currency
+func (server *Server) updateUserLimits(ctx *gin.Context) {
+	var uri getUserRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		abortWithError(
+			ctx,
+			invalidRequestError(
+				ctx,
+				err,
+			),
+		)
+		return
+	}
+
+	var req userLimitsRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		abortWithError(
+			ctx,
+			invalidRequestError(
+				ctx,
+				err,
+			),
+		)
+		return
+	}
+
+	maxAmount, dailyAmount, hourlyCount, apiErr := server.parseLimits(
+		ctx,
+		req.transferLimitsRequest,
+		req.Currency,
+	)
+	if apiErr != nil {
+		abortWithError(
+			ctx,
+			apiErr,
+		)
+		return
+	}
+
+	limits, err := server.store.UpsertUserLimits(
+		ctx,
+		db.UpsertUserLimitsParams{
+			Username:    uri.Username,
+			Currency:    req.Currency,
+			MaxAmount:   maxAmount,
+			DailyAmount: dailyAmount,
+			HourlyCount: hourlyCount,
+		},
+	)
+	if err != nil {
+		abortWithError(
+			ctx,
+			err,
+		)
+		return
+	}
+
+	ctx.JSON(
+		http.StatusOK,
+		newTransferLimitsResponse(
+			server.amountWriter(ctx),
+			limits.Currency,
+			limits.MaxAmount,
+			limits.DailyAmount,
+			limits.HourlyCount,
+		),
+	)
+}
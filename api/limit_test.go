@@ -0,0 +1,548 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	mockdb "github.com/PFefe/simplebank/db/mock"
+	db "github.com/PFefe/simplebank/db/sqlc"
+	"github.com/PFefe/simplebank/util"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestUpdateAccountLimitsAPI(t *testing.T) {
+	user, _ := RandomUser(t)
+	banker, _ := RandomUser(t)
+	account := RandomAccount(user.Username)
+	account.Currency = util.USD
+
+	limits := db.AccountLimit{
+		AccountID: account.ID,
+		MaxAmount: sql.NullInt64{
+			Int64: 50000,
+			Valid: true,
+		},
+		HourlyCount: sql.NullInt64{
+			Int64: 5,
+			Valid: true,
+		},
+		UpdatedAt: time.Now().UTC().Truncate(time.Second),
+	}
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		username      string
+		role          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{
+				"max_amount":   "500.00",
+				"hourly_count": 5,
+			},
+			username: banker.Username,
+			role:     util.BankerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Eq(account.ID),
+					).
+					Times(1).
+					Return(
+						account,
+						nil,
+					)
+
+				arg := db.UpsertAccountLimitsParams{
+					AccountID:   account.ID,
+					MaxAmount:   limits.MaxAmount,
+					HourlyCount: limits.HourlyCount,
+				}
+				store.EXPECT().
+					UpsertAccountLimits(
+						gomock.Any(),
+						gomock.Eq(arg),
+					).
+					Times(1).
+					Return(
+						limits,
+						nil,
+					)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusOK,
+					recorder.Code,
+				)
+				require.JSONEq(
+					t,
+					`{"currency":"USD","max_amount":"500.00","daily_amount":null,"hourly_count":5}`,
+					recorder.Body.String(),
+				)
+			},
+		},
+		{
+			// the limits read back from the response can be sent as they are, with null for an unset limit
+			name: "ExplicitNull",
+			body: gin.H{
+				"currency":     util.USD,
+				"max_amount":   "500.00",
+				"daily_amount": nil,
+				"hourly_count": 5,
+			},
+			username: banker.Username,
+			role:     util.BankerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Eq(account.ID),
+					).
+					Times(1).
+					Return(
+						account,
+						nil,
+					)
+				store.EXPECT().
+					UpsertAccountLimits(
+						gomock.Any(),
+						gomock.Eq(db.UpsertAccountLimitsParams{
+							AccountID:   account.ID,
+							MaxAmount:   limits.MaxAmount,
+							HourlyCount: limits.HourlyCount,
+						}),
+					).
+					Times(1).
+					Return(
+						limits,
+						nil,
+					)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusOK,
+					recorder.Code,
+				)
+				require.JSONEq(
+					t,
+					`{"currency":"USD","max_amount":"500.00","daily_amount":null,"hourly_count":5}`,
+					recorder.Body.String(),
+				)
+			},
+		},
+		{
+			name:     "ClearLimits",
+			body:     gin.H{},
+			username: banker.Username,
+			role:     util.BankerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Eq(account.ID),
+					).
+					Times(1).
+					Return(
+						account,
+						nil,
+					)
+				store.EXPECT().
+					UpsertAccountLimits(
+						gomock.Any(),
+						gomock.Eq(db.UpsertAccountLimitsParams{
+							AccountID: account.ID,
+						}),
+					).
+					Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusOK,
+					recorder.Code,
+				)
+			},
+		},
+		{
+			name: "Owner",
+			body: gin.H{
+				"max_amount": "1000000.00",
+			},
+			username: user.Username,
+			role:     util.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpsertAccountLimits(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusForbidden,
+					recorder.Code,
+				)
+			},
+		},
+		{
+			name: "ZeroHourlyCount",
+			body: gin.H{
+				"hourly_count": 0,
+			},
+			username: banker.Username,
+			role:     util.BankerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpsertAccountLimits(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusBadRequest,
+					recorder.Code,
+				)
+			},
+		},
+		{
+			name: "ZeroAmount",
+			body: gin.H{
+				"daily_amount": "0.00",
+			},
+			username: banker.Username,
+			role:     util.BankerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Eq(account.ID),
+					).
+					Times(1).
+					Return(
+						account,
+						nil,
+					)
+				store.EXPECT().
+					UpsertAccountLimits(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusBadRequest,
+					recorder.Code,
+				)
+				requireBodyMatchFieldErrors(
+					t,
+					recorder.Body,
+					[]fieldError{
+						{
+							Field:   "daily_amount",
+							Rule:    "gt",
+							Message: "must be greater than 0",
+						},
+					},
+				)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(
+			tc.name,
+			func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+
+				store := mockdb.NewMockStore(ctrl)
+				tc.buildStubs(store)
+
+				server := newTestServer(
+					t,
+					store,
+				)
+				recorder := httptest.NewRecorder()
+
+				data, err := json.Marshal(tc.body)
+				require.NoError(
+					t,
+					err,
+				)
+
+				url := fmt.Sprintf(
+					"/accounts/%d/limits",
+					account.ID,
+				)
+				request, err := http.NewRequest(
+					http.MethodPut,
+					url,
+					bytes.NewReader(data),
+				)
+				require.NoError(
+					t,
+					err,
+				)
+
+				addAuthorization(
+					t,
+					request,
+					server.tokenMaker,
+					authorizationTypeBearer,
+					tc.username,
+					tc.role,
+					time.Minute,
+				)
+				server.router.ServeHTTP(
+					recorder,
+					request,
+				)
+
+				tc.checkResponse(
+					t,
+					recorder,
+				)
+			},
+		)
+	}
+}
+
+func TestGetAccountLimitsAPI(t *testing.T) {
+	user, _ := RandomUser(t)
+	otherUser, _ := RandomUser(t)
+	account := RandomAccount(user.Username)
+	account.Currency = util.EUR
+
+	testCases := []struct {
+		name          string
+		username      string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "NoLimits",
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Eq(account.ID),
+					).
+					Times(1).
+					Return(
+						account,
+						nil,
+					)
+				store.EXPECT().
+					GetAccountLimits(
+						gomock.Any(),
+						gomock.Eq(account.ID),
+					).
+					Times(1).
+					Return(
+						db.AccountLimit{},
+						sql.ErrNoRows,
+					)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusOK,
+					recorder.Code,
+				)
+				require.JSONEq(
+					t,
+					`{"currency":"EUR","max_amount":null,"daily_amount":null,"hourly_count":null}`,
+					recorder.Body.String(),
+				)
+			},
+		},
+		{
+			name:     "UnauthorizedUser",
+			username: otherUser.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Eq(account.ID),
+					).
+					Times(1).
+					Return(
+						account,
+						nil,
+					)
+				store.EXPECT().
+					GetAccountLimits(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusForbidden,
+					recorder.Code,
+				)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(
+			tc.name,
+			func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+
+				store := mockdb.NewMockStore(ctrl)
+				tc.buildStubs(store)
+
+				server := newTestServer(
+					t,
+					store,
+				)
+				recorder := httptest.NewRecorder()
+
+				url := fmt.Sprintf(
+					"/accounts/%d/limits",
+					account.ID,
+				)
+				request, err := http.NewRequest(
+					http.MethodGet,
+					url,
+					nil,
+				)
+				require.NoError(
+					t,
+					err,
+				)
+
+				addAuthorization(
+					t,
+					request,
+					server.tokenMaker,
+					authorizationTypeBearer,
+					tc.username,
+					util.DepositorRole,
+					time.Minute,
+				)
+				server.router.ServeHTTP(
+					recorder,
+					request,
+				)
+
+				tc.checkResponse(
+					t,
+					recorder,
+				)
+			},
+		)
+	}
+}
+
+func TestUpdateUserLimitsAPI(t *testing.T) {
+	user, _ := RandomUser(t)
+	banker, _ := RandomUser(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	arg := db.UpsertUserLimitsParams{
+		Username: user.Username,
+		Currency: util.CAD,
+		DailyAmount: sql.NullInt64{
+			Int64: 200000,
+			Valid: true,
+		},
+	}
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		UpsertUserLimits(
+			gomock.Any(),
+			gomock.Eq(arg),
+		).
+		Times(1).
+		Return(
+			db.UserLimit{
+				Username:    arg.Username,
+				Currency:    arg.Currency,
+				DailyAmount: arg.DailyAmount,
+			},
+			nil,
+		)
+
+	server := newTestServer(
+		t,
+		store,
+	)
+	recorder := httptest.NewRecorder()
+
+	data, err := json.Marshal(
+		gin.H{
+			"currency":     util.CAD,
+			"daily_amount": "2000.00",
+		},
+	)
+	require.NoError(
+		t,
+		err,
+	)
+
+	request, err := http.NewRequest(
+		http.MethodPut,
+		"/users/"+user.Username+"/limits",
+		bytes.NewReader(data),
+	)
+	require.NoError(
+		t,
+		err,
+	)
+
+	addAuthorization(
+		t,
+		request,
+		server.tokenMaker,
+		authorizationTypeBearer,
+		banker.Username,
+		util.BankerRole,
+		time.Minute,
+	)
+	server.router.ServeHTTP(
+		recorder,
+		request,
+	)
+
+	require.Equal(
+		t,
+		http.StatusOK,
+		recorder.Code,
+	)
+	require.JSONEq(
+		t,
+		`{"currency":"CAD","max_amount":null,"daily_amount":"2000.00","hourly_count":null}`,
+		recorder.Body.String(),
+	)
+}
@@ -1,11 +1,74 @@
 package api
 
 import (
+	"encoding/json"
+	db "github.com/PFefe/simplebank/db/sqlc"
+	"github.com/PFefe/simplebank/util"
 	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
 	"os"
 	"testing"
+	"time"
 )
 
+func newTestServer(t *testing.T, store db.Store) *Server {
+	config := util.Config{
+		PasswordHashCost:     bcrypt.MinCost,
+		TokenSymmetricKey:    util.RandomString(32),
+		AccessTokenDuration:  time.Minute,
+		RefreshTokenDuration: time.Hour,
+		IdempotencyKeyTTL:    time.Hour,
+		MaxPageSize:          10,
+		QuoteTTL:             time.Minute,
+		Currencies:           util.DefaultCurrencies,
+		ApprovalThresholds: []string{
+			"USD:10000.00",
+			"EUR:10000.00",
+			"CAD:10000.00",
+		},
+		ApprovalTTL: time.Hour,
+		HoldTTL:     time.Hour,
+	}
+
+	server, err := NewServer(
+		config,
+		store,
+	)
+	require.NoError(
+		t,
+		err,
+	)
+
+	return server
+}
+
+// decimalAmounts writes amounts like a request without the Amount-Format header
+func decimalAmounts(t *testing.T) amountWriter {
+	currencies, err := util.NewCurrencyRegistry(util.DefaultCurrencies)
+	require.NoError(
+		t,
+		err,
+	)
+	return amountWriter{
+		currencies: currencies,
+	}
+}
+
+// requireBodyMatchJSON compares a response body with the JSON encoding of the expected response
+func requireBodyMatchJSON(t *testing.T, body []byte, expected interface{}) {
+	want, err := json.Marshal(expected)
+	require.NoError(
+		t,
+		err,
+	)
+	require.JSONEq(
+		t,
+		string(want),
+		string(body),
+	)
+}
+
 func TestMain(m *testing.M) {
 	gin.SetMode(gin.TestMode)
 	os.Exit(m.Run())
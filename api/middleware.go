@@ -0,0 +1,100 @@
+package api
+
+import (
+	"fmt"
+	"github.com/PFefe/simplebank/token"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strings"
+)
+
+const (
+	authorizationHeaderKey  = "authorization"
+	authorizationTypeBearer = "bearer"
+	authorizationPayloadKey = "authorization_payload"
+)
+
+// authMiddleware verifies the bearer token and stores its payload in the context
+func authMiddleware(tokenMaker token.Maker) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		authorizationHeader := ctx.GetHeader(authorizationHeaderKey)
+		if len(authorizationHeader) == 0 {
+			abortWithError(
+				ctx,
+				newAPIError(
+					http.StatusUnauthorized,
+					codeUnauthorized,
+					"authorization header is not provided",
+				),
+			)
+			return
+		}
+
+		fields := strings.Fields(authorizationHeader)
+		if len(fields) != 2 {
+			abortWithError(
+				ctx,
+				newAPIError(
+					http.StatusUnauthorized,
+					codeUnauthorized,
+					"invalid authorization header format",
+				),
+			)
+			return
+		}
+
+		authorizationType := strings.ToLower(fields[0])
+		if authorizationType != authorizationTypeBearer {
+			err := fmt.Errorf(
+				"unsupported authorization type %s",
+				authorizationType,
+			)
+			abortWithError(
+				ctx,
+				newAPIError(
+					http.StatusUnauthorized,
+					codeUnauthorized,
+					err.Error(),
+				),
+			)
+			return
+		}
+
+		payload, err := tokenMaker.VerifyToken(fields[1])
+		if err != nil {
+			abortWithError(
+				ctx,
+				newAPIError(
+					http.StatusUnauthorized,
+					codeUnauthorized,
+					err.Error(),
+				),
+			)
+			return
+		}
+
+		// a refresh token lives much longer and is only checked against its session on renewal
+		if payload.Type != token.TokenTypeAccess {
+			abortWithError(
+				ctx,
+				newAPIError(
+					http.StatusUnauthorized,
+					codeUnauthorized,
+					"token is not an access token",
+				),
+			)
+			return
+		}
+
+		ctx.Set(
+			authorizationPayloadKey,
+			payload,
+		)
+		ctx.Next()
+	}
+}
+
+// authPayload returns the token payload stored by authMiddleware
+func authPayload(ctx *gin.Context) *token.Payload {
+	return ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+}
@@ -0,0 +1,235 @@
+package api
+
+import (
+	"fmt"
+	"github.com/PFefe/simplebank/token"
+	"github.com/PFefe/simplebank/util"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func addAuthorization(
+	t *testing.T,
+	request *http.Request,
+	tokenMaker token.Maker,
+	authorizationType string,
+	username string,
+	role string,
+	duration time.Duration,
+) {
+	accessToken, payload, err := tokenMaker.CreateToken(
+		username,
+		role,
+		token.TokenTypeAccess,
+		duration,
+	)
+	require.NoError(
+		t,
+		err,
+	)
+	require.NotEmpty(
+		t,
+		payload,
+	)
+
+	authorizationHeader := fmt.Sprintf(
+		"%s %s",
+		authorizationType,
+		accessToken,
+	)
+	request.Header.Set(
+		authorizationHeaderKey,
+		authorizationHeader,
+	)
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	username := util.RandomOwner()
+
+	testCases := []struct {
+		name          string
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(
+					t,
+					request,
+					tokenMaker,
+					authorizationTypeBearer,
+					username,
+					util.DepositorRole,
+					time.Minute,
+				)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusOK,
+					recorder.Code,
+				)
+			},
+		},
+		{
+			name: "NoAuthorization",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusUnauthorized,
+					recorder.Code,
+				)
+			},
+		},
+		{
+			name: "UnsupportedAuthorization",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(
+					t,
+					request,
+					tokenMaker,
+					"unsupported",
+					username,
+					util.DepositorRole,
+					time.Minute,
+				)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusUnauthorized,
+					recorder.Code,
+				)
+			},
+		},
+		{
+			name: "InvalidAuthorizationFormat",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(
+					t,
+					request,
+					tokenMaker,
+					"",
+					username,
+					util.DepositorRole,
+					time.Minute,
+				)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusUnauthorized,
+					recorder.Code,
+				)
+			},
+		},
+		{
+			name: "RefreshToken",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				refreshToken, _, err := tokenMaker.CreateToken(
+					username,
+					util.DepositorRole,
+					token.TokenTypeRefresh,
+					time.Hour,
+				)
+				require.NoError(
+					t,
+					err,
+				)
+				request.Header.Set(
+					authorizationHeaderKey,
+					fmt.Sprintf(
+						"%s %s",
+						authorizationTypeBearer,
+						refreshToken,
+					),
+				)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusUnauthorized,
+					recorder.Code,
+				)
+			},
+		},
+		{
+			name: "ExpiredToken",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(
+					t,
+					request,
+					tokenMaker,
+					authorizationTypeBearer,
+					username,
+					util.DepositorRole,
+					-time.Minute,
+				)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusUnauthorized,
+					recorder.Code,
+				)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(
+			tc.name,
+			func(t *testing.T) {
+				server := newTestServer(
+					t,
+					nil,
+				)
+
+				authPath := "/auth"
+				server.router.GET(
+					authPath,
+					authMiddleware(server.tokenMaker),
+					func(ctx *gin.Context) {
+						ctx.JSON(
+							http.StatusOK,
+							gin.H{},
+						)
+					},
+				)
+
+				recorder := httptest.NewRecorder()
+				request, err := http.NewRequest(
+					http.MethodGet,
+					authPath,
+					nil,
+				)
+				require.NoError(
+					t,
+					err,
+				)
+
+				tc.setupAuth(
+					t,
+					request,
+					server.tokenMaker,
+				)
+				server.router.ServeHTTP(
+					recorder,
+					request,
+				)
+				tc.checkResponse(
+					t,
+					recorder,
+				)
+			},
+		)
+	}
+}
@@ -0,0 +1,189 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+const defaultPageSize = 10
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+// pageRequest selects a page of a list endpoint. Requests with a page_id keep the
+// old offset paging and get a bare array back; all others are paged by cursor.
+type pageRequest struct {
+	PageID   int32  `form:"page_id" binding:"omitempty,min=1"`
+	PageSize int32  `form:"page_size" binding:"omitempty,min=1"`
+	Cursor   string `form:"cursor"`
+}
+
+// pageCursor is the position of a row in a list ordered by (created_at, id)
+type pageCursor struct {
+	CreatedAt time.Time `json:"t"`
+	ID        int64     `json:"i"`
+	Backward  bool      `json:"b,omitempty"`
+}
+
+// page is a validated pageRequest
+type page struct {
+	size     int32
+	byOffset bool
+	offset   int32
+	cursor   *pageCursor
+}
+
+// pageResponse is the envelope of a list paged by cursor
+type pageResponse[T any] struct {
+	Data       []T    `json:"data"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+}
+
+// page validates req against the configured max page size
+func (server *Server) page(req pageRequest) (page, *apiError) {
+	p := page{
+		size: req.PageSize,
+	}
+	if p.size == 0 {
+		p.size = min(
+			defaultPageSize,
+			server.config.MaxPageSize,
+		)
+	}
+	if p.size > server.config.MaxPageSize {
+		return p, invalidFieldError(
+			"page_size",
+			"max",
+			fmt.Sprintf(
+				"must be at most %d",
+				server.config.MaxPageSize,
+			),
+		)
+	}
+
+	if req.PageID > 0 {
+		p.byOffset = true
+		p.offset = (req.PageID - 1) * p.size
+		return p, nil
+	}
+
+	if req.Cursor != "" {
+		cursor, err := server.decodeCursor(req.Cursor)
+		if err != nil {
+			return p, invalidFieldError(
+				"cursor",
+				"cursor",
+				"is not a valid cursor",
+			)
+		}
+		p.cursor = &cursor
+	}
+	return p, nil
+}
+
+// forward reports whether the page continues after its cursor rather than before it
+func (p page) forward() bool {
+	return p.cursor == nil || !p.cursor.Backward
+}
+
+// position returns the cursor to page from, the start of the list for the first page
+func (p page) position() pageCursor {
+	if p.cursor == nil {
+		return pageCursor{}
+	}
+	return *p.cursor
+}
+
+// limit is the number of rows to fetch: one more than the page size, to tell whether another page follows
+func (p page) limit() int32 {
+	return p.size + 1
+}
+
+// newPageResponse trims rows fetched with p.limit() to the page and links the neighbouring pages
+func newPageResponse[T any](server *Server, p page, rows []T, key func(T) pageCursor) pageResponse[T] {
+	hasMore := len(rows) > int(p.size)
+	if hasMore {
+		rows = rows[:p.size]
+	}
+	// rows before the cursor come closest first
+	if !p.forward() {
+		slices.Reverse(rows)
+	}
+
+	if rows == nil {
+		rows = []T{}
+	}
+
+	rsp := pageResponse[T]{
+		Data: rows,
+	}
+	if len(rows) == 0 {
+		return rsp
+	}
+
+	if (p.forward() && hasMore) || !p.forward() {
+		rsp.NextCursor = server.encodeCursor(key(rows[len(rows)-1]))
+	}
+	if (p.forward() && p.cursor != nil) || (!p.forward() && hasMore) {
+		prev := key(rows[0])
+		prev.Backward = true
+		rsp.PrevCursor = server.encodeCursor(prev)
+	}
+	return rsp
+}
+
+// encodeCursor signs the cursor so that clients cannot forge positions
+func (server *Server) encodeCursor(cursor pageCursor) string {
+	// a pageCursor always marshals
+	data, _ := json.Marshal(cursor)
+	payload := base64.RawURLEncoding.EncodeToString(data)
+	return payload + "." + base64.RawURLEncoding.EncodeToString(server.signCursor(payload))
+}
+
+func (server *Server) decodeCursor(s string) (pageCursor, error) {
+	var cursor pageCursor
+
+	payload, signature, found := strings.Cut(
+		s,
+		".",
+	)
+	if !found {
+		return cursor, errInvalidCursor
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(
+		mac,
+		server.signCursor(payload),
+	) {
+		return cursor, errInvalidCursor
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return cursor, errInvalidCursor
+	}
+	if err := json.Unmarshal(
+		data,
+		&cursor,
+	); err != nil {
+		return cursor, errInvalidCursor
+	}
+	return cursor, nil
+}
+
+// signCursor authenticates a cursor payload with the token key, which never leaves the server
+func (server *Server) signCursor(payload string) []byte {
+	mac := hmac.New(
+		sha256.New,
+		[]byte(server.config.TokenSymmetricKey),
+	)
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
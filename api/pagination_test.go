@@ -0,0 +1,214 @@
+package api
+
+import (
+	"github.com/PFefe/simplebank/util"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestCursor(t *testing.T) {
+	server := newTestServer(
+		t,
+		nil,
+	)
+	cursor := pageCursor{
+		CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+		ID: util.RandomInt(
+			1,
+			1000,
+		),
+		Backward: true,
+	}
+
+	encoded := server.encodeCursor(cursor)
+	decoded, err := server.decodeCursor(encoded)
+	require.NoError(
+		t,
+		err,
+	)
+	require.Equal(
+		t,
+		cursor,
+		decoded,
+	)
+
+	// a cursor signed by another server must be rejected
+	otherServer := newTestServer(
+		t,
+		nil,
+	)
+	_, err = otherServer.decodeCursor(encoded)
+	require.ErrorIs(
+		t,
+		err,
+		errInvalidCursor,
+	)
+
+	for _, invalid := range []string{"", "abc", "abc.def", encoded + "x"} {
+		_, err = server.decodeCursor(invalid)
+		require.ErrorIs(
+			t,
+			err,
+			errInvalidCursor,
+		)
+	}
+}
+
+func TestPageSize(t *testing.T) {
+	server := newTestServer(
+		t,
+		nil,
+	)
+
+	p, apiErr := server.page(pageRequest{})
+	require.Nil(
+		t,
+		apiErr,
+	)
+	require.Equal(
+		t,
+		int32(defaultPageSize),
+		p.size,
+	)
+	require.False(
+		t,
+		p.byOffset,
+	)
+
+	_, apiErr = server.page(pageRequest{PageSize: server.config.MaxPageSize + 1})
+	require.NotNil(
+		t,
+		apiErr,
+	)
+	require.Equal(
+		t,
+		"page_size",
+		apiErr.Errors[0].Field,
+	)
+
+	p, apiErr = server.page(pageRequest{PageID: 3, PageSize: 5})
+	require.Nil(
+		t,
+		apiErr,
+	)
+	require.True(
+		t,
+		p.byOffset,
+	)
+	require.Equal(
+		t,
+		int32(10),
+		p.offset,
+	)
+}
+
+func TestNewPageResponse(t *testing.T) {
+	server := newTestServer(
+		t,
+		nil,
+	)
+	key := func(id int64) pageCursor {
+		return pageCursor{
+			ID: id,
+		}
+	}
+	cursor := func(id int64, backward bool) string {
+		return server.encodeCursor(pageCursor{
+			ID:       id,
+			Backward: backward,
+		})
+	}
+
+	testCases := []struct {
+		name       string
+		cursor     *pageCursor
+		rows       []int64
+		data       []int64
+		nextCursor string
+		prevCursor string
+	}{
+		{
+			name:       "FirstPage",
+			rows:       []int64{1, 2, 3},
+			data:       []int64{1, 2},
+			nextCursor: cursor(2, false),
+		},
+		{
+			name:       "OnlyPage",
+			rows:       []int64{1},
+			data:       []int64{1},
+			nextCursor: "",
+		},
+		{
+			name:       "MiddlePage",
+			cursor:     &pageCursor{ID: 2},
+			rows:       []int64{3, 4, 5},
+			data:       []int64{3, 4},
+			nextCursor: cursor(4, false),
+			prevCursor: cursor(3, true),
+		},
+		{
+			name:       "LastPage",
+			cursor:     &pageCursor{ID: 4},
+			rows:       []int64{5},
+			data:       []int64{5},
+			prevCursor: cursor(5, true),
+		},
+		{
+			name:       "Backward",
+			cursor:     &pageCursor{ID: 5, Backward: true},
+			rows:       []int64{4, 3, 2},
+			data:       []int64{3, 4},
+			nextCursor: cursor(4, false),
+			prevCursor: cursor(3, true),
+		},
+		{
+			name:       "BackwardToStart",
+			cursor:     &pageCursor{ID: 3, Backward: true},
+			rows:       []int64{2, 1},
+			data:       []int64{1, 2},
+			nextCursor: cursor(2, false),
+		},
+		{
+			name:   "Empty",
+			cursor: &pageCursor{ID: 5},
+			rows:   nil,
+			data:   []int64{},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(
+			tc.name,
+			func(t *testing.T) {
+				p := page{
+					size:   2,
+					cursor: tc.cursor,
+				}
+				rsp := newPageResponse(
+					server,
+					p,
+					tc.rows,
+					key,
+				)
+				require.Equal(
+					t,
+					tc.data,
+					rsp.Data,
+				)
+				require.Equal(
+					t,
+					tc.nextCursor,
+					rsp.NextCursor,
+				)
+				require.Equal(
+					t,
+					tc.prevCursor,
+					rsp.PrevCursor,
+				)
+			},
+		)
+	}
+}
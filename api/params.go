@@ -0,0 +1,40 @@
+package api
+
+import (
+	"database/sql"
+	"time"
+)
+
+// validTimeRange rejects a from_time/to_time query range that is empty or reversed
+func validTimeRange(fromTime *time.Time, toTime *time.Time) *apiError {
+	if fromTime != nil && toTime != nil && !toTime.After(*fromTime) {
+		return invalidFieldError(
+			"to_time",
+			"gtfield",
+			"must be after from_time",
+		)
+	}
+	return nil
+}
+
+// nullTime converts an optional query parameter into a nullable query argument
+func nullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{
+		Time:  *t,
+		Valid: true,
+	}
+}
+
+// nullInt64 converts an optional query parameter into a nullable query argument
+func nullInt64(n *int64) sql.NullInt64 {
+	if n == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{
+		Int64: *n,
+		Valid: true,
+	}
+}
@@ -0,0 +1,62 @@
+package api
+
+import (
+	db "github.com/PFefe/simplebank/db/sqlc"
+	"github.com/PFefe/simplebank/token"
+	"github.com/PFefe/simplebank/util"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"slices"
+)
+
+// staffRoles may view every account, not only their own
+var staffRoles = []string{util.TellerRole, util.BankerRole}
+
+// roleMiddleware only lets users with one of the roles through; it must run after authMiddleware
+func roleMiddleware(roles ...string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !hasRole(
+			authPayload(ctx),
+			roles...,
+		) {
+			abortWithError(
+				ctx,
+				newAPIError(
+					http.StatusForbidden,
+					codeForbidden,
+					"user role is not allowed to perform this action",
+				),
+			)
+			return
+		}
+		ctx.Next()
+	}
+}
+
+func hasRole(payload *token.Payload, roles ...string) bool {
+	return slices.Contains(
+		roles,
+		payload.Role,
+	)
+}
+
+// authorizeAccount aborts with 403 unless the account belongs to the authenticated user
+// or the user has one of the given roles
+func authorizeAccount(ctx *gin.Context, account db.Account, roles ...string) bool {
+	authPayload := authPayload(ctx)
+	if account.Owner != authPayload.Username && !hasRole(
+		authPayload,
+		roles...,
+	) {
+		abortWithError(
+			ctx,
+			newAPIError(
+				http.StatusForbidden,
+				codeForbidden,
+				"account doesn't belong to the authenticated user",
+			),
+		)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,308 @@
+package api
+
+import (
+	db "github.com/PFefe/simplebank/db/sqlc"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"net/http"
+	"time"
+)
+
+// transferTerms is what the destination account receives for a transfer
+type transferTerms struct {
+	ToAmount     int64
+	ToCurrency   string
+	ExchangeRate string
+	Spread       string
+}
+
+// currentTerms prices a transfer at the current rate of the rate provider
+func (server *Server) currentTerms(ctx *gin.Context, amount int64, fromCurrency string, toCurrency string) (transferTerms, error) {
+	if fromCurrency == toCurrency {
+		return transferTerms{
+			ToAmount:     amount,
+			ToCurrency:   toCurrency,
+			ExchangeRate: "1",
+			Spread:       "0",
+		}, nil
+	}
+
+	rate, err := server.rateProvider.Rate(
+		ctx,
+		fromCurrency,
+		toCurrency,
+	)
+	if err != nil {
+		return transferTerms{}, err
+	}
+
+	from, _ := server.currencies.Lookup(fromCurrency)
+	to, _ := server.currencies.Lookup(toCurrency)
+	toAmount := rate.Convert(
+		amount,
+		from.MinorUnits,
+		to.MinorUnits,
+	)
+	if toAmount <= 0 {
+		return transferTerms{}, invalidFieldError(
+			"amount",
+			"gt",
+			"amount is too small to be converted",
+		)
+	}
+
+	return transferTerms{
+		ToAmount:     toAmount,
+		ToCurrency:   toCurrency,
+		ExchangeRate: rate.MidString(),
+		Spread:       rate.SpreadString(),
+	}, nil
+}
+
+// quoteTerms returns the terms that were locked in by a quote
+func quoteTerms(quote db.TransferQuote) transferTerms {
+	return transferTerms{
+		ToAmount:     quote.ToAmount,
+		ToCurrency:   quote.ToCurrency,
+		ExchangeRate: quote.ExchangeRate,
+		Spread:       quote.Spread,
+	}
+}
+
+// executeTransfer runs the transfer on the given terms, converting the amount when the currencies differ
+func (server *Server) executeTransfer(ctx *gin.Context, arg db.TransferTxParams, fromCurrency string, terms transferTerms) (db.TransferTxResult, error) {
+	if terms.ToCurrency == fromCurrency {
+		return server.store.TransferTx(
+			ctx,
+			arg,
+		)
+	}
+
+	return server.store.FXTransferTx(
+		ctx,
+		db.FXTransferTxParams{
+			TransferTxParams: arg,
+			ToAmount:         terms.ToAmount,
+			ExchangeRate:     terms.ExchangeRate,
+			Spread:           terms.Spread,
+		},
+	)
+}
+
+type transferQuoteRequest struct {
+	FromAccountID int64       `json:"from_account_id" binding:"required,min=1"`
+	ToAccountID   int64       `json:"to_account_id" binding:"required,min=1"`
+	Amount        amountInput `json:"amount" binding:"required"`
+	Currency      string      `json:"currency" binding:"required,currency"`
+}
+
+// transferQuoteResponse is the public view of a quote, without its owner and the transfer that used it
+type transferQuoteResponse struct {
+	ID            uuid.UUID    `json:"id"`
+	FromAccountID int64        `json:"from_account_id"`
+	ToAccountID   int64        `json:"to_account_id"`
+	Amount        amountOutput `json:"amount"`
+	Currency      string       `json:"currency"`
+	ToAmount      amountOutput `json:"to_amount"`
+	ToCurrency    string       `json:"to_currency"`
+	ExchangeRate  string       `json:"exchange_rate"`
+	Spread        string       `json:"spread"`
+	Fee           amountOutput `json:"fee"`
+	ExpiresAt     time.Time    `json:"expires_at"`
+}
+
+func newTransferQuoteResponse(w amountWriter, quote db.TransferQuote) transferQuoteResponse {
+	return transferQuoteResponse{
+		ID:            quote.ID,
+		FromAccountID: quote.FromAccountID,
+		ToAccountID:   quote.ToAccountID,
+		Amount: w.amount(
+			quote.Amount,
+			quote.Currency,
+		),
+		Currency: quote.Currency,
+		ToAmount: w.amount(
+			quote.ToAmount,
+			quote.ToCurrency,
+		),
+		ToCurrency:   quote.ToCurrency,
+		ExchangeRate: quote.ExchangeRate,
+		Spread:       quote.Spread,
+		Fee: w.amount(
+			quote.Fee,
+			quote.Currency,
+		),
+		ExpiresAt: quote.ExpiresAt,
+	}
+}
+
+func (server *Server) createTransferQuote(ctx *gin.Context) {
+	var req transferQuoteRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		abortWithError(
+			ctx,
+			invalidRequestError(
+				ctx,
+				err,
+			),
+		)
+		return
+	}
+
+	amount, apiErr := server.parsePositiveAmount(
+		ctx,
+		"amount",
+		req.Amount,
+		req.Currency,
+	)
+	if apiErr != nil {
+		abortWithError(
+			ctx,
+			apiErr,
+		)
+		return
+	}
+
+	fromAccount, valid := server.validAccount(
+		ctx,
+		req.FromAccountID,
+		req.Currency,
+	)
+	if !valid {
+		return
+	}
+
+	if !authorizeAccount(
+		ctx,
+		fromAccount,
+	) {
+		return
+	}
+
+	toAccount, err := server.store.GetAccount(
+		ctx,
+		req.ToAccountID,
+	)
+	if err != nil {
+		abortWithError(
+			ctx,
+			err,
+		)
+		return
+	}
+
+	terms, err := server.currentTerms(
+		ctx,
+		amount,
+		fromAccount.Currency,
+		toAccount.Currency,
+	)
+	if err != nil {
+		abortWithError(
+			ctx,
+			err,
+		)
+		return
+	}
+
+	// the fee is locked in with the rate, so the transfer charges what the client was shown
+	fee, err := db.ScheduledFee(
+		ctx,
+		server.store,
+		fromAccount,
+		amount,
+	)
+	if err != nil {
+		abortWithError(
+			ctx,
+			err,
+		)
+		return
+	}
+
+	quote, err := server.store.CreateTransferQuote(
+		ctx,
+		db.CreateTransferQuoteParams{
+			ID:            uuid.New(),
+			Username:      authPayload(ctx).Username,
+			FromAccountID: req.FromAccountID,
+			ToAccountID:   req.ToAccountID,
+			Amount:        amount,
+			Currency:      req.Currency,
+			ToAmount:      terms.ToAmount,
+			ToCurrency:    terms.ToCurrency,
+			ExchangeRate:  terms.ExchangeRate,
+			Spread:        terms.Spread,
+			Fee:           fee,
+			ExpiresAt:     time.Now().Add(server.config.QuoteTTL),
+		},
+	)
+	if err != nil {
+		abortWithError(
+			ctx,
+			err,
+		)
+		return
+	}
+
+	ctx.JSON(
+		http.StatusOK,
+		newTransferQuoteResponse(
+			server.amountWriter(ctx),
+			quote,
+		),
+	)
+}
+
+// validQuote loads the quote of a transfer request and checks that it can still be executed for it
+func (server *Server) validQuote(ctx *gin.Context, req transferRequest, amount int64) (db.TransferQuote, bool) {
+	quote, err := server.store.GetTransferQuote(
+		ctx,
+		uuid.MustParse(req.QuoteID),
+	)
+	if err != nil {
+		abortWithError(
+			ctx,
+			err,
+		)
+		return quote, false
+	}
+
+	if quote.Username != authPayload(ctx).Username {
+		abortWithError(
+			ctx,
+			newAPIError(
+				http.StatusForbidden,
+				codeForbidden,
+				"quote doesn't belong to the authenticated user",
+			),
+		)
+		return quote, false
+	}
+
+	if quote.FromAccountID != req.FromAccountID ||
+		quote.ToAccountID != req.ToAccountID ||
+		quote.Amount != amount ||
+		quote.Currency != req.Currency {
+		abortWithError(
+			ctx,
+			invalidFieldError(
+				"quote_id",
+				"quote",
+				"quote was made for a different transfer",
+			),
+		)
+		return quote, false
+	}
+
+	// the transfer transaction checks this again, since the quote may expire or be used meanwhile
+	if quote.TransferID.Valid || !time.Now().Before(quote.ExpiresAt) {
+		abortWithError(
+			ctx,
+			db.ErrQuoteUnavailable,
+		)
+		return quote, false
+	}
+
+	return quote, true
+}
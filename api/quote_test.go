@@ -0,0 +1,614 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	mockdb "github.com/PFefe/simplebank/db/mock"
+	db "github.com/PFefe/simplebank/db/sqlc"
+	"github.com/PFefe/simplebank/fx"
+	"github.com/PFefe/simplebank/util"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCreateTransferQuoteAPI(t *testing.T) {
+	user, _ := RandomUser(t)
+	otherUser, _ := RandomUser(t)
+
+	fromAccount := RandomAccount(user.Username)
+	fromAccount.Currency = util.USD
+	toAccount := RandomAccount(otherUser.Username)
+	toAccount.Currency = util.EUR
+	cadAccount := RandomAccount(otherUser.Username)
+	cadAccount.Currency = util.CAD
+
+	rateProvider, err := fx.NewStaticRateProvider(
+		map[string]string{
+			"USD/EUR": "0.9",
+		},
+		"0.01",
+	)
+	require.NoError(
+		t,
+		err,
+	)
+
+	testCases := []struct {
+		name          string
+		toAccount     db.Account
+		username      string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:      "OK",
+			toAccount: toAccount,
+			username:  user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Eq(fromAccount.ID),
+					).
+					Times(1).
+					Return(
+						fromAccount,
+						nil,
+					)
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Eq(toAccount.ID),
+					).
+					Times(1).
+					Return(
+						toAccount,
+						nil,
+					)
+				store.EXPECT().
+					GetFeeSchedule(
+						gomock.Any(),
+						gomock.Eq(db.GetFeeScheduleParams{
+							Currency:    util.USD,
+							AccountType: fromAccount.AccountType,
+							Amount:      1000,
+						}),
+					).
+					Times(1).
+					Return(
+						db.FeeSchedule{
+							Currency:    util.USD,
+							AccountType: fromAccount.AccountType,
+							FlatFee:     25,
+							Percentage:  "0.01",
+						},
+						nil,
+					)
+				store.EXPECT().
+					CreateTransferQuote(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(1).
+					DoAndReturn(
+						func(_ interface{}, arg db.CreateTransferQuoteParams) (db.TransferQuote, error) {
+							require.Equal(
+								t,
+								user.Username,
+								arg.Username,
+							)
+							// 25 cents flat plus 1% of 1000 USD cents
+							require.Equal(
+								t,
+								int64(35),
+								arg.Fee,
+							)
+							// 1000 USD cents at 0.9 less the 1% spread
+							require.Equal(
+								t,
+								int64(891),
+								arg.ToAmount,
+							)
+							require.Equal(
+								t,
+								util.EUR,
+								arg.ToCurrency,
+							)
+							require.WithinDuration(
+								t,
+								time.Now().Add(time.Minute),
+								arg.ExpiresAt,
+								time.Second,
+							)
+							return db.TransferQuote{
+								ID:            arg.ID,
+								Username:      arg.Username,
+								FromAccountID: arg.FromAccountID,
+								ToAccountID:   arg.ToAccountID,
+								Amount:        arg.Amount,
+								Currency:      arg.Currency,
+								ToAmount:      arg.ToAmount,
+								ToCurrency:    arg.ToCurrency,
+								ExchangeRate:  arg.ExchangeRate,
+								Spread:        arg.Spread,
+								Fee:           arg.Fee,
+								ExpiresAt:     arg.ExpiresAt,
+							}, nil
+						},
+					)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusOK,
+					recorder.Code,
+				)
+
+				var quote struct {
+					Amount       string `json:"amount"`
+					ToAmount     string `json:"to_amount"`
+					ExchangeRate string `json:"exchange_rate"`
+					Spread       string `json:"spread"`
+					Fee          string `json:"fee"`
+				}
+				err := json.Unmarshal(
+					recorder.Body.Bytes(),
+					&quote,
+				)
+				require.NoError(
+					t,
+					err,
+				)
+				require.Equal(
+					t,
+					"10.00",
+					quote.Amount,
+				)
+				require.Equal(
+					t,
+					"8.91",
+					quote.ToAmount,
+				)
+				require.Equal(
+					t,
+					"0.9",
+					quote.ExchangeRate,
+				)
+				require.Equal(
+					t,
+					"0.01",
+					quote.Spread,
+				)
+				require.Equal(
+					t,
+					"0.35",
+					quote.Fee,
+				)
+			},
+		},
+		{
+			name:      "RateUnavailable",
+			toAccount: cadAccount,
+			username:  user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Eq(fromAccount.ID),
+					).
+					Times(1).
+					Return(
+						fromAccount,
+						nil,
+					)
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Eq(cadAccount.ID),
+					).
+					Times(1).
+					Return(
+						cadAccount,
+						nil,
+					)
+				store.EXPECT().
+					CreateTransferQuote(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusUnprocessableEntity,
+					recorder.Code,
+				)
+			},
+		},
+		{
+			name:      "UnauthorizedUser",
+			toAccount: toAccount,
+			username:  otherUser.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Eq(fromAccount.ID),
+					).
+					Times(1).
+					Return(
+						fromAccount,
+						nil,
+					)
+				store.EXPECT().
+					CreateTransferQuote(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusForbidden,
+					recorder.Code,
+				)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(
+			tc.name,
+			func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+
+				store := mockdb.NewMockStore(ctrl)
+				tc.buildStubs(store)
+
+				server := newTestServer(
+					t,
+					store,
+				)
+				server.rateProvider = rateProvider
+				recorder := httptest.NewRecorder()
+
+				data, err := json.Marshal(
+					gin.H{
+						"from_account_id": fromAccount.ID,
+						"to_account_id":   tc.toAccount.ID,
+						"amount":          "10.00",
+						"currency":        util.USD,
+					},
+				)
+				require.NoError(
+					t,
+					err,
+				)
+
+				request, err := http.NewRequest(
+					http.MethodPost,
+					"/transfers/quotes",
+					bytes.NewReader(data),
+				)
+				require.NoError(
+					t,
+					err,
+				)
+
+				addAuthorization(
+					t,
+					request,
+					server.tokenMaker,
+					authorizationTypeBearer,
+					tc.username,
+					util.DepositorRole,
+					time.Minute,
+				)
+				server.router.ServeHTTP(
+					recorder,
+					request,
+				)
+
+				tc.checkResponse(
+					t,
+					recorder,
+				)
+			},
+		)
+	}
+}
+
+func TestTransferWithQuoteAPI(t *testing.T) {
+	user, _ := RandomUser(t)
+	otherUser, _ := RandomUser(t)
+
+	fromAccount := RandomAccount(user.Username)
+	fromAccount.Currency = util.USD
+	toAccount := RandomAccount(otherUser.Username)
+	toAccount.Currency = util.EUR
+
+	quote := db.TransferQuote{
+		ID:            uuid.New(),
+		Username:      user.Username,
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        1000,
+		Currency:      util.USD,
+		ToAmount:      900,
+		ToCurrency:    util.EUR,
+		ExchangeRate:  "0.91",
+		Spread:        "0.01",
+		ExpiresAt:     time.Now().Add(time.Minute),
+	}
+
+	expired := quote
+	expired.ExpiresAt = time.Now().Add(-time.Second)
+
+	used := quote
+	used.TransferID = sql.NullInt64{
+		Int64: 1,
+		Valid: true,
+	}
+
+	otherUsersQuote := quote
+	otherUsersQuote.Username = otherUser.Username
+
+	testCases := []struct {
+		name          string
+		amount        int64
+		quote         db.TransferQuote
+		buildStubs    func(store *mockdb.MockStore, quote db.TransferQuote)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "OK",
+			amount: quote.Amount,
+			quote:  quote,
+			buildStubs: func(store *mockdb.MockStore, quote db.TransferQuote) {
+				// the quoted terms are used even though no rate is configured now
+				arg := db.FXTransferTxParams{
+					TransferTxParams: db.TransferTxParams{
+						FromAccountID: fromAccount.ID,
+						ToAccountID:   toAccount.ID,
+						Amount:        quote.Amount,
+						QuoteID:       &quote.ID,
+					},
+					ToAmount:     quote.ToAmount,
+					ExchangeRate: quote.ExchangeRate,
+					Spread:       quote.Spread,
+				}
+				store.EXPECT().
+					FXTransferTx(
+						gomock.Any(),
+						gomock.Eq(arg),
+					).
+					Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusOK,
+					recorder.Code,
+				)
+			},
+		},
+		{
+			name:       "Expired",
+			amount:     quote.Amount,
+			quote:      expired,
+			buildStubs: expectNoTransfer,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireQuoteUnavailable(
+					t,
+					recorder,
+				)
+			},
+		},
+		{
+			name:       "AlreadyUsed",
+			amount:     quote.Amount,
+			quote:      used,
+			buildStubs: expectNoTransfer,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireQuoteUnavailable(
+					t,
+					recorder,
+				)
+			},
+		},
+		{
+			name:   "UsedConcurrently",
+			amount: quote.Amount,
+			quote:  quote,
+			buildStubs: func(store *mockdb.MockStore, quote db.TransferQuote) {
+				store.EXPECT().
+					FXTransferTx(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(1).
+					Return(
+						db.TransferTxResult{},
+						db.ErrQuoteUnavailable,
+					)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireQuoteUnavailable(
+					t,
+					recorder,
+				)
+			},
+		},
+		{
+			name:       "DifferentAmount",
+			amount:     quote.Amount + 1,
+			quote:      quote,
+			buildStubs: expectNoTransfer,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusBadRequest,
+					recorder.Code,
+				)
+			},
+		},
+		{
+			name:       "OtherUsersQuote",
+			amount:     quote.Amount,
+			quote:      otherUsersQuote,
+			buildStubs: expectNoTransfer,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusForbidden,
+					recorder.Code,
+				)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(
+			tc.name,
+			func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+
+				store := mockdb.NewMockStore(ctrl)
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Eq(fromAccount.ID),
+					).
+					Times(1).
+					Return(
+						fromAccount,
+						nil,
+					)
+				store.EXPECT().
+					GetTransferQuote(
+						gomock.Any(),
+						gomock.Eq(tc.quote.ID),
+					).
+					Times(1).
+					Return(
+						tc.quote,
+						nil,
+					)
+				tc.buildStubs(
+					store,
+					tc.quote,
+				)
+
+				server := newTestServer(
+					t,
+					store,
+				)
+				recorder := httptest.NewRecorder()
+
+				data, err := json.Marshal(
+					gin.H{
+						"from_account_id": fromAccount.ID,
+						"to_account_id":   toAccount.ID,
+						"amount": decimalAmounts(t).amount(
+							tc.amount,
+							util.USD,
+						),
+						"currency": util.USD,
+						"quote_id": tc.quote.ID,
+					},
+				)
+				require.NoError(
+					t,
+					err,
+				)
+
+				request, err := http.NewRequest(
+					http.MethodPost,
+					"/transfers",
+					bytes.NewReader(data),
+				)
+				require.NoError(
+					t,
+					err,
+				)
+
+				addAuthorization(
+					t,
+					request,
+					server.tokenMaker,
+					authorizationTypeBearer,
+					user.Username,
+					util.DepositorRole,
+					time.Minute,
+				)
+				server.router.ServeHTTP(
+					recorder,
+					request,
+				)
+
+				tc.checkResponse(
+					t,
+					recorder,
+				)
+			},
+		)
+	}
+}
+
+func expectNoTransfer(store *mockdb.MockStore, _ db.TransferQuote) {
+	store.EXPECT().
+		TransferTx(
+			gomock.Any(),
+			gomock.Any(),
+		).
+		Times(0)
+	store.EXPECT().
+		FXTransferTx(
+			gomock.Any(),
+			gomock.Any(),
+		).
+		Times(0)
+}
+
+func requireQuoteUnavailable(t *testing.T, recorder *httptest.ResponseRecorder) {
+	require.Equal(
+		t,
+		http.StatusConflict,
+		recorder.Code,
+	)
+
+	data, err := io.ReadAll(recorder.Body)
+	require.NoError(
+		t,
+		err,
+	)
+
+	var problem problemDetails
+	err = json.Unmarshal(
+		data,
+		&problem,
+	)
+	require.NoError(
+		t,
+		err,
+	)
+	require.Equal(
+		t,
+		codeQuoteUnavailable,
+		problem.Code,
+	)
+}
@@ -1,7 +1,12 @@
 package api
 
 import (
+	"context"
+	"fmt"
 	db "github.com/PFefe/simplebank/db/sqlc"
+	"github.com/PFefe/simplebank/fx"
+	"github.com/PFefe/simplebank/token"
+	"github.com/PFefe/simplebank/util"
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
 	"github.com/go-playground/validator/v10"
@@ -9,52 +14,279 @@ import (
 
 // Server serves HTTP requests for our banking service.
 type Server struct {
-	store  db.Store
-	router *gin.Engine
+	config             util.Config
+	store              db.Store
+	tokenMaker         token.Maker
+	rateProvider       fx.RateProvider
+	currencies         *util.CurrencyRegistry
+	approvalThresholds map[string]int64
+	router             *gin.Engine
 }
 
 // NewServer creates a new HTTP server and set up routing.
-func NewServer(store db.Store) *Server {
-	server := &Server{store: store}
+func NewServer(config util.Config, store db.Store) (*Server, error) {
+	tokenMaker, err := token.NewMaker(
+		config.TokenType,
+		config.TokenSymmetricKey,
+	)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"cannot create token maker: %w",
+			err,
+		)
+	}
+
+	rateProvider, err := fx.NewRateProvider(config.FXRatesFile)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"cannot create rate provider: %w",
+			err,
+		)
+	}
+
+	currencies, err := util.NewCurrencyRegistry(config.Currencies)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"cannot create currency registry: %w",
+			err,
+		)
+	}
+
+	thresholds, err := approvalThresholds(
+		config.ApprovalThresholds,
+		currencies,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if len(thresholds) > 0 && config.ApprovalTTL <= 0 {
+		return nil, fmt.Errorf(
+			"invalid approval ttl: %s",
+			config.ApprovalTTL,
+		)
+	}
+
+	if config.MaxPageSize <= 0 {
+		return nil, fmt.Errorf(
+			"invalid max page size: %d",
+			config.MaxPageSize,
+		)
+	}
+
+	server := &Server{
+		config:       config,
+		store:        store,
+		tokenMaker:   tokenMaker,
+		rateProvider: rateProvider,
+		currencies:   currencies,
+		// transfers above the threshold wait for a banker's approval
+		approvalThresholds: thresholds,
+	}
 	router := gin.Default()
+	router.Use(amountFormatMiddleware())
 
 	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(fieldName)
+
+		// the validator engine is shared, so the currencies of the last server created win
 		err := v.RegisterValidation(
 			"currency",
-			validCurrency,
+			currencyValidator(currencies),
 		)
 		if err != nil {
-			return nil
+			return nil, fmt.Errorf(
+				"cannot register currency validator: %w",
+				err,
+			)
 		}
-
 	}
 
 	router.POST(
+		"/users",
+		server.createUser,
+	)
+	router.POST(
+		"/users/login",
+		server.loginUser,
+	)
+	router.POST(
+		"/tokens/renew_access",
+		server.renewAccessToken,
+	)
+	router.GET(
+		"/currencies",
+		server.listCurrencies,
+	)
+
+	authRoutes := router.Group("/")
+	authRoutes.Use(authMiddleware(server.tokenMaker))
+	authRoutes.GET(
+		"/users/:username",
+		server.getUser,
+	)
+	authRoutes.GET(
+		"/sessions",
+		server.listSessions,
+	)
+	authRoutes.DELETE(
+		"/sessions/:id",
+		server.revokeSession,
+	)
+
+	authRoutes.POST(
 		"/accounts",
 		server.createAccount,
 	)
-	router.GET(
+	authRoutes.GET(
 		"/accounts/:id",
 		server.getAccount,
 	)
-	router.GET(
+	authRoutes.GET(
 		"/accounts",
 		server.listAccounts,
 	)
-	router.POST(
+	authRoutes.POST(
+		"/accounts/:id/close",
+		server.closeAccount,
+	)
+	authRoutes.PATCH(
+		"/accounts/:id",
+		server.updateAccount,
+	)
+	authRoutes.DELETE(
+		"/accounts/:id",
+		server.closeAccount,
+	)
+	authRoutes.GET(
+		"/accounts/:id/entries",
+		server.listEntries,
+	)
+	authRoutes.GET(
+		"/entries/:id",
+		server.getEntry,
+	)
+	authRoutes.GET(
+		"/accounts/:id/transfers",
+		server.listTransfers,
+	)
+	authRoutes.GET(
+		"/accounts/:id/limits",
+		server.getAccountLimits,
+	)
+	authRoutes.POST(
 		"/transfers",
 		server.createTransfer,
 	)
+	authRoutes.POST(
+		"/transfers/quotes",
+		server.createTransferQuote,
+	)
+	authRoutes.GET(
+		"/transfers/:id",
+		server.getTransfer,
+	)
+	authRoutes.GET(
+		"/transfer_requests/:id",
+		server.getTransferRequest,
+	)
+	authRoutes.POST(
+		"/holds",
+		server.createHold,
+	)
+	authRoutes.GET(
+		"/holds/:id",
+		server.getHold,
+	)
+	authRoutes.POST(
+		"/holds/:id/capture",
+		server.captureHold,
+	)
+	authRoutes.POST(
+		"/holds/:id/void",
+		server.voidHold,
+	)
+
+	// Cash movements go through a teller, while freezing accounts, setting
+	// fees and limits, and reviewing large transfers is reserved for bankers.
+	tellerRoutes := authRoutes.Group("/")
+	tellerRoutes.Use(roleMiddleware(util.TellerRole))
+	tellerRoutes.POST(
+		"/accounts/:id/deposits",
+		server.createDeposit,
+	)
+	tellerRoutes.POST(
+		"/accounts/:id/withdrawals",
+		server.createWithdrawal,
+	)
+
+	bankerRoutes := authRoutes.Group("/")
+	bankerRoutes.Use(roleMiddleware(util.BankerRole))
+	bankerRoutes.POST(
+		"/accounts/:id/freeze",
+		server.freezeAccount,
+	)
+	bankerRoutes.POST(
+		"/accounts/:id/unfreeze",
+		server.unfreezeAccount,
+	)
+	bankerRoutes.GET(
+		"/fee_schedules",
+		server.listFeeSchedules,
+	)
+	bankerRoutes.PUT(
+		"/fee_schedules",
+		server.upsertFeeSchedule,
+	)
+	bankerRoutes.PUT(
+		"/accounts/:id/limits",
+		server.updateAccountLimits,
+	)
+	bankerRoutes.GET(
+		"/users/:username/limits",
+		server.listUserLimits,
+	)
+	bankerRoutes.PUT(
+		"/users/:username/limits",
+		server.updateUserLimits,
+	)
+	bankerRoutes.GET(
+		"/transfer_requests",
+		server.listTransferRequests,
+	)
+	bankerRoutes.POST(
+		"/transfer_requests/:id/approve",
+		server.approveTransferRequest,
+	)
+	bankerRoutes.POST(
+		"/transfer_requests/:id/reject",
+		server.rejectTransferRequest,
+	)
+
+	// Reversals are for bankers, and only an admin may force one that overdraws the recipient.
+	reversalRoutes := authRoutes.Group("/")
+	reversalRoutes.Use(
+		roleMiddleware(
+			util.BankerRole,
+			util.AdminRole,
+		),
+	)
+	reversalRoutes.POST(
+		"/transfers/:id/reverse",
+		server.reverseTransfer,
+	)
 
 	server.router = router
-	return server
+	return server, nil
 }
 
-// Start runs the HTTP server on a specific address.
+// Start runs the HTTP server on a specific address, expiring stale transfer requests and holds in the background.
 func (server *Server) Start(address string) error {
-	return server.router.Run(address)
-}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 
-func errorResponse(err error) gin.H {
-	return gin.H{"error": err.Error()}
+	go server.runExpiry(
+		ctx,
+		expiryInterval,
+	)
+	return server.router.Run(address)
 }
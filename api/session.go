@@ -0,0 +1,99 @@
+package api
+
+import (
+	db "github.com/PFefe/simplebank/db/sqlc"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"net/http"
+	"time"
+)
+
+// sessionResponse is the public view of a session, without the refresh token
+type sessionResponse struct {
+	ID        uuid.UUID `json:"id"`
+	UserAgent string    `json:"user_agent"`
+	ClientIp  string    `json:"client_ip"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func newSessionResponse(session db.Session) sessionResponse {
+	return sessionResponse{
+		ID:        session.ID,
+		UserAgent: session.UserAgent,
+		ClientIp:  session.ClientIp,
+		ExpiresAt: session.ExpiresAt,
+		CreatedAt: session.CreatedAt,
+	}
+}
+
+func (server *Server) listSessions(ctx *gin.Context) {
+	authPayload := authPayload(ctx)
+	sessions, err := server.store.ListActiveSessions(
+		ctx,
+		authPayload.Username,
+	)
+	if err != nil {
+		abortWithError(
+			ctx,
+			err,
+		)
+		return
+	}
+
+	rsp := make(
+		[]sessionResponse,
+		0,
+		len(sessions),
+	)
+	for _, session := range sessions {
+		rsp = append(
+			rsp,
+			newSessionResponse(session),
+		)
+	}
+	ctx.JSON(
+		http.StatusOK,
+		rsp,
+	)
+}
+
+type revokeSessionRequest struct {
+	ID string `uri:"id" binding:"required,uuid"`
+}
+
+func (server *Server) revokeSession(ctx *gin.Context) {
+	var req revokeSessionRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		abortWithError(
+			ctx,
+			invalidRequestError(
+				ctx,
+				err,
+			),
+		)
+		return
+	}
+
+	authPayload := authPayload(ctx)
+	session, err := server.store.BlockSession(
+		ctx,
+		db.BlockSessionParams{
+			ID:       uuid.MustParse(req.ID),
+			Username: authPayload.Username,
+		},
+	)
+	if err != nil {
+		// sessions of other users are reported as missing
+		abortWithError(
+			ctx,
+			err,
+		)
+		return
+	}
+
+	ctx.JSON(
+		http.StatusOK,
+		newSessionResponse(session),
+	)
+}
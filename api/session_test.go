@@ -0,0 +1,495 @@
+package api
+
+import (
+	"database/sql"
+	mockdb "github.com/PFefe/simplebank/db/mock"
+	db "github.com/PFefe/simplebank/db/sqlc"
+	"github.com/PFefe/simplebank/token"
+	"github.com/PFefe/simplebank/util"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func randomUserSession(username string) db.Session {
+	return db.Session{
+		ID:           uuid.New(),
+		Username:     username,
+		RefreshToken: util.RandomString(32),
+		UserAgent:    "Mozilla/5.0",
+		ClientIp:     "127.0.0.1",
+		ExpiresAt:    time.Now().Add(time.Hour).UTC().Truncate(time.Second),
+		CreatedAt:    time.Now().UTC().Truncate(time.Second),
+	}
+}
+
+func TestListSessionsAPI(t *testing.T) {
+	user, _ := RandomUser(t)
+	sessions := []db.Session{
+		randomUserSession(user.Username),
+		randomUserSession(user.Username),
+	}
+
+	testCases := []struct {
+		name          string
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(
+					t,
+					request,
+					tokenMaker,
+					authorizationTypeBearer,
+					user.Username,
+					util.DepositorRole,
+					time.Minute,
+				)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListActiveSessions(
+						gomock.Any(),
+						gomock.Eq(user.Username),
+					).
+					Times(1).
+					Return(
+						sessions,
+						nil,
+					)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusOK,
+					recorder.Code,
+				)
+				requireBodyMatchJSON(
+					t,
+					recorder.Body.Bytes(),
+					[]sessionResponse{
+						newSessionResponse(sessions[0]),
+						newSessionResponse(sessions[1]),
+					},
+				)
+			},
+		},
+		{
+			name: "NoSessions",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(
+					t,
+					request,
+					tokenMaker,
+					authorizationTypeBearer,
+					user.Username,
+					util.DepositorRole,
+					time.Minute,
+				)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListActiveSessions(
+						gomock.Any(),
+						gomock.Eq(user.Username),
+					).
+					Times(1).
+					Return(
+						[]db.Session{},
+						nil,
+					)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusOK,
+					recorder.Code,
+				)
+				// an empty list rather than null
+				require.JSONEq(
+					t,
+					"[]",
+					recorder.Body.String(),
+				)
+			},
+		},
+		{
+			name: "NoAuthorization",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListActiveSessions(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusUnauthorized,
+					recorder.Code,
+				)
+			},
+		},
+		{
+			name: "InternalError",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(
+					t,
+					request,
+					tokenMaker,
+					authorizationTypeBearer,
+					user.Username,
+					util.DepositorRole,
+					time.Minute,
+				)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListActiveSessions(
+						gomock.Any(),
+						gomock.Eq(user.Username),
+					).
+					Times(1).
+					Return(
+						nil,
+						sql.ErrConnDone,
+					)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusInternalServerError,
+					recorder.Code,
+				)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(
+			tc.name,
+			func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+
+				store := mockdb.NewMockStore(ctrl)
+				tc.buildStubs(store)
+
+				server := newTestServer(
+					t,
+					store,
+				)
+				recorder := httptest.NewRecorder()
+
+				request, err := http.NewRequest(
+					http.MethodGet,
+					"/sessions",
+					nil,
+				)
+				require.NoError(
+					t,
+					err,
+				)
+
+				tc.setupAuth(
+					t,
+					request,
+					server.tokenMaker,
+				)
+				server.router.ServeHTTP(
+					recorder,
+					request,
+				)
+
+				tc.checkResponse(
+					t,
+					recorder,
+				)
+			},
+		)
+	}
+}
+
+func TestRevokeSessionAPI(t *testing.T) {
+	user, _ := RandomUser(t)
+	otherUser, _ := RandomUser(t)
+
+	session := randomUserSession(user.Username)
+	blocked := session
+	blocked.IsBlocked = true
+
+	testCases := []struct {
+		name          string
+		sessionID     string
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:      "OK",
+			sessionID: session.ID.String(),
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(
+					t,
+					request,
+					tokenMaker,
+					authorizationTypeBearer,
+					user.Username,
+					util.DepositorRole,
+					time.Minute,
+				)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					BlockSession(
+						gomock.Any(),
+						gomock.Eq(db.BlockSessionParams{
+							ID:       session.ID,
+							Username: user.Username,
+						}),
+					).
+					Times(1).
+					Return(
+						blocked,
+						nil,
+					)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusOK,
+					recorder.Code,
+				)
+				requireBodyMatchJSON(
+					t,
+					recorder.Body.Bytes(),
+					newSessionResponse(blocked),
+				)
+			},
+		},
+		{
+			name:      "AlreadyBlocked",
+			sessionID: blocked.ID.String(),
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(
+					t,
+					request,
+					tokenMaker,
+					authorizationTypeBearer,
+					user.Username,
+					util.DepositorRole,
+					time.Minute,
+				)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					BlockSession(
+						gomock.Any(),
+						gomock.Eq(db.BlockSessionParams{
+							ID:       session.ID,
+							Username: user.Username,
+						}),
+					).
+					Times(1).
+					Return(
+						blocked,
+						nil,
+					)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusOK,
+					recorder.Code,
+				)
+				// revoking is idempotent
+				requireBodyMatchJSON(
+					t,
+					recorder.Body.Bytes(),
+					newSessionResponse(blocked),
+				)
+			},
+		},
+		{
+			name:      "OtherUsersSession",
+			sessionID: session.ID.String(),
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(
+					t,
+					request,
+					tokenMaker,
+					authorizationTypeBearer,
+					otherUser.Username,
+					util.DepositorRole,
+					time.Minute,
+				)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					BlockSession(
+						gomock.Any(),
+						gomock.Eq(db.BlockSessionParams{
+							ID:       session.ID,
+							Username: otherUser.Username,
+						}),
+					).
+					Times(1).
+					Return(
+						db.Session{},
+						sql.ErrNoRows,
+					)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusNotFound,
+					recorder.Code,
+				)
+			},
+		},
+		{
+			name:      "NoAuthorization",
+			sessionID: session.ID.String(),
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					BlockSession(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusUnauthorized,
+					recorder.Code,
+				)
+			},
+		},
+		{
+			name:      "InvalidID",
+			sessionID: "not-a-uuid",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(
+					t,
+					request,
+					tokenMaker,
+					authorizationTypeBearer,
+					user.Username,
+					util.DepositorRole,
+					time.Minute,
+				)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					BlockSession(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusBadRequest,
+					recorder.Code,
+				)
+			},
+		},
+		{
+			name:      "InternalError",
+			sessionID: session.ID.String(),
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(
+					t,
+					request,
+					tokenMaker,
+					authorizationTypeBearer,
+					user.Username,
+					util.DepositorRole,
+					time.Minute,
+				)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					BlockSession(
+						gomock.Any(),
+						gomock.Eq(db.BlockSessionParams{
+							ID:       session.ID,
+							Username: user.Username,
+						}),
+					).
+					Times(1).
+					Return(
+						db.Session{},
+						sql.ErrConnDone,
+					)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusInternalServerError,
+					recorder.Code,
+				)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(
+			tc.name,
+			func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+
+				store := mockdb.NewMockStore(ctrl)
+				tc.buildStubs(store)
+
+				server := newTestServer(
+					t,
+					store,
+				)
+				recorder := httptest.NewRecorder()
+
+				request, err := http.NewRequest(
+					http.MethodDelete,
+					"/sessions/"+tc.sessionID,
+					nil,
+				)
+				require.NoError(
+					t,
+					err,
+				)
+
+				tc.setupAuth(
+					t,
+					request,
+					server.tokenMaker,
+				)
+				server.router.ServeHTTP(
+					recorder,
+					request,
+				)
+
+				tc.checkResponse(
+					t,
+					recorder,
+				)
+			},
+		)
+	}
+}
@@ -0,0 +1,153 @@
+package api
+
+import (
+	"github.com/PFefe/simplebank/token"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"time"
+)
+
+type renewAccessTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+type renewAccessTokenResponse struct {
+	AccessToken          string    `json:"access_token"`
+	AccessTokenExpiresAt time.Time `json:"access_token_expires_at"`
+}
+
+func (server *Server) renewAccessToken(ctx *gin.Context) {
+	var req renewAccessTokenRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		abortWithError(
+			ctx,
+			invalidRequestError(
+				ctx,
+				err,
+			),
+		)
+		return
+	}
+
+	refreshPayload, err := server.tokenMaker.VerifyToken(req.RefreshToken)
+	if err != nil {
+		abortWithError(
+			ctx,
+			newAPIError(
+				http.StatusUnauthorized,
+				codeUnauthorized,
+				err.Error(),
+			),
+		)
+		return
+	}
+
+	// an access token must not outlive its own expiry by renewing itself
+	if refreshPayload.Type != token.TokenTypeRefresh {
+		abortWithError(
+			ctx,
+			newAPIError(
+				http.StatusUnauthorized,
+				codeUnauthorized,
+				"token is not a refresh token",
+			),
+		)
+		return
+	}
+
+	session, err := server.store.GetSession(
+		ctx,
+		refreshPayload.ID,
+	)
+	if err != nil {
+		abortWithError(
+			ctx,
+			err,
+		)
+		return
+	}
+
+	if session.IsBlocked {
+		abortWithError(
+			ctx,
+			newAPIError(
+				http.StatusUnauthorized,
+				codeUnauthorized,
+				"blocked session",
+			),
+		)
+		return
+	}
+
+	if session.Username != refreshPayload.Username {
+		abortWithError(
+			ctx,
+			newAPIError(
+				http.StatusUnauthorized,
+				codeUnauthorized,
+				"incorrect session user",
+			),
+		)
+		return
+	}
+
+	if session.RefreshToken != req.RefreshToken {
+		abortWithError(
+			ctx,
+			newAPIError(
+				http.StatusUnauthorized,
+				codeUnauthorized,
+				"mismatched session token",
+			),
+		)
+		return
+	}
+
+	if time.Now().After(session.ExpiresAt) {
+		abortWithError(
+			ctx,
+			newAPIError(
+				http.StatusUnauthorized,
+				codeUnauthorized,
+				"expired session",
+			),
+		)
+		return
+	}
+
+	// the role may have changed since the login, so the new token gets the current one
+	user, err := server.store.GetUser(
+		ctx,
+		session.Username,
+	)
+	if err != nil {
+		abortWithError(
+			ctx,
+			err,
+		)
+		return
+	}
+
+	accessToken, accessPayload, err := server.tokenMaker.CreateToken(
+		user.Username,
+		user.Role,
+		token.TokenTypeAccess,
+		server.config.AccessTokenDuration,
+	)
+	if err != nil {
+		abortWithError(
+			ctx,
+			err,
+		)
+		return
+	}
+
+	rsp := renewAccessTokenResponse{
+		AccessToken:          accessToken,
+		AccessTokenExpiresAt: accessPayload.ExpiredAt,
+	}
+	ctx.JSON(
+		http.StatusOK,
+		rsp,
+	)
+}
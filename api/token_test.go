@@ -0,0 +1,411 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	mockdb "github.com/PFefe/simplebank/db/mock"
+	db "github.com/PFefe/simplebank/db/sqlc"
+	"github.com/PFefe/simplebank/token"
+	"github.com/PFefe/simplebank/util"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRenewAccessTokenAPI(t *testing.T) {
+	user, _ := RandomUser(t)
+	user.Role = util.BankerRole
+
+	testCases := []struct {
+		name          string
+		buildStubs    func(store *mockdb.MockStore, refreshToken string, payload *token.Payload)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder, tokenMaker token.Maker)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore, refreshToken string, payload *token.Payload) {
+				store.EXPECT().
+					GetSession(
+						gomock.Any(),
+						gomock.Eq(payload.ID),
+					).
+					Times(1).
+					Return(
+						randomSession(
+							refreshToken,
+							payload,
+						),
+						nil,
+					)
+				store.EXPECT().
+					GetUser(
+						gomock.Any(),
+						gomock.Eq(user.Username),
+					).
+					Times(1).
+					Return(
+						user,
+						nil,
+					)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, tokenMaker token.Maker) {
+				require.Equal(
+					t,
+					http.StatusOK,
+					recorder.Code,
+				)
+				requireAccessTokenRole(
+					t,
+					recorder,
+					tokenMaker,
+					util.BankerRole,
+				)
+
+				var rsp renewAccessTokenResponse
+				err := json.Unmarshal(
+					recorder.Body.Bytes(),
+					&rsp,
+				)
+				require.NoError(
+					t,
+					err,
+				)
+				require.NotEmpty(
+					t,
+					rsp.AccessToken,
+				)
+			},
+		},
+		{
+			name: "DemotedUser",
+			buildStubs: func(store *mockdb.MockStore, refreshToken string, payload *token.Payload) {
+				store.EXPECT().
+					GetSession(
+						gomock.Any(),
+						gomock.Eq(payload.ID),
+					).
+					Times(1).
+					Return(
+						randomSession(
+							refreshToken,
+							payload,
+						),
+						nil,
+					)
+
+				demoted := user
+				demoted.Role = util.DepositorRole
+				store.EXPECT().
+					GetUser(
+						gomock.Any(),
+						gomock.Eq(user.Username),
+					).
+					Times(1).
+					Return(
+						demoted,
+						nil,
+					)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, tokenMaker token.Maker) {
+				require.Equal(
+					t,
+					http.StatusOK,
+					recorder.Code,
+				)
+				requireAccessTokenRole(
+					t,
+					recorder,
+					tokenMaker,
+					util.DepositorRole,
+				)
+			},
+		},
+		{
+			name: "BlockedSession",
+			buildStubs: func(store *mockdb.MockStore, refreshToken string, payload *token.Payload) {
+				session := randomSession(
+					refreshToken,
+					payload,
+				)
+				session.IsBlocked = true
+				store.EXPECT().
+					GetSession(
+						gomock.Any(),
+						gomock.Eq(payload.ID),
+					).
+					Times(1).
+					Return(
+						session,
+						nil,
+					)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, tokenMaker token.Maker) {
+				require.Equal(
+					t,
+					http.StatusUnauthorized,
+					recorder.Code,
+				)
+			},
+		},
+		{
+			name: "MismatchedSessionToken",
+			buildStubs: func(store *mockdb.MockStore, refreshToken string, payload *token.Payload) {
+				store.EXPECT().
+					GetSession(
+						gomock.Any(),
+						gomock.Eq(payload.ID),
+					).
+					Times(1).
+					Return(
+						randomSession(
+							"other-token",
+							payload,
+						),
+						nil,
+					)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, tokenMaker token.Maker) {
+				require.Equal(
+					t,
+					http.StatusUnauthorized,
+					recorder.Code,
+				)
+			},
+		},
+		{
+			name: "SessionNotFound",
+			buildStubs: func(store *mockdb.MockStore, refreshToken string, payload *token.Payload) {
+				store.EXPECT().
+					GetSession(
+						gomock.Any(),
+						gomock.Eq(payload.ID),
+					).
+					Times(1).
+					Return(
+						db.Session{},
+						sql.ErrNoRows,
+					)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, tokenMaker token.Maker) {
+				require.Equal(
+					t,
+					http.StatusNotFound,
+					recorder.Code,
+				)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(
+			tc.name,
+			func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+
+				store := mockdb.NewMockStore(ctrl)
+				server := newTestServer(
+					t,
+					store,
+				)
+
+				refreshToken, payload, err := server.tokenMaker.CreateToken(
+					user.Username,
+					user.Role,
+					token.TokenTypeRefresh,
+					time.Hour,
+				)
+				require.NoError(
+					t,
+					err,
+				)
+				tc.buildStubs(
+					store,
+					refreshToken,
+					payload,
+				)
+
+				data, err := json.Marshal(gin.H{
+					"refresh_token": refreshToken,
+				})
+				require.NoError(
+					t,
+					err,
+				)
+
+				recorder := httptest.NewRecorder()
+				request, err := http.NewRequest(
+					http.MethodPost,
+					"/tokens/renew_access",
+					bytes.NewReader(data),
+				)
+				require.NoError(
+					t,
+					err,
+				)
+				server.router.ServeHTTP(
+					recorder,
+					request,
+				)
+
+				tc.checkResponse(
+					t,
+					recorder,
+					server.tokenMaker,
+				)
+			},
+		)
+	}
+}
+
+func TestRenewAccessTokenInvalidToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		GetSession(
+			gomock.Any(),
+			gomock.Any(),
+		).
+		Times(0)
+
+	server := newTestServer(
+		t,
+		store,
+	)
+
+	data, err := json.Marshal(gin.H{
+		"refresh_token": "invalid-token",
+	})
+	require.NoError(
+		t,
+		err,
+	)
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(
+		http.MethodPost,
+		"/tokens/renew_access",
+		bytes.NewReader(data),
+	)
+	require.NoError(
+		t,
+		err,
+	)
+	server.router.ServeHTTP(
+		recorder,
+		request,
+	)
+
+	require.Equal(
+		t,
+		http.StatusUnauthorized,
+		recorder.Code,
+	)
+}
+
+func TestRenewAccessTokenWithAccessToken(t *testing.T) {
+	user, _ := RandomUser(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		GetSession(
+			gomock.Any(),
+			gomock.Any(),
+		).
+		Times(0)
+
+	server := newTestServer(
+		t,
+		store,
+	)
+
+	accessToken, _, err := server.tokenMaker.CreateToken(
+		user.Username,
+		user.Role,
+		token.TokenTypeAccess,
+		time.Hour,
+	)
+	require.NoError(
+		t,
+		err,
+	)
+
+	data, err := json.Marshal(gin.H{
+		"refresh_token": accessToken,
+	})
+	require.NoError(
+		t,
+		err,
+	)
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(
+		http.MethodPost,
+		"/tokens/renew_access",
+		bytes.NewReader(data),
+	)
+	require.NoError(
+		t,
+		err,
+	)
+	server.router.ServeHTTP(
+		recorder,
+		request,
+	)
+
+	require.Equal(
+		t,
+		http.StatusUnauthorized,
+		recorder.Code,
+	)
+}
+
+// requireAccessTokenRole checks the role of the access token in a renewal response
+func requireAccessTokenRole(t *testing.T, recorder *httptest.ResponseRecorder, tokenMaker token.Maker, role string) {
+	var rsp renewAccessTokenResponse
+	err := json.Unmarshal(
+		recorder.Body.Bytes(),
+		&rsp,
+	)
+	require.NoError(
+		t,
+		err,
+	)
+
+	payload, err := tokenMaker.VerifyToken(rsp.AccessToken)
+	require.NoError(
+		t,
+		err,
+	)
+	require.Equal(
+		t,
+		role,
+		payload.Role,
+	)
+	require.Equal(
+		t,
+		token.TokenTypeAccess,
+		payload.Type,
+	)
+}
+
+func randomSession(refreshToken string, payload *token.Payload) db.Session {
+	return db.Session{
+		ID:           payload.ID,
+		Username:     payload.Username,
+		RefreshToken: refreshToken,
+		IsBlocked:    false,
+		ExpiresAt:    payload.ExpiredAt,
+	}
+}
@@ -1,113 +1,705 @@
 package api
 
 import (
-	"database/sql"
 	"errors"
 	"fmt"
 	db "github.com/PFefe/simplebank/db/sqlc"
+	"github.com/PFefe/simplebank/util"
 	"github.com/gin-gonic/gin"
 	"log"
 	"net/http"
+	"time"
 )
 
 type transferRequest struct {
-	FromAccountID int64  `json:"from_account_id" binding:"required,min=1"`
-	ToAccountID   int64  `json:"to_account_id" binding:"required,min=1"`
-	Amount        int64  `json:"amount" binding:"required,gt=0"`
-	Currency      string `json:"currency" binding:"required,currency"`
+	FromAccountID int64       `json:"from_account_id" binding:"required,min=1"`
+	ToAccountID   int64       `json:"to_account_id" binding:"required,min=1"`
+	Amount        amountInput `json:"amount" binding:"required"`
+	Currency      string      `json:"currency" binding:"required,currency"`
+	// QuoteID executes the transfer on the terms of an earlier quote
+	QuoteID string `json:"quote_id,omitempty" binding:"omitempty,uuid"`
+}
+
+// transferResponse is the public view of a transfer, with its amounts in the format of the request
+type transferResponse struct {
+	ID            int64        `json:"id"`
+	FromAccountID int64        `json:"from_account_id"`
+	ToAccountID   int64        `json:"to_account_id"`
+	Amount        amountOutput `json:"amount"`
+	Currency      string       `json:"currency"`
+	ToAmount      amountOutput `json:"to_amount"`
+	ToCurrency    string       `json:"to_currency"`
+	ExchangeRate  string       `json:"exchange_rate"`
+	Spread        string       `json:"spread"`
+	// Fee was charged to the source account on top of the amount, in its currency
+	Fee    amountOutput      `json:"fee"`
+	Status db.TransferStatus `json:"status"`
+	// ReversalOf is the transfer this one reverses
+	ReversalOf *int64    `json:"reversal_of"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func newTransferResponse(w amountWriter, transfer db.Transfer) transferResponse {
+	rsp := transferResponse{
+		ID:            transfer.ID,
+		FromAccountID: transfer.FromAccountID,
+		ToAccountID:   transfer.ToAccountID,
+		Amount: w.amount(
+			transfer.Amount,
+			transfer.Currency,
+		),
+		Currency: transfer.Currency,
+		ToAmount: w.amount(
+			transfer.ToAmount,
+			transfer.ToCurrency,
+		),
+		ToCurrency:   transfer.ToCurrency,
+		ExchangeRate: transfer.ExchangeRate,
+		Spread:       transfer.Spread,
+		Fee: w.amount(
+			transfer.Fee,
+			transfer.Currency,
+		),
+		Status:    transfer.Status,
+		CreatedAt: transfer.CreatedAt,
+	}
+	if transfer.ReversalOf.Valid {
+		rsp.ReversalOf = &transfer.ReversalOf.Int64
+	}
+	return rsp
+}
+
+func newTransferResponses(w amountWriter, transfers []db.Transfer) []transferResponse {
+	rsp := make([]transferResponse, 0, len(transfers))
+	for _, transfer := range transfers {
+		rsp = append(
+			rsp,
+			newTransferResponse(
+				w,
+				transfer,
+			),
+		)
+	}
+	return rsp
+}
+
+// transferTxResponse is the result of a transfer, every entry written in the currency of its account
+type transferTxResponse struct {
+	Transfer    transferResponse `json:"transfer"`
+	FromAccount accountResponse  `json:"from_account"`
+	ToAccount   accountResponse  `json:"to_account"`
+	FromEntry   entryResponse    `json:"from_entry"`
+	ToEntry     entryResponse    `json:"to_entry"`
+	Fee         amountOutput     `json:"fee"`
+}
+
+func newTransferTxResponse(w amountWriter, result db.TransferTxResult) transferTxResponse {
+	return transferTxResponse{
+		Transfer: newTransferResponse(
+			w,
+			result.Transfer,
+		),
+		FromAccount: newAccountResponse(
+			w,
+			result.FromAccount,
+		),
+		ToAccount: newAccountResponse(
+			w,
+			result.ToAccount,
+		),
+		FromEntry: newEntryResponse(
+			w,
+			result.FromEntry,
+			result.FromAccount.Currency,
+		),
+		ToEntry: newEntryResponse(
+			w,
+			result.ToEntry,
+			result.ToAccount.Currency,
+		),
+		Fee: w.amount(
+			result.Fee,
+			result.FromAccount.Currency,
+		),
+	}
 }
 
 func (server *Server) createTransfer(ctx *gin.Context) {
 	var req transferRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(
-			http.StatusBadRequest,
-			errorResponse(err),
+		abortWithError(
+			ctx,
+			invalidRequestError(
+				ctx,
+				err,
+			),
 		)
 		return
 	}
 
+	amount, apiErr := server.parsePositiveAmount(
+		ctx,
+		"amount",
+		req.Amount,
+		req.Currency,
+	)
+	if apiErr != nil {
+		abortWithError(
+			ctx,
+			apiErr,
+		)
+		return
+	}
+
+	key, err := idempotencyKey(ctx)
+	if err != nil {
+		abortWithError(
+			ctx,
+			invalidRequestError(
+				ctx,
+				err,
+			),
+		)
+		return
+	}
+
+	authPayload := authPayload(ctx)
+	var hash string
+	if key != "" {
+		hash, err = requestHash(req)
+		if err != nil {
+			abortWithError(
+				ctx,
+				err,
+			)
+			return
+		}
+
+		if server.replayIdempotentRequest(
+			ctx,
+			authPayload.Username,
+			key,
+			hash,
+		) {
+			return
+		}
+	}
+
 	log.Printf(
-		"Creating transfer from account %d to account %d of amount %d %s",
+		"Creating transfer from account %d to account %d of amount %s",
 		req.FromAccountID,
 		req.ToAccountID,
-		req.Amount,
-		req.Currency,
+		server.currencies.FormatAmount(
+			amount,
+			req.Currency,
+		),
 	)
 
-	if !server.validAccount(
+	fromAccount, valid := server.validAccount(
 		ctx,
 		req.FromAccountID,
 		req.Currency,
-	) {
+	)
+	if !valid {
 		return
 	}
 
-	if !server.validAccount(
+	if !authorizeAccount(
 		ctx,
-		req.ToAccountID,
-		req.Currency,
+		fromAccount,
+	) {
+		return
+	}
+
+	// a large transfer only runs once a banker approves it
+	if server.needsApproval(
+		amount,
+		fromAccount.Currency,
 	) {
+		server.requestTransferApproval(
+			ctx,
+			req,
+			amount,
+			key,
+			hash,
+		)
 		return
 	}
 
 	arg := db.TransferTxParams{
 		FromAccountID: req.FromAccountID,
 		ToAccountID:   req.ToAccountID,
-		Amount:        req.Amount,
+		Amount:        amount,
+	}
+	if key != "" {
+		arg.IdempotencyKey = &db.IdempotencyKeyParams{
+			Username:    authPayload.Username,
+			Key:         key,
+			RequestHash: hash,
+			ExpiresAt:   time.Now().Add(server.config.IdempotencyKeyTTL),
+		}
 	}
-	result, err := server.store.TransferTx(
+
+	var terms transferTerms
+	if req.QuoteID != "" {
+		quote, valid := server.validQuote(
+			ctx,
+			req,
+			amount,
+		)
+		if !valid {
+			return
+		}
+		arg.QuoteID = &quote.ID
+		terms = quoteTerms(quote)
+	} else {
+		// the amount is in the currency of the source account, the destination may hold another one
+		toAccount, err := server.store.GetAccount(
+			ctx,
+			req.ToAccountID,
+		)
+		if err != nil {
+			abortWithError(
+				ctx,
+				err,
+			)
+			return
+		}
+
+		terms, err = server.currentTerms(
+			ctx,
+			amount,
+			fromAccount.Currency,
+			toAccount.Currency,
+		)
+		if err != nil {
+			abortWithError(
+				ctx,
+				err,
+			)
+			return
+		}
+	}
+
+	result, err := server.executeTransfer(
 		ctx,
 		arg,
+		fromAccount.Currency,
+		terms,
+	)
+	if err != nil {
+		// a concurrent request with the same key won the race
+		if errors.Is(
+			err,
+			db.ErrIdempotencyKeyInUse,
+		) && server.replayIdempotentRequest(
+			ctx,
+			authPayload.Username,
+			key,
+			hash,
+		) {
+			return
+		}
+		abortWithError(
+			ctx,
+			err,
+		)
+		return
+	}
+
+	ctx.JSON(
+		http.StatusOK,
+		newTransferTxResponse(
+			server.amountWriter(ctx),
+			result,
+		),
+	)
+}
+
+type getTransferRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+func (server *Server) getTransfer(ctx *gin.Context) {
+	var req getTransferRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		abortWithError(
+			ctx,
+			invalidRequestError(
+				ctx,
+				err,
+			),
+		)
+		return
+	}
+
+	transfer, err := server.store.GetTransfer(
+		ctx,
+		req.ID,
 	)
 	if err != nil {
+		abortWithError(
+			ctx,
+			err,
+		)
+		return
+	}
+
+	authPayload := authPayload(ctx)
+	if hasRole(
+		authPayload,
+		staffRoles...,
+	) {
 		ctx.JSON(
-			http.StatusInternalServerError,
-			errorResponse(err),
+			http.StatusOK,
+			newTransferResponse(
+				server.amountWriter(ctx),
+				transfer,
+			),
+		)
+		return
+	}
+
+	// either side of the transfer may look it up
+	for _, accountID := range []int64{transfer.FromAccountID, transfer.ToAccountID} {
+		account, err := server.store.GetAccount(
+			ctx,
+			accountID,
+		)
+		if err != nil {
+			abortWithError(
+				ctx,
+				err,
+			)
+			return
+		}
+		if account.Owner == authPayload.Username {
+			ctx.JSON(
+				http.StatusOK,
+				newTransferResponse(
+					server.amountWriter(ctx),
+					transfer,
+				),
+			)
+			return
+		}
+	}
+
+	abortWithError(
+		ctx,
+		newAPIError(
+			http.StatusForbidden,
+			codeForbidden,
+			"transfer doesn't involve an account of the authenticated user",
+		),
+	)
+}
+
+type reverseTransferRequest struct {
+	// Force reverses the transfer even if that takes the recipient below its overdraft limit
+	Force bool `form:"force"`
+}
+
+// reverseTransfer books a completed transfer back to its source; the response describes the reversal
+func (server *Server) reverseTransfer(ctx *gin.Context) {
+	var uri getTransferRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		abortWithError(
+			ctx,
+			invalidRequestError(
+				ctx,
+				err,
+			),
+		)
+		return
+	}
+
+	var req reverseTransferRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		abortWithError(
+			ctx,
+			invalidRequestError(
+				ctx,
+				err,
+			),
+		)
+		return
+	}
+
+	if req.Force && !hasRole(
+		authPayload(ctx),
+		util.AdminRole,
+	) {
+		abortWithError(
+			ctx,
+			newAPIError(
+				http.StatusForbidden,
+				codeForbidden,
+				"only an admin may force a reversal",
+			),
+		)
+		return
+	}
+
+	result, err := server.store.ReverseTransferTx(
+		ctx,
+		db.ReverseTransferTxParams{
+			TransferID: uri.ID,
+			Force:      req.Force,
+		},
+	)
+	if err != nil {
+		abortWithError(
+			ctx,
+			err,
 		)
 		return
 	}
 
 	ctx.JSON(
 		http.StatusOK,
-		result,
+		newTransferTxResponse(
+			server.amountWriter(ctx),
+			result,
+		),
 	)
 }
 
-func (server *Server) validAccount(ctx *gin.Context, accountId int64, currency string) bool {
+// Directions that narrow the transfers listed for an account; both are listed by default
+const (
+	directionIncoming = "incoming"
+	directionOutgoing = "outgoing"
+)
+
+type listTransfersRequest struct {
+	pageRequest
+	Direction      string     `form:"direction" binding:"omitempty,oneof=incoming outgoing both"`
+	CounterpartyID *int64     `form:"counterparty_id" binding:"omitempty,min=1"`
+	FromTime       *time.Time `form:"from_time" time_format:"2006-01-02T15:04:05Z07:00"`
+	ToTime         *time.Time `form:"to_time" time_format:"2006-01-02T15:04:05Z07:00"`
+	// MinAmount and MaxAmount are read in the currency of the account
+	MinAmount *string `form:"min_amount"`
+	MaxAmount *string `form:"max_amount"`
+}
+
+func (server *Server) listTransfers(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		abortWithError(
+			ctx,
+			invalidRequestError(
+				ctx,
+				err,
+			),
+		)
+		return
+	}
+
+	var req listTransfersRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		abortWithError(
+			ctx,
+			invalidRequestError(
+				ctx,
+				err,
+			),
+		)
+		return
+	}
+
+	if apiErr := validTimeRange(
+		req.FromTime,
+		req.ToTime,
+	); apiErr != nil {
+		abortWithError(
+			ctx,
+			apiErr,
+		)
+		return
+	}
+	page, apiErr := server.page(req.pageRequest)
+	if apiErr != nil {
+		abortWithError(
+			ctx,
+			apiErr,
+		)
+		return
+	}
+
 	account, err := server.store.GetAccount(
 		ctx,
-		accountId,
+		uri.ID,
 	)
 	if err != nil {
-		if errors.Is(
+		abortWithError(
+			ctx,
 			err,
-			sql.ErrNoRows,
-		) {
-			ctx.JSON(
-				http.StatusNotFound,
-				errorResponse(err),
+		)
+		return
+	}
+
+	if !authorizeAccount(
+		ctx,
+		account,
+		staffRoles...,
+	) {
+		return
+	}
+
+	minAmount, apiErr := server.optionalAmount(
+		ctx,
+		"min_amount",
+		req.MinAmount,
+		account.Currency,
+	)
+	if apiErr != nil {
+		abortWithError(
+			ctx,
+			apiErr,
+		)
+		return
+	}
+	maxAmount, apiErr := server.optionalAmount(
+		ctx,
+		"max_amount",
+		req.MaxAmount,
+		account.Currency,
+	)
+	if apiErr != nil {
+		abortWithError(
+			ctx,
+			apiErr,
+		)
+		return
+	}
+	if minAmount != nil && maxAmount != nil && *maxAmount < *minAmount {
+		abortWithError(
+			ctx,
+			invalidFieldError(
+				"max_amount",
+				"gtefield",
+				"must not be less than min_amount",
+			),
+		)
+		return
+	}
+
+	if page.byOffset {
+		arg := db.ListTransfersParams{
+			AccountID:       account.ID,
+			IncludeOutgoing: req.Direction != directionIncoming,
+			IncludeIncoming: req.Direction != directionOutgoing,
+			CounterpartyID:  nullInt64(req.CounterpartyID),
+			FromTime:        nullTime(req.FromTime),
+			ToTime:          nullTime(req.ToTime),
+			MinAmount:       nullInt64(minAmount),
+			MaxAmount:       nullInt64(maxAmount),
+			Limit:           page.size,
+			Offset:          page.offset,
+		}
+
+		transfers, err := server.store.ListTransfers(
+			ctx,
+			arg,
+		)
+		if err != nil {
+			abortWithError(
+				ctx,
+				err,
 			)
-			return false
+			return
 		}
+
 		ctx.JSON(
-			http.StatusInternalServerError,
-			errorResponse(err),
+			http.StatusOK,
+			newTransferResponses(
+				server.amountWriter(ctx),
+				transfers,
+			),
 		)
-		return false
+		return
 	}
-	if account.Currency != currency {
-		err := fmt.Errorf(
-			"account [%d] currency mismatch: %s",
-			accountId,
-			account.Currency,
+
+	position := page.position()
+	arg := db.ListTransfersAfterParams{
+		AccountID:       account.ID,
+		IncludeOutgoing: req.Direction != directionIncoming,
+		IncludeIncoming: req.Direction != directionOutgoing,
+		CounterpartyID:  nullInt64(req.CounterpartyID),
+		FromTime:        nullTime(req.FromTime),
+		ToTime:          nullTime(req.ToTime),
+		MinAmount:       nullInt64(minAmount),
+		MaxAmount:       nullInt64(maxAmount),
+		CursorCreatedAt: position.CreatedAt,
+		CursorID:        position.ID,
+		Limit:           page.limit(),
+	}
+
+	var transfers []db.Transfer
+	if page.forward() {
+		transfers, err = server.store.ListTransfersAfter(
+			ctx,
+			arg,
 		)
-		ctx.JSON(
-			http.StatusBadRequest,
-			errorResponse(err),
+	} else {
+		transfers, err = server.store.ListTransfersBefore(
+			ctx,
+			db.ListTransfersBeforeParams(arg),
 		)
+	}
+	if err != nil {
+		abortWithError(
+			ctx,
+			err,
+		)
+		return
+	}
 
-		return false
+	ctx.JSON(
+		http.StatusOK,
+		newPageResponse(
+			server,
+			page,
+			newTransferResponses(
+				server.amountWriter(ctx),
+				transfers,
+			),
+			func(transfer transferResponse) pageCursor {
+				return pageCursor{
+					CreatedAt: transfer.CreatedAt,
+					ID:        transfer.ID,
+				}
+			},
+		),
+	)
+}
+
+func (server *Server) validAccount(ctx *gin.Context, accountId int64, currency string) (db.Account, bool) {
+	account, err := server.store.GetAccount(
+		ctx,
+		accountId,
+	)
+	if err != nil {
+		abortWithError(
+			ctx,
+			err,
+		)
+		return account, false
+	}
+	if account.Currency != currency {
+		abortWithError(
+			ctx,
+			newAPIError(
+				http.StatusBadRequest,
+				codeCurrencyMismatch,
+				fmt.Sprintf(
+					"account [%d] currency mismatch: %s",
+					accountId,
+					account.Currency,
+				),
+			),
+		)
+		return account, false
 	}
-	return true
+	return account, true
 }
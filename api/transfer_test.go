@@ -0,0 +1,1704 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	mockdb "github.com/PFefe/simplebank/db/mock"
+	db "github.com/PFefe/simplebank/db/sqlc"
+	"github.com/PFefe/simplebank/fx"
+	"github.com/PFefe/simplebank/token"
+	"github.com/PFefe/simplebank/util"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestTransferAPI(t *testing.T) {
+	amount := int64(10)
+	decimalAmount := "0.10"
+
+	user1, _ := RandomUser(t)
+	user2, _ := RandomUser(t)
+	user3, _ := RandomUser(t)
+
+	account1 := RandomAccount(user1.Username)
+	account2 := RandomAccount(user2.Username)
+	account3 := RandomAccount(user3.Username)
+	account4 := RandomAccount(user3.Username)
+
+	account1.Currency = util.USD
+	account2.Currency = util.USD
+	account3.Currency = util.EUR
+	account4.Currency = util.CAD
+
+	rateProvider, err := fx.NewStaticRateProvider(
+		map[string]string{
+			"USD/EUR": "0.9",
+		},
+		"0.01",
+	)
+	require.NoError(
+		t,
+		err,
+	)
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          decimalAmount,
+				"currency":        util.USD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(
+					t,
+					request,
+					tokenMaker,
+					authorizationTypeBearer,
+					user1.Username,
+					util.DepositorRole,
+					time.Minute,
+				)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Eq(account1.ID),
+					).
+					Times(1).
+					Return(
+						account1,
+						nil,
+					)
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Eq(account2.ID),
+					).
+					Times(1).
+					Return(
+						account2,
+						nil,
+					)
+
+				arg := db.TransferTxParams{
+					FromAccountID: account1.ID,
+					ToAccountID:   account2.ID,
+					Amount:        amount,
+				}
+				store.EXPECT().
+					TransferTx(
+						gomock.Any(),
+						gomock.Eq(arg),
+					).
+					Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusOK,
+					recorder.Code,
+				)
+			},
+		},
+		{
+			name: "UnauthorizedUser",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          decimalAmount,
+				"currency":        util.USD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(
+					t,
+					request,
+					tokenMaker,
+					authorizationTypeBearer,
+					user2.Username,
+					util.DepositorRole,
+					time.Minute,
+				)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Eq(account1.ID),
+					).
+					Times(1).
+					Return(
+						account1,
+						nil,
+					)
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Eq(account2.ID),
+					).
+					Times(0)
+				store.EXPECT().
+					TransferTx(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusForbidden,
+					recorder.Code,
+				)
+			},
+		},
+		{
+			name: "NoAuthorization",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          decimalAmount,
+				"currency":        util.USD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(0)
+				store.EXPECT().
+					TransferTx(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusUnauthorized,
+					recorder.Code,
+				)
+			},
+		},
+		{
+			name: "FromAccountNotFound",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          decimalAmount,
+				"currency":        util.USD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(
+					t,
+					request,
+					tokenMaker,
+					authorizationTypeBearer,
+					user1.Username,
+					util.DepositorRole,
+					time.Minute,
+				)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Eq(account1.ID),
+					).
+					Times(1).
+					Return(
+						db.Account{},
+						sql.ErrNoRows,
+					)
+				store.EXPECT().
+					TransferTx(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusNotFound,
+					recorder.Code,
+				)
+			},
+		},
+		{
+			name: "FXTransfer",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account3.ID,
+				"amount":          decimalAmount,
+				"currency":        util.USD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(
+					t,
+					request,
+					tokenMaker,
+					authorizationTypeBearer,
+					user1.Username,
+					util.DepositorRole,
+					time.Minute,
+				)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Eq(account1.ID),
+					).
+					Times(1).
+					Return(
+						account1,
+						nil,
+					)
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Eq(account3.ID),
+					).
+					Times(1).
+					Return(
+						account3,
+						nil,
+					)
+				// 10 USD at 0.9 less the 1% spread is 8.91 EUR, rounded down
+				arg := db.FXTransferTxParams{
+					TransferTxParams: db.TransferTxParams{
+						FromAccountID: account1.ID,
+						ToAccountID:   account3.ID,
+						Amount:        amount,
+					},
+					ToAmount:     8,
+					ExchangeRate: "0.9",
+					Spread:       "0.01",
+				}
+				store.EXPECT().
+					FXTransferTx(
+						gomock.Any(),
+						gomock.Eq(arg),
+					).
+					Times(1)
+				store.EXPECT().
+					TransferTx(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusOK,
+					recorder.Code,
+				)
+			},
+		},
+		{
+			name: "RateUnavailable",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account4.ID,
+				"amount":          decimalAmount,
+				"currency":        util.USD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(
+					t,
+					request,
+					tokenMaker,
+					authorizationTypeBearer,
+					user1.Username,
+					util.DepositorRole,
+					time.Minute,
+				)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Eq(account1.ID),
+					).
+					Times(1).
+					Return(
+						account1,
+						nil,
+					)
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Eq(account4.ID),
+					).
+					Times(1).
+					Return(
+						account4,
+						nil,
+					)
+				store.EXPECT().
+					FXTransferTx(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusUnprocessableEntity,
+					recorder.Code,
+				)
+			},
+		},
+		{
+			name: "TransferTxError",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          decimalAmount,
+				"currency":        util.USD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(
+					t,
+					request,
+					tokenMaker,
+					authorizationTypeBearer,
+					user1.Username,
+					util.DepositorRole,
+					time.Minute,
+				)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Eq(account1.ID),
+					).
+					Times(1).
+					Return(
+						account1,
+						nil,
+					)
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Eq(account2.ID),
+					).
+					Times(1).
+					Return(
+						account2,
+						nil,
+					)
+				store.EXPECT().
+					TransferTx(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(1).
+					Return(
+						db.TransferTxResult{},
+						sql.ErrTxDone,
+					)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusInternalServerError,
+					recorder.Code,
+				)
+			},
+		},
+		{
+			name: "InsufficientFunds",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          decimalAmount,
+				"currency":        util.USD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(
+					t,
+					request,
+					tokenMaker,
+					authorizationTypeBearer,
+					user1.Username,
+					util.DepositorRole,
+					time.Minute,
+				)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Eq(account1.ID),
+					).
+					Times(1).
+					Return(
+						account1,
+						nil,
+					)
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Eq(account2.ID),
+					).
+					Times(1).
+					Return(
+						account2,
+						nil,
+					)
+				store.EXPECT().
+					TransferTx(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(1).
+					Return(
+						db.TransferTxResult{},
+						&db.InsufficientFundsError{
+							AccountID: account1.ID,
+							Amount:    amount,
+						},
+					)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusUnprocessableEntity,
+					recorder.Code,
+				)
+			},
+		},
+		{
+			name: "NegativeAmount",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          "-" + decimalAmount,
+				"currency":        util.USD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(
+					t,
+					request,
+					tokenMaker,
+					authorizationTypeBearer,
+					user1.Username,
+					util.DepositorRole,
+					time.Minute,
+				)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(0)
+				store.EXPECT().
+					TransferTx(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusBadRequest,
+					recorder.Code,
+				)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(
+			tc.name,
+			func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+
+				store := mockdb.NewMockStore(ctrl)
+				tc.buildStubs(store)
+
+				server := newTestServer(
+					t,
+					store,
+				)
+				server.rateProvider = rateProvider
+				recorder := httptest.NewRecorder()
+
+				data, err := json.Marshal(tc.body)
+				require.NoError(
+					t,
+					err,
+				)
+
+				request, err := http.NewRequest(
+					http.MethodPost,
+					"/transfers",
+					bytes.NewReader(data),
+				)
+				require.NoError(
+					t,
+					err,
+				)
+
+				tc.setupAuth(
+					t,
+					request,
+					server.tokenMaker,
+				)
+				server.router.ServeHTTP(
+					recorder,
+					request,
+				)
+
+				tc.checkResponse(
+					t,
+					recorder,
+				)
+			},
+		)
+	}
+}
+
+func TestTransferAPIIdempotency(t *testing.T) {
+	amount := int64(10)
+	key := util.RandomString(16)
+
+	user1, _ := RandomUser(t)
+	user2, _ := RandomUser(t)
+
+	account1 := RandomAccount(user1.Username)
+	account2 := RandomAccount(user2.Username)
+	account1.Currency = util.USD
+	account2.Currency = util.USD
+
+	req := transferRequest{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        amountInput(`"0.10"`),
+		Currency:      util.USD,
+	}
+	hash, err := requestHash(req)
+	require.NoError(
+		t,
+		err,
+	)
+
+	storedResult := db.TransferTxResult{
+		Transfer: db.Transfer{
+			ID:            1,
+			FromAccountID: account1.ID,
+			ToAccountID:   account2.ID,
+			Amount:        amount,
+			Currency:      util.USD,
+			ToAmount:      amount,
+			ToCurrency:    util.USD,
+		},
+		FromAccount: account1,
+		ToAccount:   account2,
+	}
+	storedResponse, err := json.Marshal(storedResult)
+	require.NoError(
+		t,
+		err,
+	)
+
+	testCases := []struct {
+		name          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "FirstRequest",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetIdempotencyKey(
+						gomock.Any(),
+						gomock.Eq(db.GetIdempotencyKeyParams{
+							Username: user1.Username,
+							Key:      key,
+						}),
+					).
+					Times(1).
+					Return(
+						db.IdempotencyKey{},
+						sql.ErrNoRows,
+					)
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Eq(account1.ID),
+					).
+					Times(1).
+					Return(
+						account1,
+						nil,
+					)
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Eq(account2.ID),
+					).
+					Times(1).
+					Return(
+						account2,
+						nil,
+					)
+				store.EXPECT().
+					TransferTx(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.TransferTxParams) (db.TransferTxResult, error) {
+						require.NotNil(
+							t,
+							arg.IdempotencyKey,
+						)
+						require.Equal(
+							t,
+							key,
+							arg.IdempotencyKey.Key,
+						)
+						require.Equal(
+							t,
+							hash,
+							arg.IdempotencyKey.RequestHash,
+						)
+						return db.TransferTxResult{}, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusOK,
+					recorder.Code,
+				)
+				require.Empty(
+					t,
+					recorder.Header().Get(idempotentReplayedHeader),
+				)
+			},
+		},
+		{
+			name: "Replay",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetIdempotencyKey(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(1).
+					Return(
+						db.IdempotencyKey{
+							Username:    user1.Username,
+							Key:         key,
+							RequestHash: hash,
+							Response:    storedResponse,
+						},
+						nil,
+					)
+				store.EXPECT().
+					TransferTx(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusOK,
+					recorder.Code,
+				)
+				require.Equal(
+					t,
+					"true",
+					recorder.Header().Get(idempotentReplayedHeader),
+				)
+				requireBodyMatchJSON(
+					t,
+					recorder.Body.Bytes(),
+					newTransferTxResponse(
+						decimalAmounts(t),
+						storedResult,
+					),
+				)
+			},
+		},
+		{
+			name: "DifferentRequestBody",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetIdempotencyKey(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(1).
+					Return(
+						db.IdempotencyKey{
+							Username:    user1.Username,
+							Key:         key,
+							RequestHash: "other-hash",
+							Response:    storedResponse,
+						},
+						nil,
+					)
+				store.EXPECT().
+					TransferTx(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusConflict,
+					recorder.Code,
+				)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(
+			tc.name,
+			func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+
+				store := mockdb.NewMockStore(ctrl)
+				tc.buildStubs(store)
+
+				server := newTestServer(
+					t,
+					store,
+				)
+				recorder := httptest.NewRecorder()
+
+				data, err := json.Marshal(req)
+				require.NoError(
+					t,
+					err,
+				)
+
+				request, err := http.NewRequest(
+					http.MethodPost,
+					"/transfers",
+					bytes.NewReader(data),
+				)
+				require.NoError(
+					t,
+					err,
+				)
+				request.Header.Set(
+					idempotencyKeyHeader,
+					key,
+				)
+
+				addAuthorization(
+					t,
+					request,
+					server.tokenMaker,
+					authorizationTypeBearer,
+					user1.Username,
+					util.DepositorRole,
+					time.Minute,
+				)
+				server.router.ServeHTTP(
+					recorder,
+					request,
+				)
+
+				tc.checkResponse(
+					t,
+					recorder,
+				)
+			},
+		)
+	}
+}
+
+func TestGetTransferAPI(t *testing.T) {
+	user1, _ := RandomUser(t)
+	user2, _ := RandomUser(t)
+	user3, _ := RandomUser(t)
+
+	account1 := RandomAccount(user1.Username)
+	account2 := RandomAccount(user2.Username)
+	transfer := randomTransfer(
+		account1,
+		account2,
+	)
+
+	testCases := []struct {
+		name          string
+		transferID    int64
+		username      string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:       "Sender",
+			transferID: transfer.ID,
+			username:   user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetTransfer(
+						gomock.Any(),
+						gomock.Eq(transfer.ID),
+					).
+					Times(1).
+					Return(
+						transfer,
+						nil,
+					)
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Eq(account1.ID),
+					).
+					Times(1).
+					Return(
+						account1,
+						nil,
+					)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusOK,
+					recorder.Code,
+				)
+				requireBodyMatchTransfer(
+					t,
+					recorder.Body,
+					transfer,
+				)
+			},
+		},
+		{
+			name:       "Recipient",
+			transferID: transfer.ID,
+			username:   user2.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetTransfer(
+						gomock.Any(),
+						gomock.Eq(transfer.ID),
+					).
+					Times(1).
+					Return(
+						transfer,
+						nil,
+					)
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Eq(account1.ID),
+					).
+					Times(1).
+					Return(
+						account1,
+						nil,
+					)
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Eq(account2.ID),
+					).
+					Times(1).
+					Return(
+						account2,
+						nil,
+					)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusOK,
+					recorder.Code,
+				)
+				requireBodyMatchTransfer(
+					t,
+					recorder.Body,
+					transfer,
+				)
+			},
+		},
+		{
+			name:       "UnauthorizedUser",
+			transferID: transfer.ID,
+			username:   user3.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetTransfer(
+						gomock.Any(),
+						gomock.Eq(transfer.ID),
+					).
+					Times(1).
+					Return(
+						transfer,
+						nil,
+					)
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(2).
+					DoAndReturn(func(_ any, id int64) (db.Account, error) {
+						if id == account1.ID {
+							return account1, nil
+						}
+						return account2, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusForbidden,
+					recorder.Code,
+				)
+			},
+		},
+		{
+			name:       "NotFound",
+			transferID: transfer.ID,
+			username:   user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetTransfer(
+						gomock.Any(),
+						gomock.Eq(transfer.ID),
+					).
+					Times(1).
+					Return(
+						db.Transfer{},
+						sql.ErrNoRows,
+					)
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusNotFound,
+					recorder.Code,
+				)
+			},
+		},
+		{
+			name:       "InvalidID",
+			transferID: 0,
+			username:   user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetTransfer(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusBadRequest,
+					recorder.Code,
+				)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(
+			tc.name,
+			func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+
+				store := mockdb.NewMockStore(ctrl)
+				tc.buildStubs(store)
+
+				server := newTestServer(
+					t,
+					store,
+				)
+				recorder := httptest.NewRecorder()
+
+				url := fmt.Sprintf(
+					"/transfers/%d",
+					tc.transferID,
+				)
+				request, err := http.NewRequest(
+					http.MethodGet,
+					url,
+					nil,
+				)
+				require.NoError(
+					t,
+					err,
+				)
+
+				addAuthorization(
+					t,
+					request,
+					server.tokenMaker,
+					authorizationTypeBearer,
+					tc.username,
+					util.DepositorRole,
+					time.Minute,
+				)
+				server.router.ServeHTTP(
+					recorder,
+					request,
+				)
+
+				tc.checkResponse(
+					t,
+					recorder,
+				)
+			},
+		)
+	}
+}
+
+func TestListTransfersAPI(t *testing.T) {
+	user1, _ := RandomUser(t)
+	user2, _ := RandomUser(t)
+
+	account1 := RandomAccount(user1.Username)
+	account2 := RandomAccount(user2.Username)
+
+	n := 5
+	transfers := make(
+		[]db.Transfer,
+		n,
+	)
+	for i := 0; i < n; i++ {
+		transfers[i] = randomTransfer(
+			account1,
+			account2,
+		)
+	}
+
+	testCases := []struct {
+		name          string
+		query         url.Values
+		username      string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			query: url.Values{
+				"page_id":   {"1"},
+				"page_size": {fmt.Sprint(n)},
+			},
+			username: user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Eq(account1.ID),
+					).
+					Times(1).
+					Return(
+						account1,
+						nil,
+					)
+
+				arg := db.ListTransfersParams{
+					AccountID:       account1.ID,
+					IncludeOutgoing: true,
+					IncludeIncoming: true,
+					Limit:           int32(n),
+					Offset:          0,
+				}
+				store.EXPECT().
+					ListTransfers(
+						gomock.Any(),
+						gomock.Eq(arg),
+					).
+					Times(1).
+					Return(
+						transfers,
+						nil,
+					)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusOK,
+					recorder.Code,
+				)
+
+				requireBodyMatchJSON(
+					t,
+					recorder.Body.Bytes(),
+					newTransferResponses(
+						decimalAmounts(t),
+						transfers,
+					),
+				)
+			},
+		},
+		{
+			name: "Filters",
+			query: url.Values{
+				"page_id":         {"2"},
+				"page_size":       {fmt.Sprint(n)},
+				"direction":       {directionOutgoing},
+				"counterparty_id": {fmt.Sprint(account2.ID)},
+				"min_amount":      {"0.10"},
+				"max_amount":      {"1.00"},
+			},
+			username: user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Eq(account1.ID),
+					).
+					Times(1).
+					Return(
+						account1,
+						nil,
+					)
+
+				arg := db.ListTransfersParams{
+					AccountID:       account1.ID,
+					IncludeOutgoing: true,
+					IncludeIncoming: false,
+					CounterpartyID: sql.NullInt64{
+						Int64: account2.ID,
+						Valid: true,
+					},
+					MinAmount: sql.NullInt64{
+						Int64: 10,
+						Valid: true,
+					},
+					MaxAmount: sql.NullInt64{
+						Int64: 100,
+						Valid: true,
+					},
+					Limit:  int32(n),
+					Offset: int32(n),
+				}
+				store.EXPECT().
+					ListTransfers(
+						gomock.Any(),
+						gomock.Eq(arg),
+					).
+					Times(1).
+					Return(
+						[]db.Transfer{},
+						nil,
+					)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusOK,
+					recorder.Code,
+				)
+			},
+		},
+		{
+			name: "InvalidDirection",
+			query: url.Values{
+				"page_id":   {"1"},
+				"page_size": {fmt.Sprint(n)},
+				"direction": {"sideways"},
+			},
+			username: user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListTransfers(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusBadRequest,
+					recorder.Code,
+				)
+				requireBodyMatchFieldErrors(
+					t,
+					recorder.Body,
+					[]fieldError{
+						{
+							Field:   "direction",
+							Rule:    "oneof",
+							Message: "must be one of: incoming outgoing both",
+						},
+					},
+				)
+			},
+		},
+		{
+			name: "InvalidAmountRange",
+			query: url.Values{
+				"page_id":    {"1"},
+				"page_size":  {fmt.Sprint(n)},
+				"min_amount": {"1.00"},
+				"max_amount": {"0.10"},
+			},
+			username: user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				// the amounts are read in the currency of the account
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Eq(account1.ID),
+					).
+					Times(1).
+					Return(
+						account1,
+						nil,
+					)
+				store.EXPECT().
+					ListTransfers(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusBadRequest,
+					recorder.Code,
+				)
+			},
+		},
+		{
+			name: "UnauthorizedUser",
+			query: url.Values{
+				"page_id":   {"1"},
+				"page_size": {fmt.Sprint(n)},
+			},
+			username: user2.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(
+						gomock.Any(),
+						gomock.Eq(account1.ID),
+					).
+					Times(1).
+					Return(
+						account1,
+						nil,
+					)
+				store.EXPECT().
+					ListTransfers(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusForbidden,
+					recorder.Code,
+				)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(
+			tc.name,
+			func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+
+				store := mockdb.NewMockStore(ctrl)
+				tc.buildStubs(store)
+
+				server := newTestServer(
+					t,
+					store,
+				)
+				recorder := httptest.NewRecorder()
+
+				url := fmt.Sprintf(
+					"/accounts/%d/transfers?%s",
+					account1.ID,
+					tc.query.Encode(),
+				)
+				request, err := http.NewRequest(
+					http.MethodGet,
+					url,
+					nil,
+				)
+				require.NoError(
+					t,
+					err,
+				)
+
+				addAuthorization(
+					t,
+					request,
+					server.tokenMaker,
+					authorizationTypeBearer,
+					tc.username,
+					util.DepositorRole,
+					time.Minute,
+				)
+				server.router.ServeHTTP(
+					recorder,
+					request,
+				)
+
+				tc.checkResponse(
+					t,
+					recorder,
+				)
+			},
+		)
+	}
+}
+
+func TestReverseTransferAPI(t *testing.T) {
+	user1, _ := RandomUser(t)
+	user2, _ := RandomUser(t)
+	banker, _ := RandomUser(t)
+	admin, _ := RandomUser(t)
+
+	account1 := RandomAccount(user1.Username)
+	account2 := RandomAccount(user2.Username)
+	account1.Currency = util.USD
+	account2.Currency = util.USD
+
+	original := randomTransfer(
+		account1,
+		account2,
+	)
+	reversal := randomTransfer(
+		account2,
+		account1,
+	)
+	reversal.ReversalOf = sql.NullInt64{
+		Int64: original.ID,
+		Valid: true,
+	}
+	result := db.TransferTxResult{
+		Transfer:    reversal,
+		FromAccount: account2,
+		ToAccount:   account1,
+	}
+
+	testCases := []struct {
+		name          string
+		query         string
+		username      string
+		role          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			username: banker.Username,
+			role:     util.BankerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ReverseTransferTx(
+						gomock.Any(),
+						gomock.Eq(db.ReverseTransferTxParams{
+							TransferID: original.ID,
+						}),
+					).
+					Times(1).
+					Return(
+						result,
+						nil,
+					)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusOK,
+					recorder.Code,
+				)
+				requireBodyMatchJSON(
+					t,
+					recorder.Body.Bytes(),
+					newTransferTxResponse(
+						decimalAmounts(t),
+						result,
+					),
+				)
+			},
+		},
+		{
+			name:     "AdminForces",
+			query:    "?force=true",
+			username: admin.Username,
+			role:     util.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ReverseTransferTx(
+						gomock.Any(),
+						gomock.Eq(db.ReverseTransferTxParams{
+							TransferID: original.ID,
+							Force:      true,
+						}),
+					).
+					Times(1).
+					Return(
+						result,
+						nil,
+					)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusOK,
+					recorder.Code,
+				)
+			},
+		},
+		{
+			name:     "BankerForces",
+			query:    "?force=true",
+			username: banker.Username,
+			role:     util.BankerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ReverseTransferTx(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusForbidden,
+					recorder.Code,
+				)
+			},
+		},
+		{
+			name:     "Depositor",
+			username: user1.Username,
+			role:     util.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ReverseTransferTx(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusForbidden,
+					recorder.Code,
+				)
+			},
+		},
+		{
+			name:     "AlreadyReversed",
+			username: banker.Username,
+			role:     util.BankerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ReverseTransferTx(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(1).
+					Return(
+						db.TransferTxResult{},
+						db.ErrInvalidTransferTransition,
+					)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusConflict,
+					recorder.Code,
+				)
+			},
+		},
+		{
+			name:     "RecipientOverdrawn",
+			username: banker.Username,
+			role:     util.BankerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ReverseTransferTx(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(1).
+					Return(
+						db.TransferTxResult{},
+						&db.InsufficientFundsError{
+							AccountID: account2.ID,
+						},
+					)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusUnprocessableEntity,
+					recorder.Code,
+				)
+			},
+		},
+		{
+			name:     "NotFound",
+			username: banker.Username,
+			role:     util.BankerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ReverseTransferTx(
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Times(1).
+					Return(
+						db.TransferTxResult{},
+						sql.ErrNoRows,
+					)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(
+					t,
+					http.StatusNotFound,
+					recorder.Code,
+				)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(
+			tc.name,
+			func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+
+				store := mockdb.NewMockStore(ctrl)
+				tc.buildStubs(store)
+
+				server := newTestServer(
+					t,
+					store,
+				)
+				recorder := httptest.NewRecorder()
+
+				url := fmt.Sprintf(
+					"/transfers/%d/reverse%s",
+					original.ID,
+					tc.query,
+				)
+				request, err := http.NewRequest(
+					http.MethodPost,
+					url,
+					nil,
+				)
+				require.NoError(
+					t,
+					err,
+				)
+
+				addAuthorization(
+					t,
+					request,
+					server.tokenMaker,
+					authorizationTypeBearer,
+					tc.username,
+					tc.role,
+					time.Minute,
+				)
+				server.router.ServeHTTP(
+					recorder,
+					request,
+				)
+
+				tc.checkResponse(
+					t,
+					recorder,
+				)
+			},
+		)
+	}
+}
+
+func randomTransfer(fromAccount db.Account, toAccount db.Account) db.Transfer {
+	amount := util.RandomMoney()
+	return db.Transfer{
+		ID: util.RandomInt(
+			1,
+			1000,
+		),
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        amount,
+		Currency:      fromAccount.Currency,
+		ToAmount:      amount,
+		ToCurrency:    toAccount.Currency,
+		ExchangeRate:  "1",
+		Spread:        "0",
+		Status:        db.TransferStatusCompleted,
+		CreatedAt:     time.Now().UTC().Truncate(time.Second),
+	}
+}
+
+func requireBodyMatchTransfer(t *testing.T, body *bytes.Buffer, transfer db.Transfer) {
+	requireBodyMatchJSON(
+		t,
+		body.Bytes(),
+		newTransferResponse(
+			decimalAmounts(t),
+			transfer,
+		),
+	)
+}
@@ -0,0 +1,302 @@
+package api
+
+import (
+	db "github.com/PFefe/simplebank/db/sqlc"
+	"github.com/PFefe/simplebank/token"
+	"github.com/PFefe/simplebank/util"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"log"
+	"net/http"
+	"time"
+)
+
+type createUserRequest struct {
+	Username string `json:"username" binding:"required,alphanum"`
+	Password string `json:"password" binding:"required,min=6"`
+	FullName string `json:"full_name" binding:"required"`
+	Email    string `json:"email" binding:"required,email"`
+}
+
+// userResponse is the public view of a user, without the hashed password
+type userResponse struct {
+	Username          string    `json:"username"`
+	FullName          string    `json:"full_name"`
+	Email             string    `json:"email"`
+	Role              string    `json:"role"`
+	PasswordChangedAt time.Time `json:"password_changed_at"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+func newUserResponse(user db.User) userResponse {
+	return userResponse{
+		Username:          user.Username,
+		FullName:          user.FullName,
+		Email:             user.Email,
+		Role:              user.Role,
+		PasswordChangedAt: user.PasswordChangedAt,
+		CreatedAt:         user.CreatedAt,
+	}
+}
+
+func (server *Server) createUser(ctx *gin.Context) {
+	var req createUserRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		abortWithError(
+			ctx,
+			invalidRequestError(
+				ctx,
+				err,
+			),
+		)
+		return
+	}
+
+	hashedPassword, err := util.HashPassword(
+		req.Password,
+		server.config.PasswordHashCost,
+	)
+	if err != nil {
+		abortWithError(
+			ctx,
+			err,
+		)
+		return
+	}
+
+	arg := db.CreateUserParams{
+		Username:       req.Username,
+		HashedPassword: hashedPassword,
+		FullName:       req.FullName,
+		Email:          req.Email,
+	}
+
+	user, err := server.store.CreateUser(
+		ctx,
+		arg,
+	)
+	if err != nil {
+		abortWithError(
+			ctx,
+			err,
+		)
+		return
+	}
+
+	ctx.JSON(
+		http.StatusOK,
+		newUserResponse(user),
+	)
+}
+
+type getUserRequest struct {
+	Username string `uri:"username" binding:"required,alphanum"`
+}
+
+func (server *Server) getUser(ctx *gin.Context) {
+	var req getUserRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		abortWithError(
+			ctx,
+			invalidRequestError(
+				ctx,
+				err,
+			),
+		)
+		return
+	}
+
+	authPayload := authPayload(ctx)
+	if req.Username != authPayload.Username {
+		abortWithError(
+			ctx,
+			newAPIError(
+				http.StatusForbidden,
+				codeForbidden,
+				"user doesn't match the authenticated user",
+			),
+		)
+		return
+	}
+
+	user, err := server.store.GetUser(
+		ctx,
+		req.Username,
+	)
+	if err != nil {
+		abortWithError(
+			ctx,
+			err,
+		)
+		return
+	}
+
+	ctx.JSON(
+		http.StatusOK,
+		newUserResponse(user),
+	)
+}
+
+type loginUserRequest struct {
+	Username string `json:"username" binding:"required,alphanum"`
+	Password string `json:"password" binding:"required,min=6"`
+}
+
+type loginUserResponse struct {
+	SessionID             uuid.UUID    `json:"session_id"`
+	AccessToken           string       `json:"access_token"`
+	AccessTokenExpiresAt  time.Time    `json:"access_token_expires_at"`
+	RefreshToken          string       `json:"refresh_token"`
+	RefreshTokenExpiresAt time.Time    `json:"refresh_token_expires_at"`
+	User                  userResponse `json:"user"`
+}
+
+func (server *Server) loginUser(ctx *gin.Context) {
+	var req loginUserRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		abortWithError(
+			ctx,
+			invalidRequestError(
+				ctx,
+				err,
+			),
+		)
+		return
+	}
+
+	user, err := server.store.GetUser(
+		ctx,
+		req.Username,
+	)
+	if err != nil {
+		abortWithError(
+			ctx,
+			err,
+		)
+		return
+	}
+
+	err = util.CheckPassword(
+		req.Password,
+		user.HashedPassword,
+	)
+	if err != nil {
+		abortWithError(
+			ctx,
+			newAPIError(
+				http.StatusUnauthorized,
+				codeUnauthorized,
+				"incorrect password",
+			),
+		)
+		return
+	}
+
+	user = server.rehashPassword(
+		ctx,
+		user,
+		req.Password,
+	)
+
+	accessToken, accessPayload, err := server.tokenMaker.CreateToken(
+		user.Username,
+		user.Role,
+		token.TokenTypeAccess,
+		server.config.AccessTokenDuration,
+	)
+	if err != nil {
+		abortWithError(
+			ctx,
+			err,
+		)
+		return
+	}
+
+	refreshToken, refreshPayload, err := server.tokenMaker.CreateToken(
+		user.Username,
+		user.Role,
+		token.TokenTypeRefresh,
+		server.config.RefreshTokenDuration,
+	)
+	if err != nil {
+		abortWithError(
+			ctx,
+			err,
+		)
+		return
+	}
+
+	session, err := server.store.CreateSession(
+		ctx,
+		db.CreateSessionParams{
+			ID:           refreshPayload.ID,
+			Username:     user.Username,
+			RefreshToken: refreshToken,
+			UserAgent:    ctx.Request.UserAgent(),
+			ClientIp:     ctx.ClientIP(),
+			IsBlocked:    false,
+			ExpiresAt:    refreshPayload.ExpiredAt,
+		},
+	)
+	if err != nil {
+		abortWithError(
+			ctx,
+			err,
+		)
+		return
+	}
+
+	rsp := loginUserResponse{
+		SessionID:             session.ID,
+		AccessToken:           accessToken,
+		AccessTokenExpiresAt:  accessPayload.ExpiredAt,
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: refreshPayload.ExpiredAt,
+		User:                  newUserResponse(user),
+	}
+	ctx.JSON(
+		http.StatusOK,
+		rsp,
+	)
+}
+
+// rehashPassword upgrades the stored hash when the configured cost has changed.
+// It runs after a successful login, so a failure only gets logged.
+func (server *Server) rehashPassword(ctx *gin.Context, user db.User, password string) db.User {
+	if !util.PasswordNeedsRehash(
+		user.HashedPassword,
+		server.config.PasswordHashCost,
+	) {
+		return user
+	}
+
+	hashedPassword, err := util.HashPassword(
+		password,
+		server.config.PasswordHashCost,
+	)
+	if err != nil {
+		log.Printf(
+			"Failed to rehash password for user %s: %v",
+			user.Username,
+			err,
+		)
+		return user
+	}
+
+	updatedUser, err := server.store.UpdateUserHashedPassword(
+		ctx,
+		db.UpdateUserHashedPasswordParams{
+			Username:       user.Username,
+			HashedPassword: hashedPassword,
+		},
+	)
+	if err != nil {
+		log.Printf(
+			"Failed to store rehashed password for user %s: %v",
+			user.Username,
+			err,
+		)
+		return user
+	}
+	return updatedUser
+}
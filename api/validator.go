@@ -1,13 +1,228 @@
 package api
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/PFefe/simplebank/util"
+	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 )
 
-var validCurrency validator.Func = func(fieldLevel validator.FieldLevel) bool {
-	if currency, ok := fieldLevel.Field().Interface().(string); ok {
-		return util.IsSupportedCurrency(currency)
+// currencyValidator accepts the currencies enabled in the registry
+func currencyValidator(currencies *util.CurrencyRegistry) validator.Func {
+	return func(fieldLevel validator.FieldLevel) bool {
+		if currency, ok := fieldLevel.Field().Interface().(string); ok {
+			return currencies.IsSupported(currency)
+		}
+		return false
 	}
-	return false
+}
+
+// fieldName reports struct fields by the name the client used: the json, form or uri tag
+func fieldName(field reflect.StructField) string {
+	for _, tag := range []string{"json", "form", "uri"} {
+		name := strings.SplitN(
+			field.Tag.Get(tag),
+			",",
+			2,
+		)[0]
+		if name == "-" {
+			return ""
+		}
+		if name != "" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+// bindingFieldErrors lists the invalid fields of a binding error, or nil if it is not about fields
+func bindingFieldErrors(ctx *gin.Context, err error) []fieldError {
+	var validationErrors validator.ValidationErrors
+	if errors.As(
+		err,
+		&validationErrors,
+	) {
+		fieldErrors := make(
+			[]fieldError,
+			0,
+			len(validationErrors),
+		)
+		for _, fe := range validationErrors {
+			fieldErrors = append(
+				fieldErrors,
+				fieldError{
+					Field:   fe.Field(),
+					Rule:    fe.Tag(),
+					Message: validationMessage(fe),
+				},
+			)
+		}
+		return fieldErrors
+	}
+
+	var typeError *json.UnmarshalTypeError
+	if errors.As(
+		err,
+		&typeError,
+	) && typeError.Field != "" {
+		return []fieldError{
+			{
+				Field: typeError.Field,
+				Rule:  "type",
+				Message: fmt.Sprintf(
+					"must be a %s",
+					typeError.Type.String(),
+				),
+			},
+		}
+	}
+
+	// uri and query parameters that cannot be parsed are reported without their name,
+	// so the parameter is found by the value that was rejected
+	var numError *strconv.NumError
+	if errors.As(
+		err,
+		&numError,
+	) {
+		if field, ok := paramWithValue(
+			ctx,
+			numError.Num,
+		); ok {
+			return []fieldError{
+				{
+					Field:   field,
+					Rule:    "type",
+					Message: numErrorMessage(numError),
+				},
+			}
+		}
+	}
+
+	var timeError *time.ParseError
+	if errors.As(
+		err,
+		&timeError,
+	) {
+		if field, ok := paramWithValue(
+			ctx,
+			timeError.Value,
+		); ok {
+			return []fieldError{
+				{
+					Field:   field,
+					Rule:    "type",
+					Message: "must be an RFC 3339 timestamp",
+				},
+			}
+		}
+	}
+
+	return nil
+}
+
+// paramWithValue finds the uri or query parameter that was sent with value
+func paramWithValue(ctx *gin.Context, value string) (string, bool) {
+	for _, param := range ctx.Params {
+		if param.Value == value {
+			return param.Key, true
+		}
+	}
+
+	query := ctx.Request.URL.Query()
+	keys := make(
+		[]string,
+		0,
+		len(query),
+	)
+	for key := range query {
+		keys = append(
+			keys,
+			key,
+		)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		for _, v := range query[key] {
+			if v == value {
+				return key, true
+			}
+		}
+	}
+	return "", false
+}
+
+// numErrorMessage describes the number a parameter must be, after the function that failed to parse it
+func numErrorMessage(err *strconv.NumError) string {
+	if errors.Is(
+		err,
+		strconv.ErrRange,
+	) {
+		return "is out of range"
+	}
+	switch err.Func {
+	case "ParseBool":
+		return "must be true or false"
+	case "ParseFloat":
+		return "must be a number"
+	}
+	return "must be an integer"
+}
+
+// validationMessage turns a failed validation rule into a human-readable message
+func validationMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "min":
+		if fe.Kind() == reflect.String {
+			return fmt.Sprintf(
+				"must be at least %s characters long",
+				fe.Param(),
+			)
+		}
+		return fmt.Sprintf(
+			"must be at least %s",
+			fe.Param(),
+		)
+	case "max":
+		if fe.Kind() == reflect.String {
+			return fmt.Sprintf(
+				"must be at most %s characters long",
+				fe.Param(),
+			)
+		}
+		return fmt.Sprintf(
+			"must be at most %s",
+			fe.Param(),
+		)
+	case "gt":
+		return fmt.Sprintf(
+			"must be greater than %s",
+			fe.Param(),
+		)
+	case "oneof":
+		return fmt.Sprintf(
+			"must be one of: %s",
+			fe.Param(),
+		)
+	case "alphanum":
+		return "must contain only letters and digits"
+	case "email":
+		return "must be a valid email address"
+	case "uuid":
+		return "must be a valid UUID"
+	case "currency":
+		return "must be a supported currency code"
+	}
+	return fmt.Sprintf(
+		"failed the %s rule",
+		fe.Tag(),
+	)
 }
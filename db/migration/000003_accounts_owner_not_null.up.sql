@@ -0,0 +1,3 @@
+-- every account must belong to a user now that owner references users
+ALTER TABLE "accounts"
+    ALTER COLUMN "owner" SET NOT NULL;
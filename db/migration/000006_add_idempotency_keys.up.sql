@@ -0,0 +1,13 @@
+CREATE TABLE "idempotency_keys"
+(
+    "username"     varchar     NOT NULL,
+    "key"          varchar     NOT NULL,
+    "request_hash" varchar     NOT NULL,
+    "response"     jsonb       NOT NULL,
+    "expires_at"   timestamptz NOT NULL,
+    "created_at"   timestamptz NOT NULL DEFAULT (now()),
+    PRIMARY KEY ("username", "key")
+);
+
+ALTER TABLE "idempotency_keys"
+    ADD FOREIGN KEY ("username") REFERENCES "users" ("username") ON DELETE CASCADE;
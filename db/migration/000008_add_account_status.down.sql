@@ -0,0 +1,23 @@
+ALTER TABLE "entries"
+    DROP CONSTRAINT IF EXISTS "entries_account_id_fkey";
+ALTER TABLE "entries"
+    ADD CONSTRAINT "entries_account_id_fkey" FOREIGN KEY ("account_id") REFERENCES "accounts" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "transfers"
+    DROP CONSTRAINT IF EXISTS "transfers_from_account_id_fkey";
+ALTER TABLE "transfers"
+    ADD CONSTRAINT "transfers_from_account_id_fkey" FOREIGN KEY ("from_account_id") REFERENCES "accounts" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "transfers"
+    DROP CONSTRAINT IF EXISTS "transfers_to_account_id_fkey";
+ALTER TABLE "transfers"
+    ADD CONSTRAINT "transfers_to_account_id_fkey" FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id") ON DELETE CASCADE;
+
+DROP INDEX IF EXISTS "accounts_owner_currency_key";
+ALTER TABLE "accounts"
+    ADD CONSTRAINT "accounts_owner_currency_key" UNIQUE ("owner", "currency");
+
+ALTER TABLE "accounts"
+    DROP COLUMN IF EXISTS "status";
+
+DROP TYPE IF EXISTS "account_status";
@@ -0,0 +1,27 @@
+CREATE TYPE "account_status" AS ENUM ('active', 'frozen', 'closed');
+
+ALTER TABLE "accounts"
+    ADD COLUMN "status" account_status NOT NULL DEFAULT 'active';
+
+COMMENT ON COLUMN "accounts"."status" IS 'only active accounts can move money';
+
+-- a closed account no longer blocks opening a new one in the same currency
+ALTER TABLE "accounts"
+    DROP CONSTRAINT IF EXISTS "accounts_owner_currency_key";
+CREATE UNIQUE INDEX "accounts_owner_currency_key" ON "accounts" ("owner", "currency") WHERE "status" <> 'closed';
+
+-- accounts are closed instead of deleted, so the ledger must never cascade away
+ALTER TABLE "entries"
+    DROP CONSTRAINT IF EXISTS "entries_account_id_fkey";
+ALTER TABLE "entries"
+    ADD CONSTRAINT "entries_account_id_fkey" FOREIGN KEY ("account_id") REFERENCES "accounts" ("id") ON DELETE RESTRICT;
+
+ALTER TABLE "transfers"
+    DROP CONSTRAINT IF EXISTS "transfers_from_account_id_fkey";
+ALTER TABLE "transfers"
+    ADD CONSTRAINT "transfers_from_account_id_fkey" FOREIGN KEY ("from_account_id") REFERENCES "accounts" ("id") ON DELETE RESTRICT;
+
+ALTER TABLE "transfers"
+    DROP CONSTRAINT IF EXISTS "transfers_to_account_id_fkey";
+ALTER TABLE "transfers"
+    ADD CONSTRAINT "transfers_to_account_id_fkey" FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id") ON DELETE RESTRICT;
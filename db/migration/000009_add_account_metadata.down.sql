@@ -0,0 +1,8 @@
+ALTER TABLE "accounts"
+    DROP COLUMN IF EXISTS "version";
+ALTER TABLE "accounts"
+    DROP COLUMN IF EXISTS "account_type";
+ALTER TABLE "accounts"
+    DROP COLUMN IF EXISTS "nickname";
+
+DROP TYPE IF EXISTS "account_type";
@@ -0,0 +1,10 @@
+CREATE TYPE "account_type" AS ENUM ('checking', 'savings');
+
+ALTER TABLE "accounts"
+    ADD COLUMN "nickname" varchar NOT NULL DEFAULT '';
+ALTER TABLE "accounts"
+    ADD COLUMN "account_type" account_type NOT NULL DEFAULT 'checking';
+ALTER TABLE "accounts"
+    ADD COLUMN "version" bigint NOT NULL DEFAULT 1;
+
+COMMENT ON COLUMN "accounts"."version" IS 'bumped on every update, sent to clients as the ETag';
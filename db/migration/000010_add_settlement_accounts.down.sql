@@ -0,0 +1,16 @@
+DROP TABLE IF EXISTS "settlement_accounts";
+
+-- the ledger of the settlement accounts goes with them
+DELETE
+FROM "entries"
+WHERE "account_id" IN (SELECT "id" FROM "accounts" WHERE "owner" = 'system');
+DELETE
+FROM "transfers"
+WHERE "from_account_id" IN (SELECT "id" FROM "accounts" WHERE "owner" = 'system')
+   OR "to_account_id" IN (SELECT "id" FROM "accounts" WHERE "owner" = 'system');
+DELETE
+FROM "accounts"
+WHERE "owner" = 'system';
+DELETE
+FROM "users"
+WHERE "username" = 'system';
@@ -0,0 +1,22 @@
+-- the system user owns the settlement accounts and cannot log in, since its password hash is empty
+INSERT INTO "users" ("username", "hashed_password", "full_name", "email")
+VALUES ('system', '', 'Settlement', 'system@simplebank.invalid');
+
+CREATE TABLE "settlement_accounts"
+(
+    "currency"   varchar PRIMARY KEY,
+    "account_id" bigint UNIQUE NOT NULL REFERENCES "accounts" ("id") ON DELETE RESTRICT
+);
+
+COMMENT ON TABLE "settlement_accounts" IS 'cash that entered or left the bank through deposits and withdrawals, one account per currency';
+
+WITH "created" AS (
+    INSERT INTO "accounts" ("owner", "balance", "currency")
+        VALUES ('system', 0, 'USD'),
+               ('system', 0, 'EUR'),
+               ('system', 0, 'CAD')
+        RETURNING "id", "currency")
+INSERT
+INTO "settlement_accounts" ("currency", "account_id")
+SELECT "currency", "id"
+FROM "created";
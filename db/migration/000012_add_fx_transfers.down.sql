@@ -0,0 +1,22 @@
+DROP TABLE IF EXISTS "fx_accounts";
+
+-- the fx legs and every converted transfer go with the fx accounts
+DELETE
+FROM "entries"
+WHERE "account_id" IN (SELECT "id" FROM "accounts" WHERE "owner" = 'fx');
+DELETE
+FROM "transfers"
+WHERE "exchange_rate" <> 1;
+DELETE
+FROM "accounts"
+WHERE "owner" = 'fx';
+DELETE
+FROM "users"
+WHERE "username" = 'fx';
+
+ALTER TABLE "transfers"
+    DROP COLUMN IF EXISTS "spread",
+    DROP COLUMN IF EXISTS "exchange_rate",
+    DROP COLUMN IF EXISTS "to_amount";
+
+COMMENT ON COLUMN "transfers"."amount" IS 'can not be a negative';
@@ -0,0 +1,42 @@
+ALTER TABLE "transfers"
+    ADD COLUMN "to_amount" bigint;
+
+UPDATE "transfers"
+SET "to_amount" = "amount";
+
+ALTER TABLE "transfers"
+    ALTER COLUMN "to_amount" SET NOT NULL,
+    ADD COLUMN "exchange_rate" numeric NOT NULL DEFAULT 1,
+    ADD COLUMN "spread" numeric NOT NULL DEFAULT 0;
+
+COMMENT ON COLUMN "transfers"."amount" IS 'debited from the source account in its currency, can not be a negative';
+
+COMMENT ON COLUMN "transfers"."to_amount" IS 'credited to the destination account in its currency';
+
+COMMENT ON COLUMN "transfers"."exchange_rate" IS 'mid rate from the source to the destination currency, 1 within a currency';
+
+COMMENT ON COLUMN "transfers"."spread" IS 'fraction of the converted amount kept by the bank';
+
+-- the fx user owns the accounts that absorb the two legs of every currency conversion;
+-- it cannot share the system user, which already has an account in every currency
+INSERT INTO "users" ("username", "hashed_password", "full_name", "email")
+VALUES ('fx', '', 'Foreign exchange', 'fx@simplebank.invalid');
+
+CREATE TABLE "fx_accounts"
+(
+    "currency"   varchar PRIMARY KEY,
+    "account_id" bigint UNIQUE NOT NULL REFERENCES "accounts" ("id") ON DELETE RESTRICT
+);
+
+COMMENT ON TABLE "fx_accounts" IS 'position of the bank in each currency from foreign exchange transfers';
+
+WITH "created" AS (
+    INSERT INTO "accounts" ("owner", "balance", "currency")
+        VALUES ('fx', 0, 'USD'),
+               ('fx', 0, 'EUR'),
+               ('fx', 0, 'CAD')
+        RETURNING "id", "currency")
+INSERT
+INTO "fx_accounts" ("currency", "account_id")
+SELECT "currency", "id"
+FROM "created";
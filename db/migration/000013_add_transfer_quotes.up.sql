@@ -0,0 +1,20 @@
+CREATE TABLE "transfer_quotes"
+(
+    "id"              uuid PRIMARY KEY,
+    "username"        varchar     NOT NULL REFERENCES "users" ("username") ON DELETE CASCADE,
+    "from_account_id" bigint      NOT NULL REFERENCES "accounts" ("id") ON DELETE CASCADE,
+    "to_account_id"   bigint      NOT NULL REFERENCES "accounts" ("id") ON DELETE CASCADE,
+    "amount"          bigint      NOT NULL,
+    "currency"        varchar     NOT NULL,
+    "to_amount"       bigint      NOT NULL,
+    "to_currency"     varchar     NOT NULL,
+    "exchange_rate"   numeric     NOT NULL,
+    "spread"          numeric     NOT NULL,
+    "transfer_id"     bigint UNIQUE REFERENCES "transfers" ("id") ON DELETE RESTRICT,
+    "expires_at"      timestamptz NOT NULL,
+    "created_at"      timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "transfer_quotes" ("username");
+
+COMMENT ON COLUMN "transfer_quotes"."transfer_id" IS 'transfer that used the quote, a quote can only be used once';
@@ -0,0 +1,3 @@
+ALTER TABLE "transfers"
+    DROP COLUMN IF EXISTS "to_currency",
+    DROP COLUMN IF EXISTS "currency";
@@ -0,0 +1,19 @@
+ALTER TABLE "transfers"
+    ADD COLUMN "currency"    varchar,
+    ADD COLUMN "to_currency" varchar;
+
+UPDATE "transfers"
+SET "currency"    = "from_account"."currency",
+    "to_currency" = "to_account"."currency"
+FROM "accounts" AS "from_account",
+     "accounts" AS "to_account"
+WHERE "from_account"."id" = "transfers"."from_account_id"
+  AND "to_account"."id" = "transfers"."to_account_id";
+
+ALTER TABLE "transfers"
+    ALTER COLUMN "currency" SET NOT NULL,
+    ALTER COLUMN "to_currency" SET NOT NULL;
+
+COMMENT ON COLUMN "transfers"."currency" IS 'currency of amount, copied from the source account';
+
+COMMENT ON COLUMN "transfers"."to_currency" IS 'currency of to_amount, copied from the destination account';
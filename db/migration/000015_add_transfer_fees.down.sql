@@ -0,0 +1,17 @@
+DROP TABLE IF EXISTS "revenue_accounts";
+
+-- the fee legs go with the revenue accounts
+DELETE
+FROM "entries"
+WHERE "account_id" IN (SELECT "id" FROM "accounts" WHERE "owner" = 'revenue');
+DELETE
+FROM "accounts"
+WHERE "owner" = 'revenue';
+DELETE
+FROM "users"
+WHERE "username" = 'revenue';
+
+DROP TABLE IF EXISTS "fee_schedules";
+
+ALTER TABLE "transfers"
+    DROP COLUMN IF EXISTS "fee";
@@ -0,0 +1,48 @@
+ALTER TABLE "transfers"
+    ADD COLUMN "fee" bigint NOT NULL DEFAULT 0;
+
+COMMENT ON COLUMN "transfers"."fee" IS 'charged to the source account on top of amount, in its currency';
+
+-- every tier of a schedule is a row: a transfer pays the tier with the highest min_amount it reaches
+CREATE TABLE "fee_schedules"
+(
+    "id"           bigserial PRIMARY KEY,
+    "currency"     varchar      NOT NULL,
+    "account_type" account_type NOT NULL,
+    "min_amount"   bigint       NOT NULL DEFAULT 0 CHECK ("min_amount" >= 0),
+    "flat_fee"     bigint       NOT NULL DEFAULT 0 CHECK ("flat_fee" >= 0),
+    "percentage"   numeric      NOT NULL DEFAULT 0 CHECK ("percentage" >= 0 AND "percentage" < 1),
+    "min_fee"      bigint       NOT NULL DEFAULT 0 CHECK ("min_fee" >= 0),
+    "max_fee"      bigint CHECK ("max_fee" >= "min_fee"),
+    "created_at"   timestamptz  NOT NULL DEFAULT (now()),
+    UNIQUE ("currency", "account_type", "min_amount")
+);
+
+COMMENT ON COLUMN "fee_schedules"."min_amount" IS 'lowest transfer amount of the tier, in minor units of the currency';
+
+COMMENT ON COLUMN "fee_schedules"."percentage" IS 'fraction of the amount charged on top of flat_fee';
+
+COMMENT ON COLUMN "fee_schedules"."max_fee" IS 'cap of the fee, uncapped when null';
+
+-- the revenue user owns the accounts that collect the fees
+INSERT INTO "users" ("username", "hashed_password", "full_name", "email")
+VALUES ('revenue', '', 'Fee revenue', 'revenue@simplebank.invalid');
+
+CREATE TABLE "revenue_accounts"
+(
+    "currency"   varchar PRIMARY KEY,
+    "account_id" bigint UNIQUE NOT NULL REFERENCES "accounts" ("id") ON DELETE RESTRICT
+);
+
+COMMENT ON TABLE "revenue_accounts" IS 'fees collected by the bank, one account per currency';
+
+WITH "created" AS (
+    INSERT INTO "accounts" ("owner", "balance", "currency")
+        VALUES ('revenue', 0, 'USD'),
+               ('revenue', 0, 'EUR'),
+               ('revenue', 0, 'CAD')
+        RETURNING "id", "currency")
+INSERT
+INTO "revenue_accounts" ("currency", "account_id")
+SELECT "currency", "id"
+FROM "created";
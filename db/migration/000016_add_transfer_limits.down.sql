@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS "user_limits";
+
+DROP TABLE IF EXISTS "account_limits";
@@ -0,0 +1,31 @@
+CREATE TABLE "account_limits"
+(
+    "account_id"   bigint PRIMARY KEY REFERENCES "accounts" ("id") ON DELETE CASCADE,
+    "max_amount"   bigint CHECK ("max_amount" > 0),
+    "daily_amount" bigint CHECK ("daily_amount" > 0),
+    "hourly_count" bigint CHECK ("hourly_count" > 0),
+    "updated_at"   timestamptz NOT NULL DEFAULT (now())
+);
+
+COMMENT ON TABLE "account_limits" IS 'caps on the transfers out of one account, a null cap is not enforced';
+
+COMMENT ON COLUMN "account_limits"."daily_amount" IS 'total of the last 24 hours';
+
+COMMENT ON COLUMN "account_limits"."hourly_count" IS 'number of transfers in the last hour';
+
+CREATE TABLE "user_limits"
+(
+    "username"     varchar     NOT NULL REFERENCES "users" ("username") ON DELETE CASCADE,
+    "currency"     varchar     NOT NULL,
+    "max_amount"   bigint CHECK ("max_amount" > 0),
+    "daily_amount" bigint CHECK ("daily_amount" > 0),
+    "hourly_count" bigint CHECK ("hourly_count" > 0),
+    "updated_at"   timestamptz NOT NULL DEFAULT (now()),
+    PRIMARY KEY ("username", "currency")
+);
+
+COMMENT ON TABLE "user_limits" IS 'caps on the transfers out of all accounts of a user in one currency, a null cap is not enforced';
+
+COMMENT ON COLUMN "user_limits"."daily_amount" IS 'total of the last 24 hours';
+
+COMMENT ON COLUMN "user_limits"."hourly_count" IS 'number of transfers in the last hour';
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS "transfer_requests";
+
+DROP TYPE IF EXISTS "transfer_request_status";
@@ -0,0 +1,24 @@
+CREATE TYPE "transfer_request_status" AS ENUM ('pending_approval', 'approved', 'rejected', 'expired');
+
+-- transfers above the approval threshold wait here until a banker other than the requester reviews them
+CREATE TABLE "transfer_requests"
+(
+    "id"              bigserial PRIMARY KEY,
+    "requested_by"    varchar                 NOT NULL REFERENCES "users" ("username") ON DELETE CASCADE,
+    "from_account_id" bigint                  NOT NULL REFERENCES "accounts" ("id") ON DELETE CASCADE,
+    "to_account_id"   bigint                  NOT NULL REFERENCES "accounts" ("id") ON DELETE CASCADE,
+    "amount"          bigint                  NOT NULL CHECK ("amount" > 0),
+    "currency"        varchar                 NOT NULL,
+    "status"          transfer_request_status NOT NULL DEFAULT 'pending_approval',
+    "reviewed_by"     varchar REFERENCES "users" ("username") ON DELETE SET NULL,
+    "reviewed_at"     timestamptz,
+    "transfer_id"     bigint UNIQUE REFERENCES "transfers" ("id") ON DELETE RESTRICT,
+    "expires_at"      timestamptz             NOT NULL,
+    "created_at"      timestamptz             NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "transfer_requests" ("status", "created_at", "id");
+
+COMMENT ON COLUMN "transfer_requests"."transfer_id" IS 'transfer executed on approval';
+
+COMMENT ON COLUMN "transfer_requests"."expires_at" IS 'a request still pending at this time can no longer be approved';
@@ -0,0 +1,14 @@
+UPDATE "users"
+SET "role" = 'banker'
+WHERE "role" = 'admin';
+
+ALTER TABLE "users"
+    DROP CONSTRAINT IF EXISTS "users_role_check";
+ALTER TABLE "users"
+    ADD CONSTRAINT "users_role_check" CHECK ("role" IN ('depositor', 'teller', 'banker'));
+
+ALTER TABLE "transfers"
+    DROP COLUMN IF EXISTS "reversal_of",
+    DROP COLUMN IF EXISTS "status";
+
+DROP TYPE IF EXISTS "transfer_status";
@@ -0,0 +1,16 @@
+CREATE TYPE "transfer_status" AS ENUM ('pending', 'completed', 'failed', 'reversed');
+
+-- every transfer so far was booked in a single transaction, so it completed
+ALTER TABLE "transfers"
+    ADD COLUMN "status"      transfer_status NOT NULL DEFAULT 'completed',
+    ADD COLUMN "reversal_of" bigint UNIQUE REFERENCES "transfers" ("id") ON DELETE RESTRICT;
+
+COMMENT ON COLUMN "transfers"."status" IS 'pending and failed are for transfers settled outside of a single transaction';
+
+COMMENT ON COLUMN "transfers"."reversal_of" IS 'transfer this one compensates, it can only be reversed once';
+
+-- admins may force a reversal that overdraws the recipient
+ALTER TABLE "users"
+    DROP CONSTRAINT IF EXISTS "users_role_check";
+ALTER TABLE "users"
+    ADD CONSTRAINT "users_role_check" CHECK ("role" IN ('depositor', 'teller', 'banker', 'admin'));
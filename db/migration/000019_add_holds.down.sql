@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS "holds";
+
+DROP TYPE IF EXISTS "hold_status";
+
+ALTER TABLE "accounts"
+    DROP COLUMN IF EXISTS "available_balance",
+    DROP COLUMN IF EXISTS "held_amount";
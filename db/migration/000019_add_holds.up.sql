@@ -0,0 +1,32 @@
+-- a hold reserves money of an account until it is captured, voided or expires
+ALTER TABLE "accounts"
+    ADD COLUMN "held_amount"       bigint NOT NULL DEFAULT 0 CHECK ("held_amount" >= 0),
+    ADD COLUMN "available_balance" bigint NOT NULL GENERATED ALWAYS AS ("balance" - "held_amount") STORED;
+
+COMMENT ON COLUMN "accounts"."held_amount" IS 'sum of the authorized holds on the account';
+
+COMMENT ON COLUMN "accounts"."available_balance" IS 'balance that is not held, what transfers and withdrawals may spend';
+
+CREATE TYPE "hold_status" AS ENUM ('authorized', 'captured', 'voided', 'expired');
+
+CREATE TABLE "holds"
+(
+    "id"              bigserial PRIMARY KEY,
+    "account_id"      bigint      NOT NULL REFERENCES "accounts" ("id") ON DELETE RESTRICT,
+    "to_account_id"   bigint      NOT NULL REFERENCES "accounts" ("id") ON DELETE RESTRICT,
+    "amount"          bigint      NOT NULL CHECK ("amount" > 0),
+    "currency"        varchar     NOT NULL,
+    "status"          hold_status NOT NULL DEFAULT 'authorized',
+    "captured_amount" bigint      NOT NULL DEFAULT 0 CHECK ("captured_amount" >= 0 AND "captured_amount" <= "amount"),
+    "transfer_id"     bigint UNIQUE REFERENCES "transfers" ("id") ON DELETE RESTRICT,
+    "expires_at"      timestamptz NOT NULL,
+    "created_at"      timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "holds" ("account_id");
+
+CREATE INDEX ON "holds" ("status", "expires_at");
+
+COMMENT ON COLUMN "holds"."amount" IS 'authorized amount, reserved on the account while the hold is authorized';
+
+COMMENT ON COLUMN "holds"."transfer_id" IS 'transfer of the captured amount to to_account_id';
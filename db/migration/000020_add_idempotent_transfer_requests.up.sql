@@ -0,0 +1,3 @@
+-- a transfer that waits for approval stores its Idempotency-Key against the request instead of a transfer result
+ALTER TABLE "idempotency_keys"
+    ADD COLUMN "transfer_request_id" bigint REFERENCES "transfer_requests" ("id") ON DELETE CASCADE;
@@ -0,0 +1,2 @@
+ALTER TABLE "transfer_quotes"
+    DROP COLUMN IF EXISTS "fee";
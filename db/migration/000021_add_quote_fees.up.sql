@@ -0,0 +1,4 @@
+ALTER TABLE "transfer_quotes"
+    ADD COLUMN "fee" bigint NOT NULL DEFAULT 0 CHECK ("fee" >= 0);
+
+COMMENT ON COLUMN "transfer_quotes"."fee" IS 'fee locked in by the quote, charged in the source currency on top of the amount';
@@ -10,6 +10,7 @@ import (
 
 	db "github.com/PFefe/simplebank/db/sqlc"
 	gomock "github.com/golang/mock/gomock"
+	uuid "github.com/google/uuid"
 )
 
 // MockStore is a mock of Store interface.
@@ -18,11 +19,6 @@ type MockStore struct {
 	recorder *MockStoreMockRecorder
 }
 
-func (m *MockStore) ListAccounts(ctx context.Context, arg db.ListAccountsParams) ([]db.Account, error) {
-	//TODO implement me
-	panic("implement me")
-}
-
 // MockStoreMockRecorder is the mock recorder for MockStore.
 type MockStoreMockRecorder struct {
 	mock *MockStore
@@ -55,6 +51,111 @@ func (mr *MockStoreMockRecorder) AddAccountBalance(arg0, arg1 interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddAccountBalance", reflect.TypeOf((*MockStore)(nil).AddAccountBalance), arg0, arg1)
 }
 
+// AddAccountHeldAmount mocks base method.
+func (m *MockStore) AddAccountHeldAmount(arg0 context.Context, arg1 db.AddAccountHeldAmountParams) (db.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddAccountHeldAmount", arg0, arg1)
+	ret0, _ := ret[0].(db.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddAccountHeldAmount indicates an expected call of AddAccountHeldAmount.
+func (mr *MockStoreMockRecorder) AddAccountHeldAmount(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddAccountHeldAmount", reflect.TypeOf((*MockStore)(nil).AddAccountHeldAmount), arg0, arg1)
+}
+
+// ApproveTransferRequest mocks base method.
+func (m *MockStore) ApproveTransferRequest(arg0 context.Context, arg1 db.ApproveTransferRequestParams) (db.TransferRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ApproveTransferRequest", arg0, arg1)
+	ret0, _ := ret[0].(db.TransferRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ApproveTransferRequest indicates an expected call of ApproveTransferRequest.
+func (mr *MockStoreMockRecorder) ApproveTransferRequest(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ApproveTransferRequest", reflect.TypeOf((*MockStore)(nil).ApproveTransferRequest), arg0, arg1)
+}
+
+// AuthorizeHoldTx mocks base method.
+func (m *MockStore) AuthorizeHoldTx(arg0 context.Context, arg1 db.AuthorizeHoldTxParams) (db.HoldTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuthorizeHoldTx", arg0, arg1)
+	ret0, _ := ret[0].(db.HoldTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AuthorizeHoldTx indicates an expected call of AuthorizeHoldTx.
+func (mr *MockStoreMockRecorder) AuthorizeHoldTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthorizeHoldTx", reflect.TypeOf((*MockStore)(nil).AuthorizeHoldTx), arg0, arg1)
+}
+
+// BlockSession mocks base method.
+func (m *MockStore) BlockSession(arg0 context.Context, arg1 db.BlockSessionParams) (db.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BlockSession", arg0, arg1)
+	ret0, _ := ret[0].(db.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BlockSession indicates an expected call of BlockSession.
+func (mr *MockStoreMockRecorder) BlockSession(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BlockSession", reflect.TypeOf((*MockStore)(nil).BlockSession), arg0, arg1)
+}
+
+// CaptureHold mocks base method.
+func (m *MockStore) CaptureHold(arg0 context.Context, arg1 db.CaptureHoldParams) (db.Hold, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CaptureHold", arg0, arg1)
+	ret0, _ := ret[0].(db.Hold)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CaptureHold indicates an expected call of CaptureHold.
+func (mr *MockStoreMockRecorder) CaptureHold(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CaptureHold", reflect.TypeOf((*MockStore)(nil).CaptureHold), arg0, arg1)
+}
+
+// CaptureHoldTx mocks base method.
+func (m *MockStore) CaptureHoldTx(arg0 context.Context, arg1 db.CaptureHoldTxParams) (db.CaptureHoldTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CaptureHoldTx", arg0, arg1)
+	ret0, _ := ret[0].(db.CaptureHoldTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CaptureHoldTx indicates an expected call of CaptureHoldTx.
+func (mr *MockStoreMockRecorder) CaptureHoldTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CaptureHoldTx", reflect.TypeOf((*MockStore)(nil).CaptureHoldTx), arg0, arg1)
+}
+
+// ChangeAccountStatusTx mocks base method.
+func (m *MockStore) ChangeAccountStatusTx(arg0 context.Context, arg1 db.ChangeAccountStatusTxParams) (db.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangeAccountStatusTx", arg0, arg1)
+	ret0, _ := ret[0].(db.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChangeAccountStatusTx indicates an expected call of ChangeAccountStatusTx.
+func (mr *MockStoreMockRecorder) ChangeAccountStatusTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangeAccountStatusTx", reflect.TypeOf((*MockStore)(nil).ChangeAccountStatusTx), arg0, arg1)
+}
+
 // CreateAccount mocks base method.
 func (m *MockStore) CreateAccount(arg0 context.Context, arg1 db.CreateAccountParams) (db.Account, error) {
 	m.ctrl.T.Helper()
@@ -85,6 +186,66 @@ func (mr *MockStoreMockRecorder) CreateEntry(arg0, arg1 interface{}) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateEntry", reflect.TypeOf((*MockStore)(nil).CreateEntry), arg0, arg1)
 }
 
+// CreateHold mocks base method.
+func (m *MockStore) CreateHold(arg0 context.Context, arg1 db.CreateHoldParams) (db.Hold, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateHold", arg0, arg1)
+	ret0, _ := ret[0].(db.Hold)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateHold indicates an expected call of CreateHold.
+func (mr *MockStoreMockRecorder) CreateHold(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateHold", reflect.TypeOf((*MockStore)(nil).CreateHold), arg0, arg1)
+}
+
+// CreateIdempotencyKey mocks base method.
+func (m *MockStore) CreateIdempotencyKey(arg0 context.Context, arg1 db.CreateIdempotencyKeyParams) (db.IdempotencyKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateIdempotencyKey", arg0, arg1)
+	ret0, _ := ret[0].(db.IdempotencyKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateIdempotencyKey indicates an expected call of CreateIdempotencyKey.
+func (mr *MockStoreMockRecorder) CreateIdempotencyKey(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateIdempotencyKey", reflect.TypeOf((*MockStore)(nil).CreateIdempotencyKey), arg0, arg1)
+}
+
+// CreateReversalTransfer mocks base method.
+func (m *MockStore) CreateReversalTransfer(arg0 context.Context, arg1 int64) (db.Transfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateReversalTransfer", arg0, arg1)
+	ret0, _ := ret[0].(db.Transfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateReversalTransfer indicates an expected call of CreateReversalTransfer.
+func (mr *MockStoreMockRecorder) CreateReversalTransfer(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateReversalTransfer", reflect.TypeOf((*MockStore)(nil).CreateReversalTransfer), arg0, arg1)
+}
+
+// CreateSession mocks base method.
+func (m *MockStore) CreateSession(arg0 context.Context, arg1 db.CreateSessionParams) (db.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSession", arg0, arg1)
+	ret0, _ := ret[0].(db.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateSession indicates an expected call of CreateSession.
+func (mr *MockStoreMockRecorder) CreateSession(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSession", reflect.TypeOf((*MockStore)(nil).CreateSession), arg0, arg1)
+}
+
 // CreateTransfer mocks base method.
 func (m *MockStore) CreateTransfer(arg0 context.Context, arg1 db.CreateTransferParams) (db.Transfer, error) {
 	m.ctrl.T.Helper()
@@ -100,18 +261,153 @@ func (mr *MockStoreMockRecorder) CreateTransfer(arg0, arg1 interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTransfer", reflect.TypeOf((*MockStore)(nil).CreateTransfer), arg0, arg1)
 }
 
-// DeleteAccount mocks base method.
-func (m *MockStore) DeleteAccount(arg0 context.Context, arg1 int64) error {
+// CreateTransferQuote mocks base method.
+func (m *MockStore) CreateTransferQuote(arg0 context.Context, arg1 db.CreateTransferQuoteParams) (db.TransferQuote, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateTransferQuote", arg0, arg1)
+	ret0, _ := ret[0].(db.TransferQuote)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateTransferQuote indicates an expected call of CreateTransferQuote.
+func (mr *MockStoreMockRecorder) CreateTransferQuote(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTransferQuote", reflect.TypeOf((*MockStore)(nil).CreateTransferQuote), arg0, arg1)
+}
+
+// CreateTransferRequest mocks base method.
+func (m *MockStore) CreateTransferRequest(arg0 context.Context, arg1 db.CreateTransferRequestParams) (db.TransferRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateTransferRequest", arg0, arg1)
+	ret0, _ := ret[0].(db.TransferRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateTransferRequest indicates an expected call of CreateTransferRequest.
+func (mr *MockStoreMockRecorder) CreateTransferRequest(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTransferRequest", reflect.TypeOf((*MockStore)(nil).CreateTransferRequest), arg0, arg1)
+}
+
+// CreateTransferRequestTx mocks base method.
+func (m *MockStore) CreateTransferRequestTx(arg0 context.Context, arg1 db.CreateTransferRequestTxParams) (db.TransferRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateTransferRequestTx", arg0, arg1)
+	ret0, _ := ret[0].(db.TransferRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateTransferRequestTx indicates an expected call of CreateTransferRequestTx.
+func (mr *MockStoreMockRecorder) CreateTransferRequestTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTransferRequestTx", reflect.TypeOf((*MockStore)(nil).CreateTransferRequestTx), arg0, arg1)
+}
+
+// CreateUser mocks base method.
+func (m *MockStore) CreateUser(arg0 context.Context, arg1 db.CreateUserParams) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUser", arg0, arg1)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateUser indicates an expected call of CreateUser.
+func (mr *MockStoreMockRecorder) CreateUser(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockStore)(nil).CreateUser), arg0, arg1)
+}
+
+// DeleteFeeSchedule mocks base method.
+func (m *MockStore) DeleteFeeSchedule(arg0 context.Context, arg1 int64) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteAccount", arg0, arg1)
+	ret := m.ctrl.Call(m, "DeleteFeeSchedule", arg0, arg1)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// DeleteAccount indicates an expected call of DeleteAccount.
-func (mr *MockStoreMockRecorder) DeleteAccount(arg0, arg1 interface{}) *gomock.Call {
+// DeleteFeeSchedule indicates an expected call of DeleteFeeSchedule.
+func (mr *MockStoreMockRecorder) DeleteFeeSchedule(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteFeeSchedule", reflect.TypeOf((*MockStore)(nil).DeleteFeeSchedule), arg0, arg1)
+}
+
+// DepositTx mocks base method.
+func (m *MockStore) DepositTx(arg0 context.Context, arg1 db.CashTxParams) (db.CashTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DepositTx", arg0, arg1)
+	ret0, _ := ret[0].(db.CashTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DepositTx indicates an expected call of DepositTx.
+func (mr *MockStoreMockRecorder) DepositTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DepositTx", reflect.TypeOf((*MockStore)(nil).DepositTx), arg0, arg1)
+}
+
+// ExpireHolds mocks base method.
+func (m *MockStore) ExpireHolds(arg0 context.Context) ([]db.ExpireHoldsRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExpireHolds", arg0)
+	ret0, _ := ret[0].([]db.ExpireHoldsRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExpireHolds indicates an expected call of ExpireHolds.
+func (mr *MockStoreMockRecorder) ExpireHolds(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExpireHolds", reflect.TypeOf((*MockStore)(nil).ExpireHolds), arg0)
+}
+
+// ExpireHoldsTx mocks base method.
+func (m *MockStore) ExpireHoldsTx(arg0 context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExpireHoldsTx", arg0)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExpireHoldsTx indicates an expected call of ExpireHoldsTx.
+func (mr *MockStoreMockRecorder) ExpireHoldsTx(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExpireHoldsTx", reflect.TypeOf((*MockStore)(nil).ExpireHoldsTx), arg0)
+}
+
+// ExpireTransferRequests mocks base method.
+func (m *MockStore) ExpireTransferRequests(arg0 context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExpireTransferRequests", arg0)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExpireTransferRequests indicates an expected call of ExpireTransferRequests.
+func (mr *MockStoreMockRecorder) ExpireTransferRequests(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExpireTransferRequests", reflect.TypeOf((*MockStore)(nil).ExpireTransferRequests), arg0)
+}
+
+// FXTransferTx mocks base method.
+func (m *MockStore) FXTransferTx(arg0 context.Context, arg1 db.FXTransferTxParams) (db.TransferTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FXTransferTx", arg0, arg1)
+	ret0, _ := ret[0].(db.TransferTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FXTransferTx indicates an expected call of FXTransferTx.
+func (mr *MockStoreMockRecorder) FXTransferTx(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAccount", reflect.TypeOf((*MockStore)(nil).DeleteAccount), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FXTransferTx", reflect.TypeOf((*MockStore)(nil).FXTransferTx), arg0, arg1)
 }
 
 // GetAccount mocks base method.
@@ -129,6 +425,51 @@ func (mr *MockStoreMockRecorder) GetAccount(arg0, arg1 interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccount", reflect.TypeOf((*MockStore)(nil).GetAccount), arg0, arg1)
 }
 
+// GetAccountLimits mocks base method.
+func (m *MockStore) GetAccountLimits(arg0 context.Context, arg1 int64) (db.AccountLimit, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccountLimits", arg0, arg1)
+	ret0, _ := ret[0].(db.AccountLimit)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccountLimits indicates an expected call of GetAccountLimits.
+func (mr *MockStoreMockRecorder) GetAccountLimits(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountLimits", reflect.TypeOf((*MockStore)(nil).GetAccountLimits), arg0, arg1)
+}
+
+// GetAccountTransferVelocity mocks base method.
+func (m *MockStore) GetAccountTransferVelocity(arg0 context.Context, arg1 int64) (db.GetAccountTransferVelocityRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccountTransferVelocity", arg0, arg1)
+	ret0, _ := ret[0].(db.GetAccountTransferVelocityRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccountTransferVelocity indicates an expected call of GetAccountTransferVelocity.
+func (mr *MockStoreMockRecorder) GetAccountTransferVelocity(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountTransferVelocity", reflect.TypeOf((*MockStore)(nil).GetAccountTransferVelocity), arg0, arg1)
+}
+
+// GetAccountUnlocked mocks base method.
+func (m *MockStore) GetAccountUnlocked(arg0 context.Context, arg1 int64) (db.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccountUnlocked", arg0, arg1)
+	ret0, _ := ret[0].(db.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccountUnlocked indicates an expected call of GetAccountUnlocked.
+func (mr *MockStoreMockRecorder) GetAccountUnlocked(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountUnlocked", reflect.TypeOf((*MockStore)(nil).GetAccountUnlocked), arg0, arg1)
+}
+
 // GetEntry mocks base method.
 func (m *MockStore) GetEntry(arg0 context.Context, arg1 int64) (db.Entry, error) {
 	m.ctrl.T.Helper()
@@ -144,92 +485,752 @@ func (mr *MockStoreMockRecorder) GetEntry(arg0, arg1 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEntry", reflect.TypeOf((*MockStore)(nil).GetEntry), arg0, arg1)
 }
 
-// GetTransfer mocks base method.
-func (m *MockStore) GetTransfer(arg0 context.Context, arg1 int64) (db.Transfer, error) {
+// GetEntryWithBalance mocks base method.
+func (m *MockStore) GetEntryWithBalance(arg0 context.Context, arg1 int64) (db.GetEntryWithBalanceRow, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetTransfer", arg0, arg1)
-	ret0, _ := ret[0].(db.Transfer)
+	ret := m.ctrl.Call(m, "GetEntryWithBalance", arg0, arg1)
+	ret0, _ := ret[0].(db.GetEntryWithBalanceRow)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetTransfer indicates an expected call of GetTransfer.
-func (mr *MockStoreMockRecorder) GetTransfer(arg0, arg1 interface{}) *gomock.Call {
+// GetEntryWithBalance indicates an expected call of GetEntryWithBalance.
+func (mr *MockStoreMockRecorder) GetEntryWithBalance(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransfer", reflect.TypeOf((*MockStore)(nil).GetTransfer), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEntryWithBalance", reflect.TypeOf((*MockStore)(nil).GetEntryWithBalance), arg0, arg1)
 }
 
-// ListAccount mocks base method.
-func (m *MockStore) ListAccount(arg0 context.Context, arg1 db.ListAccountsParams) ([]db.Account, error) {
+// GetFXAccount mocks base method.
+func (m *MockStore) GetFXAccount(arg0 context.Context, arg1 string) (db.Account, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListAccount", arg0, arg1)
-	ret0, _ := ret[0].([]db.Account)
+	ret := m.ctrl.Call(m, "GetFXAccount", arg0, arg1)
+	ret0, _ := ret[0].(db.Account)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// ListAccount indicates an expected call of ListAccount.
-func (mr *MockStoreMockRecorder) ListAccount(arg0, arg1 interface{}) *gomock.Call {
+// GetFXAccount indicates an expected call of GetFXAccount.
+func (mr *MockStoreMockRecorder) GetFXAccount(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAccount", reflect.TypeOf((*MockStore)(nil).ListAccount), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFXAccount", reflect.TypeOf((*MockStore)(nil).GetFXAccount), arg0, arg1)
 }
 
-// ListEntry mocks base method.
-func (m *MockStore) ListEntry(arg0 context.Context, arg1 db.ListEntryParams) ([]db.Entry, error) {
+// GetFeeSchedule mocks base method.
+func (m *MockStore) GetFeeSchedule(arg0 context.Context, arg1 db.GetFeeScheduleParams) (db.FeeSchedule, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListEntry", arg0, arg1)
-	ret0, _ := ret[0].([]db.Entry)
+	ret := m.ctrl.Call(m, "GetFeeSchedule", arg0, arg1)
+	ret0, _ := ret[0].(db.FeeSchedule)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// ListEntry indicates an expected call of ListEntry.
-func (mr *MockStoreMockRecorder) ListEntry(arg0, arg1 interface{}) *gomock.Call {
+// GetFeeSchedule indicates an expected call of GetFeeSchedule.
+func (mr *MockStoreMockRecorder) GetFeeSchedule(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListEntry", reflect.TypeOf((*MockStore)(nil).ListEntry), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFeeSchedule", reflect.TypeOf((*MockStore)(nil).GetFeeSchedule), arg0, arg1)
 }
 
-// ListTransfers mocks base method.
-func (m *MockStore) ListTransfers(arg0 context.Context, arg1 db.ListTransfersParams) ([]db.Transfer, error) {
+// GetHold mocks base method.
+func (m *MockStore) GetHold(arg0 context.Context, arg1 int64) (db.Hold, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListTransfers", arg0, arg1)
-	ret0, _ := ret[0].([]db.Transfer)
+	ret := m.ctrl.Call(m, "GetHold", arg0, arg1)
+	ret0, _ := ret[0].(db.Hold)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// ListTransfers indicates an expected call of ListTransfers.
-func (mr *MockStoreMockRecorder) ListTransfers(arg0, arg1 interface{}) *gomock.Call {
+// GetHold indicates an expected call of GetHold.
+func (mr *MockStoreMockRecorder) GetHold(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTransfers", reflect.TypeOf((*MockStore)(nil).ListTransfers), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHold", reflect.TypeOf((*MockStore)(nil).GetHold), arg0, arg1)
 }
 
-// TransferTx mocks base method.
-func (m *MockStore) TransferTx(arg0 context.Context, arg1 db.TransferTxParams) (db.TransferTxResult, error) {
+// GetHoldForUpdate mocks base method.
+func (m *MockStore) GetHoldForUpdate(arg0 context.Context, arg1 int64) (db.Hold, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "TransferTx", arg0, arg1)
-	ret0, _ := ret[0].(db.TransferTxResult)
+	ret := m.ctrl.Call(m, "GetHoldForUpdate", arg0, arg1)
+	ret0, _ := ret[0].(db.Hold)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// TransferTx indicates an expected call of TransferTx.
-func (mr *MockStoreMockRecorder) TransferTx(arg0, arg1 interface{}) *gomock.Call {
+// GetHoldForUpdate indicates an expected call of GetHoldForUpdate.
+func (mr *MockStoreMockRecorder) GetHoldForUpdate(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TransferTx", reflect.TypeOf((*MockStore)(nil).TransferTx), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHoldForUpdate", reflect.TypeOf((*MockStore)(nil).GetHoldForUpdate), arg0, arg1)
 }
 
-// UpdateAccount mocks base method.
-func (m *MockStore) UpdateAccount(arg0 context.Context, arg1 db.UpdateAccountParams) (db.Account, error) {
+// GetIdempotencyKey mocks base method.
+func (m *MockStore) GetIdempotencyKey(arg0 context.Context, arg1 db.GetIdempotencyKeyParams) (db.IdempotencyKey, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateAccount", arg0, arg1)
+	ret := m.ctrl.Call(m, "GetIdempotencyKey", arg0, arg1)
+	ret0, _ := ret[0].(db.IdempotencyKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetIdempotencyKey indicates an expected call of GetIdempotencyKey.
+func (mr *MockStoreMockRecorder) GetIdempotencyKey(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetIdempotencyKey", reflect.TypeOf((*MockStore)(nil).GetIdempotencyKey), arg0, arg1)
+}
+
+// GetRevenueAccount mocks base method.
+func (m *MockStore) GetRevenueAccount(arg0 context.Context, arg1 string) (db.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRevenueAccount", arg0, arg1)
 	ret0, _ := ret[0].(db.Account)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// UpdateAccount indicates an expected call of UpdateAccount.
-func (mr *MockStoreMockRecorder) UpdateAccount(arg0, arg1 interface{}) *gomock.Call {
+// GetRevenueAccount indicates an expected call of GetRevenueAccount.
+func (mr *MockStoreMockRecorder) GetRevenueAccount(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAccount", reflect.TypeOf((*MockStore)(nil).UpdateAccount), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRevenueAccount", reflect.TypeOf((*MockStore)(nil).GetRevenueAccount), arg0, arg1)
+}
+
+// GetSession mocks base method.
+func (m *MockStore) GetSession(arg0 context.Context, arg1 uuid.UUID) (db.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSession", arg0, arg1)
+	ret0, _ := ret[0].(db.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSession indicates an expected call of GetSession.
+func (mr *MockStoreMockRecorder) GetSession(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSession", reflect.TypeOf((*MockStore)(nil).GetSession), arg0, arg1)
+}
+
+// GetSettlementAccount mocks base method.
+func (m *MockStore) GetSettlementAccount(arg0 context.Context, arg1 string) (db.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSettlementAccount", arg0, arg1)
+	ret0, _ := ret[0].(db.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSettlementAccount indicates an expected call of GetSettlementAccount.
+func (mr *MockStoreMockRecorder) GetSettlementAccount(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSettlementAccount", reflect.TypeOf((*MockStore)(nil).GetSettlementAccount), arg0, arg1)
+}
+
+// GetTransfer mocks base method.
+func (m *MockStore) GetTransfer(arg0 context.Context, arg1 int64) (db.Transfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTransfer", arg0, arg1)
+	ret0, _ := ret[0].(db.Transfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTransfer indicates an expected call of GetTransfer.
+func (mr *MockStoreMockRecorder) GetTransfer(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransfer", reflect.TypeOf((*MockStore)(nil).GetTransfer), arg0, arg1)
+}
+
+// GetTransferForUpdate mocks base method.
+func (m *MockStore) GetTransferForUpdate(arg0 context.Context, arg1 int64) (db.Transfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTransferForUpdate", arg0, arg1)
+	ret0, _ := ret[0].(db.Transfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTransferForUpdate indicates an expected call of GetTransferForUpdate.
+func (mr *MockStoreMockRecorder) GetTransferForUpdate(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransferForUpdate", reflect.TypeOf((*MockStore)(nil).GetTransferForUpdate), arg0, arg1)
+}
+
+// GetTransferQuote mocks base method.
+func (m *MockStore) GetTransferQuote(arg0 context.Context, arg1 uuid.UUID) (db.TransferQuote, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTransferQuote", arg0, arg1)
+	ret0, _ := ret[0].(db.TransferQuote)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTransferQuote indicates an expected call of GetTransferQuote.
+func (mr *MockStoreMockRecorder) GetTransferQuote(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransferQuote", reflect.TypeOf((*MockStore)(nil).GetTransferQuote), arg0, arg1)
+}
+
+// GetTransferRequest mocks base method.
+func (m *MockStore) GetTransferRequest(arg0 context.Context, arg1 int64) (db.TransferRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTransferRequest", arg0, arg1)
+	ret0, _ := ret[0].(db.TransferRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTransferRequest indicates an expected call of GetTransferRequest.
+func (mr *MockStoreMockRecorder) GetTransferRequest(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransferRequest", reflect.TypeOf((*MockStore)(nil).GetTransferRequest), arg0, arg1)
+}
+
+// GetUser mocks base method.
+func (m *MockStore) GetUser(arg0 context.Context, arg1 string) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUser", arg0, arg1)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUser indicates an expected call of GetUser.
+func (mr *MockStoreMockRecorder) GetUser(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockStore)(nil).GetUser), arg0, arg1)
+}
+
+// GetUserLimitsForUpdate mocks base method.
+func (m *MockStore) GetUserLimitsForUpdate(arg0 context.Context, arg1 db.GetUserLimitsForUpdateParams) (db.UserLimit, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserLimitsForUpdate", arg0, arg1)
+	ret0, _ := ret[0].(db.UserLimit)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserLimitsForUpdate indicates an expected call of GetUserLimitsForUpdate.
+func (mr *MockStoreMockRecorder) GetUserLimitsForUpdate(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserLimitsForUpdate", reflect.TypeOf((*MockStore)(nil).GetUserLimitsForUpdate), arg0, arg1)
+}
+
+// GetUserTransferVelocity mocks base method.
+func (m *MockStore) GetUserTransferVelocity(arg0 context.Context, arg1 db.GetUserTransferVelocityParams) (db.GetUserTransferVelocityRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserTransferVelocity", arg0, arg1)
+	ret0, _ := ret[0].(db.GetUserTransferVelocityRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserTransferVelocity indicates an expected call of GetUserTransferVelocity.
+func (mr *MockStoreMockRecorder) GetUserTransferVelocity(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserTransferVelocity", reflect.TypeOf((*MockStore)(nil).GetUserTransferVelocity), arg0, arg1)
+}
+
+// IsSystemAccount mocks base method.
+func (m *MockStore) IsSystemAccount(arg0 context.Context, arg1 int64) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsSystemAccount", arg0, arg1)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsSystemAccount indicates an expected call of IsSystemAccount.
+func (mr *MockStoreMockRecorder) IsSystemAccount(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsSystemAccount", reflect.TypeOf((*MockStore)(nil).IsSystemAccount), arg0, arg1)
+}
+
+// ListAccountEntries mocks base method.
+func (m *MockStore) ListAccountEntries(arg0 context.Context, arg1 db.ListAccountEntriesParams) ([]db.ListAccountEntriesRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAccountEntries", arg0, arg1)
+	ret0, _ := ret[0].([]db.ListAccountEntriesRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAccountEntries indicates an expected call of ListAccountEntries.
+func (mr *MockStoreMockRecorder) ListAccountEntries(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAccountEntries", reflect.TypeOf((*MockStore)(nil).ListAccountEntries), arg0, arg1)
+}
+
+// ListAccountEntriesAfter mocks base method.
+func (m *MockStore) ListAccountEntriesAfter(arg0 context.Context, arg1 db.ListAccountEntriesAfterParams) ([]db.ListAccountEntriesAfterRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAccountEntriesAfter", arg0, arg1)
+	ret0, _ := ret[0].([]db.ListAccountEntriesAfterRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAccountEntriesAfter indicates an expected call of ListAccountEntriesAfter.
+func (mr *MockStoreMockRecorder) ListAccountEntriesAfter(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAccountEntriesAfter", reflect.TypeOf((*MockStore)(nil).ListAccountEntriesAfter), arg0, arg1)
+}
+
+// ListAccountEntriesBefore mocks base method.
+func (m *MockStore) ListAccountEntriesBefore(arg0 context.Context, arg1 db.ListAccountEntriesBeforeParams) ([]db.ListAccountEntriesBeforeRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAccountEntriesBefore", arg0, arg1)
+	ret0, _ := ret[0].([]db.ListAccountEntriesBeforeRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAccountEntriesBefore indicates an expected call of ListAccountEntriesBefore.
+func (mr *MockStoreMockRecorder) ListAccountEntriesBefore(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAccountEntriesBefore", reflect.TypeOf((*MockStore)(nil).ListAccountEntriesBefore), arg0, arg1)
+}
+
+// ListAccounts mocks base method.
+func (m *MockStore) ListAccounts(arg0 context.Context, arg1 db.ListAccountsParams) ([]db.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAccounts", arg0, arg1)
+	ret0, _ := ret[0].([]db.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAccounts indicates an expected call of ListAccounts.
+func (mr *MockStoreMockRecorder) ListAccounts(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAccounts", reflect.TypeOf((*MockStore)(nil).ListAccounts), arg0, arg1)
+}
+
+// ListAccountsAfter mocks base method.
+func (m *MockStore) ListAccountsAfter(arg0 context.Context, arg1 db.ListAccountsAfterParams) ([]db.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAccountsAfter", arg0, arg1)
+	ret0, _ := ret[0].([]db.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAccountsAfter indicates an expected call of ListAccountsAfter.
+func (mr *MockStoreMockRecorder) ListAccountsAfter(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAccountsAfter", reflect.TypeOf((*MockStore)(nil).ListAccountsAfter), arg0, arg1)
+}
+
+// ListAccountsBefore mocks base method.
+func (m *MockStore) ListAccountsBefore(arg0 context.Context, arg1 db.ListAccountsBeforeParams) ([]db.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAccountsBefore", arg0, arg1)
+	ret0, _ := ret[0].([]db.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAccountsBefore indicates an expected call of ListAccountsBefore.
+func (mr *MockStoreMockRecorder) ListAccountsBefore(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAccountsBefore", reflect.TypeOf((*MockStore)(nil).ListAccountsBefore), arg0, arg1)
+}
+
+// ListActiveSessions mocks base method.
+func (m *MockStore) ListActiveSessions(arg0 context.Context, arg1 string) ([]db.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListActiveSessions", arg0, arg1)
+	ret0, _ := ret[0].([]db.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListActiveSessions indicates an expected call of ListActiveSessions.
+func (mr *MockStoreMockRecorder) ListActiveSessions(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListActiveSessions", reflect.TypeOf((*MockStore)(nil).ListActiveSessions), arg0, arg1)
+}
+
+// ListEntry mocks base method.
+func (m *MockStore) ListEntry(arg0 context.Context, arg1 db.ListEntryParams) ([]db.Entry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListEntry", arg0, arg1)
+	ret0, _ := ret[0].([]db.Entry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListEntry indicates an expected call of ListEntry.
+func (mr *MockStoreMockRecorder) ListEntry(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListEntry", reflect.TypeOf((*MockStore)(nil).ListEntry), arg0, arg1)
+}
+
+// ListFeeSchedules mocks base method.
+func (m *MockStore) ListFeeSchedules(arg0 context.Context) ([]db.FeeSchedule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListFeeSchedules", arg0)
+	ret0, _ := ret[0].([]db.FeeSchedule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListFeeSchedules indicates an expected call of ListFeeSchedules.
+func (mr *MockStoreMockRecorder) ListFeeSchedules(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListFeeSchedules", reflect.TypeOf((*MockStore)(nil).ListFeeSchedules), arg0)
+}
+
+// ListTransferRequests mocks base method.
+func (m *MockStore) ListTransferRequests(arg0 context.Context, arg1 db.ListTransferRequestsParams) ([]db.TransferRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTransferRequests", arg0, arg1)
+	ret0, _ := ret[0].([]db.TransferRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTransferRequests indicates an expected call of ListTransferRequests.
+func (mr *MockStoreMockRecorder) ListTransferRequests(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTransferRequests", reflect.TypeOf((*MockStore)(nil).ListTransferRequests), arg0, arg1)
+}
+
+// ListTransferRequestsAfter mocks base method.
+func (m *MockStore) ListTransferRequestsAfter(arg0 context.Context, arg1 db.ListTransferRequestsAfterParams) ([]db.TransferRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTransferRequestsAfter", arg0, arg1)
+	ret0, _ := ret[0].([]db.TransferRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTransferRequestsAfter indicates an expected call of ListTransferRequestsAfter.
+func (mr *MockStoreMockRecorder) ListTransferRequestsAfter(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTransferRequestsAfter", reflect.TypeOf((*MockStore)(nil).ListTransferRequestsAfter), arg0, arg1)
+}
+
+// ListTransferRequestsBefore mocks base method.
+func (m *MockStore) ListTransferRequestsBefore(arg0 context.Context, arg1 db.ListTransferRequestsBeforeParams) ([]db.TransferRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTransferRequestsBefore", arg0, arg1)
+	ret0, _ := ret[0].([]db.TransferRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTransferRequestsBefore indicates an expected call of ListTransferRequestsBefore.
+func (mr *MockStoreMockRecorder) ListTransferRequestsBefore(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTransferRequestsBefore", reflect.TypeOf((*MockStore)(nil).ListTransferRequestsBefore), arg0, arg1)
+}
+
+// ListTransfers mocks base method.
+func (m *MockStore) ListTransfers(arg0 context.Context, arg1 db.ListTransfersParams) ([]db.Transfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTransfers", arg0, arg1)
+	ret0, _ := ret[0].([]db.Transfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTransfers indicates an expected call of ListTransfers.
+func (mr *MockStoreMockRecorder) ListTransfers(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTransfers", reflect.TypeOf((*MockStore)(nil).ListTransfers), arg0, arg1)
+}
+
+// ListTransfersAfter mocks base method.
+func (m *MockStore) ListTransfersAfter(arg0 context.Context, arg1 db.ListTransfersAfterParams) ([]db.Transfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTransfersAfter", arg0, arg1)
+	ret0, _ := ret[0].([]db.Transfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTransfersAfter indicates an expected call of ListTransfersAfter.
+func (mr *MockStoreMockRecorder) ListTransfersAfter(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTransfersAfter", reflect.TypeOf((*MockStore)(nil).ListTransfersAfter), arg0, arg1)
+}
+
+// ListTransfersBefore mocks base method.
+func (m *MockStore) ListTransfersBefore(arg0 context.Context, arg1 db.ListTransfersBeforeParams) ([]db.Transfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTransfersBefore", arg0, arg1)
+	ret0, _ := ret[0].([]db.Transfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTransfersBefore indicates an expected call of ListTransfersBefore.
+func (mr *MockStoreMockRecorder) ListTransfersBefore(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTransfersBefore", reflect.TypeOf((*MockStore)(nil).ListTransfersBefore), arg0, arg1)
+}
+
+// ListUserLimits mocks base method.
+func (m *MockStore) ListUserLimits(arg0 context.Context, arg1 string) ([]db.UserLimit, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUserLimits", arg0, arg1)
+	ret0, _ := ret[0].([]db.UserLimit)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUserLimits indicates an expected call of ListUserLimits.
+func (mr *MockStoreMockRecorder) ListUserLimits(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUserLimits", reflect.TypeOf((*MockStore)(nil).ListUserLimits), arg0, arg1)
+}
+
+// ProvisionFXAccount mocks base method.
+func (m *MockStore) ProvisionFXAccount(arg0 context.Context, arg1 string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ProvisionFXAccount", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ProvisionFXAccount indicates an expected call of ProvisionFXAccount.
+func (mr *MockStoreMockRecorder) ProvisionFXAccount(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProvisionFXAccount", reflect.TypeOf((*MockStore)(nil).ProvisionFXAccount), arg0, arg1)
+}
+
+// ProvisionRevenueAccount mocks base method.
+func (m *MockStore) ProvisionRevenueAccount(arg0 context.Context, arg1 string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ProvisionRevenueAccount", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ProvisionRevenueAccount indicates an expected call of ProvisionRevenueAccount.
+func (mr *MockStoreMockRecorder) ProvisionRevenueAccount(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProvisionRevenueAccount", reflect.TypeOf((*MockStore)(nil).ProvisionRevenueAccount), arg0, arg1)
+}
+
+// ProvisionSettlementAccount mocks base method.
+func (m *MockStore) ProvisionSettlementAccount(arg0 context.Context, arg1 string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ProvisionSettlementAccount", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ProvisionSettlementAccount indicates an expected call of ProvisionSettlementAccount.
+func (mr *MockStoreMockRecorder) ProvisionSettlementAccount(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProvisionSettlementAccount", reflect.TypeOf((*MockStore)(nil).ProvisionSettlementAccount), arg0, arg1)
+}
+
+// RejectTransferRequest mocks base method.
+func (m *MockStore) RejectTransferRequest(arg0 context.Context, arg1 db.RejectTransferRequestParams) (db.TransferRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RejectTransferRequest", arg0, arg1)
+	ret0, _ := ret[0].(db.TransferRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RejectTransferRequest indicates an expected call of RejectTransferRequest.
+func (mr *MockStoreMockRecorder) RejectTransferRequest(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RejectTransferRequest", reflect.TypeOf((*MockStore)(nil).RejectTransferRequest), arg0, arg1)
+}
+
+// ReverseTransferTx mocks base method.
+func (m *MockStore) ReverseTransferTx(arg0 context.Context, arg1 db.ReverseTransferTxParams) (db.TransferTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReverseTransferTx", arg0, arg1)
+	ret0, _ := ret[0].(db.TransferTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReverseTransferTx indicates an expected call of ReverseTransferTx.
+func (mr *MockStoreMockRecorder) ReverseTransferTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReverseTransferTx", reflect.TypeOf((*MockStore)(nil).ReverseTransferTx), arg0, arg1)
+}
+
+// TransferTx mocks base method.
+func (m *MockStore) TransferTx(arg0 context.Context, arg1 db.TransferTxParams) (db.TransferTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TransferTx", arg0, arg1)
+	ret0, _ := ret[0].(db.TransferTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TransferTx indicates an expected call of TransferTx.
+func (mr *MockStoreMockRecorder) TransferTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TransferTx", reflect.TypeOf((*MockStore)(nil).TransferTx), arg0, arg1)
+}
+
+// UpdateAccount mocks base method.
+func (m *MockStore) UpdateAccount(arg0 context.Context, arg1 db.UpdateAccountParams) (db.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateAccount", arg0, arg1)
+	ret0, _ := ret[0].(db.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateAccount indicates an expected call of UpdateAccount.
+func (mr *MockStoreMockRecorder) UpdateAccount(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAccount", reflect.TypeOf((*MockStore)(nil).UpdateAccount), arg0, arg1)
+}
+
+// UpdateAccountStatus mocks base method.
+func (m *MockStore) UpdateAccountStatus(arg0 context.Context, arg1 db.UpdateAccountStatusParams) (db.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateAccountStatus", arg0, arg1)
+	ret0, _ := ret[0].(db.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateAccountStatus indicates an expected call of UpdateAccountStatus.
+func (mr *MockStoreMockRecorder) UpdateAccountStatus(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAccountStatus", reflect.TypeOf((*MockStore)(nil).UpdateAccountStatus), arg0, arg1)
+}
+
+// UpdateTransferStatus mocks base method.
+func (m *MockStore) UpdateTransferStatus(arg0 context.Context, arg1 db.UpdateTransferStatusParams) (db.Transfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateTransferStatus", arg0, arg1)
+	ret0, _ := ret[0].(db.Transfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateTransferStatus indicates an expected call of UpdateTransferStatus.
+func (mr *MockStoreMockRecorder) UpdateTransferStatus(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateTransferStatus", reflect.TypeOf((*MockStore)(nil).UpdateTransferStatus), arg0, arg1)
+}
+
+// UpdateUserHashedPassword mocks base method.
+func (m *MockStore) UpdateUserHashedPassword(arg0 context.Context, arg1 db.UpdateUserHashedPasswordParams) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUserHashedPassword", arg0, arg1)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateUserHashedPassword indicates an expected call of UpdateUserHashedPassword.
+func (mr *MockStoreMockRecorder) UpdateUserHashedPassword(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserHashedPassword", reflect.TypeOf((*MockStore)(nil).UpdateUserHashedPassword), arg0, arg1)
+}
+
+// UpsertAccountLimits mocks base method.
+func (m *MockStore) UpsertAccountLimits(arg0 context.Context, arg1 db.UpsertAccountLimitsParams) (db.AccountLimit, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpsertAccountLimits", arg0, arg1)
+	ret0, _ := ret[0].(db.AccountLimit)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpsertAccountLimits indicates an expected call of UpsertAccountLimits.
+func (mr *MockStoreMockRecorder) UpsertAccountLimits(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpsertAccountLimits", reflect.TypeOf((*MockStore)(nil).UpsertAccountLimits), arg0, arg1)
+}
+
+// UpsertFeeSchedule mocks base method.
+func (m *MockStore) UpsertFeeSchedule(arg0 context.Context, arg1 db.UpsertFeeScheduleParams) (db.FeeSchedule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpsertFeeSchedule", arg0, arg1)
+	ret0, _ := ret[0].(db.FeeSchedule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpsertFeeSchedule indicates an expected call of UpsertFeeSchedule.
+func (mr *MockStoreMockRecorder) UpsertFeeSchedule(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpsertFeeSchedule", reflect.TypeOf((*MockStore)(nil).UpsertFeeSchedule), arg0, arg1)
+}
+
+// UpsertUserLimits mocks base method.
+func (m *MockStore) UpsertUserLimits(arg0 context.Context, arg1 db.UpsertUserLimitsParams) (db.UserLimit, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpsertUserLimits", arg0, arg1)
+	ret0, _ := ret[0].(db.UserLimit)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpsertUserLimits indicates an expected call of UpsertUserLimits.
+func (mr *MockStoreMockRecorder) UpsertUserLimits(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpsertUserLimits", reflect.TypeOf((*MockStore)(nil).UpsertUserLimits), arg0, arg1)
+}
+
+// UseTransferQuote mocks base method.
+func (m *MockStore) UseTransferQuote(arg0 context.Context, arg1 db.UseTransferQuoteParams) (db.TransferQuote, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseTransferQuote", arg0, arg1)
+	ret0, _ := ret[0].(db.TransferQuote)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UseTransferQuote indicates an expected call of UseTransferQuote.
+func (mr *MockStoreMockRecorder) UseTransferQuote(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseTransferQuote", reflect.TypeOf((*MockStore)(nil).UseTransferQuote), arg0, arg1)
+}
+
+// VoidHold mocks base method.
+func (m *MockStore) VoidHold(arg0 context.Context, arg1 int64) (db.Hold, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VoidHold", arg0, arg1)
+	ret0, _ := ret[0].(db.Hold)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VoidHold indicates an expected call of VoidHold.
+func (mr *MockStoreMockRecorder) VoidHold(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VoidHold", reflect.TypeOf((*MockStore)(nil).VoidHold), arg0, arg1)
+}
+
+// VoidHoldTx mocks base method.
+func (m *MockStore) VoidHoldTx(arg0 context.Context, arg1 int64) (db.HoldTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VoidHoldTx", arg0, arg1)
+	ret0, _ := ret[0].(db.HoldTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VoidHoldTx indicates an expected call of VoidHoldTx.
+func (mr *MockStoreMockRecorder) VoidHoldTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VoidHoldTx", reflect.TypeOf((*MockStore)(nil).VoidHoldTx), arg0, arg1)
+}
+
+// WithdrawTx mocks base method.
+func (m *MockStore) WithdrawTx(arg0 context.Context, arg1 db.CashTxParams) (db.CashTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithdrawTx", arg0, arg1)
+	ret0, _ := ret[0].(db.CashTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WithdrawTx indicates an expected call of WithdrawTx.
+func (mr *MockStoreMockRecorder) WithdrawTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithdrawTx", reflect.TypeOf((*MockStore)(nil).WithdrawTx), arg0, arg1)
 }
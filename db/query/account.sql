@@ -8,24 +8,131 @@ FROM accounts
 WHERE id = $1 LIMIT 1
 FOR NO KEY UPDATE;
 
+-- name: GetAccountUnlocked :one
+-- reads an account without locking it, so a transaction can look up its currency before addMoneyInOrder locks the rows
+SELECT *
+FROM accounts
+WHERE id = $1 LIMIT 1;
+
 -- name: ListAccounts :many
 SELECT *
 FROM accounts
-ORDER BY id LIMIT $1
-OFFSET $2;
+WHERE owner = $1
+ORDER BY id LIMIT $2
+OFFSET $3;
+
+-- name: ListAccountsAfter :many
+-- keyset page of the accounts that come after the cursor
+SELECT *
+FROM accounts
+WHERE owner = sqlc.arg(owner)
+  AND (created_at, id) > (sqlc.arg(cursor_created_at)::timestamptz, sqlc.arg(cursor_id)::bigint)
+ORDER BY created_at, id
+LIMIT sqlc.arg('limit');
+
+-- name: ListAccountsBefore :many
+-- keyset page of the accounts that come before the cursor, closest first
+SELECT *
+FROM accounts
+WHERE owner = sqlc.arg(owner)
+  AND (created_at, id) < (sqlc.arg(cursor_created_at)::timestamptz, sqlc.arg(cursor_id)::bigint)
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit');
 
 -- name: UpdateAccount :one
+-- updates the non-monetary fields that are not null, if the account is still at the given version
 UPDATE accounts
-set balance = balance + $2
-WHERE id = $1 RETURNING *;
+SET nickname        = COALESCE(sqlc.narg(nickname), nickname),
+    account_type    = COALESCE(sqlc.narg(account_type), account_type),
+    overdraft_limit = COALESCE(sqlc.narg(overdraft_limit), overdraft_limit),
+    version         = version + 1
+WHERE id = sqlc.arg(id)
+  AND version = sqlc.arg(version)
+RETURNING *;
 
 -- name: AddAccountBalance :one
 UPDATE accounts
-set balance = balance + sqlc.arg(amount)
+set balance = balance + sqlc.arg(amount),
+    version = version + 1
 WHERE id = sqlc.arg(id)
 RETURNING *;
 
--- name: DeleteAccount :exec
-DELETE
+-- name: UpdateAccountStatus :one
+-- moves the account to status only if it is still in from_status, so concurrent changes cannot be lost
+UPDATE accounts
+SET status  = sqlc.arg(status),
+    version = version + 1
+WHERE id = sqlc.arg(id)
+  AND status = sqlc.arg(from_status)
+RETURNING *;
+
+-- name: GetSettlementAccount :one
+SELECT accounts.*
+FROM accounts
+         JOIN settlement_accounts ON settlement_accounts.account_id = accounts.id
+WHERE settlement_accounts.currency = $1
+LIMIT 1;
+
+-- name: GetFXAccount :one
+SELECT accounts.*
 FROM accounts
-WHERE id = $1;
\ No newline at end of file
+         JOIN fx_accounts ON fx_accounts.account_id = accounts.id
+WHERE fx_accounts.currency = $1
+LIMIT 1;
+
+-- name: GetRevenueAccount :one
+SELECT accounts.*
+FROM accounts
+         JOIN revenue_accounts ON revenue_accounts.account_id = accounts.id
+WHERE revenue_accounts.currency = $1
+LIMIT 1;
+
+-- name: ProvisionSettlementAccount :execrows
+-- creates the settlement account of a currency that has none yet, so enabling a currency needs no migration
+WITH created AS (
+    INSERT INTO accounts (owner, balance, currency)
+        SELECT 'system', 0, sqlc.arg(currency)::varchar
+        WHERE NOT EXISTS (SELECT 1 FROM settlement_accounts WHERE settlement_accounts.currency = sqlc.arg(currency))
+        RETURNING id, currency)
+INSERT
+INTO settlement_accounts (currency, account_id)
+SELECT currency, id
+FROM created;
+
+-- name: ProvisionFXAccount :execrows
+-- creates the fx account of a currency that has none yet, so enabling a currency needs no migration
+WITH created AS (
+    INSERT INTO accounts (owner, balance, currency)
+        SELECT 'fx', 0, sqlc.arg(currency)::varchar
+        WHERE NOT EXISTS (SELECT 1 FROM fx_accounts WHERE fx_accounts.currency = sqlc.arg(currency))
+        RETURNING id, currency)
+INSERT
+INTO fx_accounts (currency, account_id)
+SELECT currency, id
+FROM created;
+
+-- name: ProvisionRevenueAccount :execrows
+-- creates the revenue account of a currency that has none yet, so enabling a currency needs no migration
+WITH created AS (
+    INSERT INTO accounts (owner, balance, currency)
+        SELECT 'revenue', 0, sqlc.arg(currency)::varchar
+        WHERE NOT EXISTS (SELECT 1 FROM revenue_accounts WHERE revenue_accounts.currency = sqlc.arg(currency))
+        RETURNING id, currency)
+INSERT
+INTO revenue_accounts (currency, account_id)
+SELECT currency, id
+FROM created;
+
+-- name: IsSystemAccount :one
+-- reports whether the account is the settlement, fx or revenue account of a currency
+SELECT (EXISTS (SELECT 1 FROM settlement_accounts WHERE settlement_accounts.account_id = sqlc.arg(id))
+    OR EXISTS (SELECT 1 FROM fx_accounts WHERE fx_accounts.account_id = sqlc.arg(id))
+    OR EXISTS (SELECT 1 FROM revenue_accounts WHERE revenue_accounts.account_id = sqlc.arg(id)))::bool AS is_system;
+
+-- name: AddAccountHeldAmount :one
+-- reserves a positive amount of the balance for a hold, or releases a negative one
+UPDATE accounts
+SET held_amount = held_amount + sqlc.arg(amount),
+    version     = version + 1
+WHERE id = sqlc.arg(id)
+RETURNING *;
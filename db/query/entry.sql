@@ -15,3 +15,113 @@ FROM entries
 WHERE account_id = $1
 ORDER BY id
 LIMIT $2 OFFSET $3;
+
+-- name: GetEntryWithBalance :one
+-- running_balance is the account balance right after the entry was applied, taking entries in (created_at, id) order
+SELECT entries.id,
+       entries.account_id,
+       entries.amount,
+       entries.created_at,
+       (accounts.balance - COALESCE((SELECT SUM(later.amount)
+                                     FROM entries AS later
+                                     WHERE later.account_id = entries.account_id
+                                       AND (later.created_at, later.id) > (entries.created_at, entries.id)), 0))::bigint AS running_balance
+FROM entries
+         JOIN accounts ON accounts.id = entries.account_id
+WHERE entries.id = $1
+LIMIT 1;
+
+-- name: ListAccountEntries :many
+-- the page is read first, so the running balances only sum the entries from the page on, not the whole history
+WITH page AS (SELECT entries.*
+              FROM entries
+              WHERE entries.account_id = sqlc.arg(account_id)
+                AND (sqlc.narg(from_time)::timestamptz IS NULL OR entries.created_at >= sqlc.narg(from_time))
+                AND (sqlc.narg(to_time)::timestamptz IS NULL OR entries.created_at < sqlc.narg(to_time))
+              ORDER BY entries.created_at, entries.id
+              LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset')),
+     newest AS (SELECT page.created_at, page.id
+                FROM page
+                ORDER BY page.created_at DESC, page.id DESC
+                LIMIT 1),
+     later AS (SELECT COALESCE(SUM(entries.amount), 0)::bigint AS amount
+               FROM entries,
+                    newest
+               WHERE entries.account_id = sqlc.arg(account_id)
+                 AND (entries.created_at, entries.id) > (newest.created_at, newest.id))
+SELECT page.id,
+       page.account_id,
+       page.amount,
+       page.created_at,
+       (accounts.balance - later.amount - COALESCE(SUM(page.amount) OVER (
+           ORDER BY page.created_at DESC, page.id DESC
+           ROWS BETWEEN UNBOUNDED PRECEDING AND 1 PRECEDING
+           ), 0))::bigint AS running_balance
+FROM page
+         JOIN accounts ON accounts.id = page.account_id
+         CROSS JOIN later
+ORDER BY page.created_at, page.id;
+
+-- name: ListAccountEntriesAfter :many
+-- keyset page of the entries that come after the cursor
+WITH page AS (SELECT entries.*
+              FROM entries
+              WHERE entries.account_id = sqlc.arg(account_id)
+                AND (sqlc.narg(from_time)::timestamptz IS NULL OR entries.created_at >= sqlc.narg(from_time))
+                AND (sqlc.narg(to_time)::timestamptz IS NULL OR entries.created_at < sqlc.narg(to_time))
+                AND (entries.created_at, entries.id) > (sqlc.arg(cursor_created_at)::timestamptz, sqlc.arg(cursor_id)::bigint)
+              ORDER BY entries.created_at, entries.id
+              LIMIT sqlc.arg('limit')),
+     newest AS (SELECT page.created_at, page.id
+                FROM page
+                ORDER BY page.created_at DESC, page.id DESC
+                LIMIT 1),
+     later AS (SELECT COALESCE(SUM(entries.amount), 0)::bigint AS amount
+               FROM entries,
+                    newest
+               WHERE entries.account_id = sqlc.arg(account_id)
+                 AND (entries.created_at, entries.id) > (newest.created_at, newest.id))
+SELECT page.id,
+       page.account_id,
+       page.amount,
+       page.created_at,
+       (accounts.balance - later.amount - COALESCE(SUM(page.amount) OVER (
+           ORDER BY page.created_at DESC, page.id DESC
+           ROWS BETWEEN UNBOUNDED PRECEDING AND 1 PRECEDING
+           ), 0))::bigint AS running_balance
+FROM page
+         JOIN accounts ON accounts.id = page.account_id
+         CROSS JOIN later
+ORDER BY page.created_at, page.id;
+
+-- name: ListAccountEntriesBefore :many
+-- keyset page of the entries that come before the cursor, closest first
+WITH page AS (SELECT entries.*
+              FROM entries
+              WHERE entries.account_id = sqlc.arg(account_id)
+                AND (sqlc.narg(from_time)::timestamptz IS NULL OR entries.created_at >= sqlc.narg(from_time))
+                AND (sqlc.narg(to_time)::timestamptz IS NULL OR entries.created_at < sqlc.narg(to_time))
+                AND (entries.created_at, entries.id) < (sqlc.arg(cursor_created_at)::timestamptz, sqlc.arg(cursor_id)::bigint)
+              ORDER BY entries.created_at DESC, entries.id DESC
+              LIMIT sqlc.arg('limit')),
+     newest AS (SELECT page.created_at, page.id
+                FROM page
+                ORDER BY page.created_at DESC, page.id DESC
+                LIMIT 1),
+     later AS (SELECT COALESCE(SUM(entries.amount), 0)::bigint AS amount
+               FROM entries,
+                    newest
+               WHERE entries.account_id = sqlc.arg(account_id)
+                 AND (entries.created_at, entries.id) > (newest.created_at, newest.id))
+SELECT page.id,
+       page.account_id,
+       page.amount,
+       page.created_at,
+       (accounts.balance - later.amount - COALESCE(SUM(page.amount) OVER (
+           ORDER BY page.created_at DESC, page.id DESC
+           ROWS BETWEEN UNBOUNDED PRECEDING AND 1 PRECEDING
+           ), 0))::bigint AS running_balance
+FROM page
+         JOIN accounts ON accounts.id = page.account_id
+         CROSS JOIN later
+ORDER BY page.created_at DESC, page.id DESC;
@@ -0,0 +1,36 @@
+-- name: UpsertFeeSchedule :one
+-- creates the tier of a schedule, or replaces the tier with the same lower bound
+INSERT INTO fee_schedules (currency,
+                           account_type,
+                           min_amount,
+                           flat_fee,
+                           percentage,
+                           min_fee,
+                           max_fee)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (currency, account_type, min_amount) DO UPDATE
+    SET flat_fee   = excluded.flat_fee,
+        percentage = excluded.percentage,
+        min_fee    = excluded.min_fee,
+        max_fee    = excluded.max_fee
+RETURNING *;
+
+-- name: GetFeeSchedule :one
+-- finds the tier that applies to an amount: the one with the highest lower bound the amount reaches
+SELECT *
+FROM fee_schedules
+WHERE currency = sqlc.arg(currency)
+  AND account_type = sqlc.arg(account_type)
+  AND min_amount <= sqlc.arg(amount)::bigint
+ORDER BY min_amount DESC
+LIMIT 1;
+
+-- name: ListFeeSchedules :many
+SELECT *
+FROM fee_schedules
+ORDER BY currency, account_type, min_amount;
+
+-- name: DeleteFeeSchedule :exec
+DELETE
+FROM fee_schedules
+WHERE id = $1;
@@ -0,0 +1,53 @@
+-- name: CreateHold :one
+INSERT INTO holds (account_id,
+                   to_account_id,
+                   amount,
+                   currency,
+                   expires_at)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: GetHold :one
+SELECT *
+FROM holds
+WHERE id = $1
+LIMIT 1;
+
+-- name: GetHoldForUpdate :one
+SELECT *
+FROM holds
+WHERE id = $1
+LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: CaptureHold :one
+-- records the capture and its transfer, unless the hold was settled already or expired
+UPDATE holds
+SET status          = 'captured',
+    captured_amount = sqlc.arg(captured_amount),
+    transfer_id     = sqlc.arg(transfer_id)::bigint
+WHERE id = sqlc.arg(id)
+  AND status = 'authorized'
+  AND expires_at > now()
+RETURNING *;
+
+-- name: VoidHold :one
+UPDATE holds
+SET status = 'voided'
+WHERE id = $1
+  AND status = 'authorized'
+RETURNING *;
+
+-- name: ExpireHolds :many
+-- expires the holds that were not settled in time and sums up the amounts to release per account,
+-- in id order so ExpireHoldsTx locks the accounts in the same order as transfers
+WITH expired AS (
+    UPDATE holds
+        SET status = 'expired'
+        WHERE status = 'authorized'
+            AND expires_at <= now()
+        RETURNING account_id, amount)
+SELECT account_id, sum(amount)::bigint AS amount
+FROM expired
+GROUP BY account_id
+ORDER BY account_id;
@@ -0,0 +1,20 @@
+-- name: GetIdempotencyKey :one
+SELECT *
+FROM idempotency_keys
+WHERE username = $1
+  AND key = $2
+  AND expires_at > now()
+LIMIT 1;
+
+-- name: CreateIdempotencyKey :one
+-- an expired key may be taken over, a live one makes this return no rows
+INSERT INTO idempotency_keys (username, key, request_hash, response, transfer_request_id, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (username, key) DO UPDATE
+    SET request_hash        = excluded.request_hash,
+        response            = excluded.response,
+        transfer_request_id = excluded.transfer_request_id,
+        expires_at          = excluded.expires_at,
+        created_at          = now()
+WHERE idempotency_keys.expires_at <= now()
+RETURNING *;
@@ -1,6 +1,19 @@
 -- name: CreateTransfer :one
-INSERT INTO transfers (from_account_id, to_account_id, amount)
-VALUES ($1, $2, $3)
+-- the currencies are copied from the accounts, so a transfer can be read without them
+INSERT INTO transfers (from_account_id, to_account_id, amount, to_amount, exchange_rate, spread, fee, currency, to_currency)
+SELECT from_account.id,
+       to_account.id,
+       sqlc.arg(amount)::bigint,
+       sqlc.arg(to_amount)::bigint,
+       sqlc.arg(exchange_rate)::numeric,
+       sqlc.arg(spread)::numeric,
+       sqlc.arg(fee)::bigint,
+       from_account.currency,
+       to_account.currency
+FROM accounts AS from_account,
+     accounts AS to_account
+WHERE from_account.id = sqlc.arg(from_account_id)
+  AND to_account.id = sqlc.arg(to_account_id)
 RETURNING *;
 
 -- name: GetTransfer :one
@@ -10,9 +23,86 @@ WHERE id = $1
 LIMIT 1;
 
 -- name: ListTransfers :many
+-- lists the transfers of an account in the directions enabled by include_outgoing and include_incoming;
+-- every other filter is skipped when its argument is null
 SELECT *
 FROM transfers
-WHERE from_account_id = $1
-   OR to_account_id = $2
+WHERE ((sqlc.arg(include_outgoing)::bool AND from_account_id = sqlc.arg(account_id))
+    OR (sqlc.arg(include_incoming)::bool AND to_account_id = sqlc.arg(account_id)))
+  AND (sqlc.narg(counterparty_id)::bigint IS NULL
+    OR from_account_id = sqlc.narg(counterparty_id)
+    OR to_account_id = sqlc.narg(counterparty_id))
+  AND (sqlc.narg(from_time)::timestamptz IS NULL OR created_at >= sqlc.narg(from_time))
+  AND (sqlc.narg(to_time)::timestamptz IS NULL OR created_at < sqlc.narg(to_time))
+  AND (sqlc.narg(min_amount)::bigint IS NULL OR amount >= sqlc.narg(min_amount))
+  AND (sqlc.narg(max_amount)::bigint IS NULL OR amount <= sqlc.narg(max_amount))
 ORDER BY id
-LIMIT $3 OFFSET $4;
\ No newline at end of file
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- name: ListTransfersAfter :many
+-- keyset page of the transfers that come after the cursor, filtered like ListTransfers
+SELECT *
+FROM transfers
+WHERE ((sqlc.arg(include_outgoing)::bool AND from_account_id = sqlc.arg(account_id))
+    OR (sqlc.arg(include_incoming)::bool AND to_account_id = sqlc.arg(account_id)))
+  AND (sqlc.narg(counterparty_id)::bigint IS NULL
+    OR from_account_id = sqlc.narg(counterparty_id)
+    OR to_account_id = sqlc.narg(counterparty_id))
+  AND (sqlc.narg(from_time)::timestamptz IS NULL OR created_at >= sqlc.narg(from_time))
+  AND (sqlc.narg(to_time)::timestamptz IS NULL OR created_at < sqlc.narg(to_time))
+  AND (sqlc.narg(min_amount)::bigint IS NULL OR amount >= sqlc.narg(min_amount))
+  AND (sqlc.narg(max_amount)::bigint IS NULL OR amount <= sqlc.narg(max_amount))
+  AND (created_at, id) > (sqlc.arg(cursor_created_at)::timestamptz, sqlc.arg(cursor_id)::bigint)
+ORDER BY created_at, id
+LIMIT sqlc.arg('limit');
+
+-- name: ListTransfersBefore :many
+-- keyset page of the transfers that come before the cursor, closest first
+SELECT *
+FROM transfers
+WHERE ((sqlc.arg(include_outgoing)::bool AND from_account_id = sqlc.arg(account_id))
+    OR (sqlc.arg(include_incoming)::bool AND to_account_id = sqlc.arg(account_id)))
+  AND (sqlc.narg(counterparty_id)::bigint IS NULL
+    OR from_account_id = sqlc.narg(counterparty_id)
+    OR to_account_id = sqlc.narg(counterparty_id))
+  AND (sqlc.narg(from_time)::timestamptz IS NULL OR created_at >= sqlc.narg(from_time))
+  AND (sqlc.narg(to_time)::timestamptz IS NULL OR created_at < sqlc.narg(to_time))
+  AND (sqlc.narg(min_amount)::bigint IS NULL OR amount >= sqlc.narg(min_amount))
+  AND (sqlc.narg(max_amount)::bigint IS NULL OR amount <= sqlc.narg(max_amount))
+  AND (created_at, id) < (sqlc.arg(cursor_created_at)::timestamptz, sqlc.arg(cursor_id)::bigint)
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: GetTransferForUpdate :one
+SELECT *
+FROM transfers
+WHERE id = $1
+LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: UpdateTransferStatus :one
+-- moves the transfer to status only if it is still in from_status, so concurrent changes cannot be lost
+UPDATE transfers
+SET status = sqlc.arg(status)
+WHERE id = sqlc.arg(id)
+  AND status = sqlc.arg(from_status)
+RETURNING *;
+
+-- name: CreateReversalTransfer :one
+-- books the reversal of a transfer from its destination back to its source with the amounts swapped,
+-- so both accounts get back exactly what they had; the fee is refunded by separate entries
+INSERT INTO transfers (from_account_id, to_account_id, amount, to_amount, exchange_rate, spread, fee, currency,
+                       to_currency, reversal_of)
+SELECT original.to_account_id,
+       original.from_account_id,
+       original.to_amount,
+       original.amount,
+       trim_scale(round(1 / original.exchange_rate, 10)),
+       0,
+       0,
+       original.to_currency,
+       original.currency,
+       original.id
+FROM transfers AS original
+WHERE original.id = sqlc.arg(id)
+RETURNING *;
@@ -0,0 +1,63 @@
+-- name: UpsertAccountLimits :one
+-- replaces every cap of the account, a null clears it
+INSERT INTO account_limits (account_id, max_amount, daily_amount, hourly_count)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (account_id) DO UPDATE
+    SET max_amount   = excluded.max_amount,
+        daily_amount = excluded.daily_amount,
+        hourly_count = excluded.hourly_count,
+        updated_at   = now()
+RETURNING *;
+
+-- name: GetAccountLimits :one
+SELECT *
+FROM account_limits
+WHERE account_id = $1
+LIMIT 1;
+
+-- name: UpsertUserLimits :one
+-- replaces every cap of the user in the currency, a null clears it
+INSERT INTO user_limits (username, currency, max_amount, daily_amount, hourly_count)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (username, currency) DO UPDATE
+    SET max_amount   = excluded.max_amount,
+        daily_amount = excluded.daily_amount,
+        hourly_count = excluded.hourly_count,
+        updated_at   = now()
+RETURNING *;
+
+-- name: ListUserLimits :many
+SELECT *
+FROM user_limits
+WHERE username = $1
+ORDER BY currency;
+
+-- name: GetUserLimitsForUpdate :one
+-- locks the limits of the user, so transfers out of different accounts of the user are checked one at a time
+SELECT *
+FROM user_limits
+WHERE username = $1
+  AND currency = $2
+LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: GetAccountTransferVelocity :one
+-- sums up the transfers out of the account in the last 24 hours and counts those of the last hour;
+-- reversals are left out, since they give money back rather than spend it
+SELECT COALESCE(SUM(amount), 0)::bigint AS daily_amount,
+       COUNT(*) FILTER (WHERE created_at > now() - interval '1 hour')::bigint AS hourly_count
+FROM transfers
+WHERE from_account_id = $1
+  AND reversal_of IS NULL
+  AND created_at > now() - interval '24 hours';
+
+-- name: GetUserTransferVelocity :one
+-- sums up the transfers out of the accounts of the user in the currency, like GetAccountTransferVelocity
+SELECT COALESCE(SUM(transfers.amount), 0)::bigint AS daily_amount,
+       COUNT(*) FILTER (WHERE transfers.created_at > now() - interval '1 hour')::bigint AS hourly_count
+FROM transfers
+         JOIN accounts ON accounts.id = transfers.from_account_id
+WHERE accounts.owner = $1
+  AND accounts.currency = $2
+  AND transfers.reversal_of IS NULL
+  AND transfers.created_at > now() - interval '24 hours';
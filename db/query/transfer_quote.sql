@@ -0,0 +1,30 @@
+-- name: CreateTransferQuote :one
+INSERT INTO transfer_quotes (id,
+                             username,
+                             from_account_id,
+                             to_account_id,
+                             amount,
+                             currency,
+                             to_amount,
+                             to_currency,
+                             exchange_rate,
+                             spread,
+                             fee,
+                             expires_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+RETURNING *;
+
+-- name: GetTransferQuote :one
+SELECT *
+FROM transfer_quotes
+WHERE id = $1
+LIMIT 1;
+
+-- name: UseTransferQuote :one
+-- links the quote to the transfer that used it, unless it expired or was used already
+UPDATE transfer_quotes
+SET transfer_id = sqlc.arg(transfer_id)::bigint
+WHERE id = sqlc.arg(id)
+  AND transfer_id IS NULL
+  AND expires_at > now()
+RETURNING *;
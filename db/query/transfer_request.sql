@@ -0,0 +1,69 @@
+-- name: CreateTransferRequest :one
+INSERT INTO transfer_requests (requested_by,
+                               from_account_id,
+                               to_account_id,
+                               amount,
+                               currency,
+                               expires_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: GetTransferRequest :one
+SELECT *
+FROM transfer_requests
+WHERE id = $1
+LIMIT 1;
+
+-- name: ListTransferRequests :many
+SELECT *
+FROM transfer_requests
+WHERE status = $1
+ORDER BY created_at, id
+LIMIT $2 OFFSET $3;
+
+-- name: ListTransferRequestsAfter :many
+-- keyset page of the requests in a status that come after the cursor
+SELECT *
+FROM transfer_requests
+WHERE status = sqlc.arg(status)
+  AND (created_at, id) > (sqlc.arg(cursor_created_at)::timestamptz, sqlc.arg(cursor_id)::bigint)
+ORDER BY created_at, id
+LIMIT sqlc.arg('limit');
+
+-- name: ListTransferRequestsBefore :many
+-- keyset page of the requests in a status that come before the cursor, closest first
+SELECT *
+FROM transfer_requests
+WHERE status = sqlc.arg(status)
+  AND (created_at, id) < (sqlc.arg(cursor_created_at)::timestamptz, sqlc.arg(cursor_id)::bigint)
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: ApproveTransferRequest :one
+-- records the approval and the transfer it executed, unless the request was reviewed already or expired
+UPDATE transfer_requests
+SET status      = 'approved',
+    reviewed_by = sqlc.arg(reviewed_by),
+    reviewed_at = now(),
+    transfer_id = sqlc.arg(transfer_id)::bigint
+WHERE id = sqlc.arg(id)
+  AND status = 'pending_approval'
+  AND expires_at > now()
+RETURNING *;
+
+-- name: RejectTransferRequest :one
+UPDATE transfer_requests
+SET status      = 'rejected',
+    reviewed_by = sqlc.arg(reviewed_by),
+    reviewed_at = now()
+WHERE id = sqlc.arg(id)
+  AND status = 'pending_approval'
+  AND expires_at > now()
+RETURNING *;
+
+-- name: ExpireTransferRequests :execrows
+-- moves the requests that were not reviewed in time to expired
+UPDATE transfer_requests
+SET status = 'expired'
+WHERE status = 'pending_approval'
+  AND expires_at <= now();
@@ -7,13 +7,16 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"time"
 )
 
 const addAccountBalance = `-- name: AddAccountBalance :one
 UPDATE accounts
-set balance = balance + $1
+set balance = balance + $1,
+    version = version + 1
 WHERE id = $2
-RETURNING id, owner, balance, currency, created_at
+RETURNING id, balance, currency, created_at, owner, overdraft_limit, status, nickname, account_type, version, held_amount, available_balance
 `
 
 type AddAccountBalanceParams struct {
@@ -26,17 +29,58 @@ func (q *Queries) AddAccountBalance(ctx context.Context, arg AddAccountBalancePa
 	var i Account
 	err := row.Scan(
 		&i.ID,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
 		&i.Owner,
+		&i.OverdraftLimit,
+		&i.Status,
+		&i.Nickname,
+		&i.AccountType,
+		&i.Version,
+		&i.HeldAmount,
+		&i.AvailableBalance,
+	)
+	return i, err
+}
+
+const addAccountHeldAmount = `-- name: AddAccountHeldAmount :one
+UPDATE accounts
+SET held_amount = held_amount + $1,
+    version     = version + 1
+WHERE id = $2
+RETURNING id, balance, currency, created_at, owner, overdraft_limit, status, nickname, account_type, version, held_amount, available_balance
+`
+
+type AddAccountHeldAmountParams struct {
+	Amount int64 `json:"amount"`
+	ID     int64 `json:"id"`
+}
+
+// reserves a positive amount of the balance for a hold, or releases a negative one
+func (q *Queries) AddAccountHeldAmount(ctx context.Context, arg AddAccountHeldAmountParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, addAccountHeldAmount, arg.Amount, arg.ID)
+	var i Account
+	err := row.Scan(
+		&i.ID,
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.Owner,
+		&i.OverdraftLimit,
+		&i.Status,
+		&i.Nickname,
+		&i.AccountType,
+		&i.Version,
+		&i.HeldAmount,
+		&i.AvailableBalance,
 	)
 	return i, err
 }
 
 const createAccount = `-- name: CreateAccount :one
 INSERT INTO accounts (owner, balance, currency)
-VALUES ($1, $2, $3) RETURNING id, owner, balance, currency, created_at
+VALUES ($1, $2, $3) RETURNING id, balance, currency, created_at, owner, overdraft_limit, status, nickname, account_type, version, held_amount, available_balance
 `
 
 type CreateAccountParams struct {
@@ -50,59 +94,189 @@ func (q *Queries) CreateAccount(ctx context.Context, arg CreateAccountParams) (A
 	var i Account
 	err := row.Scan(
 		&i.ID,
-		&i.Owner,
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.Owner,
+		&i.OverdraftLimit,
+		&i.Status,
+		&i.Nickname,
+		&i.AccountType,
+		&i.Version,
+		&i.HeldAmount,
+		&i.AvailableBalance,
 	)
 	return i, err
 }
 
-const deleteAccount = `-- name: DeleteAccount :exec
-DELETE
+const getAccount = `-- name: GetAccount :one
+SELECT id, balance, currency, created_at, owner, overdraft_limit, status, nickname, account_type, version, held_amount, available_balance
 FROM accounts
-WHERE id = $1
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE
 `
 
-func (q *Queries) DeleteAccount(ctx context.Context, id int64) error {
-	_, err := q.db.ExecContext(ctx, deleteAccount, id)
-	return err
+func (q *Queries) GetAccount(ctx context.Context, id int64) (Account, error) {
+	row := q.db.QueryRowContext(ctx, getAccount, id)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.Owner,
+		&i.OverdraftLimit,
+		&i.Status,
+		&i.Nickname,
+		&i.AccountType,
+		&i.Version,
+		&i.HeldAmount,
+		&i.AvailableBalance,
+	)
+	return i, err
 }
 
-const getAccount = `-- name: GetAccount :one
-SELECT id, owner, balance, currency, created_at
+const getAccountUnlocked = `-- name: GetAccountUnlocked :one
+SELECT id, balance, currency, created_at, owner, overdraft_limit, status, nickname, account_type, version, held_amount, available_balance
 FROM accounts
 WHERE id = $1 LIMIT 1
-FOR NO KEY UPDATE
 `
 
-func (q *Queries) GetAccount(ctx context.Context, id int64) (Account, error) {
-	row := q.db.QueryRowContext(ctx, getAccount, id)
+// reads an account without locking it, so a transaction can look up its currency before addMoneyInOrder locks the rows
+func (q *Queries) GetAccountUnlocked(ctx context.Context, id int64) (Account, error) {
+	row := q.db.QueryRowContext(ctx, getAccountUnlocked, id)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.Owner,
+		&i.OverdraftLimit,
+		&i.Status,
+		&i.Nickname,
+		&i.AccountType,
+		&i.Version,
+		&i.HeldAmount,
+		&i.AvailableBalance,
+	)
+	return i, err
+}
+
+const getFXAccount = `-- name: GetFXAccount :one
+SELECT accounts.id, accounts.balance, accounts.currency, accounts.created_at, accounts.owner, accounts.overdraft_limit, accounts.status, accounts.nickname, accounts.account_type, accounts.version, accounts.held_amount, accounts.available_balance
+FROM accounts
+         JOIN fx_accounts ON fx_accounts.account_id = accounts.id
+WHERE fx_accounts.currency = $1
+LIMIT 1
+`
+
+func (q *Queries) GetFXAccount(ctx context.Context, currency string) (Account, error) {
+	row := q.db.QueryRowContext(ctx, getFXAccount, currency)
 	var i Account
 	err := row.Scan(
 		&i.ID,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
 		&i.Owner,
+		&i.OverdraftLimit,
+		&i.Status,
+		&i.Nickname,
+		&i.AccountType,
+		&i.Version,
+		&i.HeldAmount,
+		&i.AvailableBalance,
+	)
+	return i, err
+}
+
+const getRevenueAccount = `-- name: GetRevenueAccount :one
+SELECT accounts.id, accounts.balance, accounts.currency, accounts.created_at, accounts.owner, accounts.overdraft_limit, accounts.status, accounts.nickname, accounts.account_type, accounts.version, accounts.held_amount, accounts.available_balance
+FROM accounts
+         JOIN revenue_accounts ON revenue_accounts.account_id = accounts.id
+WHERE revenue_accounts.currency = $1
+LIMIT 1
+`
+
+func (q *Queries) GetRevenueAccount(ctx context.Context, currency string) (Account, error) {
+	row := q.db.QueryRowContext(ctx, getRevenueAccount, currency)
+	var i Account
+	err := row.Scan(
+		&i.ID,
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.Owner,
+		&i.OverdraftLimit,
+		&i.Status,
+		&i.Nickname,
+		&i.AccountType,
+		&i.Version,
+		&i.HeldAmount,
+		&i.AvailableBalance,
 	)
 	return i, err
 }
 
+const getSettlementAccount = `-- name: GetSettlementAccount :one
+SELECT accounts.id, accounts.balance, accounts.currency, accounts.created_at, accounts.owner, accounts.overdraft_limit, accounts.status, accounts.nickname, accounts.account_type, accounts.version, accounts.held_amount, accounts.available_balance
+FROM accounts
+         JOIN settlement_accounts ON settlement_accounts.account_id = accounts.id
+WHERE settlement_accounts.currency = $1
+LIMIT 1
+`
+
+func (q *Queries) GetSettlementAccount(ctx context.Context, currency string) (Account, error) {
+	row := q.db.QueryRowContext(ctx, getSettlementAccount, currency)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.Owner,
+		&i.OverdraftLimit,
+		&i.Status,
+		&i.Nickname,
+		&i.AccountType,
+		&i.Version,
+		&i.HeldAmount,
+		&i.AvailableBalance,
+	)
+	return i, err
+}
+
+const isSystemAccount = `-- name: IsSystemAccount :one
+SELECT (EXISTS (SELECT 1 FROM settlement_accounts WHERE settlement_accounts.account_id = $1)
+    OR EXISTS (SELECT 1 FROM fx_accounts WHERE fx_accounts.account_id = $1)
+    OR EXISTS (SELECT 1 FROM revenue_accounts WHERE revenue_accounts.account_id = $1))::bool AS is_system
+`
+
+// reports whether the account is the settlement, fx or revenue account of a currency
+func (q *Queries) IsSystemAccount(ctx context.Context, id int64) (bool, error) {
+	row := q.db.QueryRowContext(ctx, isSystemAccount, id)
+	var is_system bool
+	err := row.Scan(&is_system)
+	return is_system, err
+}
+
 const listAccounts = `-- name: ListAccounts :many
-SELECT id, owner, balance, currency, created_at
+SELECT id, balance, currency, created_at, owner, overdraft_limit, status, nickname, account_type, version, held_amount, available_balance
 FROM accounts
-ORDER BY id LIMIT $1
-OFFSET $2
+WHERE owner = $1
+ORDER BY id LIMIT $2
+OFFSET $3
 `
 
 type ListAccountsParams struct {
-	Limit  int32 `json:"limit"`
-	Offset int32 `json:"offset"`
+	Owner  string `json:"owner"`
+	Limit  int32  `json:"limit"`
+	Offset int32  `json:"offset"`
 }
 
 func (q *Queries) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]Account, error) {
-	rows, err := q.db.QueryContext(ctx, listAccounts, arg.Limit, arg.Offset)
+	rows, err := q.db.QueryContext(ctx, listAccounts, arg.Owner, arg.Limit, arg.Offset)
 	if err != nil {
 		return nil, err
 	}
@@ -112,10 +286,75 @@ func (q *Queries) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]A
 		var i Account
 		if err := rows.Scan(
 			&i.ID,
+			&i.Balance,
+			&i.Currency,
+			&i.CreatedAt,
 			&i.Owner,
+			&i.OverdraftLimit,
+			&i.Status,
+			&i.Nickname,
+			&i.AccountType,
+			&i.Version,
+			&i.HeldAmount,
+			&i.AvailableBalance,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAccountsAfter = `-- name: ListAccountsAfter :many
+SELECT id, balance, currency, created_at, owner, overdraft_limit, status, nickname, account_type, version, held_amount, available_balance
+FROM accounts
+WHERE owner = $1
+  AND (created_at, id) > ($2::timestamptz, $3::bigint)
+ORDER BY created_at, id
+LIMIT $4
+`
+
+type ListAccountsAfterParams struct {
+	Owner           string    `json:"owner"`
+	CursorCreatedAt time.Time `json:"cursor_created_at"`
+	CursorID        int64     `json:"cursor_id"`
+	Limit           int32     `json:"limit"`
+}
+
+// keyset page of the accounts that come after the cursor
+func (q *Queries) ListAccountsAfter(ctx context.Context, arg ListAccountsAfterParams) ([]Account, error) {
+	rows, err := q.db.QueryContext(ctx, listAccountsAfter,
+		arg.Owner,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Account{}
+	for rows.Next() {
+		var i Account
+		if err := rows.Scan(
+			&i.ID,
 			&i.Balance,
 			&i.Currency,
 			&i.CreatedAt,
+			&i.Owner,
+			&i.OverdraftLimit,
+			&i.Status,
+			&i.Nickname,
+			&i.AccountType,
+			&i.Version,
+			&i.HeldAmount,
+			&i.AvailableBalance,
 		); err != nil {
 			return nil, err
 		}
@@ -130,26 +369,205 @@ func (q *Queries) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]A
 	return items, nil
 }
 
+const listAccountsBefore = `-- name: ListAccountsBefore :many
+SELECT id, balance, currency, created_at, owner, overdraft_limit, status, nickname, account_type, version, held_amount, available_balance
+FROM accounts
+WHERE owner = $1
+  AND (created_at, id) < ($2::timestamptz, $3::bigint)
+ORDER BY created_at DESC, id DESC
+LIMIT $4
+`
+
+type ListAccountsBeforeParams struct {
+	Owner           string    `json:"owner"`
+	CursorCreatedAt time.Time `json:"cursor_created_at"`
+	CursorID        int64     `json:"cursor_id"`
+	Limit           int32     `json:"limit"`
+}
+
+// keyset page of the accounts that come before the cursor, closest first
+func (q *Queries) ListAccountsBefore(ctx context.Context, arg ListAccountsBeforeParams) ([]Account, error) {
+	rows, err := q.db.QueryContext(ctx, listAccountsBefore,
+		arg.Owner,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Account{}
+	for rows.Next() {
+		var i Account
+		if err := rows.Scan(
+			&i.ID,
+			&i.Balance,
+			&i.Currency,
+			&i.CreatedAt,
+			&i.Owner,
+			&i.OverdraftLimit,
+			&i.Status,
+			&i.Nickname,
+			&i.AccountType,
+			&i.Version,
+			&i.HeldAmount,
+			&i.AvailableBalance,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const provisionFXAccount = `-- name: ProvisionFXAccount :execrows
+WITH created AS (
+    INSERT INTO accounts (owner, balance, currency)
+        SELECT 'fx', 0, $1::varchar
+        WHERE NOT EXISTS (SELECT 1 FROM fx_accounts WHERE fx_accounts.currency = $1)
+        RETURNING id, currency)
+INSERT
+INTO fx_accounts (currency, account_id)
+SELECT currency, id
+FROM created
+`
+
+// creates the fx account of a currency that has none yet, so enabling a currency needs no migration
+func (q *Queries) ProvisionFXAccount(ctx context.Context, currency string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, provisionFXAccount, currency)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const provisionRevenueAccount = `-- name: ProvisionRevenueAccount :execrows
+WITH created AS (
+    INSERT INTO accounts (owner, balance, currency)
+        SELECT 'revenue', 0, $1::varchar
+        WHERE NOT EXISTS (SELECT 1 FROM revenue_accounts WHERE revenue_accounts.currency = $1)
+        RETURNING id, currency)
+INSERT
+INTO revenue_accounts (currency, account_id)
+SELECT currency, id
+FROM created
+`
+
+// creates the revenue account of a currency that has none yet, so enabling a currency needs no migration
+func (q *Queries) ProvisionRevenueAccount(ctx context.Context, currency string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, provisionRevenueAccount, currency)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const provisionSettlementAccount = `-- name: ProvisionSettlementAccount :execrows
+WITH created AS (
+    INSERT INTO accounts (owner, balance, currency)
+        SELECT 'system', 0, $1::varchar
+        WHERE NOT EXISTS (SELECT 1 FROM settlement_accounts WHERE settlement_accounts.currency = $1)
+        RETURNING id, currency)
+INSERT
+INTO settlement_accounts (currency, account_id)
+SELECT currency, id
+FROM created
+`
+
+// creates the settlement account of a currency that has none yet, so enabling a currency needs no migration
+func (q *Queries) ProvisionSettlementAccount(ctx context.Context, currency string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, provisionSettlementAccount, currency)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const updateAccount = `-- name: UpdateAccount :one
 UPDATE accounts
-set balance = balance + $2
-WHERE id = $1 RETURNING id, owner, balance, currency, created_at
+SET nickname        = COALESCE($1, nickname),
+    account_type    = COALESCE($2, account_type),
+    overdraft_limit = COALESCE($3, overdraft_limit),
+    version         = version + 1
+WHERE id = $4
+  AND version = $5
+RETURNING id, balance, currency, created_at, owner, overdraft_limit, status, nickname, account_type, version, held_amount, available_balance
 `
 
 type UpdateAccountParams struct {
-	ID      int64 `json:"id"`
-	Balance int64 `json:"balance"`
+	Nickname       sql.NullString  `json:"nickname"`
+	AccountType    NullAccountType `json:"account_type"`
+	OverdraftLimit sql.NullInt64   `json:"overdraft_limit"`
+	ID             int64           `json:"id"`
+	Version        int64           `json:"version"`
 }
 
+// updates the non-monetary fields that are not null, if the account is still at the given version
 func (q *Queries) UpdateAccount(ctx context.Context, arg UpdateAccountParams) (Account, error) {
-	row := q.db.QueryRowContext(ctx, updateAccount, arg.ID, arg.Balance)
+	row := q.db.QueryRowContext(ctx, updateAccount,
+		arg.Nickname,
+		arg.AccountType,
+		arg.OverdraftLimit,
+		arg.ID,
+		arg.Version,
+	)
 	var i Account
 	err := row.Scan(
 		&i.ID,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
 		&i.Owner,
+		&i.OverdraftLimit,
+		&i.Status,
+		&i.Nickname,
+		&i.AccountType,
+		&i.Version,
+		&i.HeldAmount,
+		&i.AvailableBalance,
+	)
+	return i, err
+}
+
+const updateAccountStatus = `-- name: UpdateAccountStatus :one
+UPDATE accounts
+SET status  = $1,
+    version = version + 1
+WHERE id = $2
+  AND status = $3
+RETURNING id, balance, currency, created_at, owner, overdraft_limit, status, nickname, account_type, version, held_amount, available_balance
+`
+
+type UpdateAccountStatusParams struct {
+	Status     AccountStatus `json:"status"`
+	ID         int64         `json:"id"`
+	FromStatus AccountStatus `json:"from_status"`
+}
+
+// moves the account to status only if it is still in from_status, so concurrent changes cannot be lost
+func (q *Queries) UpdateAccountStatus(ctx context.Context, arg UpdateAccountStatusParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, updateAccountStatus, arg.Status, arg.ID, arg.FromStatus)
+	var i Account
+	err := row.Scan(
+		&i.ID,
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.Owner,
+		&i.OverdraftLimit,
+		&i.Status,
+		&i.Nickname,
+		&i.AccountType,
+		&i.Version,
+		&i.HeldAmount,
+		&i.AvailableBalance,
 	)
 	return i, err
 }
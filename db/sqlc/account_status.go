@@ -0,0 +1,98 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+var (
+	// ErrAccountNotActive is returned when money would move in or out of a frozen or closed account
+	ErrAccountNotActive = errors.New("account is not active")
+	// ErrInvalidStatusTransition is returned when an account cannot move to the requested status
+	ErrInvalidStatusTransition = errors.New("invalid account status transition")
+	// ErrAccountBalanceNotZero is returned when closing an account that still holds or owes money
+	ErrAccountBalanceNotZero = errors.New("account balance is not zero")
+)
+
+// accountStatusTransitions lists the statuses each status may move to; closed is final
+var accountStatusTransitions = map[AccountStatus][]AccountStatus{
+	AccountStatusActive: {AccountStatusFrozen, AccountStatusClosed},
+	AccountStatusFrozen: {AccountStatusActive},
+}
+
+// ChangeAccountStatusTxParams contains the input parameters of the account status transaction
+type ChangeAccountStatusTxParams struct {
+	AccountID int64         `json:"account_id"`
+	Status    AccountStatus `json:"status"`
+}
+
+// ChangeAccountStatusTx freezes, unfreezes or closes an account. Only accounts with a zero balance can be closed.
+func (store *SQLStore) ChangeAccountStatusTx(ctx context.Context, arg ChangeAccountStatusTxParams) (Account, error) {
+	var account Account
+
+	err := store.execTx(
+		ctx,
+		func(q *Queries) error {
+			var err error
+
+			// locks the row until the status is updated
+			account, err = q.GetAccount(
+				ctx,
+				arg.AccountID,
+			)
+			if err != nil {
+				return err
+			}
+
+			if !slices.Contains(
+				accountStatusTransitions[account.Status],
+				arg.Status,
+			) {
+				return fmt.Errorf(
+					"account [%d] cannot go from %s to %s: %w",
+					account.ID,
+					account.Status,
+					arg.Status,
+					ErrInvalidStatusTransition,
+				)
+			}
+
+			if arg.Status == AccountStatusClosed && (account.Balance != 0 || account.HeldAmount != 0) {
+				return fmt.Errorf(
+					"account [%d] has balance %d with %d held: %w",
+					account.ID,
+					account.Balance,
+					account.HeldAmount,
+					ErrAccountBalanceNotZero,
+				)
+			}
+
+			account, err = q.UpdateAccountStatus(
+				ctx,
+				UpdateAccountStatusParams{
+					Status:     arg.Status,
+					ID:         account.ID,
+					FromStatus: account.Status,
+				},
+			)
+			return err
+		},
+	)
+
+	return account, err
+}
+
+// checkAccountActive checks that money may move in or out of the account
+func checkAccountActive(account Account) error {
+	if account.Status != AccountStatusActive {
+		return fmt.Errorf(
+			"account [%d] is %s: %w",
+			account.ID,
+			account.Status,
+			ErrAccountNotActive,
+		)
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"database/sql"
 	"testing"
 	"time"
 
@@ -11,8 +12,10 @@ import (
 
 // createRandomAccount is a helper function to create a random account
 func createRandomAccount(t *testing.T) Account {
+	user := createRandomUser(t)
+
 	arg := CreateAccountParams{
-		Owner:    util.RandomOwner(),
+		Owner:    user.Username,
 		Balance:  util.RandomMoney(),
 		Currency: util.RandomCurrency(),
 	}
@@ -105,16 +108,23 @@ func TestGetAccount(t *testing.T) {
 	)
 }
 
-// TestUpdateAccount tests updating an account
+// TestUpdateAccount tests updating the metadata of an account
 func TestUpdateAccount(t *testing.T) {
 	account1 := createRandomAccount(t)
 
 	arg := UpdateAccountParams{
 		ID:      account1.ID,
-		Balance: util.RandomMoney(),
+		Version: account1.Version,
+		Nickname: sql.NullString{
+			String: util.RandomOwner(),
+			Valid:  true,
+		},
+		AccountType: NullAccountType{
+			AccountType: AccountTypeSavings,
+			Valid:       true,
+		},
 	}
 
-	// Update the account balance
 	account2, err := testQueries.UpdateAccount(
 		context.Background(),
 		arg,
@@ -123,63 +133,55 @@ func TestUpdateAccount(t *testing.T) {
 		t,
 		err,
 	)
-	require.NotEmpty(
-		t,
-		account2,
-	)
-
-	// Fetch the updated account to verify the changes
-	account3, err := testQueries.GetAccount(
-		context.Background(),
-		account1.ID,
-	)
-	require.NoError(
-		t,
-		err,
-	)
-	require.NotEmpty(
+	require.Equal(
 		t,
-		account3,
+		arg.Nickname.String,
+		account2.Nickname,
 	)
-
-	expectedBalance := account1.Balance + arg.Balance
-
 	require.Equal(
 		t,
-		account1.ID,
-		account3.ID,
+		AccountTypeSavings,
+		account2.AccountType,
 	)
 	require.Equal(
 		t,
-		account1.Owner,
-		account3.Owner,
+		account1.Version+1,
+		account2.Version,
 	)
+	// fields that were not set and the balance stay untouched
 	require.Equal(
 		t,
-		expectedBalance,
-		account3.Balance,
+		account1.Balance,
+		account2.Balance,
 	)
 	require.Equal(
 		t,
-		account1.Currency,
-		account3.Currency,
+		account1.OverdraftLimit,
+		account2.OverdraftLimit,
 	)
-	require.WithinDuration(
+
+	// the old version no longer matches
+	_, err = testQueries.UpdateAccount(
+		context.Background(),
+		arg,
+	)
+	require.ErrorIs(
 		t,
-		account1.CreatedAt,
-		account3.CreatedAt,
-		time.Second,
+		err,
+		sql.ErrNoRows,
 	)
 }
 
 func TestListAccounts(t *testing.T) {
+	var lastAccount Account
 	for i := 0; i < 10; i++ {
-		createRandomAccount(t)
+		lastAccount = createRandomAccount(t)
 	}
 
 	arg := ListAccountsParams{
+		Owner:  lastAccount.Owner,
 		Limit:  5,
-		Offset: 5,
+		Offset: 0,
 	}
 
 	accounts, err := testQueries.ListAccounts(
@@ -190,10 +192,9 @@ func TestListAccounts(t *testing.T) {
 		t,
 		err,
 	)
-	require.Len(
+	require.NotEmpty(
 		t,
 		accounts,
-		5,
 	)
 
 	for _, account := range accounts {
@@ -201,31 +202,190 @@ func TestListAccounts(t *testing.T) {
 			t,
 			account,
 		)
+		require.Equal(
+			t,
+			lastAccount.Owner,
+			account.Owner,
+		)
 	}
 }
 
-// TestDeleteAccount tests deleting an account
-func TestDeleteAccount(t *testing.T) {
-	account1 := createRandomAccount(t)
-	err := testQueries.DeleteAccount(
+func TestListAccountsKeyset(t *testing.T) {
+	user := createRandomUser(t)
+	var accounts []Account
+	for _, currency := range []string{util.USD, util.EUR, util.CAD} {
+		account, err := testQueries.CreateAccount(
+			context.Background(),
+			CreateAccountParams{
+				Owner:    user.Username,
+				Balance:  util.RandomMoney(),
+				Currency: currency,
+			},
+		)
+		require.NoError(
+			t,
+			err,
+		)
+		accounts = append(
+			accounts,
+			account,
+		)
+	}
+
+	after, err := testQueries.ListAccountsAfter(
 		context.Background(),
-		account1.ID,
+		ListAccountsAfterParams{
+			Owner:           user.Username,
+			CursorCreatedAt: accounts[0].CreatedAt,
+			CursorID:        accounts[0].ID,
+			Limit:           5,
+		},
 	)
 	require.NoError(
 		t,
 		err,
 	)
+	require.Equal(
+		t,
+		accounts[1:],
+		after,
+	)
 
-	account2, err := testQueries.GetAccount(
+	before, err := testQueries.ListAccountsBefore(
 		context.Background(),
-		account1.ID,
+		ListAccountsBeforeParams{
+			Owner:           user.Username,
+			CursorCreatedAt: accounts[2].CreatedAt,
+			CursorID:        accounts[2].ID,
+			Limit:           5,
+		},
 	)
-	require.Error(
+	require.NoError(
 		t,
 		err,
 	)
-	require.Empty(
+	// closest first
+	require.Equal(
 		t,
-		account2,
+		[]Account{accounts[1], accounts[0]},
+		before,
+	)
+}
+
+// TestChangeAccountStatusTx tests freezing, unfreezing and closing an account
+func TestChangeAccountStatusTx(t *testing.T) {
+	store := NewStore(testDB)
+	account1 := createAccountWithBalance(
+		t,
+		10,
+		0,
+	)
+	account2 := createAccountWithBalance(
+		t,
+		0,
+		0,
+	)
+
+	changeStatus := func(status AccountStatus) (Account, error) {
+		return store.ChangeAccountStatusTx(
+			context.Background(),
+			ChangeAccountStatusTxParams{
+				AccountID: account1.ID,
+				Status:    status,
+			},
+		)
+	}
+	transfer := func() error {
+		_, err := store.TransferTx(
+			context.Background(),
+			TransferTxParams{
+				FromAccountID: account1.ID,
+				ToAccountID:   account2.ID,
+				Amount:        account1.Balance,
+			},
+		)
+		return err
+	}
+
+	account, err := changeStatus(AccountStatusFrozen)
+	require.NoError(
+		t,
+		err,
+	)
+	require.Equal(
+		t,
+		AccountStatusFrozen,
+		account.Status,
+	)
+
+	// a frozen account can neither send money nor be closed
+	require.ErrorIs(
+		t,
+		transfer(),
+		ErrAccountNotActive,
+	)
+	_, err = changeStatus(AccountStatusClosed)
+	require.ErrorIs(
+		t,
+		err,
+		ErrInvalidStatusTransition,
+	)
+
+	account, err = changeStatus(AccountStatusActive)
+	require.NoError(
+		t,
+		err,
+	)
+	require.Equal(
+		t,
+		AccountStatusActive,
+		account.Status,
+	)
+
+	_, err = changeStatus(AccountStatusClosed)
+	require.ErrorIs(
+		t,
+		err,
+		ErrAccountBalanceNotZero,
+	)
+
+	require.NoError(
+		t,
+		transfer(),
+	)
+	account, err = changeStatus(AccountStatusClosed)
+	require.NoError(
+		t,
+		err,
+	)
+	require.Equal(
+		t,
+		AccountStatusClosed,
+		account.Status,
+	)
+
+	// closing is final and keeps the ledger
+	_, err = changeStatus(AccountStatusActive)
+	require.ErrorIs(
+		t,
+		err,
+		ErrInvalidStatusTransition,
+	)
+	entries, err := testQueries.ListEntry(
+		context.Background(),
+		ListEntryParams{
+			AccountID: account1.ID,
+			Limit:     5,
+			Offset:    0,
+		},
+	)
+	require.NoError(
+		t,
+		err,
+	)
+	require.Len(
+		t,
+		entries,
+		1,
 	)
 }
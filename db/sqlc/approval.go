@@ -0,0 +1,77 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// ErrTransferRequestUnavailable is returned when a transfer request was reviewed already or expired
+var ErrTransferRequestUnavailable = errors.New("transfer request was already reviewed or has expired")
+
+// TransferApprovalParams identifies the transfer request a transfer executes and the banker who approved it
+type TransferApprovalParams struct {
+	RequestID  int64
+	ReviewedBy string
+}
+
+// useApproval marks the request as approved by the transfer, so it cannot be executed twice
+func useApproval(ctx context.Context, q *Queries, approval TransferApprovalParams, transferID int64) error {
+	_, err := q.ApproveTransferRequest(
+		ctx,
+		ApproveTransferRequestParams{
+			ReviewedBy: sql.NullString{
+				String: approval.ReviewedBy,
+				Valid:  true,
+			},
+			TransferID: transferID,
+			ID:         approval.RequestID,
+		},
+	)
+	if errors.Is(
+		err,
+		sql.ErrNoRows,
+	) {
+		return ErrTransferRequestUnavailable
+	}
+	return err
+}
+
+// CreateTransferRequestTxParams contains the input parameters of the transfer request transaction
+type CreateTransferRequestTxParams struct {
+	CreateTransferRequestParams
+	// IdempotencyKey is stored against the request when set, so a retry finds it instead of making another one
+	IdempotencyKey *IdempotencyKeyParams `json:"-"`
+}
+
+// CreateTransferRequestTx stores a transfer for review together with its idempotency key
+func (store *SQLStore) CreateTransferRequestTx(ctx context.Context, arg CreateTransferRequestTxParams) (TransferRequest, error) {
+	var request TransferRequest
+
+	err := store.execTx(
+		ctx,
+		func(q *Queries) error {
+			var err error
+			request, err = q.CreateTransferRequest(
+				ctx,
+				arg.CreateTransferRequestParams,
+			)
+			if err != nil || arg.IdempotencyKey == nil {
+				return err
+			}
+
+			return storeIdempotencyKey(
+				ctx,
+				q,
+				*arg.IdempotencyKey,
+				request,
+				sql.NullInt64{
+					Int64: request.ID,
+					Valid: true,
+				},
+			)
+		},
+	)
+
+	return request, err
+}
@@ -0,0 +1,243 @@
+package db
+
+import (
+	"context"
+	"github.com/PFefe/simplebank/util"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func createPendingTransferRequest(t *testing.T, fromAccount Account, toAccount Account, amount int64, expiresAt time.Time) TransferRequest {
+	request, err := testQueries.CreateTransferRequest(
+		context.Background(),
+		CreateTransferRequestParams{
+			RequestedBy:   fromAccount.Owner,
+			FromAccountID: fromAccount.ID,
+			ToAccountID:   toAccount.ID,
+			Amount:        amount,
+			Currency:      fromAccount.Currency,
+			ExpiresAt:     expiresAt,
+		},
+	)
+	require.NoError(
+		t,
+		err,
+	)
+	require.Equal(
+		t,
+		TransferRequestStatusPendingApproval,
+		request.Status,
+	)
+	return request
+}
+
+func TestTransferTxApproval(t *testing.T) {
+	store := NewStore(testDB)
+	fromAccount := createAccountWithBalance(
+		t,
+		1000,
+		0,
+	)
+	toAccount := createRandomAccount(t)
+
+	request := createPendingTransferRequest(
+		t,
+		fromAccount,
+		toAccount,
+		100,
+		time.Now().Add(time.Hour),
+	)
+
+	arg := TransferTxParams{
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        request.Amount,
+		Approval: &TransferApprovalParams{
+			RequestID:  request.ID,
+			ReviewedBy: toAccount.Owner,
+		},
+	}
+	result, err := store.TransferTx(
+		context.Background(),
+		arg,
+	)
+	require.NoError(
+		t,
+		err,
+	)
+
+	request, err = testQueries.GetTransferRequest(
+		context.Background(),
+		request.ID,
+	)
+	require.NoError(
+		t,
+		err,
+	)
+	require.Equal(
+		t,
+		TransferRequestStatusApproved,
+		request.Status,
+	)
+	require.Equal(
+		t,
+		toAccount.Owner,
+		request.ReviewedBy.String,
+	)
+	require.Equal(
+		t,
+		result.Transfer.ID,
+		request.TransferID.Int64,
+	)
+
+	// a second approval is rolled back together with its transfer
+	_, err = store.TransferTx(
+		context.Background(),
+		arg,
+	)
+	require.ErrorIs(
+		t,
+		err,
+		ErrTransferRequestUnavailable,
+	)
+
+	account, err := testQueries.GetAccount(
+		context.Background(),
+		fromAccount.ID,
+	)
+	require.NoError(
+		t,
+		err,
+	)
+	require.Equal(
+		t,
+		result.FromAccount.Balance,
+		account.Balance,
+	)
+}
+
+func TestExpireTransferRequests(t *testing.T) {
+	store := NewStore(testDB)
+	fromAccount := createAccountWithBalance(
+		t,
+		1000,
+		0,
+	)
+	toAccount := createRandomAccount(t)
+
+	request := createPendingTransferRequest(
+		t,
+		fromAccount,
+		toAccount,
+		100,
+		time.Now().Add(-time.Minute),
+	)
+
+	// an expired request cannot be approved even before it is marked as expired
+	_, err := store.TransferTx(
+		context.Background(),
+		TransferTxParams{
+			FromAccountID: fromAccount.ID,
+			ToAccountID:   toAccount.ID,
+			Amount:        request.Amount,
+			Approval: &TransferApprovalParams{
+				RequestID:  request.ID,
+				ReviewedBy: toAccount.Owner,
+			},
+		},
+	)
+	require.ErrorIs(
+		t,
+		err,
+		ErrTransferRequestUnavailable,
+	)
+
+	expired, err := testQueries.ExpireTransferRequests(context.Background())
+	require.NoError(
+		t,
+		err,
+	)
+	require.GreaterOrEqual(
+		t,
+		expired,
+		int64(1),
+	)
+
+	request, err = testQueries.GetTransferRequest(
+		context.Background(),
+		request.ID,
+	)
+	require.NoError(
+		t,
+		err,
+	)
+	require.Equal(
+		t,
+		TransferRequestStatusExpired,
+		request.Status,
+	)
+	require.False(
+		t,
+		request.ReviewedBy.Valid,
+	)
+}
+
+func TestCreateTransferRequestTxIdempotencyKey(t *testing.T) {
+	store := NewStore(testDB)
+	fromAccount := createRandomAccount(t)
+	toAccount := createRandomAccount(t)
+
+	arg := CreateTransferRequestTxParams{
+		CreateTransferRequestParams: CreateTransferRequestParams{
+			RequestedBy:   fromAccount.Owner,
+			FromAccountID: fromAccount.ID,
+			ToAccountID:   toAccount.ID,
+			Amount:        100,
+			Currency:      fromAccount.Currency,
+			ExpiresAt:     time.Now().Add(time.Hour),
+		},
+		IdempotencyKey: &IdempotencyKeyParams{
+			Username:    fromAccount.Owner,
+			Key:         util.RandomString(16),
+			RequestHash: util.RandomString(32),
+			ExpiresAt:   time.Now().Add(time.Hour),
+		},
+	}
+	request, err := store.CreateTransferRequestTx(
+		context.Background(),
+		arg,
+	)
+	require.NoError(
+		t,
+		err,
+	)
+
+	stored, err := testQueries.GetIdempotencyKey(
+		context.Background(),
+		GetIdempotencyKeyParams{
+			Username: fromAccount.Owner,
+			Key:      arg.IdempotencyKey.Key,
+		},
+	)
+	require.NoError(
+		t,
+		err,
+	)
+	require.Equal(
+		t,
+		request.ID,
+		stored.TransferRequestID.Int64,
+	)
+
+	// the retry must not leave a second request behind
+	_, err = store.CreateTransferRequestTx(
+		context.Background(),
+		arg,
+	)
+	require.ErrorIs(
+		t,
+		err,
+		ErrIdempotencyKeyInUse,
+	)
+}
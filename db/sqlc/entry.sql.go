@@ -7,6 +7,8 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"time"
 )
 
 const createEntry = `-- name: CreateEntry :one
@@ -51,6 +53,298 @@ func (q *Queries) GetEntry(ctx context.Context, id int64) (Entry, error) {
 	return i, err
 }
 
+const getEntryWithBalance = `-- name: GetEntryWithBalance :one
+SELECT entries.id,
+       entries.account_id,
+       entries.amount,
+       entries.created_at,
+       (accounts.balance - COALESCE((SELECT SUM(later.amount)
+                                     FROM entries AS later
+                                     WHERE later.account_id = entries.account_id
+                                       AND (later.created_at, later.id) > (entries.created_at, entries.id)), 0))::bigint AS running_balance
+FROM entries
+         JOIN accounts ON accounts.id = entries.account_id
+WHERE entries.id = $1
+LIMIT 1
+`
+
+type GetEntryWithBalanceRow struct {
+	ID             int64     `json:"id"`
+	AccountID      int64     `json:"account_id"`
+	Amount         int64     `json:"amount"`
+	CreatedAt      time.Time `json:"created_at"`
+	RunningBalance int64     `json:"running_balance"`
+}
+
+// running_balance is the account balance right after the entry was applied, taking entries in (created_at, id) order
+func (q *Queries) GetEntryWithBalance(ctx context.Context, id int64) (GetEntryWithBalanceRow, error) {
+	row := q.db.QueryRowContext(ctx, getEntryWithBalance, id)
+	var i GetEntryWithBalanceRow
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.Amount,
+		&i.CreatedAt,
+		&i.RunningBalance,
+	)
+	return i, err
+}
+
+const listAccountEntries = `-- name: ListAccountEntries :many
+WITH page AS (SELECT entries.id, entries.account_id, entries.amount, entries.created_at
+              FROM entries
+              WHERE entries.account_id = $1
+                AND ($2::timestamptz IS NULL OR entries.created_at >= $2)
+                AND ($3::timestamptz IS NULL OR entries.created_at < $3)
+              ORDER BY entries.created_at, entries.id
+              LIMIT $5 OFFSET $4),
+     newest AS (SELECT page.created_at, page.id
+                FROM page
+                ORDER BY page.created_at DESC, page.id DESC
+                LIMIT 1),
+     later AS (SELECT COALESCE(SUM(entries.amount), 0)::bigint AS amount
+               FROM entries,
+                    newest
+               WHERE entries.account_id = $1
+                 AND (entries.created_at, entries.id) > (newest.created_at, newest.id))
+SELECT page.id,
+       page.account_id,
+       page.amount,
+       page.created_at,
+       (accounts.balance - later.amount - COALESCE(SUM(page.amount) OVER (
+           ORDER BY page.created_at DESC, page.id DESC
+           ROWS BETWEEN UNBOUNDED PRECEDING AND 1 PRECEDING
+           ), 0))::bigint AS running_balance
+FROM page
+         JOIN accounts ON accounts.id = page.account_id
+         CROSS JOIN later
+ORDER BY page.created_at, page.id
+`
+
+type ListAccountEntriesParams struct {
+	AccountID int64        `json:"account_id"`
+	FromTime  sql.NullTime `json:"from_time"`
+	ToTime    sql.NullTime `json:"to_time"`
+	Offset    int32        `json:"offset"`
+	Limit     int32        `json:"limit"`
+}
+
+type ListAccountEntriesRow struct {
+	ID             int64     `json:"id"`
+	AccountID      int64     `json:"account_id"`
+	Amount         int64     `json:"amount"`
+	CreatedAt      time.Time `json:"created_at"`
+	RunningBalance int64     `json:"running_balance"`
+}
+
+// the page is read first, so the running balances only sum the entries from the page on, not the whole history
+func (q *Queries) ListAccountEntries(ctx context.Context, arg ListAccountEntriesParams) ([]ListAccountEntriesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listAccountEntries,
+		arg.AccountID,
+		arg.FromTime,
+		arg.ToTime,
+		arg.Offset,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListAccountEntriesRow{}
+	for rows.Next() {
+		var i ListAccountEntriesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.RunningBalance,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAccountEntriesAfter = `-- name: ListAccountEntriesAfter :many
+WITH page AS (SELECT entries.id, entries.account_id, entries.amount, entries.created_at
+              FROM entries
+              WHERE entries.account_id = $1
+                AND ($2::timestamptz IS NULL OR entries.created_at >= $2)
+                AND ($3::timestamptz IS NULL OR entries.created_at < $3)
+                AND (entries.created_at, entries.id) > ($4::timestamptz, $5::bigint)
+              ORDER BY entries.created_at, entries.id
+              LIMIT $6),
+     newest AS (SELECT page.created_at, page.id
+                FROM page
+                ORDER BY page.created_at DESC, page.id DESC
+                LIMIT 1),
+     later AS (SELECT COALESCE(SUM(entries.amount), 0)::bigint AS amount
+               FROM entries,
+                    newest
+               WHERE entries.account_id = $1
+                 AND (entries.created_at, entries.id) > (newest.created_at, newest.id))
+SELECT page.id,
+       page.account_id,
+       page.amount,
+       page.created_at,
+       (accounts.balance - later.amount - COALESCE(SUM(page.amount) OVER (
+           ORDER BY page.created_at DESC, page.id DESC
+           ROWS BETWEEN UNBOUNDED PRECEDING AND 1 PRECEDING
+           ), 0))::bigint AS running_balance
+FROM page
+         JOIN accounts ON accounts.id = page.account_id
+         CROSS JOIN later
+ORDER BY page.created_at, page.id
+`
+
+type ListAccountEntriesAfterParams struct {
+	AccountID       int64        `json:"account_id"`
+	FromTime        sql.NullTime `json:"from_time"`
+	ToTime          sql.NullTime `json:"to_time"`
+	CursorCreatedAt time.Time    `json:"cursor_created_at"`
+	CursorID        int64        `json:"cursor_id"`
+	Limit           int32        `json:"limit"`
+}
+
+type ListAccountEntriesAfterRow struct {
+	ID             int64     `json:"id"`
+	AccountID      int64     `json:"account_id"`
+	Amount         int64     `json:"amount"`
+	CreatedAt      time.Time `json:"created_at"`
+	RunningBalance int64     `json:"running_balance"`
+}
+
+// keyset page of the entries that come after the cursor
+func (q *Queries) ListAccountEntriesAfter(ctx context.Context, arg ListAccountEntriesAfterParams) ([]ListAccountEntriesAfterRow, error) {
+	rows, err := q.db.QueryContext(ctx, listAccountEntriesAfter,
+		arg.AccountID,
+		arg.FromTime,
+		arg.ToTime,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListAccountEntriesAfterRow{}
+	for rows.Next() {
+		var i ListAccountEntriesAfterRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.RunningBalance,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAccountEntriesBefore = `-- name: ListAccountEntriesBefore :many
+WITH page AS (SELECT entries.id, entries.account_id, entries.amount, entries.created_at
+              FROM entries
+              WHERE entries.account_id = $1
+                AND ($2::timestamptz IS NULL OR entries.created_at >= $2)
+                AND ($3::timestamptz IS NULL OR entries.created_at < $3)
+                AND (entries.created_at, entries.id) < ($4::timestamptz, $5::bigint)
+              ORDER BY entries.created_at DESC, entries.id DESC
+              LIMIT $6),
+     newest AS (SELECT page.created_at, page.id
+                FROM page
+                ORDER BY page.created_at DESC, page.id DESC
+                LIMIT 1),
+     later AS (SELECT COALESCE(SUM(entries.amount), 0)::bigint AS amount
+               FROM entries,
+                    newest
+               WHERE entries.account_id = $1
+                 AND (entries.created_at, entries.id) > (newest.created_at, newest.id))
+SELECT page.id,
+       page.account_id,
+       page.amount,
+       page.created_at,
+       (accounts.balance - later.amount - COALESCE(SUM(page.amount) OVER (
+           ORDER BY page.created_at DESC, page.id DESC
+           ROWS BETWEEN UNBOUNDED PRECEDING AND 1 PRECEDING
+           ), 0))::bigint AS running_balance
+FROM page
+         JOIN accounts ON accounts.id = page.account_id
+         CROSS JOIN later
+ORDER BY page.created_at DESC, page.id DESC
+`
+
+type ListAccountEntriesBeforeParams struct {
+	AccountID       int64        `json:"account_id"`
+	FromTime        sql.NullTime `json:"from_time"`
+	ToTime          sql.NullTime `json:"to_time"`
+	CursorCreatedAt time.Time    `json:"cursor_created_at"`
+	CursorID        int64        `json:"cursor_id"`
+	Limit           int32        `json:"limit"`
+}
+
+type ListAccountEntriesBeforeRow struct {
+	ID             int64     `json:"id"`
+	AccountID      int64     `json:"account_id"`
+	Amount         int64     `json:"amount"`
+	CreatedAt      time.Time `json:"created_at"`
+	RunningBalance int64     `json:"running_balance"`
+}
+
+// keyset page of the entries that come before the cursor, closest first
+func (q *Queries) ListAccountEntriesBefore(ctx context.Context, arg ListAccountEntriesBeforeParams) ([]ListAccountEntriesBeforeRow, error) {
+	rows, err := q.db.QueryContext(ctx, listAccountEntriesBefore,
+		arg.AccountID,
+		arg.FromTime,
+		arg.ToTime,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListAccountEntriesBeforeRow{}
+	for rows.Next() {
+		var i ListAccountEntriesBeforeRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.RunningBalance,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listEntry = `-- name: ListEntry :many
 SELECT id, account_id, amount, created_at
 FROM entries
@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"database/sql"
 	"github.com/PFefe/simplebank/util"
 	"github.com/stretchr/testify/require"
 	"testing"
@@ -128,3 +129,106 @@ func TestListEntries(t *testing.T) {
 		)
 	}
 }
+
+func TestListAccountEntries(t *testing.T) {
+	store := NewStore(testDB)
+	account1 := createAccountWithBalance(
+		t,
+		100,
+		0,
+	)
+	account2 := createAccountWithBalance(
+		t,
+		0,
+		0,
+	)
+
+	n := 3
+	amount := int64(10)
+	for i := 0; i < n; i++ {
+		_, err := store.TransferTx(
+			context.Background(),
+			TransferTxParams{
+				FromAccountID: account1.ID,
+				ToAccountID:   account2.ID,
+				Amount:        amount,
+			},
+		)
+		require.NoError(
+			t,
+			err,
+		)
+	}
+
+	entries, err := testQueries.ListAccountEntries(
+		context.Background(),
+		ListAccountEntriesParams{
+			AccountID: account1.ID,
+			Limit:     5,
+			Offset:    0,
+		},
+	)
+	require.NoError(
+		t,
+		err,
+	)
+	require.Len(
+		t,
+		entries,
+		n,
+	)
+	for i, entry := range entries {
+		require.Equal(
+			t,
+			account1.ID,
+			entry.AccountID,
+		)
+		require.Equal(
+			t,
+			-amount,
+			entry.Amount,
+		)
+		// the running balance is the balance right after the entry
+		require.Equal(
+			t,
+			account1.Balance-int64(i+1)*amount,
+			entry.RunningBalance,
+		)
+
+		entryWithBalance, err := testQueries.GetEntryWithBalance(
+			context.Background(),
+			entry.ID,
+		)
+		require.NoError(
+			t,
+			err,
+		)
+		require.Equal(
+			t,
+			entry.RunningBalance,
+			entryWithBalance.RunningBalance,
+		)
+	}
+
+	// the time range filters entries without changing their running balance
+	entries, err = testQueries.ListAccountEntries(
+		context.Background(),
+		ListAccountEntriesParams{
+			AccountID: account1.ID,
+			FromTime: sql.NullTime{
+				Time:  time.Now().Add(time.Minute),
+				Valid: true,
+			},
+			Limit:  5,
+			Offset: 0,
+		},
+	)
+	require.NoError(
+		t,
+		err,
+	)
+	require.Empty(
+		t,
+		entries,
+	)
+}
@@ -0,0 +1,108 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+// Postgres error codes we classify, see https://www.postgresql.org/docs/current/errcodes-appendix.html
+const (
+	UniqueViolation      = "23505"
+	ForeignKeyViolation  = "23503"
+	CheckViolation       = "23514"
+	SerializationFailure = "40001"
+	DeadlockDetected     = "40P01"
+)
+
+// Classified store errors, matched with errors.Is on the result of ClassifyError
+var (
+	ErrRecordNotFound       = errors.New("record not found")
+	ErrUniqueViolation      = errors.New("unique violation")
+	ErrForeignKeyViolation  = errors.New("foreign key violation")
+	ErrCheckViolation       = errors.New("check violation")
+	ErrSerializationFailure = errors.New("serialization failure")
+)
+
+// Error is a store error tagged with its classification
+type Error struct {
+	Kind       error
+	Constraint string
+	Err        error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap exposes both the classification and the original error
+func (e *Error) Unwrap() []error {
+	return []error{e.Kind, e.Err}
+}
+
+// ClassifyError tags err with one of the classified store errors.
+// Errors that are already classified or not recognised are returned unchanged.
+func ClassifyError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var classified *Error
+	if errors.As(
+		err,
+		&classified,
+	) {
+		return err
+	}
+
+	if errors.Is(
+		err,
+		sql.ErrNoRows,
+	) {
+		return &Error{
+			Kind: ErrRecordNotFound,
+			Err:  err,
+		}
+	}
+
+	var pqErr *pq.Error
+	if !errors.As(
+		err,
+		&pqErr,
+	) {
+		return err
+	}
+
+	var kind error
+	switch pqErr.Code {
+	case UniqueViolation:
+		kind = ErrUniqueViolation
+	case ForeignKeyViolation:
+		kind = ErrForeignKeyViolation
+	case CheckViolation:
+		kind = ErrCheckViolation
+	case SerializationFailure, DeadlockDetected:
+		kind = ErrSerializationFailure
+	default:
+		return err
+	}
+
+	return &Error{
+		Kind:       kind,
+		Constraint: pqErr.Constraint,
+		Err:        err,
+	}
+}
+
+// ErrorCode returns the Postgres error code of err, or an empty string if it has none
+func ErrorCode(err error) string {
+	var pqErr *pq.Error
+	if errors.As(
+		err,
+		&pqErr,
+	) {
+		return string(pqErr.Code)
+	}
+	return ""
+}
@@ -0,0 +1,103 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifyError(t *testing.T) {
+	testCases := []struct {
+		name string
+		err  error
+		kind error
+	}{
+		{
+			name: "NotFound",
+			err:  sql.ErrNoRows,
+			kind: ErrRecordNotFound,
+		},
+		{
+			name: "WrappedNotFound",
+			err: fmt.Errorf(
+				"get account: %w",
+				sql.ErrNoRows,
+			),
+			kind: ErrRecordNotFound,
+		},
+		{
+			name: "UniqueViolation",
+			err:  &pq.Error{Code: UniqueViolation, Constraint: "accounts_owner_currency_key"},
+			kind: ErrUniqueViolation,
+		},
+		{
+			name: "ForeignKeyViolation",
+			err:  &pq.Error{Code: ForeignKeyViolation},
+			kind: ErrForeignKeyViolation,
+		},
+		{
+			name: "CheckViolation",
+			err:  &pq.Error{Code: CheckViolation},
+			kind: ErrCheckViolation,
+		},
+		{
+			name: "SerializationFailure",
+			err:  &pq.Error{Code: SerializationFailure},
+			kind: ErrSerializationFailure,
+		},
+		{
+			name: "DeadlockDetected",
+			err:  &pq.Error{Code: DeadlockDetected},
+			kind: ErrSerializationFailure,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(
+			tc.name,
+			func(t *testing.T) {
+				err := ClassifyError(tc.err)
+				require.ErrorIs(
+					t,
+					err,
+					tc.kind,
+				)
+				require.ErrorIs(
+					t,
+					err,
+					tc.err,
+				)
+			},
+		)
+	}
+}
+
+func TestClassifyErrorUnknown(t *testing.T) {
+	err := errors.New("connection reset")
+	require.Equal(
+		t,
+		err,
+		ClassifyError(err),
+	)
+	require.Nil(
+		t,
+		ClassifyError(nil),
+	)
+
+	pqErr := &pq.Error{Code: "08006"}
+	require.Equal(
+		t,
+		error(pqErr),
+		ClassifyError(pqErr),
+	)
+	require.Equal(
+		t,
+		"08006",
+		ErrorCode(pqErr),
+	)
+}
@@ -0,0 +1,145 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// transferFee is the fee charged on a transfer and the revenue account that collects it
+type transferFee struct {
+	Amount           int64
+	RevenueAccountID int64
+}
+
+// Fee returns what the tier charges for a transfer of the amount: the flat fee plus the percentage
+// of the amount rounded up to the minor unit, then held between the min and max fee
+func (s FeeSchedule) Fee(amount int64) (int64, error) {
+	percentage, ok := new(big.Rat).SetString(s.Percentage)
+	if !ok {
+		return 0, fmt.Errorf(
+			"invalid fee percentage: %q",
+			s.Percentage,
+		)
+	}
+
+	variable := percentage.Mul(
+		percentage,
+		new(big.Rat).SetInt64(amount),
+	)
+	quotient, remainder := new(big.Int).QuoRem(
+		variable.Num(),
+		variable.Denom(),
+		new(big.Int),
+	)
+	if remainder.Sign() > 0 {
+		quotient.Add(
+			quotient,
+			big.NewInt(1),
+		)
+	}
+
+	fee := s.FlatFee + quotient.Int64()
+	if fee < s.MinFee {
+		fee = s.MinFee
+	}
+	if s.MaxFee.Valid && fee > s.MaxFee.Int64 {
+		fee = s.MaxFee.Int64
+	}
+	return fee, nil
+}
+
+// ScheduledFee evaluates the fee schedule of the account's currency and type for the amount.
+// Without a schedule the transfer is free.
+func ScheduledFee(ctx context.Context, q Querier, account Account, amount int64) (int64, error) {
+	schedule, err := q.GetFeeSchedule(
+		ctx,
+		GetFeeScheduleParams{
+			Currency:    account.Currency,
+			AccountType: account.AccountType,
+			Amount:      amount,
+		},
+	)
+	if errors.Is(
+		err,
+		sql.ErrNoRows,
+	) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return schedule.Fee(amount)
+}
+
+// transferFeeFor returns the fee of a transfer and the revenue account that collects it:
+// the fee its quote locked in, or else the one of the schedule
+func transferFeeFor(ctx context.Context, q *Queries, arg TransferTxParams) (transferFee, error) {
+	var fee transferFee
+	var currency string
+
+	if arg.QuoteID != nil {
+		// the quoted fee is charged even if the schedule changed since; useQuote checks the quote is still usable
+		quote, err := q.GetTransferQuote(
+			ctx,
+			*arg.QuoteID,
+		)
+		if err != nil {
+			return fee, err
+		}
+		fee.Amount = quote.Fee
+		currency = quote.Currency
+	} else {
+		// the account is locked later with the others by addMoneyInOrder
+		account, err := q.GetAccountUnlocked(
+			ctx,
+			arg.FromAccountID,
+		)
+		if err != nil {
+			return fee, err
+		}
+
+		fee.Amount, err = ScheduledFee(
+			ctx,
+			q,
+			account,
+			arg.Amount,
+		)
+		if err != nil {
+			return fee, err
+		}
+		currency = account.Currency
+	}
+	if fee.Amount == 0 {
+		return fee, nil
+	}
+
+	revenue, err := q.GetRevenueAccount(
+		ctx,
+		currency,
+	)
+	if err != nil {
+		return fee, err
+	}
+	fee.RevenueAccountID = revenue.ID
+	return fee, nil
+}
+
+// legs returns the entries that move the fee from the source account to the revenue account
+func (fee transferFee) legs(fromAccountID int64) []CreateEntryParams {
+	if fee.Amount == 0 {
+		return nil
+	}
+	return []CreateEntryParams{
+		{
+			AccountID: fromAccountID,
+			Amount:    -fee.Amount,
+		},
+		{
+			AccountID: fee.RevenueAccountID,
+			Amount:    fee.Amount,
+		},
+	}
+}
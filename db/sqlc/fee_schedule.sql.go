@@ -0,0 +1,148 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.26.0
+// source: fee_schedule.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+)
+
+const deleteFeeSchedule = `-- name: DeleteFeeSchedule :exec
+DELETE
+FROM fee_schedules
+WHERE id = $1
+`
+
+func (q *Queries) DeleteFeeSchedule(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, deleteFeeSchedule, id)
+	return err
+}
+
+const getFeeSchedule = `-- name: GetFeeSchedule :one
+SELECT id, currency, account_type, min_amount, flat_fee, percentage, min_fee, max_fee, created_at
+FROM fee_schedules
+WHERE currency = $1
+  AND account_type = $2
+  AND min_amount <= $3::bigint
+ORDER BY min_amount DESC
+LIMIT 1
+`
+
+type GetFeeScheduleParams struct {
+	Currency    string      `json:"currency"`
+	AccountType AccountType `json:"account_type"`
+	Amount      int64       `json:"amount"`
+}
+
+// finds the tier that applies to an amount: the one with the highest lower bound the amount reaches
+func (q *Queries) GetFeeSchedule(ctx context.Context, arg GetFeeScheduleParams) (FeeSchedule, error) {
+	row := q.db.QueryRowContext(ctx, getFeeSchedule, arg.Currency, arg.AccountType, arg.Amount)
+	var i FeeSchedule
+	err := row.Scan(
+		&i.ID,
+		&i.Currency,
+		&i.AccountType,
+		&i.MinAmount,
+		&i.FlatFee,
+		&i.Percentage,
+		&i.MinFee,
+		&i.MaxFee,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listFeeSchedules = `-- name: ListFeeSchedules :many
+SELECT id, currency, account_type, min_amount, flat_fee, percentage, min_fee, max_fee, created_at
+FROM fee_schedules
+ORDER BY currency, account_type, min_amount
+`
+
+func (q *Queries) ListFeeSchedules(ctx context.Context) ([]FeeSchedule, error) {
+	rows, err := q.db.QueryContext(ctx, listFeeSchedules)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []FeeSchedule{}
+	for rows.Next() {
+		var i FeeSchedule
+		if err := rows.Scan(
+			&i.ID,
+			&i.Currency,
+			&i.AccountType,
+			&i.MinAmount,
+			&i.FlatFee,
+			&i.Percentage,
+			&i.MinFee,
+			&i.MaxFee,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const upsertFeeSchedule = `-- name: UpsertFeeSchedule :one
+INSERT INTO fee_schedules (currency,
+                           account_type,
+                           min_amount,
+                           flat_fee,
+                           percentage,
+                           min_fee,
+                           max_fee)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (currency, account_type, min_amount) DO UPDATE
+    SET flat_fee   = excluded.flat_fee,
+        percentage = excluded.percentage,
+        min_fee    = excluded.min_fee,
+        max_fee    = excluded.max_fee
+RETURNING id, currency, account_type, min_amount, flat_fee, percentage, min_fee, max_fee, created_at
+`
+
+type UpsertFeeScheduleParams struct {
+	Currency    string        `json:"currency"`
+	AccountType AccountType   `json:"account_type"`
+	MinAmount   int64         `json:"min_amount"`
+	FlatFee     int64         `json:"flat_fee"`
+	Percentage  string        `json:"percentage"`
+	MinFee      int64         `json:"min_fee"`
+	MaxFee      sql.NullInt64 `json:"max_fee"`
+}
+
+// creates the tier of a schedule, or replaces the tier with the same lower bound
+func (q *Queries) UpsertFeeSchedule(ctx context.Context, arg UpsertFeeScheduleParams) (FeeSchedule, error) {
+	row := q.db.QueryRowContext(ctx, upsertFeeSchedule,
+		arg.Currency,
+		arg.AccountType,
+		arg.MinAmount,
+		arg.FlatFee,
+		arg.Percentage,
+		arg.MinFee,
+		arg.MaxFee,
+	)
+	var i FeeSchedule
+	err := row.Scan(
+		&i.ID,
+		&i.Currency,
+		&i.AccountType,
+		&i.MinAmount,
+		&i.FlatFee,
+		&i.Percentage,
+		&i.MinFee,
+		&i.MaxFee,
+		&i.CreatedAt,
+	)
+	return i, err
+}
@@ -0,0 +1,293 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestFeeScheduleFee(t *testing.T) {
+	testCases := []struct {
+		name     string
+		schedule FeeSchedule
+		amount   int64
+		fee      int64
+	}{
+		{
+			name: "Flat",
+			schedule: FeeSchedule{
+				FlatFee:    25,
+				Percentage: "0",
+			},
+			amount: 10000,
+			fee:    25,
+		},
+		{
+			name: "PercentageRoundsUp",
+			schedule: FeeSchedule{
+				Percentage: "0.015",
+			},
+			amount: 1001,
+			fee:    16,
+		},
+		{
+			name: "FlatAndPercentage",
+			schedule: FeeSchedule{
+				FlatFee:    30,
+				Percentage: "0.01",
+			},
+			amount: 5000,
+			fee:    80,
+		},
+		{
+			name: "MinFee",
+			schedule: FeeSchedule{
+				Percentage: "0.01",
+				MinFee:     50,
+			},
+			amount: 100,
+			fee:    50,
+		},
+		{
+			name: "MaxFee",
+			schedule: FeeSchedule{
+				Percentage: "0.01",
+				MaxFee: sql.NullInt64{
+					Int64: 500,
+					Valid: true,
+				},
+			},
+			amount: 1000000,
+			fee:    500,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(
+			tc.name,
+			func(t *testing.T) {
+				fee, err := tc.schedule.Fee(tc.amount)
+				require.NoError(
+					t,
+					err,
+				)
+				require.Equal(
+					t,
+					tc.fee,
+					fee,
+				)
+			},
+		)
+	}
+}
+
+// createSavingsAccount creates an account of the savings type, so its fee schedule doesn't affect other tests
+func createSavingsAccount(t *testing.T, balance int64) Account {
+	account := createAccountWithBalance(
+		t,
+		balance,
+		0,
+	)
+	account, err := testQueries.UpdateAccount(
+		context.Background(),
+		UpdateAccountParams{
+			AccountType: NullAccountType{
+				AccountType: AccountTypeSavings,
+				Valid:       true,
+			},
+			ID:      account.ID,
+			Version: account.Version,
+		},
+	)
+	require.NoError(
+		t,
+		err,
+	)
+	return account
+}
+
+func TestTransferTxFee(t *testing.T) {
+	store := NewStore(testDB)
+	fromAccount := createSavingsAccount(
+		t,
+		10000,
+	)
+	toAccount := createRandomAccount(t)
+
+	// tiers: 1% from 0, and 0.5% capped at 20 from 5000
+	tiers := []UpsertFeeScheduleParams{
+		{
+			Currency:    fromAccount.Currency,
+			AccountType: AccountTypeSavings,
+			MinAmount:   0,
+			Percentage:  "0.01",
+		},
+		{
+			Currency:    fromAccount.Currency,
+			AccountType: AccountTypeSavings,
+			MinAmount:   5000,
+			Percentage:  "0.005",
+			MaxFee: sql.NullInt64{
+				Int64: 20,
+				Valid: true,
+			},
+		},
+	}
+	for _, tier := range tiers {
+		schedule, err := testQueries.UpsertFeeSchedule(
+			context.Background(),
+			tier,
+		)
+		require.NoError(
+			t,
+			err,
+		)
+		t.Cleanup(func() {
+			err := testQueries.DeleteFeeSchedule(
+				context.Background(),
+				schedule.ID,
+			)
+			require.NoError(
+				t,
+				err,
+			)
+		})
+	}
+
+	revenue, err := testQueries.GetRevenueAccount(
+		context.Background(),
+		fromAccount.Currency,
+	)
+	require.NoError(
+		t,
+		err,
+	)
+
+	testCases := []struct {
+		name   string
+		amount int64
+		fee    int64
+	}{
+		{
+			name:   "LowerTier",
+			amount: 1000,
+			fee:    10,
+		},
+		{
+			name:   "UpperTierCapped",
+			amount: 6000,
+			fee:    20,
+		},
+	}
+
+	balance := fromAccount.Balance
+	for _, tc := range testCases {
+		revenueBefore, err := testQueries.GetAccount(
+			context.Background(),
+			revenue.ID,
+		)
+		require.NoError(
+			t,
+			err,
+		)
+
+		result, err := store.TransferTx(
+			context.Background(),
+			TransferTxParams{
+				FromAccountID: fromAccount.ID,
+				ToAccountID:   toAccount.ID,
+				Amount:        tc.amount,
+			},
+		)
+		require.NoError(
+			t,
+			err,
+			tc.name,
+		)
+		require.Equal(
+			t,
+			tc.fee,
+			result.Fee,
+			tc.name,
+		)
+		require.Equal(
+			t,
+			tc.fee,
+			result.Transfer.Fee,
+			tc.name,
+		)
+
+		balance -= tc.amount + tc.fee
+		require.Equal(
+			t,
+			balance,
+			result.FromAccount.Balance,
+			tc.name,
+		)
+
+		revenueAfter, err := testQueries.GetAccount(
+			context.Background(),
+			revenue.ID,
+		)
+		require.NoError(
+			t,
+			err,
+		)
+		require.Equal(
+			t,
+			tc.fee,
+			revenueAfter.Balance-revenueBefore.Balance,
+			tc.name,
+		)
+	}
+}
+
+func TestTransferTxFeeInsufficientFunds(t *testing.T) {
+	store := NewStore(testDB)
+	fromAccount := createSavingsAccount(
+		t,
+		1000,
+	)
+	toAccount := createRandomAccount(t)
+
+	schedule, err := testQueries.UpsertFeeSchedule(
+		context.Background(),
+		UpsertFeeScheduleParams{
+			Currency:    fromAccount.Currency,
+			AccountType: AccountTypeSavings,
+			FlatFee:     1,
+			Percentage:  "0",
+		},
+	)
+	require.NoError(
+		t,
+		err,
+	)
+	t.Cleanup(func() {
+		err := testQueries.DeleteFeeSchedule(
+			context.Background(),
+			schedule.ID,
+		)
+		require.NoError(
+			t,
+			err,
+		)
+	})
+
+	// the balance covers the amount but not the fee on top of it
+	_, err = store.TransferTx(
+		context.Background(),
+		TransferTxParams{
+			FromAccountID: fromAccount.ID,
+			ToAccountID:   toAccount.ID,
+			Amount:        1000,
+		},
+	)
+	require.ErrorIs(
+		t,
+		err,
+		ErrInsufficientFunds,
+	)
+}
@@ -0,0 +1,219 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sort"
+)
+
+// ErrSameCurrency is returned when an fx transfer is asked for between accounts of the same currency
+var ErrSameCurrency = errors.New("fx transfer needs accounts in different currencies")
+
+// FXTransferTxParams contains the input parameters of the fx transfer transaction
+type FXTransferTxParams struct {
+	// TransferTxParams.Amount is debited in the currency of the source account
+	TransferTxParams
+	// ToAmount is credited in the currency of the destination account
+	ToAmount     int64  `json:"to_amount"`
+	ExchangeRate string `json:"exchange_rate"`
+	Spread       string `json:"spread"`
+}
+
+// FXTransferTx converts money between accounts of different currencies.
+// The fx account of each currency takes the other side of the customer entry,
+// so the entries of every currency still add up to zero.
+func (store *SQLStore) FXTransferTx(ctx context.Context, arg FXTransferTxParams) (TransferTxResult, error) {
+	var result TransferTxResult
+
+	err := store.execTx(
+		ctx,
+		func(q *Queries) error {
+			fromFX, toFX, err := fxAccountsFor(
+				ctx,
+				q,
+				arg.FromAccountID,
+				arg.ToAccountID,
+			)
+			if err != nil {
+				return err
+			}
+
+			fee, err := transferFeeFor(
+				ctx,
+				q,
+				arg.TransferTxParams,
+			)
+			if err != nil {
+				return err
+			}
+
+			result.Transfer, err = q.CreateTransfer(
+				ctx,
+				CreateTransferParams{
+					FromAccountID: arg.FromAccountID,
+					ToAccountID:   arg.ToAccountID,
+					Amount:        arg.Amount,
+					ToAmount:      arg.ToAmount,
+					ExchangeRate:  arg.ExchangeRate,
+					Spread:        arg.Spread,
+					Fee:           fee.Amount,
+				},
+			)
+			if err != nil {
+				log.Printf(
+					"Failed to create fx transfer: %v",
+					err,
+				)
+				return err
+			}
+			result.Fee = fee.Amount
+
+			// the legs are booked in this order, so the entries read as source, fx out, fx in, destination, then the fee
+			legs := []CreateEntryParams{
+				{
+					AccountID: arg.FromAccountID,
+					Amount:    -arg.Amount,
+				},
+				{
+					AccountID: fromFX.ID,
+					Amount:    arg.Amount,
+				},
+				{
+					AccountID: toFX.ID,
+					Amount:    -arg.ToAmount,
+				},
+				{
+					AccountID: arg.ToAccountID,
+					Amount:    arg.ToAmount,
+				},
+			}
+			toLeg := len(legs) - 1
+			legs = append(
+				legs,
+				fee.legs(arg.FromAccountID)...,
+			)
+			amounts := make(map[int64]int64, len(legs))
+			for i, leg := range legs {
+				entry, err := q.CreateEntry(
+					ctx,
+					leg,
+				)
+				if err != nil {
+					log.Printf(
+						"Failed to create fx entry: %v",
+						err,
+					)
+					return err
+				}
+
+				switch i {
+				case 0:
+					result.FromEntry = entry
+				case toLeg:
+					result.ToEntry = entry
+				}
+				amounts[leg.AccountID] += leg.Amount
+			}
+
+			accounts, err := addMoneyInOrder(
+				ctx,
+				q,
+				amounts,
+			)
+			if err != nil {
+				log.Printf(
+					"Failed to update fx accounts: %v",
+					err,
+				)
+				return err
+			}
+			result.FromAccount = accounts[arg.FromAccountID]
+			result.ToAccount = accounts[arg.ToAccountID]
+
+			// the fx accounts may go negative: that is the bank's open position in the currency
+			result, err = completeTransfer(
+				ctx,
+				q,
+				arg.TransferTxParams,
+				result,
+			)
+			return err
+		},
+	)
+
+	return result, err
+}
+
+// fxAccountsFor returns the fx accounts in the currencies of the source and destination accounts.
+// It locks none of them: addMoneyInOrder locks every row of the transfer by ascending id.
+func fxAccountsFor(ctx context.Context, q *Queries, fromAccountID int64, toAccountID int64) (fromFX Account, toFX Account, err error) {
+	fromAccount, err := q.GetAccountUnlocked(
+		ctx,
+		fromAccountID,
+	)
+	if err != nil {
+		return
+	}
+
+	toAccount, err := q.GetAccountUnlocked(
+		ctx,
+		toAccountID,
+	)
+	if err != nil {
+		return
+	}
+
+	if fromAccount.Currency == toAccount.Currency {
+		err = ErrSameCurrency
+		return
+	}
+
+	fromFX, err = q.GetFXAccount(
+		ctx,
+		fromAccount.Currency,
+	)
+	if err != nil {
+		return
+	}
+
+	toFX, err = q.GetFXAccount(
+		ctx,
+		toAccount.Currency,
+	)
+	return
+}
+
+// addMoneyInOrder adds the amounts to the balances of their accounts,
+// locking the rows by ascending id to avoid deadlocks
+func addMoneyInOrder(ctx context.Context, q *Queries, amounts map[int64]int64) (map[int64]Account, error) {
+	ids := make([]int64, 0, len(amounts))
+	for id := range amounts {
+		ids = append(
+			ids,
+			id,
+		)
+	}
+	sort.Slice(
+		ids,
+		func(i, j int) bool {
+			return ids[i] < ids[j]
+		},
+	)
+
+	accounts := make(map[int64]Account, len(ids))
+	for _, id := range ids {
+		account, err := q.AddAccountBalance(
+			ctx,
+			AddAccountBalanceParams{
+				Amount: amounts[id],
+				ID:     id,
+			},
+		)
+		if err != nil {
+			return nil, err
+		}
+		accounts[id] = account
+	}
+	return accounts, nil
+}
@@ -0,0 +1,190 @@
+package db
+
+import (
+	"context"
+	"github.com/PFefe/simplebank/util"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestFXTransferTx(t *testing.T) {
+	store := NewStore(testDB)
+	fromAccount := createAccountWithBalance(
+		t,
+		1000,
+		0,
+	)
+
+	toCurrency := util.USD
+	if fromAccount.Currency == util.USD {
+		toCurrency = util.EUR
+	}
+	user := createRandomUser(t)
+	toAccount, err := testQueries.CreateAccount(
+		context.Background(),
+		CreateAccountParams{
+			Owner:    user.Username,
+			Balance:  0,
+			Currency: toCurrency,
+		},
+	)
+	require.NoError(
+		t,
+		err,
+	)
+
+	fromFX, err := testQueries.GetFXAccount(
+		context.Background(),
+		fromAccount.Currency,
+	)
+	require.NoError(
+		t,
+		err,
+	)
+	toFX, err := testQueries.GetFXAccount(
+		context.Background(),
+		toCurrency,
+	)
+	require.NoError(
+		t,
+		err,
+	)
+
+	result, err := store.FXTransferTx(
+		context.Background(),
+		FXTransferTxParams{
+			TransferTxParams: TransferTxParams{
+				FromAccountID: fromAccount.ID,
+				ToAccountID:   toAccount.ID,
+				Amount:        100,
+			},
+			ToAmount:     90,
+			ExchangeRate: "0.91",
+			Spread:       "0.01",
+		},
+	)
+	require.NoError(
+		t,
+		err,
+	)
+	require.Equal(
+		t,
+		int64(100),
+		result.Transfer.Amount,
+	)
+	require.Equal(
+		t,
+		int64(90),
+		result.Transfer.ToAmount,
+	)
+	require.Equal(
+		t,
+		"0.91",
+		result.Transfer.ExchangeRate,
+	)
+	require.Equal(
+		t,
+		"0.01",
+		result.Transfer.Spread,
+	)
+	require.Equal(
+		t,
+		int64(-100),
+		result.FromEntry.Amount,
+	)
+	require.Equal(
+		t,
+		int64(90),
+		result.ToEntry.Amount,
+	)
+	require.Equal(
+		t,
+		int64(900),
+		result.FromAccount.Balance,
+	)
+	require.Equal(
+		t,
+		int64(90),
+		result.ToAccount.Balance,
+	)
+
+	// the fx accounts take the other side of both legs
+	updatedFromFX, err := testQueries.GetAccount(
+		context.Background(),
+		fromFX.ID,
+	)
+	require.NoError(
+		t,
+		err,
+	)
+	require.Equal(
+		t,
+		fromFX.Balance+100,
+		updatedFromFX.Balance,
+	)
+
+	updatedToFX, err := testQueries.GetAccount(
+		context.Background(),
+		toFX.ID,
+	)
+	require.NoError(
+		t,
+		err,
+	)
+	require.Equal(
+		t,
+		toFX.Balance-90,
+		updatedToFX.Balance,
+	)
+
+	_, err = store.FXTransferTx(
+		context.Background(),
+		FXTransferTxParams{
+			TransferTxParams: TransferTxParams{
+				FromAccountID: fromAccount.ID,
+				ToAccountID:   toAccount.ID,
+				Amount:        1000,
+			},
+			ToAmount:     900,
+			ExchangeRate: "0.91",
+			Spread:       "0.01",
+		},
+	)
+	require.ErrorIs(
+		t,
+		err,
+		ErrInsufficientFunds,
+	)
+
+	sameCurrencyAccount, err := testQueries.CreateAccount(
+		context.Background(),
+		CreateAccountParams{
+			Owner:    user.Username,
+			Balance:  0,
+			Currency: fromAccount.Currency,
+		},
+	)
+	require.NoError(
+		t,
+		err,
+	)
+
+	_, err = store.FXTransferTx(
+		context.Background(),
+		FXTransferTxParams{
+			TransferTxParams: TransferTxParams{
+				FromAccountID: fromAccount.ID,
+				ToAccountID:   sameCurrencyAccount.ID,
+				Amount:        10,
+			},
+			ToAmount:     10,
+			ExchangeRate: "1",
+			Spread:       "0",
+		},
+	)
+	require.ErrorIs(
+		t,
+		err,
+		ErrSameCurrency,
+	)
+}
@@ -0,0 +1,249 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrHoldUnavailable is returned when a hold was captured, voided or expired already
+	ErrHoldUnavailable = errors.New("hold was already captured, voided or has expired")
+	// ErrCaptureExceedsHold is returned when more than the authorized amount of a hold is captured
+	ErrCaptureExceedsHold = errors.New("capture exceeds the authorized amount of the hold")
+)
+
+// AuthorizeHoldTxParams contains the input parameters of the hold authorization transaction
+type AuthorizeHoldTxParams struct {
+	AccountID   int64     `json:"account_id"`
+	ToAccountID int64     `json:"to_account_id"`
+	Amount      int64     `json:"amount"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// HoldTxResult is the result of the hold transactions
+type HoldTxResult struct {
+	Hold    Hold    `json:"hold"`
+	Account Account `json:"account"`
+}
+
+// AuthorizeHoldTx reserves the amount of the available balance of an account for a later capture
+func (store *SQLStore) AuthorizeHoldTx(ctx context.Context, arg AuthorizeHoldTxParams) (HoldTxResult, error) {
+	var result HoldTxResult
+
+	err := store.execTx(
+		ctx,
+		func(q *Queries) error {
+			var err error
+			result.Account, err = q.AddAccountHeldAmount(
+				ctx,
+				AddAccountHeldAmountParams{
+					Amount: arg.Amount,
+					ID:     arg.AccountID,
+				},
+			)
+			if err != nil {
+				return err
+			}
+
+			err = checkAccountActive(result.Account)
+			if err != nil {
+				return err
+			}
+
+			err = checkSufficientFunds(
+				result.Account,
+				arg.Amount,
+			)
+			if err != nil {
+				return err
+			}
+
+			result.Hold, err = q.CreateHold(
+				ctx,
+				CreateHoldParams{
+					AccountID:   result.Account.ID,
+					ToAccountID: arg.ToAccountID,
+					Amount:      arg.Amount,
+					Currency:    result.Account.Currency,
+					ExpiresAt:   arg.ExpiresAt,
+				},
+			)
+			return err
+		},
+	)
+
+	return result, err
+}
+
+// CaptureHoldTxParams contains the input parameters of the capture transaction
+type CaptureHoldTxParams struct {
+	HoldID int64 `json:"hold_id"`
+	// Amount is at most the authorized amount; the rest of the hold is released
+	Amount int64 `json:"amount"`
+}
+
+// CaptureHoldTxResult is the result of the capture transaction
+type CaptureHoldTxResult struct {
+	Hold     Hold             `json:"hold"`
+	Transfer TransferTxResult `json:"transfer"`
+}
+
+// CaptureHoldTx releases a hold and transfers the captured amount to its destination,
+// with the same checks as any other transfer
+func (store *SQLStore) CaptureHoldTx(ctx context.Context, arg CaptureHoldTxParams) (CaptureHoldTxResult, error) {
+	var result CaptureHoldTxResult
+
+	err := store.execTx(
+		ctx,
+		func(q *Queries) error {
+			hold, err := lockAuthorizedHold(
+				ctx,
+				q,
+				arg.HoldID,
+			)
+			if err != nil {
+				return err
+			}
+
+			if arg.Amount > hold.Amount {
+				return fmt.Errorf(
+					"hold [%d] authorized %d, cannot capture %d: %w",
+					hold.ID,
+					hold.Amount,
+					arg.Amount,
+					ErrCaptureExceedsHold,
+				)
+			}
+
+			// the whole hold is released within the transfer, after its accounts were locked in order
+			result.Transfer, err = runTransfer(
+				ctx,
+				q,
+				TransferTxParams{
+					FromAccountID: hold.AccountID,
+					ToAccountID:   hold.ToAccountID,
+					Amount:        arg.Amount,
+					ReleaseHeld:   hold.Amount,
+				},
+			)
+			if err != nil {
+				return err
+			}
+
+			result.Hold, err = q.CaptureHold(
+				ctx,
+				CaptureHoldParams{
+					CapturedAmount: arg.Amount,
+					TransferID:     result.Transfer.Transfer.ID,
+					ID:             hold.ID,
+				},
+			)
+			if errors.Is(
+				err,
+				sql.ErrNoRows,
+			) {
+				return ErrHoldUnavailable
+			}
+			return err
+		},
+	)
+
+	return result, err
+}
+
+// VoidHoldTx cancels a hold, releasing its whole amount
+func (store *SQLStore) VoidHoldTx(ctx context.Context, holdID int64) (HoldTxResult, error) {
+	var result HoldTxResult
+
+	err := store.execTx(
+		ctx,
+		func(q *Queries) error {
+			hold, err := lockAuthorizedHold(
+				ctx,
+				q,
+				holdID,
+			)
+			if err != nil {
+				return err
+			}
+
+			result.Hold, err = q.VoidHold(
+				ctx,
+				hold.ID,
+			)
+			if err != nil {
+				return err
+			}
+
+			result.Account, err = q.AddAccountHeldAmount(
+				ctx,
+				AddAccountHeldAmountParams{
+					Amount: -hold.Amount,
+					ID:     hold.AccountID,
+				},
+			)
+			return err
+		},
+	)
+
+	return result, err
+}
+
+// ExpireHoldsTx expires the holds that were not settled in time and releases their amounts,
+// returning the number of accounts released
+func (store *SQLStore) ExpireHoldsTx(ctx context.Context) (int64, error) {
+	var released int64
+
+	err := store.execTx(
+		ctx,
+		func(q *Queries) error {
+			amounts, err := q.ExpireHolds(ctx)
+			if err != nil {
+				return err
+			}
+
+			// one account at a time in id order, like addMoneyInOrder, so the sweep can't deadlock with a transfer
+			for _, amount := range amounts {
+				_, err = q.AddAccountHeldAmount(
+					ctx,
+					AddAccountHeldAmountParams{
+						Amount: -amount.Amount,
+						ID:     amount.AccountID,
+					},
+				)
+				if err != nil {
+					return err
+				}
+			}
+
+			released = int64(len(amounts))
+			return nil
+		},
+	)
+
+	return released, err
+}
+
+// lockAuthorizedHold locks a hold until the transaction ends, provided it is still authorized
+func lockAuthorizedHold(ctx context.Context, q *Queries, holdID int64) (Hold, error) {
+	hold, err := q.GetHoldForUpdate(
+		ctx,
+		holdID,
+	)
+	if err != nil {
+		return hold, err
+	}
+
+	if hold.Status != HoldStatusAuthorized {
+		return hold, fmt.Errorf(
+			"hold [%d] is %s: %w",
+			hold.ID,
+			hold.Status,
+			ErrHoldUnavailable,
+		)
+	}
+	return hold, nil
+}
@@ -0,0 +1,209 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.26.0
+// source: hold.sql
+
+package db
+
+import (
+	"context"
+	"time"
+)
+
+const captureHold = `-- name: CaptureHold :one
+UPDATE holds
+SET status          = 'captured',
+    captured_amount = $1,
+    transfer_id     = $2::bigint
+WHERE id = $3
+  AND status = 'authorized'
+  AND expires_at > now()
+RETURNING id, account_id, to_account_id, amount, currency, status, captured_amount, transfer_id, expires_at, created_at
+`
+
+type CaptureHoldParams struct {
+	CapturedAmount int64 `json:"captured_amount"`
+	TransferID     int64 `json:"transfer_id"`
+	ID             int64 `json:"id"`
+}
+
+// records the capture and its transfer, unless the hold was settled already or expired
+func (q *Queries) CaptureHold(ctx context.Context, arg CaptureHoldParams) (Hold, error) {
+	row := q.db.QueryRowContext(ctx, captureHold, arg.CapturedAmount, arg.TransferID, arg.ID)
+	var i Hold
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.Currency,
+		&i.Status,
+		&i.CapturedAmount,
+		&i.TransferID,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createHold = `-- name: CreateHold :one
+INSERT INTO holds (account_id,
+                   to_account_id,
+                   amount,
+                   currency,
+                   expires_at)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, account_id, to_account_id, amount, currency, status, captured_amount, transfer_id, expires_at, created_at
+`
+
+type CreateHoldParams struct {
+	AccountID   int64     `json:"account_id"`
+	ToAccountID int64     `json:"to_account_id"`
+	Amount      int64     `json:"amount"`
+	Currency    string    `json:"currency"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+func (q *Queries) CreateHold(ctx context.Context, arg CreateHoldParams) (Hold, error) {
+	row := q.db.QueryRowContext(ctx, createHold,
+		arg.AccountID,
+		arg.ToAccountID,
+		arg.Amount,
+		arg.Currency,
+		arg.ExpiresAt,
+	)
+	var i Hold
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.Currency,
+		&i.Status,
+		&i.CapturedAmount,
+		&i.TransferID,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const expireHolds = `-- name: ExpireHolds :many
+WITH expired AS (
+    UPDATE holds
+        SET status = 'expired'
+        WHERE status = 'authorized'
+            AND expires_at <= now()
+        RETURNING account_id, amount)
+SELECT account_id, sum(amount)::bigint AS amount
+FROM expired
+GROUP BY account_id
+ORDER BY account_id
+`
+
+type ExpireHoldsRow struct {
+	AccountID int64 `json:"account_id"`
+	Amount    int64 `json:"amount"`
+}
+
+// expires the holds that were not settled in time and sums up the amounts to release per account,
+// in id order so ExpireHoldsTx locks the accounts in the same order as transfers
+func (q *Queries) ExpireHolds(ctx context.Context) ([]ExpireHoldsRow, error) {
+	rows, err := q.db.QueryContext(ctx, expireHolds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ExpireHoldsRow{}
+	for rows.Next() {
+		var i ExpireHoldsRow
+		if err := rows.Scan(&i.AccountID, &i.Amount); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getHold = `-- name: GetHold :one
+SELECT id, account_id, to_account_id, amount, currency, status, captured_amount, transfer_id, expires_at, created_at
+FROM holds
+WHERE id = $1
+LIMIT 1
+`
+
+func (q *Queries) GetHold(ctx context.Context, id int64) (Hold, error) {
+	row := q.db.QueryRowContext(ctx, getHold, id)
+	var i Hold
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.Currency,
+		&i.Status,
+		&i.CapturedAmount,
+		&i.TransferID,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getHoldForUpdate = `-- name: GetHoldForUpdate :one
+SELECT id, account_id, to_account_id, amount, currency, status, captured_amount, transfer_id, expires_at, created_at
+FROM holds
+WHERE id = $1
+LIMIT 1
+FOR NO KEY UPDATE
+`
+
+func (q *Queries) GetHoldForUpdate(ctx context.Context, id int64) (Hold, error) {
+	row := q.db.QueryRowContext(ctx, getHoldForUpdate, id)
+	var i Hold
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.Currency,
+		&i.Status,
+		&i.CapturedAmount,
+		&i.TransferID,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const voidHold = `-- name: VoidHold :one
+UPDATE holds
+SET status = 'voided'
+WHERE id = $1
+  AND status = 'authorized'
+RETURNING id, account_id, to_account_id, amount, currency, status, captured_amount, transfer_id, expires_at, created_at
+`
+
+func (q *Queries) VoidHold(ctx context.Context, id int64) (Hold, error) {
+	row := q.db.QueryRowContext(ctx, voidHold, id)
+	var i Hold
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.Currency,
+		&i.Status,
+		&i.CapturedAmount,
+		&i.TransferID,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
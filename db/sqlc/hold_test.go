@@ -0,0 +1,334 @@
+package db
+
+import (
+	"context"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+// createHoldAccounts creates an account with the balance and a recipient of the same currency
+func createHoldAccounts(t *testing.T, balance int64) (Account, Account) {
+	account := createAccountWithBalance(
+		t,
+		balance,
+		0,
+	)
+
+	user := createRandomUser(t)
+	toAccount, err := testQueries.CreateAccount(
+		context.Background(),
+		CreateAccountParams{
+			Owner:    user.Username,
+			Balance:  0,
+			Currency: account.Currency,
+		},
+	)
+	require.NoError(
+		t,
+		err,
+	)
+	return account, toAccount
+}
+
+func authorizeHold(t *testing.T, store Store, account Account, toAccount Account, amount int64) HoldTxResult {
+	result, err := store.AuthorizeHoldTx(
+		context.Background(),
+		AuthorizeHoldTxParams{
+			AccountID:   account.ID,
+			ToAccountID: toAccount.ID,
+			Amount:      amount,
+			ExpiresAt:   time.Now().Add(time.Hour),
+		},
+	)
+	require.NoError(
+		t,
+		err,
+	)
+	return result
+}
+
+func TestAuthorizeHoldTx(t *testing.T) {
+	store := NewStore(testDB)
+	account, toAccount := createHoldAccounts(
+		t,
+		1000,
+	)
+
+	result := authorizeHold(
+		t,
+		store,
+		account,
+		toAccount,
+		600,
+	)
+	require.Equal(
+		t,
+		HoldStatusAuthorized,
+		result.Hold.Status,
+	)
+	require.Equal(
+		t,
+		int64(600),
+		result.Account.HeldAmount,
+	)
+	require.Equal(
+		t,
+		int64(1000),
+		result.Account.Balance,
+	)
+	require.Equal(
+		t,
+		int64(400),
+		result.Account.AvailableBalance,
+	)
+
+	// neither another hold nor a transfer may spend the reserved money
+	_, err := store.AuthorizeHoldTx(
+		context.Background(),
+		AuthorizeHoldTxParams{
+			AccountID:   account.ID,
+			ToAccountID: toAccount.ID,
+			Amount:      500,
+			ExpiresAt:   time.Now().Add(time.Hour),
+		},
+	)
+	require.ErrorIs(
+		t,
+		err,
+		ErrInsufficientFunds,
+	)
+
+	_, err = store.TransferTx(
+		context.Background(),
+		TransferTxParams{
+			FromAccountID: account.ID,
+			ToAccountID:   toAccount.ID,
+			Amount:        500,
+		},
+	)
+	require.ErrorIs(
+		t,
+		err,
+		ErrInsufficientFunds,
+	)
+}
+
+func TestCaptureHoldTx(t *testing.T) {
+	store := NewStore(testDB)
+	account, toAccount := createHoldAccounts(
+		t,
+		1000,
+	)
+
+	hold := authorizeHold(
+		t,
+		store,
+		account,
+		toAccount,
+		600,
+	).Hold
+
+	_, err := store.CaptureHoldTx(
+		context.Background(),
+		CaptureHoldTxParams{
+			HoldID: hold.ID,
+			Amount: 700,
+		},
+	)
+	require.ErrorIs(
+		t,
+		err,
+		ErrCaptureExceedsHold,
+	)
+
+	result, err := store.CaptureHoldTx(
+		context.Background(),
+		CaptureHoldTxParams{
+			HoldID: hold.ID,
+			Amount: 250,
+		},
+	)
+	require.NoError(
+		t,
+		err,
+	)
+	require.Equal(
+		t,
+		HoldStatusCaptured,
+		result.Hold.Status,
+	)
+	require.Equal(
+		t,
+		int64(250),
+		result.Hold.CapturedAmount,
+	)
+	require.Equal(
+		t,
+		result.Transfer.Transfer.ID,
+		result.Hold.TransferID.Int64,
+	)
+
+	// the rest of the hold is released
+	fromAccount := result.Transfer.FromAccount
+	require.Equal(
+		t,
+		int64(750),
+		fromAccount.Balance,
+	)
+	require.Zero(
+		t,
+		fromAccount.HeldAmount,
+	)
+	require.Equal(
+		t,
+		int64(750),
+		fromAccount.AvailableBalance,
+	)
+	require.Equal(
+		t,
+		int64(250),
+		result.Transfer.ToAccount.Balance,
+	)
+
+	_, err = store.CaptureHoldTx(
+		context.Background(),
+		CaptureHoldTxParams{
+			HoldID: hold.ID,
+			Amount: 250,
+		},
+	)
+	require.ErrorIs(
+		t,
+		err,
+		ErrHoldUnavailable,
+	)
+}
+
+func TestVoidHoldTx(t *testing.T) {
+	store := NewStore(testDB)
+	account, toAccount := createHoldAccounts(
+		t,
+		1000,
+	)
+
+	hold := authorizeHold(
+		t,
+		store,
+		account,
+		toAccount,
+		600,
+	).Hold
+
+	result, err := store.VoidHoldTx(
+		context.Background(),
+		hold.ID,
+	)
+	require.NoError(
+		t,
+		err,
+	)
+	require.Equal(
+		t,
+		HoldStatusVoided,
+		result.Hold.Status,
+	)
+	require.Zero(
+		t,
+		result.Account.HeldAmount,
+	)
+	require.Equal(
+		t,
+		int64(1000),
+		result.Account.AvailableBalance,
+	)
+
+	_, err = store.CaptureHoldTx(
+		context.Background(),
+		CaptureHoldTxParams{
+			HoldID: hold.ID,
+			Amount: hold.Amount,
+		},
+	)
+	require.ErrorIs(
+		t,
+		err,
+		ErrHoldUnavailable,
+	)
+}
+
+func TestExpireHolds(t *testing.T) {
+	account, toAccount := createHoldAccounts(
+		t,
+		1000,
+	)
+
+	hold, err := testQueries.CreateHold(
+		context.Background(),
+		CreateHoldParams{
+			AccountID:   account.ID,
+			ToAccountID: toAccount.ID,
+			Amount:      400,
+			Currency:    account.Currency,
+			ExpiresAt:   time.Now().Add(-time.Minute),
+		},
+	)
+	require.NoError(
+		t,
+		err,
+	)
+	_, err = testQueries.AddAccountHeldAmount(
+		context.Background(),
+		AddAccountHeldAmountParams{
+			Amount: hold.Amount,
+			ID:     account.ID,
+		},
+	)
+	require.NoError(
+		t,
+		err,
+	)
+
+	n, err := NewStore(testDB).ExpireHoldsTx(context.Background())
+	require.NoError(
+		t,
+		err,
+	)
+	require.GreaterOrEqual(
+		t,
+		n,
+		int64(1),
+	)
+
+	expired, err := testQueries.GetHold(
+		context.Background(),
+		hold.ID,
+	)
+	require.NoError(
+		t,
+		err,
+	)
+	require.Equal(
+		t,
+		HoldStatusExpired,
+		expired.Status,
+	)
+
+	released, err := testQueries.GetAccount(
+		context.Background(),
+		account.ID,
+	)
+	require.NoError(
+		t,
+		err,
+	)
+	require.Zero(
+		t,
+		released.HeldAmount,
+	)
+	require.Equal(
+		t,
+		int64(1000),
+		released.AvailableBalance,
+	)
+}
@@ -0,0 +1,87 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.26.0
+// source: idempotency_key.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+const createIdempotencyKey = `-- name: CreateIdempotencyKey :one
+INSERT INTO idempotency_keys (username, key, request_hash, response, transfer_request_id, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (username, key) DO UPDATE
+    SET request_hash        = excluded.request_hash,
+        response            = excluded.response,
+        transfer_request_id = excluded.transfer_request_id,
+        expires_at          = excluded.expires_at,
+        created_at          = now()
+WHERE idempotency_keys.expires_at <= now()
+RETURNING username, key, request_hash, response, expires_at, created_at, transfer_request_id
+`
+
+type CreateIdempotencyKeyParams struct {
+	Username          string          `json:"username"`
+	Key               string          `json:"key"`
+	RequestHash       string          `json:"request_hash"`
+	Response          json.RawMessage `json:"response"`
+	TransferRequestID sql.NullInt64   `json:"transfer_request_id"`
+	ExpiresAt         time.Time       `json:"expires_at"`
+}
+
+// an expired key may be taken over, a live one makes this return no rows
+func (q *Queries) CreateIdempotencyKey(ctx context.Context, arg CreateIdempotencyKeyParams) (IdempotencyKey, error) {
+	row := q.db.QueryRowContext(ctx, createIdempotencyKey,
+		arg.Username,
+		arg.Key,
+		arg.RequestHash,
+		arg.Response,
+		arg.TransferRequestID,
+		arg.ExpiresAt,
+	)
+	var i IdempotencyKey
+	err := row.Scan(
+		&i.Username,
+		&i.Key,
+		&i.RequestHash,
+		&i.Response,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.TransferRequestID,
+	)
+	return i, err
+}
+
+const getIdempotencyKey = `-- name: GetIdempotencyKey :one
+SELECT username, key, request_hash, response, expires_at, created_at, transfer_request_id
+FROM idempotency_keys
+WHERE username = $1
+  AND key = $2
+  AND expires_at > now()
+LIMIT 1
+`
+
+type GetIdempotencyKeyParams struct {
+	Username string `json:"username"`
+	Key      string `json:"key"`
+}
+
+func (q *Queries) GetIdempotencyKey(ctx context.Context, arg GetIdempotencyKeyParams) (IdempotencyKey, error) {
+	row := q.db.QueryRowContext(ctx, getIdempotencyKey, arg.Username, arg.Key)
+	var i IdempotencyKey
+	err := row.Scan(
+		&i.Username,
+		&i.Key,
+		&i.RequestHash,
+		&i.Response,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.TransferRequestID,
+	)
+	return i, err
+}
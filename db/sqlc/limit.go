@@ -0,0 +1,179 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// Limits that can be put on the transfers out of an account or a user
+const (
+	LimitMaxAmount   = "max_amount"
+	LimitDailyAmount = "daily_amount"
+	LimitHourlyCount = "hourly_count"
+)
+
+// Scopes of the limits
+const (
+	LimitScopeAccount = "account"
+	LimitScopeUser    = "user"
+)
+
+// ErrLimitExceeded is matched by every LimitExceededError
+var ErrLimitExceeded = errors.New("transfer limit exceeded")
+
+// LimitExceededError is returned when a transfer would go over a limit of its source account or its owner
+type LimitExceededError struct {
+	Scope string
+	Limit string
+	Max   int64
+	// Value is what the limit would reach with the transfer
+	Value int64
+}
+
+func (e *LimitExceededError) Error() string {
+	return fmt.Sprintf(
+		"%s %s limit of %d exceeded: %d",
+		e.Scope,
+		e.Limit,
+		e.Max,
+		e.Value,
+	)
+}
+
+func (e *LimitExceededError) Unwrap() error {
+	return ErrLimitExceeded
+}
+
+// transferLimits are the caps of one scope, a null cap is not enforced
+type transferLimits struct {
+	MaxAmount   sql.NullInt64
+	DailyAmount sql.NullInt64
+	HourlyCount sql.NullInt64
+}
+
+// check compares the limits with the amount of the transfer and the velocity that includes it
+func (l transferLimits) check(scope string, amount int64, dailyAmount int64, hourlyCount int64) error {
+	checks := []struct {
+		limit string
+		max   sql.NullInt64
+		value int64
+	}{
+		{
+			limit: LimitMaxAmount,
+			max:   l.MaxAmount,
+			value: amount,
+		},
+		{
+			limit: LimitDailyAmount,
+			max:   l.DailyAmount,
+			value: dailyAmount,
+		},
+		{
+			limit: LimitHourlyCount,
+			max:   l.HourlyCount,
+			value: hourlyCount,
+		},
+	}
+	for _, c := range checks {
+		if c.max.Valid && c.value > c.max.Int64 {
+			return &LimitExceededError{
+				Scope: scope,
+				Limit: c.limit,
+				Max:   c.max.Int64,
+				Value: c.value,
+			}
+		}
+	}
+	return nil
+}
+
+func (l transferLimits) velocityLimited() bool {
+	return l.DailyAmount.Valid || l.HourlyCount.Valid
+}
+
+// checkTransferLimits checks the transfer, which must already be recorded, against the limits of its
+// source account and of the owner in the account's currency. The source account must be locked, so
+// concurrent transfers out of it wait for this one and count it.
+func checkTransferLimits(ctx context.Context, q *Queries, account Account, amount int64) error {
+	accountLimits, err := q.GetAccountLimits(
+		ctx,
+		account.ID,
+	)
+	if err != nil && !errors.Is(
+		err,
+		sql.ErrNoRows,
+	) {
+		return err
+	}
+	err = checkScopeLimits(
+		LimitScopeAccount,
+		transferLimits{
+			MaxAmount:   accountLimits.MaxAmount,
+			DailyAmount: accountLimits.DailyAmount,
+			HourlyCount: accountLimits.HourlyCount,
+		},
+		amount,
+		func() (GetAccountTransferVelocityRow, error) {
+			return q.GetAccountTransferVelocity(
+				ctx,
+				account.ID,
+			)
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	userLimits, err := q.GetUserLimitsForUpdate(
+		ctx,
+		GetUserLimitsForUpdateParams{
+			Username: account.Owner,
+			Currency: account.Currency,
+		},
+	)
+	if err != nil && !errors.Is(
+		err,
+		sql.ErrNoRows,
+	) {
+		return err
+	}
+	return checkScopeLimits(
+		LimitScopeUser,
+		transferLimits{
+			MaxAmount:   userLimits.MaxAmount,
+			DailyAmount: userLimits.DailyAmount,
+			HourlyCount: userLimits.HourlyCount,
+		},
+		amount,
+		func() (GetAccountTransferVelocityRow, error) {
+			velocity, err := q.GetUserTransferVelocity(
+				ctx,
+				GetUserTransferVelocityParams{
+					Owner:    account.Owner,
+					Currency: account.Currency,
+				},
+			)
+			return GetAccountTransferVelocityRow(velocity), err
+		},
+	)
+}
+
+// checkScopeLimits reads the velocity of the scope only when one of its limits needs it
+func checkScopeLimits(scope string, limits transferLimits, amount int64, velocity func() (GetAccountTransferVelocityRow, error)) error {
+	var recent GetAccountTransferVelocityRow
+	if limits.velocityLimited() {
+		var err error
+		recent, err = velocity()
+		if err != nil {
+			return err
+		}
+	}
+	return limits.check(
+		scope,
+		amount,
+		recent.DailyAmount,
+		recent.HourlyCount,
+	)
+}
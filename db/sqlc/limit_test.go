@@ -0,0 +1,208 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func transferFrom(t *testing.T, store Store, fromAccount Account, toAccount Account, amount int64) error {
+	_, err := store.TransferTx(
+		context.Background(),
+		TransferTxParams{
+			FromAccountID: fromAccount.ID,
+			ToAccountID:   toAccount.ID,
+			Amount:        amount,
+		},
+	)
+	return err
+}
+
+func requireLimitExceeded(t *testing.T, err error, scope string, limit string) {
+	var limitErr *LimitExceededError
+	require.ErrorAs(
+		t,
+		err,
+		&limitErr,
+	)
+	require.Equal(
+		t,
+		scope,
+		limitErr.Scope,
+	)
+	require.Equal(
+		t,
+		limit,
+		limitErr.Limit,
+	)
+}
+
+func TestTransferTxAccountLimits(t *testing.T) {
+	store := NewStore(testDB)
+	fromAccount := createAccountWithBalance(
+		t,
+		1000,
+		0,
+	)
+	toAccount := createRandomAccount(t)
+
+	_, err := testQueries.UpsertAccountLimits(
+		context.Background(),
+		UpsertAccountLimitsParams{
+			AccountID: fromAccount.ID,
+			MaxAmount: sql.NullInt64{
+				Int64: 100,
+				Valid: true,
+			},
+			DailyAmount: sql.NullInt64{
+				Int64: 150,
+				Valid: true,
+			},
+		},
+	)
+	require.NoError(
+		t,
+		err,
+	)
+
+	err = transferFrom(
+		t,
+		store,
+		fromAccount,
+		toAccount,
+		101,
+	)
+	requireLimitExceeded(
+		t,
+		err,
+		LimitScopeAccount,
+		LimitMaxAmount,
+	)
+
+	err = transferFrom(
+		t,
+		store,
+		fromAccount,
+		toAccount,
+		100,
+	)
+	require.NoError(
+		t,
+		err,
+	)
+
+	// the rejected transfers were rolled back, so they don't count
+	err = transferFrom(
+		t,
+		store,
+		fromAccount,
+		toAccount,
+		60,
+	)
+	requireLimitExceeded(
+		t,
+		err,
+		LimitScopeAccount,
+		LimitDailyAmount,
+	)
+
+	err = transferFrom(
+		t,
+		store,
+		fromAccount,
+		toAccount,
+		50,
+	)
+	require.NoError(
+		t,
+		err,
+	)
+
+	// with only a count limit left, the daily total no longer matters
+	_, err = testQueries.UpsertAccountLimits(
+		context.Background(),
+		UpsertAccountLimitsParams{
+			AccountID: fromAccount.ID,
+			HourlyCount: sql.NullInt64{
+				Int64: 2,
+				Valid: true,
+			},
+		},
+	)
+	require.NoError(
+		t,
+		err,
+	)
+
+	err = transferFrom(
+		t,
+		store,
+		fromAccount,
+		toAccount,
+		1,
+	)
+	requireLimitExceeded(
+		t,
+		err,
+		LimitScopeAccount,
+		LimitHourlyCount,
+	)
+}
+
+func TestTransferTxUserLimits(t *testing.T) {
+	store := NewStore(testDB)
+	fromAccount := createAccountWithBalance(
+		t,
+		1000,
+		0,
+	)
+	toAccount := createRandomAccount(t)
+
+	_, err := testQueries.UpsertUserLimits(
+		context.Background(),
+		UpsertUserLimitsParams{
+			Username: fromAccount.Owner,
+			Currency: fromAccount.Currency,
+			HourlyCount: sql.NullInt64{
+				Int64: 1,
+				Valid: true,
+			},
+		},
+	)
+	require.NoError(
+		t,
+		err,
+	)
+
+	err = transferFrom(
+		t,
+		store,
+		fromAccount,
+		toAccount,
+		10,
+	)
+	require.NoError(
+		t,
+		err,
+	)
+
+	err = transferFrom(
+		t,
+		store,
+		fromAccount,
+		toAccount,
+		10,
+	)
+	requireLimitExceeded(
+		t,
+		err,
+		LimitScopeUser,
+		LimitHourlyCount,
+	)
+	require.ErrorIs(
+		t,
+		err,
+		ErrLimitExceeded,
+	)
+}
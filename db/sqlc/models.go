@@ -5,15 +5,261 @@
 package db
 
 import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
 	"time"
+
+	"github.com/google/uuid"
+)
+
+type AccountStatus string
+
+const (
+	AccountStatusActive AccountStatus = "active"
+	AccountStatusFrozen AccountStatus = "frozen"
+	AccountStatusClosed AccountStatus = "closed"
+)
+
+func (e *AccountStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = AccountStatus(s)
+	case string:
+		*e = AccountStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for AccountStatus: %T", src)
+	}
+	return nil
+}
+
+type NullAccountStatus struct {
+	AccountStatus AccountStatus `json:"account_status"`
+	Valid         bool          `json:"valid"` // Valid is true if AccountStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullAccountStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.AccountStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.AccountStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullAccountStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.AccountStatus), nil
+}
+
+type AccountType string
+
+const (
+	AccountTypeChecking AccountType = "checking"
+	AccountTypeSavings  AccountType = "savings"
+)
+
+func (e *AccountType) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = AccountType(s)
+	case string:
+		*e = AccountType(s)
+	default:
+		return fmt.Errorf("unsupported scan type for AccountType: %T", src)
+	}
+	return nil
+}
+
+type NullAccountType struct {
+	AccountType AccountType `json:"account_type"`
+	Valid       bool        `json:"valid"` // Valid is true if AccountType is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullAccountType) Scan(value interface{}) error {
+	if value == nil {
+		ns.AccountType, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.AccountType.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullAccountType) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.AccountType), nil
+}
+
+type HoldStatus string
+
+const (
+	HoldStatusAuthorized HoldStatus = "authorized"
+	HoldStatusCaptured   HoldStatus = "captured"
+	HoldStatusVoided     HoldStatus = "voided"
+	HoldStatusExpired    HoldStatus = "expired"
 )
 
+func (e *HoldStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = HoldStatus(s)
+	case string:
+		*e = HoldStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for HoldStatus: %T", src)
+	}
+	return nil
+}
+
+type NullHoldStatus struct {
+	HoldStatus HoldStatus `json:"hold_status"`
+	Valid      bool       `json:"valid"` // Valid is true if HoldStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullHoldStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.HoldStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.HoldStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullHoldStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.HoldStatus), nil
+}
+
+type TransferRequestStatus string
+
+const (
+	TransferRequestStatusPendingApproval TransferRequestStatus = "pending_approval"
+	TransferRequestStatusApproved        TransferRequestStatus = "approved"
+	TransferRequestStatusRejected        TransferRequestStatus = "rejected"
+	TransferRequestStatusExpired         TransferRequestStatus = "expired"
+)
+
+func (e *TransferRequestStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = TransferRequestStatus(s)
+	case string:
+		*e = TransferRequestStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for TransferRequestStatus: %T", src)
+	}
+	return nil
+}
+
+type NullTransferRequestStatus struct {
+	TransferRequestStatus TransferRequestStatus `json:"transfer_request_status"`
+	Valid                 bool                  `json:"valid"` // Valid is true if TransferRequestStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullTransferRequestStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.TransferRequestStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.TransferRequestStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullTransferRequestStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.TransferRequestStatus), nil
+}
+
+type TransferStatus string
+
+const (
+	TransferStatusPending   TransferStatus = "pending"
+	TransferStatusCompleted TransferStatus = "completed"
+	TransferStatusFailed    TransferStatus = "failed"
+	TransferStatusReversed  TransferStatus = "reversed"
+)
+
+func (e *TransferStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = TransferStatus(s)
+	case string:
+		*e = TransferStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for TransferStatus: %T", src)
+	}
+	return nil
+}
+
+type NullTransferStatus struct {
+	TransferStatus TransferStatus `json:"transfer_status"`
+	Valid          bool           `json:"valid"` // Valid is true if TransferStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullTransferStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.TransferStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.TransferStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullTransferStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.TransferStatus), nil
+}
+
 type Account struct {
 	ID        int64     `json:"id"`
-	Owner     string    `json:"owner"`
 	Balance   int64     `json:"balance"`
 	Currency  string    `json:"currency"`
 	CreatedAt time.Time `json:"created_at"`
+	Owner     string    `json:"owner"`
+	// how far below zero the balance may go
+	OverdraftLimit int64 `json:"overdraft_limit"`
+	// only active accounts can move money
+	Status      AccountStatus `json:"status"`
+	Nickname    string        `json:"nickname"`
+	AccountType AccountType   `json:"account_type"`
+	// bumped on every update, sent to clients as the ETag
+	Version int64 `json:"version"`
+	// sum of the authorized holds on the account
+	HeldAmount int64 `json:"held_amount"`
+	// balance that is not held, what transfers and withdrawals may spend
+	AvailableBalance int64 `json:"available_balance"`
+}
+
+// caps on the transfers out of one account, a null cap is not enforced
+type AccountLimit struct {
+	AccountID int64         `json:"account_id"`
+	MaxAmount sql.NullInt64 `json:"max_amount"`
+	// total of the last 24 hours
+	DailyAmount sql.NullInt64 `json:"daily_amount"`
+	// number of transfers in the last hour
+	HourlyCount sql.NullInt64 `json:"hourly_count"`
+	UpdatedAt   time.Time     `json:"updated_at"`
 }
 
 type Entry struct {
@@ -24,11 +270,154 @@ type Entry struct {
 	CreatedAt time.Time `json:"created_at"`
 }
 
+type FeeSchedule struct {
+	ID          int64       `json:"id"`
+	Currency    string      `json:"currency"`
+	AccountType AccountType `json:"account_type"`
+	// lowest transfer amount of the tier, in minor units of the currency
+	MinAmount int64 `json:"min_amount"`
+	FlatFee   int64 `json:"flat_fee"`
+	// fraction of the amount charged on top of flat_fee
+	Percentage string `json:"percentage"`
+	MinFee     int64  `json:"min_fee"`
+	// cap of the fee, uncapped when null
+	MaxFee    sql.NullInt64 `json:"max_fee"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+// position of the bank in each currency from foreign exchange transfers
+type FxAccount struct {
+	Currency  string `json:"currency"`
+	AccountID int64  `json:"account_id"`
+}
+
+type Hold struct {
+	ID          int64 `json:"id"`
+	AccountID   int64 `json:"account_id"`
+	ToAccountID int64 `json:"to_account_id"`
+	// authorized amount, reserved on the account while the hold is authorized
+	Amount         int64      `json:"amount"`
+	Currency       string     `json:"currency"`
+	Status         HoldStatus `json:"status"`
+	CapturedAmount int64      `json:"captured_amount"`
+	// transfer of the captured amount to to_account_id
+	TransferID sql.NullInt64 `json:"transfer_id"`
+	ExpiresAt  time.Time     `json:"expires_at"`
+	CreatedAt  time.Time     `json:"created_at"`
+}
+
+type IdempotencyKey struct {
+	Username          string          `json:"username"`
+	Key               string          `json:"key"`
+	RequestHash       string          `json:"request_hash"`
+	Response          json.RawMessage `json:"response"`
+	ExpiresAt         time.Time       `json:"expires_at"`
+	CreatedAt         time.Time       `json:"created_at"`
+	TransferRequestID sql.NullInt64   `json:"transfer_request_id"`
+}
+
+// fees collected by the bank, one account per currency
+type RevenueAccount struct {
+	Currency  string `json:"currency"`
+	AccountID int64  `json:"account_id"`
+}
+
+type Session struct {
+	ID           uuid.UUID `json:"id"`
+	Username     string    `json:"username"`
+	RefreshToken string    `json:"refresh_token"`
+	UserAgent    string    `json:"user_agent"`
+	ClientIp     string    `json:"client_ip"`
+	IsBlocked    bool      `json:"is_blocked"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// cash that entered or left the bank through deposits and withdrawals, one account per currency
+type SettlementAccount struct {
+	Currency  string `json:"currency"`
+	AccountID int64  `json:"account_id"`
+}
+
 type Transfer struct {
 	ID            int64 `json:"id"`
 	FromAccountID int64 `json:"from_account_id"`
 	ToAccountID   int64 `json:"to_account_id"`
-	// can not be a negative
+	// debited from the source account in its currency, can not be a negative
 	Amount    int64     `json:"amount"`
 	CreatedAt time.Time `json:"created_at"`
+	// credited to the destination account in its currency
+	ToAmount int64 `json:"to_amount"`
+	// mid rate from the source to the destination currency, 1 within a currency
+	ExchangeRate string `json:"exchange_rate"`
+	// fraction of the converted amount kept by the bank
+	Spread string `json:"spread"`
+	// currency of amount, copied from the source account
+	Currency string `json:"currency"`
+	// currency of to_amount, copied from the destination account
+	ToCurrency string `json:"to_currency"`
+	// charged to the source account on top of amount, in its currency
+	Fee int64 `json:"fee"`
+	// pending and failed are for transfers settled outside of a single transaction
+	Status TransferStatus `json:"status"`
+	// transfer this one compensates, it can only be reversed once
+	ReversalOf sql.NullInt64 `json:"reversal_of"`
+}
+
+type TransferQuote struct {
+	ID            uuid.UUID `json:"id"`
+	Username      string    `json:"username"`
+	FromAccountID int64     `json:"from_account_id"`
+	ToAccountID   int64     `json:"to_account_id"`
+	Amount        int64     `json:"amount"`
+	Currency      string    `json:"currency"`
+	ToAmount      int64     `json:"to_amount"`
+	ToCurrency    string    `json:"to_currency"`
+	ExchangeRate  string    `json:"exchange_rate"`
+	Spread        string    `json:"spread"`
+	// transfer that used the quote, a quote can only be used once
+	TransferID sql.NullInt64 `json:"transfer_id"`
+	ExpiresAt  time.Time     `json:"expires_at"`
+	CreatedAt  time.Time     `json:"created_at"`
+	// fee locked in by the quote, charged in the source currency on top of the amount
+	Fee int64 `json:"fee"`
+}
+
+type TransferRequest struct {
+	ID            int64                 `json:"id"`
+	RequestedBy   string                `json:"requested_by"`
+	FromAccountID int64                 `json:"from_account_id"`
+	ToAccountID   int64                 `json:"to_account_id"`
+	Amount        int64                 `json:"amount"`
+	Currency      string                `json:"currency"`
+	Status        TransferRequestStatus `json:"status"`
+	ReviewedBy    sql.NullString        `json:"reviewed_by"`
+	ReviewedAt    sql.NullTime          `json:"reviewed_at"`
+	// transfer executed on approval
+	TransferID sql.NullInt64 `json:"transfer_id"`
+	// a request still pending at this time can no longer be approved
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type User struct {
+	Username          string    `json:"username"`
+	HashedPassword    string    `json:"hashed_password"`
+	FullName          string    `json:"full_name"`
+	Email             string    `json:"email"`
+	PasswordChangedAt time.Time `json:"password_changed_at"`
+	CreatedAt         time.Time `json:"created_at"`
+	Role              string    `json:"role"`
+}
+
+// caps on the transfers out of all accounts of a user in one currency, a null cap is not enforced
+type UserLimit struct {
+	Username  string        `json:"username"`
+	Currency  string        `json:"currency"`
+	MaxAmount sql.NullInt64 `json:"max_amount"`
+	// total of the last 24 hours
+	DailyAmount sql.NullInt64 `json:"daily_amount"`
+	// number of transfers in the last hour
+	HourlyCount sql.NullInt64 `json:"hourly_count"`
+	UpdatedAt   time.Time     `json:"updated_at"`
 }
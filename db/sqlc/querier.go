@@ -6,21 +6,119 @@ package db
 
 import (
 	"context"
+
+	"github.com/google/uuid"
 )
 
 type Querier interface {
 	AddAccountBalance(ctx context.Context, arg AddAccountBalanceParams) (Account, error)
+	// reserves a positive amount of the balance for a hold, or releases a negative one
+	AddAccountHeldAmount(ctx context.Context, arg AddAccountHeldAmountParams) (Account, error)
+	// records the approval and the transfer it executed, unless the request was reviewed already or expired
+	ApproveTransferRequest(ctx context.Context, arg ApproveTransferRequestParams) (TransferRequest, error)
+	BlockSession(ctx context.Context, arg BlockSessionParams) (Session, error)
+	// records the capture and its transfer, unless the hold was settled already or expired
+	CaptureHold(ctx context.Context, arg CaptureHoldParams) (Hold, error)
 	CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error)
 	CreateEntry(ctx context.Context, arg CreateEntryParams) (Entry, error)
+	CreateHold(ctx context.Context, arg CreateHoldParams) (Hold, error)
+	// an expired key may be taken over, a live one makes this return no rows
+	CreateIdempotencyKey(ctx context.Context, arg CreateIdempotencyKeyParams) (IdempotencyKey, error)
+	// books the reversal of a transfer from its destination back to its source with the amounts swapped,
+	// so both accounts get back exactly what they had; the fee is refunded by separate entries
+	CreateReversalTransfer(ctx context.Context, id int64) (Transfer, error)
+	CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error)
+	// the currencies are copied from the accounts, so a transfer can be read without them
 	CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfer, error)
-	DeleteAccount(ctx context.Context, id int64) error
+	CreateTransferQuote(ctx context.Context, arg CreateTransferQuoteParams) (TransferQuote, error)
+	CreateTransferRequest(ctx context.Context, arg CreateTransferRequestParams) (TransferRequest, error)
+	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
+	DeleteFeeSchedule(ctx context.Context, id int64) error
+	// expires the holds that were not settled in time and sums up the amounts to release per account,
+	// in id order so ExpireHoldsTx locks the accounts in the same order as transfers
+	ExpireHolds(ctx context.Context) ([]ExpireHoldsRow, error)
+	// moves the requests that were not reviewed in time to expired
+	ExpireTransferRequests(ctx context.Context) (int64, error)
 	GetAccount(ctx context.Context, id int64) (Account, error)
+	GetAccountLimits(ctx context.Context, accountID int64) (AccountLimit, error)
+	// sums up the transfers out of the account in the last 24 hours and counts those of the last hour;
+	// reversals are left out, since they give money back rather than spend it
+	GetAccountTransferVelocity(ctx context.Context, fromAccountID int64) (GetAccountTransferVelocityRow, error)
+	// reads an account without locking it, so a transaction can look up its currency before addMoneyInOrder locks the rows
+	GetAccountUnlocked(ctx context.Context, id int64) (Account, error)
 	GetEntry(ctx context.Context, id int64) (Entry, error)
+	// running_balance is the account balance right after the entry was applied, taking entries in (created_at, id) order
+	GetEntryWithBalance(ctx context.Context, id int64) (GetEntryWithBalanceRow, error)
+	GetFXAccount(ctx context.Context, currency string) (Account, error)
+	// finds the tier that applies to an amount: the one with the highest lower bound the amount reaches
+	GetFeeSchedule(ctx context.Context, arg GetFeeScheduleParams) (FeeSchedule, error)
+	GetHold(ctx context.Context, id int64) (Hold, error)
+	GetHoldForUpdate(ctx context.Context, id int64) (Hold, error)
+	GetIdempotencyKey(ctx context.Context, arg GetIdempotencyKeyParams) (IdempotencyKey, error)
+	GetRevenueAccount(ctx context.Context, currency string) (Account, error)
+	GetSession(ctx context.Context, id uuid.UUID) (Session, error)
+	GetSettlementAccount(ctx context.Context, currency string) (Account, error)
 	GetTransfer(ctx context.Context, id int64) (Transfer, error)
+	GetTransferForUpdate(ctx context.Context, id int64) (Transfer, error)
+	GetTransferQuote(ctx context.Context, id uuid.UUID) (TransferQuote, error)
+	GetTransferRequest(ctx context.Context, id int64) (TransferRequest, error)
+	GetUser(ctx context.Context, username string) (User, error)
+	// locks the limits of the user, so transfers out of different accounts of the user are checked one at a time
+	GetUserLimitsForUpdate(ctx context.Context, arg GetUserLimitsForUpdateParams) (UserLimit, error)
+	// sums up the transfers out of the accounts of the user in the currency, like GetAccountTransferVelocity
+	GetUserTransferVelocity(ctx context.Context, arg GetUserTransferVelocityParams) (GetUserTransferVelocityRow, error)
+	// reports whether the account is the settlement, fx or revenue account of a currency
+	IsSystemAccount(ctx context.Context, id int64) (bool, error)
+	// the page is read first, so the running balances only sum the entries from the page on, not the whole history
+	ListAccountEntries(ctx context.Context, arg ListAccountEntriesParams) ([]ListAccountEntriesRow, error)
+	// keyset page of the entries that come after the cursor
+	ListAccountEntriesAfter(ctx context.Context, arg ListAccountEntriesAfterParams) ([]ListAccountEntriesAfterRow, error)
+	// keyset page of the entries that come before the cursor, closest first
+	ListAccountEntriesBefore(ctx context.Context, arg ListAccountEntriesBeforeParams) ([]ListAccountEntriesBeforeRow, error)
 	ListAccounts(ctx context.Context, arg ListAccountsParams) ([]Account, error)
+	// keyset page of the accounts that come after the cursor
+	ListAccountsAfter(ctx context.Context, arg ListAccountsAfterParams) ([]Account, error)
+	// keyset page of the accounts that come before the cursor, closest first
+	ListAccountsBefore(ctx context.Context, arg ListAccountsBeforeParams) ([]Account, error)
+	ListActiveSessions(ctx context.Context, username string) ([]Session, error)
 	ListEntry(ctx context.Context, arg ListEntryParams) ([]Entry, error)
+	ListFeeSchedules(ctx context.Context) ([]FeeSchedule, error)
+	ListTransferRequests(ctx context.Context, arg ListTransferRequestsParams) ([]TransferRequest, error)
+	// keyset page of the requests in a status that come after the cursor
+	ListTransferRequestsAfter(ctx context.Context, arg ListTransferRequestsAfterParams) ([]TransferRequest, error)
+	// keyset page of the requests in a status that come before the cursor, closest first
+	ListTransferRequestsBefore(ctx context.Context, arg ListTransferRequestsBeforeParams) ([]TransferRequest, error)
+	// lists the transfers of an account in the directions enabled by include_outgoing and include_incoming;
+	// every other filter is skipped when its argument is null
 	ListTransfers(ctx context.Context, arg ListTransfersParams) ([]Transfer, error)
+	// keyset page of the transfers that come after the cursor, filtered like ListTransfers
+	ListTransfersAfter(ctx context.Context, arg ListTransfersAfterParams) ([]Transfer, error)
+	// keyset page of the transfers that come before the cursor, closest first
+	ListTransfersBefore(ctx context.Context, arg ListTransfersBeforeParams) ([]Transfer, error)
+	ListUserLimits(ctx context.Context, username string) ([]UserLimit, error)
+	// creates the fx account of a currency that has none yet, so enabling a currency needs no migration
+	ProvisionFXAccount(ctx context.Context, currency string) (int64, error)
+	// creates the revenue account of a currency that has none yet, so enabling a currency needs no migration
+	ProvisionRevenueAccount(ctx context.Context, currency string) (int64, error)
+	// creates the settlement account of a currency that has none yet, so enabling a currency needs no migration
+	ProvisionSettlementAccount(ctx context.Context, currency string) (int64, error)
+	RejectTransferRequest(ctx context.Context, arg RejectTransferRequestParams) (TransferRequest, error)
+	// updates the non-monetary fields that are not null, if the account is still at the given version
 	UpdateAccount(ctx context.Context, arg UpdateAccountParams) (Account, error)
+	// moves the account to status only if it is still in from_status, so concurrent changes cannot be lost
+	UpdateAccountStatus(ctx context.Context, arg UpdateAccountStatusParams) (Account, error)
+	// moves the transfer to status only if it is still in from_status, so concurrent changes cannot be lost
+	UpdateTransferStatus(ctx context.Context, arg UpdateTransferStatusParams) (Transfer, error)
+	UpdateUserHashedPassword(ctx context.Context, arg UpdateUserHashedPasswordParams) (User, error)
+	// replaces every cap of the account, a null clears it
+	UpsertAccountLimits(ctx context.Context, arg UpsertAccountLimitsParams) (AccountLimit, error)
+	// creates the tier of a schedule, or replaces the tier with the same lower bound
+	UpsertFeeSchedule(ctx context.Context, arg UpsertFeeScheduleParams) (FeeSchedule, error)
+	// replaces every cap of the user in the currency, a null clears it
+	UpsertUserLimits(ctx context.Context, arg UpsertUserLimitsParams) (UserLimit, error)
+	// links the quote to the transfer that used it, unless it expired or was used already
+	UseTransferQuote(ctx context.Context, arg UseTransferQuoteParams) (TransferQuote, error)
+	VoidHold(ctx context.Context, id int64) (Hold, error)
 }
 
 var _ Querier = (*Queries)(nil)
@@ -0,0 +1,30 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+// ErrQuoteUnavailable is returned when a transfer uses a quote that expired or was used already
+var ErrQuoteUnavailable = errors.New("quote has expired or was already used")
+
+// useQuote marks the quote as used by the transfer, so its terms cannot be executed twice
+func useQuote(ctx context.Context, q *Queries, quoteID uuid.UUID, transferID int64) error {
+	_, err := q.UseTransferQuote(
+		ctx,
+		UseTransferQuoteParams{
+			TransferID: transferID,
+			ID:         quoteID,
+		},
+	)
+	if errors.Is(
+		err,
+		sql.ErrNoRows,
+	) {
+		return ErrQuoteUnavailable
+	}
+	return err
+}
@@ -0,0 +1,249 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+)
+
+// ErrInvalidTransferTransition is returned when a transfer cannot move to the requested status
+var ErrInvalidTransferTransition = errors.New("invalid transfer status transition")
+
+// transferStatusTransitions lists the statuses each status may move to; failed and reversed are final
+var transferStatusTransitions = map[TransferStatus][]TransferStatus{
+	TransferStatusPending:   {TransferStatusCompleted, TransferStatusFailed},
+	TransferStatusCompleted: {TransferStatusReversed},
+}
+
+// checkTransferTransition checks that the state machine lets the transfer move to status
+func checkTransferTransition(transfer Transfer, status TransferStatus) error {
+	if !slices.Contains(
+		transferStatusTransitions[transfer.Status],
+		status,
+	) {
+		return fmt.Errorf(
+			"transfer [%d] cannot go from %s to %s: %w",
+			transfer.ID,
+			transfer.Status,
+			status,
+			ErrInvalidTransferTransition,
+		)
+	}
+	return nil
+}
+
+// ReverseTransferTxParams contains the input parameters of the reversal transaction
+type ReverseTransferTxParams struct {
+	TransferID int64 `json:"transfer_id"`
+	// Force lets the reversal take the recipient below its overdraft limit
+	Force bool `json:"force"`
+}
+
+// ReverseTransferTx books a transfer back from its destination to its source and marks it as reversed.
+// The result describes the reversal, whose source is the destination of the original transfer.
+func (store *SQLStore) ReverseTransferTx(ctx context.Context, arg ReverseTransferTxParams) (TransferTxResult, error) {
+	var result TransferTxResult
+
+	err := store.execTx(
+		ctx,
+		func(q *Queries) error {
+			// locks the row, so a concurrent reversal waits and then sees the new status
+			original, err := q.GetTransferForUpdate(
+				ctx,
+				arg.TransferID,
+			)
+			if err != nil {
+				return err
+			}
+
+			err = checkTransferTransition(
+				original,
+				TransferStatusReversed,
+			)
+			if err != nil {
+				return err
+			}
+			if original.ReversalOf.Valid {
+				return fmt.Errorf(
+					"transfer [%d] is the reversal of transfer [%d]: %w",
+					original.ID,
+					original.ReversalOf.Int64,
+					ErrInvalidTransferTransition,
+				)
+			}
+
+			result.Transfer, err = q.CreateReversalTransfer(
+				ctx,
+				original.ID,
+			)
+			if err != nil {
+				log.Printf(
+					"Failed to create reversal transfer: %v",
+					err,
+				)
+				return err
+			}
+
+			legs, toLeg, err := reversalLegs(
+				ctx,
+				q,
+				original,
+			)
+			if err != nil {
+				return err
+			}
+
+			amounts := make(map[int64]int64, len(legs))
+			for i, leg := range legs {
+				entry, err := q.CreateEntry(
+					ctx,
+					leg,
+				)
+				if err != nil {
+					log.Printf(
+						"Failed to create reversal entry: %v",
+						err,
+					)
+					return err
+				}
+
+				switch i {
+				case 0:
+					result.FromEntry = entry
+				case toLeg:
+					result.ToEntry = entry
+				}
+				amounts[leg.AccountID] += leg.Amount
+			}
+
+			accounts, err := addMoneyInOrder(
+				ctx,
+				q,
+				amounts,
+			)
+			if err != nil {
+				log.Printf(
+					"Failed to update reversal accounts: %v",
+					err,
+				)
+				return err
+			}
+			result.FromAccount = accounts[original.ToAccountID]
+			result.ToAccount = accounts[original.FromAccountID]
+
+			for _, account := range []Account{result.FromAccount, result.ToAccount} {
+				err = checkAccountActive(account)
+				if err != nil {
+					return err
+				}
+			}
+
+			// the accounts of the bank run negative by design, e.g. the settlement account for the cash paid in,
+			// so only a customer needs Force to be overdrawn
+			system, err := q.IsSystemAccount(
+				ctx,
+				result.FromAccount.ID,
+			)
+			if err != nil {
+				return err
+			}
+			if !arg.Force && !system {
+				err = checkSufficientFunds(
+					result.FromAccount,
+					original.ToAmount,
+				)
+				if err != nil {
+					return err
+				}
+			}
+
+			_, err = q.UpdateTransferStatus(
+				ctx,
+				UpdateTransferStatusParams{
+					Status:     TransferStatusReversed,
+					ID:         original.ID,
+					FromStatus: original.Status,
+				},
+			)
+			return err
+		},
+	)
+
+	return result, err
+}
+
+// reversalLegs returns the entries that undo a transfer, through the fx accounts when it converted
+// currencies, then refund its fee. toLeg is the index of the entry crediting the original source.
+func reversalLegs(ctx context.Context, q *Queries, original Transfer) (legs []CreateEntryParams, toLeg int, err error) {
+	legs = []CreateEntryParams{
+		{
+			AccountID: original.ToAccountID,
+			Amount:    -original.ToAmount,
+		},
+	}
+
+	if original.Currency != original.ToCurrency {
+		var fromFX, toFX Account
+		fromFX, err = q.GetFXAccount(
+			ctx,
+			original.ToCurrency,
+		)
+		if err != nil {
+			return
+		}
+		toFX, err = q.GetFXAccount(
+			ctx,
+			original.Currency,
+		)
+		if err != nil {
+			return
+		}
+
+		legs = append(
+			legs,
+			CreateEntryParams{
+				AccountID: fromFX.ID,
+				Amount:    original.ToAmount,
+			},
+			CreateEntryParams{
+				AccountID: toFX.ID,
+				Amount:    -original.Amount,
+			},
+		)
+	}
+
+	legs = append(
+		legs,
+		CreateEntryParams{
+			AccountID: original.FromAccountID,
+			Amount:    original.Amount,
+		},
+	)
+	toLeg = len(legs) - 1
+
+	if original.Fee > 0 {
+		var revenue Account
+		revenue, err = q.GetRevenueAccount(
+			ctx,
+			original.Currency,
+		)
+		if err != nil {
+			return
+		}
+
+		legs = append(
+			legs,
+			CreateEntryParams{
+				AccountID: revenue.ID,
+				Amount:    -original.Fee,
+			},
+			CreateEntryParams{
+				AccountID: original.FromAccountID,
+				Amount:    original.Fee,
+			},
+		)
+	}
+	return
+}
@@ -0,0 +1,342 @@
+package db
+
+import (
+	"context"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestReverseTransferTx(t *testing.T) {
+	store := NewStore(testDB)
+	fromAccount := createAccountWithBalance(
+		t,
+		1000,
+		0,
+	)
+	toAccount := createAccountWithBalance(
+		t,
+		0,
+		0,
+	)
+
+	transfer, err := store.TransferTx(
+		context.Background(),
+		TransferTxParams{
+			FromAccountID: fromAccount.ID,
+			ToAccountID:   toAccount.ID,
+			Amount:        300,
+		},
+	)
+	require.NoError(
+		t,
+		err,
+	)
+	require.Equal(
+		t,
+		TransferStatusCompleted,
+		transfer.Transfer.Status,
+	)
+
+	result, err := store.ReverseTransferTx(
+		context.Background(),
+		ReverseTransferTxParams{
+			TransferID: transfer.Transfer.ID,
+		},
+	)
+	require.NoError(
+		t,
+		err,
+	)
+
+	reversal := result.Transfer
+	require.Equal(
+		t,
+		toAccount.ID,
+		reversal.FromAccountID,
+	)
+	require.Equal(
+		t,
+		fromAccount.ID,
+		reversal.ToAccountID,
+	)
+	require.Equal(
+		t,
+		transfer.Transfer.ID,
+		reversal.ReversalOf.Int64,
+	)
+	require.Equal(
+		t,
+		int64(-300),
+		result.FromEntry.Amount,
+	)
+	require.Equal(
+		t,
+		int64(300),
+		result.ToEntry.Amount,
+	)
+	require.Equal(
+		t,
+		fromAccount.Balance,
+		result.ToAccount.Balance,
+	)
+	require.Zero(
+		t,
+		result.FromAccount.Balance,
+	)
+
+	// the reversal gives money back, so it doesn't count against the limits of the recipient
+	velocity, err := testQueries.GetAccountTransferVelocity(
+		context.Background(),
+		toAccount.ID,
+	)
+	require.NoError(
+		t,
+		err,
+	)
+	require.Zero(
+		t,
+		velocity.DailyAmount,
+	)
+	require.Zero(
+		t,
+		velocity.HourlyCount,
+	)
+
+	original, err := testQueries.GetTransfer(
+		context.Background(),
+		transfer.Transfer.ID,
+	)
+	require.NoError(
+		t,
+		err,
+	)
+	require.Equal(
+		t,
+		TransferStatusReversed,
+		original.Status,
+	)
+
+	// neither the original nor its reversal can be reversed again
+	for _, id := range []int64{original.ID, reversal.ID} {
+		_, err = store.ReverseTransferTx(
+			context.Background(),
+			ReverseTransferTxParams{
+				TransferID: id,
+			},
+		)
+		require.ErrorIs(
+			t,
+			err,
+			ErrInvalidTransferTransition,
+		)
+	}
+}
+
+func TestReverseTransferTxOverdraw(t *testing.T) {
+	store := NewStore(testDB)
+	fromAccount := createAccountWithBalance(
+		t,
+		1000,
+		0,
+	)
+	toAccount := createAccountWithBalance(
+		t,
+		0,
+		0,
+	)
+
+	transfer, err := store.TransferTx(
+		context.Background(),
+		TransferTxParams{
+			FromAccountID: fromAccount.ID,
+			ToAccountID:   toAccount.ID,
+			Amount:        300,
+		},
+	)
+	require.NoError(
+		t,
+		err,
+	)
+
+	// the recipient spends part of the money before the reversal
+	_, err = store.TransferTx(
+		context.Background(),
+		TransferTxParams{
+			FromAccountID: toAccount.ID,
+			ToAccountID:   fromAccount.ID,
+			Amount:        100,
+		},
+	)
+	require.NoError(
+		t,
+		err,
+	)
+
+	arg := ReverseTransferTxParams{
+		TransferID: transfer.Transfer.ID,
+	}
+	_, err = store.ReverseTransferTx(
+		context.Background(),
+		arg,
+	)
+	require.ErrorIs(
+		t,
+		err,
+		ErrInsufficientFunds,
+	)
+
+	arg.Force = true
+	result, err := store.ReverseTransferTx(
+		context.Background(),
+		arg,
+	)
+	require.NoError(
+		t,
+		err,
+	)
+	require.Equal(
+		t,
+		int64(-100),
+		result.FromAccount.Balance,
+	)
+}
+
+func TestReverseWithdrawal(t *testing.T) {
+	store := NewStore(testDB)
+	account := createAccountWithBalance(
+		t,
+		0,
+		0,
+	)
+
+	// the deposit takes the settlement account below zero, as only cash paid out brings it back up
+	_, err := store.DepositTx(
+		context.Background(),
+		CashTxParams{
+			AccountID: account.ID,
+			Amount:    10000,
+		},
+	)
+	require.NoError(
+		t,
+		err,
+	)
+	withdrawal, err := store.WithdrawTx(
+		context.Background(),
+		CashTxParams{
+			AccountID: account.ID,
+			Amount:    300,
+		},
+	)
+	require.NoError(
+		t,
+		err,
+	)
+
+	result, err := store.ReverseTransferTx(
+		context.Background(),
+		ReverseTransferTxParams{
+			TransferID: withdrawal.Transfer.ID,
+		},
+	)
+	require.NoError(
+		t,
+		err,
+	)
+	require.Less(
+		t,
+		result.FromAccount.Balance,
+		int64(0),
+	)
+	require.Equal(
+		t,
+		int64(10000),
+		result.ToAccount.Balance,
+	)
+}
+
+func TestCheckTransferTransition(t *testing.T) {
+	testCases := []struct {
+		from    TransferStatus
+		to      TransferStatus
+		allowed bool
+	}{
+		{
+			from:    TransferStatusPending,
+			to:      TransferStatusCompleted,
+			allowed: true,
+		},
+		{
+			from:    TransferStatusPending,
+			to:      TransferStatusFailed,
+			allowed: true,
+		},
+		{
+			from:    TransferStatusCompleted,
+			to:      TransferStatusReversed,
+			allowed: true,
+		},
+		{
+			from:    TransferStatusPending,
+			to:      TransferStatusReversed,
+			allowed: false,
+		},
+		{
+			from:    TransferStatusCompleted,
+			to:      TransferStatusPending,
+			allowed: false,
+		},
+		{
+			from:    TransferStatusCompleted,
+			to:      TransferStatusFailed,
+			allowed: false,
+		},
+		{
+			from:    TransferStatusFailed,
+			to:      TransferStatusCompleted,
+			allowed: false,
+		},
+		{
+			from:    TransferStatusFailed,
+			to:      TransferStatusReversed,
+			allowed: false,
+		},
+		{
+			from:    TransferStatusReversed,
+			to:      TransferStatusCompleted,
+			allowed: false,
+		},
+		{
+			from:    TransferStatusReversed,
+			to:      TransferStatusReversed,
+			allowed: false,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(
+			string(tc.from)+"To"+string(tc.to),
+			func(t *testing.T) {
+				err := checkTransferTransition(
+					Transfer{
+						Status: tc.from,
+					},
+					tc.to,
+				)
+				if tc.allowed {
+					require.NoError(
+						t,
+						err,
+					)
+					return
+				}
+				require.ErrorIs(
+					t,
+					err,
+					ErrInvalidTransferTransition,
+				)
+			},
+		)
+	}
+}
@@ -0,0 +1,147 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/PFefe/simplebank/util"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// createRandomSession is a helper function to create a random session for the user
+func createRandomSession(t *testing.T, user User) Session {
+	arg := CreateSessionParams{
+		ID:           uuid.New(),
+		Username:     user.Username,
+		RefreshToken: util.RandomString(32),
+		UserAgent:    util.RandomString(10),
+		ClientIp:     "127.0.0.1",
+		IsBlocked:    false,
+		ExpiresAt:    time.Now().Add(time.Hour),
+	}
+	session, err := testQueries.CreateSession(
+		context.Background(),
+		arg,
+	)
+	require.NoError(
+		t,
+		err,
+	)
+	require.Equal(
+		t,
+		arg.ID,
+		session.ID,
+	)
+	require.Equal(
+		t,
+		arg.Username,
+		session.Username,
+	)
+	require.Equal(
+		t,
+		arg.RefreshToken,
+		session.RefreshToken,
+	)
+	require.False(
+		t,
+		session.IsBlocked,
+	)
+	require.NotZero(
+		t,
+		session.CreatedAt,
+	)
+
+	return session
+}
+
+// TestGetSession tests retrieving a session
+func TestGetSession(t *testing.T) {
+	user := createRandomUser(t)
+	session1 := createRandomSession(
+		t,
+		user,
+	)
+
+	session2, err := testQueries.GetSession(
+		context.Background(),
+		session1.ID,
+	)
+	require.NoError(
+		t,
+		err,
+	)
+	require.Equal(
+		t,
+		session1.RefreshToken,
+		session2.RefreshToken,
+	)
+	require.WithinDuration(
+		t,
+		session1.ExpiresAt,
+		session2.ExpiresAt,
+		time.Second,
+	)
+}
+
+// TestBlockSession tests that a blocked session is no longer listed as active
+func TestBlockSession(t *testing.T) {
+	user := createRandomUser(t)
+	session1 := createRandomSession(
+		t,
+		user,
+	)
+	session2 := createRandomSession(
+		t,
+		user,
+	)
+
+	blocked, err := testQueries.BlockSession(
+		context.Background(),
+		BlockSessionParams{
+			ID:       session1.ID,
+			Username: user.Username,
+		},
+	)
+	require.NoError(
+		t,
+		err,
+	)
+	require.True(
+		t,
+		blocked.IsBlocked,
+	)
+
+	sessions, err := testQueries.ListActiveSessions(
+		context.Background(),
+		user.Username,
+	)
+	require.NoError(
+		t,
+		err,
+	)
+	require.Len(
+		t,
+		sessions,
+		1,
+	)
+	require.Equal(
+		t,
+		session2.ID,
+		sessions[0].ID,
+	)
+
+	// another user cannot block the session
+	_, err = testQueries.BlockSession(
+		context.Background(),
+		BlockSessionParams{
+			ID:       session2.ID,
+			Username: createRandomUser(t).Username,
+		},
+	)
+	require.Error(
+		t,
+		err,
+	)
+}
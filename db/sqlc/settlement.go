@@ -0,0 +1,174 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"log"
+)
+
+// CashTxParams contains the input parameters of the deposit and withdrawal transactions
+type CashTxParams struct {
+	AccountID int64 `json:"account_id"`
+	Amount    int64 `json:"amount"`
+}
+
+// CashTxResult is the result of the deposit and withdrawal transactions.
+// The settlement side of the transfer is left out, since it belongs to the bank.
+type CashTxResult struct {
+	Transfer Transfer `json:"transfer"`
+	Account  Account  `json:"account"`
+	Entry    Entry    `json:"entry"`
+}
+
+// DepositTx moves cash into an account from the settlement account of its currency
+func (store *SQLStore) DepositTx(ctx context.Context, arg CashTxParams) (CashTxResult, error) {
+	var result CashTxResult
+
+	err := store.execTx(
+		ctx,
+		func(q *Queries) error {
+			settlement, err := settlementAccountFor(
+				ctx,
+				q,
+				arg.AccountID,
+			)
+			if err != nil {
+				return err
+			}
+
+			transfer, err := moveMoney(
+				ctx,
+				q,
+				settlement.ID,
+				arg.AccountID,
+				arg.Amount,
+				transferFee{},
+			)
+			if err != nil {
+				return err
+			}
+
+			// the settlement account may go negative: that is the cash the bank now holds
+			err = checkAccountActive(transfer.ToAccount)
+			if err != nil {
+				return err
+			}
+
+			result = CashTxResult{
+				Transfer: transfer.Transfer,
+				Account:  transfer.ToAccount,
+				Entry:    transfer.ToEntry,
+			}
+			return nil
+		},
+	)
+
+	return result, err
+}
+
+// WithdrawTx moves cash out of an account into the settlement account of its currency
+func (store *SQLStore) WithdrawTx(ctx context.Context, arg CashTxParams) (CashTxResult, error) {
+	var result CashTxResult
+
+	err := store.execTx(
+		ctx,
+		func(q *Queries) error {
+			settlement, err := settlementAccountFor(
+				ctx,
+				q,
+				arg.AccountID,
+			)
+			if err != nil {
+				return err
+			}
+
+			transfer, err := moveMoney(
+				ctx,
+				q,
+				arg.AccountID,
+				settlement.ID,
+				arg.Amount,
+				transferFee{},
+			)
+			if err != nil {
+				return err
+			}
+
+			err = checkAccountActive(transfer.FromAccount)
+			if err != nil {
+				return err
+			}
+
+			err = checkSufficientFunds(
+				transfer.FromAccount,
+				arg.Amount,
+			)
+			if err != nil {
+				return err
+			}
+
+			result = CashTxResult{
+				Transfer: transfer.Transfer,
+				Account:  transfer.FromAccount,
+				Entry:    transfer.FromEntry,
+			}
+			return nil
+		},
+	)
+
+	return result, err
+}
+
+// settlementAccountFor returns the settlement account in the currency of the given account,
+// leaving the locks to moveMoney
+func settlementAccountFor(ctx context.Context, q *Queries, accountID int64) (Account, error) {
+	account, err := q.GetAccountUnlocked(
+		ctx,
+		accountID,
+	)
+	if err != nil {
+		return Account{}, err
+	}
+
+	return q.GetSettlementAccount(
+		ctx,
+		account.Currency,
+	)
+}
+
+// ProvisionSystemAccounts creates the settlement, fx and revenue accounts that deposits, withdrawals,
+// fx transfers and fees book against, for every currency that doesn't have them yet
+func ProvisionSystemAccounts(ctx context.Context, q Querier, currencies []string) error {
+	for _, currency := range currencies {
+		provisions := []struct {
+			kind      string
+			provision func(context.Context, string) (int64, error)
+		}{
+			{"settlement", q.ProvisionSettlementAccount},
+			{"fx", q.ProvisionFXAccount},
+			{"revenue", q.ProvisionRevenueAccount},
+		}
+		for _, provision := range provisions {
+			created, err := provision.provision(
+				ctx,
+				currency,
+			)
+			if err != nil {
+				return fmt.Errorf(
+					"cannot create %s account for currency %s: %w",
+					provision.kind,
+					currency,
+					err,
+				)
+			}
+			if created > 0 {
+				log.Printf(
+					"Created %s account for currency %s",
+					provision.kind,
+					currency,
+				)
+			}
+		}
+	}
+	return nil
+}
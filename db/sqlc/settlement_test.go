@@ -0,0 +1,180 @@
+package db
+
+import (
+	"context"
+	"github.com/PFefe/simplebank/util"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestDepositAndWithdrawTx(t *testing.T) {
+	store := NewStore(testDB)
+	account := createAccountWithBalance(
+		t,
+		0,
+		0,
+	)
+	settlement, err := testQueries.GetSettlementAccount(
+		context.Background(),
+		account.Currency,
+	)
+	require.NoError(
+		t,
+		err,
+	)
+
+	deposit, err := store.DepositTx(
+		context.Background(),
+		CashTxParams{
+			AccountID: account.ID,
+			Amount:    100,
+		},
+	)
+	require.NoError(
+		t,
+		err,
+	)
+	require.Equal(
+		t,
+		int64(100),
+		deposit.Account.Balance,
+	)
+	require.Equal(
+		t,
+		settlement.ID,
+		deposit.Transfer.FromAccountID,
+	)
+	require.Equal(
+		t,
+		int64(100),
+		deposit.Entry.Amount,
+	)
+
+	_, err = store.WithdrawTx(
+		context.Background(),
+		CashTxParams{
+			AccountID: account.ID,
+			Amount:    101,
+		},
+	)
+	require.ErrorIs(
+		t,
+		err,
+		ErrInsufficientFunds,
+	)
+
+	withdrawal, err := store.WithdrawTx(
+		context.Background(),
+		CashTxParams{
+			AccountID: account.ID,
+			Amount:    40,
+		},
+	)
+	require.NoError(
+		t,
+		err,
+	)
+	require.Equal(
+		t,
+		int64(60),
+		withdrawal.Account.Balance,
+	)
+	require.Equal(
+		t,
+		settlement.ID,
+		withdrawal.Transfer.ToAccountID,
+	)
+	require.Equal(
+		t,
+		int64(-40),
+		withdrawal.Entry.Amount,
+	)
+
+	// the settlement account balances every deposit and withdrawal
+	updatedSettlement, err := testQueries.GetAccount(
+		context.Background(),
+		settlement.ID,
+	)
+	require.NoError(
+		t,
+		err,
+	)
+	require.Equal(
+		t,
+		settlement.Balance-60,
+		updatedSettlement.Balance,
+	)
+}
+
+func TestProvisionSystemAccounts(t *testing.T) {
+	// the migrations only create system accounts for the default currencies
+	currencies := append(
+		[]string{"JPY"},
+		util.DefaultCurrencies...,
+	)
+
+	err := ProvisionSystemAccounts(
+		context.Background(),
+		testQueries,
+		currencies,
+	)
+	require.NoError(
+		t,
+		err,
+	)
+
+	lookups := []func(context.Context, string) (Account, error){
+		testQueries.GetSettlementAccount,
+		testQueries.GetFXAccount,
+		testQueries.GetRevenueAccount,
+	}
+	accounts := make(
+		[]Account,
+		len(lookups),
+	)
+	for i, lookup := range lookups {
+		accounts[i], err = lookup(
+			context.Background(),
+			"JPY",
+		)
+		require.NoError(
+			t,
+			err,
+		)
+		require.Equal(
+			t,
+			"JPY",
+			accounts[i].Currency,
+		)
+		require.Zero(
+			t,
+			accounts[i].Balance,
+		)
+	}
+
+	// provisioning again keeps the accounts
+	err = ProvisionSystemAccounts(
+		context.Background(),
+		testQueries,
+		currencies,
+	)
+	require.NoError(
+		t,
+		err,
+	)
+	for i, lookup := range lookups {
+		account, err := lookup(
+			context.Background(),
+			"JPY",
+		)
+		require.NoError(
+			t,
+			err,
+		)
+		require.Equal(
+			t,
+			accounts[i].ID,
+			account.ID,
+		)
+	}
+}
@@ -3,14 +3,28 @@ package db
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"github.com/google/uuid"
 	"log"
+	"time"
 )
 
 // Store interface defines all store methods
 type Store interface {
 	Querier
 	TransferTx(ctx context.Context, arg TransferTxParams) (TransferTxResult, error)
+	ChangeAccountStatusTx(ctx context.Context, arg ChangeAccountStatusTxParams) (Account, error)
+	DepositTx(ctx context.Context, arg CashTxParams) (CashTxResult, error)
+	WithdrawTx(ctx context.Context, arg CashTxParams) (CashTxResult, error)
+	FXTransferTx(ctx context.Context, arg FXTransferTxParams) (TransferTxResult, error)
+	ReverseTransferTx(ctx context.Context, arg ReverseTransferTxParams) (TransferTxResult, error)
+	AuthorizeHoldTx(ctx context.Context, arg AuthorizeHoldTxParams) (HoldTxResult, error)
+	CaptureHoldTx(ctx context.Context, arg CaptureHoldTxParams) (CaptureHoldTxResult, error)
+	VoidHoldTx(ctx context.Context, holdID int64) (HoldTxResult, error)
+	ExpireHoldsTx(ctx context.Context) (int64, error)
+	CreateTransferRequestTx(ctx context.Context, arg CreateTransferRequestTxParams) (TransferRequest, error)
 }
 
 // SQLStore struct implements Store and provides methods to execute db queries and transactions
@@ -58,8 +72,27 @@ type TransferTxParams struct {
 	FromAccountID int64 `json:"from_account_id"`
 	ToAccountID   int64 `json:"to_account_id"`
 	Amount        int64 `json:"amount"`
+	// IdempotencyKey is stored together with the result when set
+	IdempotencyKey *IdempotencyKeyParams `json:"-"`
+	// QuoteID is the quote whose terms are executed; it gets marked as used when set
+	QuoteID *uuid.UUID `json:"-"`
+	// Approval is the approved transfer request being executed; it gets marked as approved when set
+	Approval *TransferApprovalParams `json:"-"`
+	// ReleaseHeld is taken off the held amount of the source account once its row is locked
+	ReleaseHeld int64 `json:"-"`
 }
 
+// IdempotencyKeyParams identifies a client request that must only be executed once
+type IdempotencyKeyParams struct {
+	Username    string
+	Key         string
+	RequestHash string
+	ExpiresAt   time.Time
+}
+
+// ErrIdempotencyKeyInUse is returned when a live idempotency key was already stored by another transaction
+var ErrIdempotencyKeyInUse = errors.New("idempotency key is already in use")
+
 // TransferTxResult is the result of the transfer transaction
 type TransferTxResult struct {
 	Transfer    Transfer `json:"transfer"`
@@ -67,6 +100,8 @@ type TransferTxResult struct {
 	ToAccount   Account  `json:"to_account"`
 	FromEntry   Entry    `json:"from_entry"`
 	ToEntry     Entry    `json:"to_entry"`
+	// Fee is charged to the source account on top of the amount, in its currency
+	Fee int64 `json:"fee"`
 }
 
 // TransferTx performs a money transfer from one account to another
@@ -77,79 +112,11 @@ func (store *SQLStore) TransferTx(ctx context.Context, arg TransferTxParams) (Tr
 		ctx,
 		func(q *Queries) error {
 			var err error
-
-			result.Transfer, err = q.CreateTransfer(
-				ctx,
-				CreateTransferParams{
-					FromAccountID: arg.FromAccountID,
-					ToAccountID:   arg.ToAccountID,
-					Amount:        arg.Amount,
-				},
-			)
-			if err != nil {
-				log.Printf(
-					"Failed to create transfer: %v",
-					err,
-				)
-				return err
-			}
-
-			result.FromEntry, err = q.CreateEntry(
+			result, err = runTransfer(
 				ctx,
-				CreateEntryParams{
-					AccountID: arg.FromAccountID,
-					Amount:    -arg.Amount,
-				},
+				q,
+				arg,
 			)
-			if err != nil {
-				log.Printf(
-					"Failed to create from entry: %v",
-					err,
-				)
-				return err
-			}
-
-			result.ToEntry, err = q.CreateEntry(
-				ctx,
-				CreateEntryParams{
-					AccountID: arg.ToAccountID,
-					Amount:    arg.Amount,
-				},
-			)
-			if err != nil {
-				log.Printf(
-					"Failed to create to entry: %v",
-					err,
-				)
-				return err
-			}
-
-			if arg.FromAccountID < arg.ToAccountID {
-				result.FromAccount, result.ToAccount, err = addMoney(
-					ctx,
-					q,
-					arg.FromAccountID,
-					-arg.Amount,
-					arg.ToAccountID,
-					arg.Amount,
-				)
-			} else {
-				result.ToAccount, result.FromAccount, err = addMoney(
-					ctx,
-					q,
-					arg.ToAccountID,
-					arg.Amount,
-					arg.FromAccountID,
-					-arg.Amount,
-				)
-			}
-
-			if err != nil {
-				log.Printf(
-					"Failed to update accounts: %v",
-					err,
-				)
-			}
 			return err
 		},
 	)
@@ -157,27 +124,273 @@ func (store *SQLStore) TransferTx(ctx context.Context, arg TransferTxParams) (Tr
 	return result, err
 }
 
-func addMoney(ctx context.Context, q *Queries, accountID1, amount1, accountID2, amount2 int64) (account1, account2 Account, err error) {
-	account1, err = q.AddAccountBalance(
+// runTransfer books a transfer and runs every check on it within the transaction of q
+func runTransfer(ctx context.Context, q *Queries, arg TransferTxParams) (TransferTxResult, error) {
+	var result TransferTxResult
+
+	fee, err := transferFeeFor(
+		ctx,
+		q,
+		arg,
+	)
+	if err != nil {
+		return result, err
+	}
+
+	result, err = moveMoney(
+		ctx,
+		q,
+		arg.FromAccountID,
+		arg.ToAccountID,
+		arg.Amount,
+		fee,
+	)
+	if err != nil {
+		return result, err
+	}
+
+	return completeTransfer(
+		ctx,
+		q,
+		arg,
+		result,
+	)
+}
+
+// completeTransfer runs the checks every booked transfer goes through once its accounts are locked,
+// then marks the quote, approval and idempotency key it used
+func completeTransfer(ctx context.Context, q *Queries, arg TransferTxParams, result TransferTxResult) (TransferTxResult, error) {
+	var err error
+	if arg.ReleaseHeld != 0 {
+		result.FromAccount, err = q.AddAccountHeldAmount(
+			ctx,
+			AddAccountHeldAmountParams{
+				Amount: -arg.ReleaseHeld,
+				ID:     arg.FromAccountID,
+			},
+		)
+		if err != nil {
+			return result, err
+		}
+	}
+
+	// both rows are locked now, so neither the status nor the balance can change under us
+	for _, account := range []Account{result.FromAccount, result.ToAccount} {
+		err = checkAccountActive(account)
+		if err != nil {
+			return result, err
+		}
+	}
+
+	err = checkSufficientFunds(
+		result.FromAccount,
+		arg.Amount+result.Fee,
+	)
+	if err != nil {
+		return result, err
+	}
+
+	err = checkTransferLimits(
+		ctx,
+		q,
+		result.FromAccount,
+		arg.Amount,
+	)
+	if err != nil {
+		return result, err
+	}
+
+	if arg.QuoteID != nil {
+		err = useQuote(
+			ctx,
+			q,
+			*arg.QuoteID,
+			result.Transfer.ID,
+		)
+		if err != nil {
+			return result, err
+		}
+	}
+
+	if arg.Approval != nil {
+		err = useApproval(
+			ctx,
+			q,
+			*arg.Approval,
+			result.Transfer.ID,
+		)
+		if err != nil {
+			return result, err
+		}
+	}
+
+	if arg.IdempotencyKey != nil {
+		return result, storeIdempotencyKey(
+			ctx,
+			q,
+			*arg.IdempotencyKey,
+			result,
+			sql.NullInt64{},
+		)
+	}
+	return result, nil
+}
+
+// moveMoney records a transfer with its entries, including the fee legs, and updates the balances, locking the accounts
+func moveMoney(ctx context.Context, q *Queries, fromAccountID int64, toAccountID int64, amount int64, fee transferFee) (TransferTxResult, error) {
+	var result TransferTxResult
+	var err error
+
+	result.Transfer, err = q.CreateTransfer(
+		ctx,
+		CreateTransferParams{
+			FromAccountID: fromAccountID,
+			ToAccountID:   toAccountID,
+			Amount:        amount,
+			ToAmount:      amount,
+			ExchangeRate:  "1",
+			Spread:        "0",
+			Fee:           fee.Amount,
+		},
+	)
+	if err != nil {
+		log.Printf(
+			"Failed to create transfer: %v",
+			err,
+		)
+		return result, err
+	}
+	result.Fee = fee.Amount
+
+	result.FromEntry, err = q.CreateEntry(
 		ctx,
-		AddAccountBalanceParams{
-			Amount: amount1,
-			ID:     accountID1,
+		CreateEntryParams{
+			AccountID: fromAccountID,
+			Amount:    -amount,
 		},
 	)
 	if err != nil {
-		return
+		log.Printf(
+			"Failed to create from entry: %v",
+			err,
+		)
+		return result, err
 	}
 
-	account2, err = q.AddAccountBalance(
+	result.ToEntry, err = q.CreateEntry(
 		ctx,
-		AddAccountBalanceParams{
-			Amount: amount2,
-			ID:     accountID2,
+		CreateEntryParams{
+			AccountID: toAccountID,
+			Amount:    amount,
 		},
 	)
 	if err != nil {
-		return
+		log.Printf(
+			"Failed to create to entry: %v",
+			err,
+		)
+		return result, err
+	}
+
+	amounts := map[int64]int64{
+		fromAccountID: -amount,
+	}
+	amounts[toAccountID] += amount
+	for _, leg := range fee.legs(fromAccountID) {
+		_, err = q.CreateEntry(
+			ctx,
+			leg,
+		)
+		if err != nil {
+			log.Printf(
+				"Failed to create fee entry: %v",
+				err,
+			)
+			return result, err
+		}
+		amounts[leg.AccountID] += leg.Amount
+	}
+
+	accounts, err := addMoneyInOrder(
+		ctx,
+		q,
+		amounts,
+	)
+	if err != nil {
+		log.Printf(
+			"Failed to update accounts: %v",
+			err,
+		)
+		return result, err
+	}
+	result.FromAccount = accounts[fromAccountID]
+	result.ToAccount = accounts[toAccountID]
+
+	return result, nil
+}
+
+// storeIdempotencyKey saves the result under the idempotency key, along with the transfer request it created if any
+func storeIdempotencyKey(ctx context.Context, q *Queries, key IdempotencyKeyParams, result interface{}, transferRequestID sql.NullInt64) error {
+	response, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+
+	_, err = q.CreateIdempotencyKey(
+		ctx,
+		CreateIdempotencyKeyParams{
+			Username:          key.Username,
+			Key:               key.Key,
+			RequestHash:       key.RequestHash,
+			Response:          response,
+			TransferRequestID: transferRequestID,
+			ExpiresAt:         key.ExpiresAt,
+		},
+	)
+	if errors.Is(
+		err,
+		sql.ErrNoRows,
+	) {
+		return ErrIdempotencyKeyInUse
+	}
+	return err
+}
+
+// ErrInsufficientFunds is matched by every InsufficientFundsError
+var ErrInsufficientFunds = errors.New("insufficient funds")
+
+// InsufficientFundsError is returned when a transfer would take an account below its overdraft limit.
+// Balance is the available balance, so money reserved by holds cannot be spent twice.
+type InsufficientFundsError struct {
+	AccountID      int64
+	Balance        int64
+	Amount         int64
+	OverdraftLimit int64
+}
+
+func (e *InsufficientFundsError) Error() string {
+	return fmt.Sprintf(
+		"account [%d] has insufficient funds: balance %d, amount %d, overdraft limit %d",
+		e.AccountID,
+		e.Balance,
+		e.Amount,
+		e.OverdraftLimit,
+	)
+}
+
+func (e *InsufficientFundsError) Unwrap() error {
+	return ErrInsufficientFunds
+}
+
+// checkSufficientFunds checks the debited account's available balance after the amount was taken out or held
+func checkSufficientFunds(account Account, amount int64) error {
+	if account.AvailableBalance+account.OverdraftLimit < 0 {
+		return &InsufficientFundsError{
+			AccountID:      account.ID,
+			Balance:        account.AvailableBalance + amount,
+			Amount:         amount,
+			OverdraftLimit: account.OverdraftLimit,
+		}
 	}
-	return
+	return nil
 }
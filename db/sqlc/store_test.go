@@ -2,18 +2,61 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"github.com/PFefe/simplebank/util"
 	"github.com/stretchr/testify/require"
 	"testing"
+	"time"
 )
 
+// createAccountWithBalance creates a random account holding exactly the given balance and overdraft limit
+func createAccountWithBalance(t *testing.T, balance int64, overdraftLimit int64) Account {
+	user := createRandomUser(t)
+	account, err := testQueries.CreateAccount(
+		context.Background(),
+		CreateAccountParams{
+			Owner:    user.Username,
+			Balance:  balance,
+			Currency: util.RandomCurrency(),
+		},
+	)
+	require.NoError(
+		t,
+		err,
+	)
+
+	account, err = testQueries.UpdateAccount(
+		context.Background(),
+		UpdateAccountParams{
+			OverdraftLimit: sql.NullInt64{
+				Int64: overdraftLimit,
+				Valid: true,
+			},
+			ID:      account.ID,
+			Version: account.Version,
+		},
+	)
+	require.NoError(
+		t,
+		err,
+	)
+	return account
+}
+
 func TestTransferTx(t *testing.T) {
 	store := NewStore(testDB)
-	account1 := createRandomAccount(t)
-	account2 := createRandomAccount(t)
-
 	n := 5
 	amount := int64(10)
 
+	account1 := createAccountWithBalance(
+		t,
+		int64(n)*amount,
+		0,
+	)
+	account2 := createRandomAccount(t)
+
 	errs := make(chan error)
 	results := make(chan TransferTxResult)
 
@@ -174,12 +217,22 @@ func TestTransferTx(t *testing.T) {
 
 func TestTransferTxDeadlock(t *testing.T) {
 	store := NewStore(testDB)
-	account1 := createRandomAccount(t)
-	account2 := createRandomAccount(t)
-
-	// Run n concurrent transfer transactions
 	n := 10
 	amount := int64(10)
+
+	// both accounts can cover every transfer in whatever order they run
+	account1 := createAccountWithBalance(
+		t,
+		int64(n)*amount,
+		0,
+	)
+	account2 := createAccountWithBalance(
+		t,
+		int64(n)*amount,
+		0,
+	)
+
+	// Run n concurrent transfer transactions
 	errs := make(chan error)
 	for i := 0; i < n; i++ {
 		fromAccountID := account1.ID
@@ -242,3 +295,214 @@ func TestTransferTxDeadlock(t *testing.T) {
 	)
 
 }
+
+func TestTransferTxInsufficientFunds(t *testing.T) {
+	testCases := []struct {
+		name           string
+		balance        int64
+		overdraftLimit int64
+	}{
+		{
+			name:           "NoOverdraft",
+			balance:        35,
+			overdraftLimit: 0,
+		},
+		{
+			name:           "WithOverdraft",
+			balance:        35,
+			overdraftLimit: 20,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(
+			tc.name,
+			func(t *testing.T) {
+				store := NewStore(testDB)
+				account1 := createAccountWithBalance(
+					t,
+					tc.balance,
+					tc.overdraftLimit,
+				)
+				account2 := createRandomAccount(t)
+
+				// Run more concurrent transfers than the balance can cover
+				n := 10
+				amount := int64(10)
+				errs := make(chan error)
+				for i := 0; i < n; i++ {
+					go func() {
+						_, err := store.TransferTx(
+							context.Background(),
+							TransferTxParams{
+								FromAccountID: account1.ID,
+								ToAccountID:   account2.ID,
+								Amount:        amount,
+							},
+						)
+						errs <- err
+					}()
+				}
+
+				succeeded := 0
+				for i := 0; i < n; i++ {
+					err := <-errs
+					if err == nil {
+						succeeded++
+						continue
+					}
+					require.True(
+						t,
+						errors.Is(
+							err,
+							ErrInsufficientFunds,
+						),
+					)
+				}
+
+				expected := int((tc.balance + tc.overdraftLimit) / amount)
+				require.Equal(
+					t,
+					expected,
+					succeeded,
+				)
+
+				updatedAccount1, err := store.GetAccount(
+					context.Background(),
+					account1.ID,
+				)
+				require.NoError(
+					t,
+					err,
+				)
+				require.Equal(
+					t,
+					tc.balance-int64(succeeded)*amount,
+					updatedAccount1.Balance,
+				)
+				require.GreaterOrEqual(
+					t,
+					updatedAccount1.Balance,
+					-tc.overdraftLimit,
+				)
+
+				updatedAccount2, err := store.GetAccount(
+					context.Background(),
+					account2.ID,
+				)
+				require.NoError(
+					t,
+					err,
+				)
+				require.Equal(
+					t,
+					account2.Balance+int64(succeeded)*amount,
+					updatedAccount2.Balance,
+				)
+			},
+		)
+	}
+}
+
+func TestTransferTxIdempotencyKey(t *testing.T) {
+	store := NewStore(testDB)
+	n := 5
+	amount := int64(10)
+
+	account1 := createAccountWithBalance(
+		t,
+		int64(n)*amount,
+		0,
+	)
+	account2 := createRandomAccount(t)
+
+	key := IdempotencyKeyParams{
+		Username:    account1.Owner,
+		Key:         util.RandomString(16),
+		RequestHash: util.RandomString(32),
+		ExpiresAt:   time.Now().Add(time.Hour),
+	}
+
+	// Run n concurrent retries of the same request
+	errs := make(chan error)
+	for i := 0; i < n; i++ {
+		go func() {
+			_, err := store.TransferTx(
+				context.Background(),
+				TransferTxParams{
+					FromAccountID:  account1.ID,
+					ToAccountID:    account2.ID,
+					Amount:         amount,
+					IdempotencyKey: &key,
+				},
+			)
+			errs <- err
+		}()
+	}
+
+	succeeded := 0
+	for i := 0; i < n; i++ {
+		err := <-errs
+		if err == nil {
+			succeeded++
+			continue
+		}
+		require.ErrorIs(
+			t,
+			err,
+			ErrIdempotencyKeyInUse,
+		)
+	}
+	require.Equal(
+		t,
+		1,
+		succeeded,
+	)
+
+	stored, err := store.GetIdempotencyKey(
+		context.Background(),
+		GetIdempotencyKeyParams{
+			Username: key.Username,
+			Key:      key.Key,
+		},
+	)
+	require.NoError(
+		t,
+		err,
+	)
+	require.Equal(
+		t,
+		key.RequestHash,
+		stored.RequestHash,
+	)
+
+	var result TransferTxResult
+	err = json.Unmarshal(
+		stored.Response,
+		&result,
+	)
+	require.NoError(
+		t,
+		err,
+	)
+	require.Equal(
+		t,
+		account1.ID,
+		result.Transfer.FromAccountID,
+	)
+
+	updatedAccount1, err := store.GetAccount(
+		context.Background(),
+		account1.ID,
+	)
+	require.NoError(
+		t,
+		err,
+	)
+	require.Equal(
+		t,
+		account1.Balance-amount,
+		updatedAccount1.Balance,
+	)
+}
@@ -7,22 +7,90 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"time"
 )
 
+const createReversalTransfer = `-- name: CreateReversalTransfer :one
+INSERT INTO transfers (from_account_id, to_account_id, amount, to_amount, exchange_rate, spread, fee, currency,
+                       to_currency, reversal_of)
+SELECT original.to_account_id,
+       original.from_account_id,
+       original.to_amount,
+       original.amount,
+       trim_scale(round(1 / original.exchange_rate, 10)),
+       0,
+       0,
+       original.to_currency,
+       original.currency,
+       original.id
+FROM transfers AS original
+WHERE original.id = $1
+RETURNING id, from_account_id, to_account_id, amount, created_at, to_amount, exchange_rate, spread, currency, to_currency, fee, status, reversal_of
+`
+
+// books the reversal of a transfer from its destination back to its source with the amounts swapped,
+// so both accounts get back exactly what they had; the fee is refunded by separate entries
+func (q *Queries) CreateReversalTransfer(ctx context.Context, id int64) (Transfer, error) {
+	row := q.db.QueryRowContext(ctx, createReversalTransfer, id)
+	var i Transfer
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.CreatedAt,
+		&i.ToAmount,
+		&i.ExchangeRate,
+		&i.Spread,
+		&i.Currency,
+		&i.ToCurrency,
+		&i.Fee,
+		&i.Status,
+		&i.ReversalOf,
+	)
+	return i, err
+}
+
 const createTransfer = `-- name: CreateTransfer :one
-INSERT INTO transfers (from_account_id, to_account_id, amount)
-VALUES ($1, $2, $3)
-RETURNING id, from_account_id, to_account_id, amount, created_at
+INSERT INTO transfers (from_account_id, to_account_id, amount, to_amount, exchange_rate, spread, fee, currency, to_currency)
+SELECT from_account.id,
+       to_account.id,
+       $1::bigint,
+       $2::bigint,
+       $3::numeric,
+       $4::numeric,
+       $5::bigint,
+       from_account.currency,
+       to_account.currency
+FROM accounts AS from_account,
+     accounts AS to_account
+WHERE from_account.id = $6
+  AND to_account.id = $7
+RETURNING id, from_account_id, to_account_id, amount, created_at, to_amount, exchange_rate, spread, currency, to_currency, fee, status, reversal_of
 `
 
 type CreateTransferParams struct {
-	FromAccountID int64 `json:"from_account_id"`
-	ToAccountID   int64 `json:"to_account_id"`
-	Amount        int64 `json:"amount"`
+	Amount        int64  `json:"amount"`
+	ToAmount      int64  `json:"to_amount"`
+	ExchangeRate  string `json:"exchange_rate"`
+	Spread        string `json:"spread"`
+	Fee           int64  `json:"fee"`
+	FromAccountID int64  `json:"from_account_id"`
+	ToAccountID   int64  `json:"to_account_id"`
 }
 
+// the currencies are copied from the accounts, so a transfer can be read without them
 func (q *Queries) CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfer, error) {
-	row := q.db.QueryRowContext(ctx, createTransfer, arg.FromAccountID, arg.ToAccountID, arg.Amount)
+	row := q.db.QueryRowContext(ctx, createTransfer,
+		arg.Amount,
+		arg.ToAmount,
+		arg.ExchangeRate,
+		arg.Spread,
+		arg.Fee,
+		arg.FromAccountID,
+		arg.ToAccountID,
+	)
 	var i Transfer
 	err := row.Scan(
 		&i.ID,
@@ -30,12 +98,20 @@ func (q *Queries) CreateTransfer(ctx context.Context, arg CreateTransferParams)
 		&i.ToAccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.ToAmount,
+		&i.ExchangeRate,
+		&i.Spread,
+		&i.Currency,
+		&i.ToCurrency,
+		&i.Fee,
+		&i.Status,
+		&i.ReversalOf,
 	)
 	return i, err
 }
 
 const getTransfer = `-- name: GetTransfer :one
-SELECT id, from_account_id, to_account_id, amount, created_at
+SELECT id, from_account_id, to_account_id, amount, created_at, to_amount, exchange_rate, spread, currency, to_currency, fee, status, reversal_of
 FROM transfers
 WHERE id = $1
 LIMIT 1
@@ -50,32 +126,90 @@ func (q *Queries) GetTransfer(ctx context.Context, id int64) (Transfer, error) {
 		&i.ToAccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.ToAmount,
+		&i.ExchangeRate,
+		&i.Spread,
+		&i.Currency,
+		&i.ToCurrency,
+		&i.Fee,
+		&i.Status,
+		&i.ReversalOf,
+	)
+	return i, err
+}
+
+const getTransferForUpdate = `-- name: GetTransferForUpdate :one
+SELECT id, from_account_id, to_account_id, amount, created_at, to_amount, exchange_rate, spread, currency, to_currency, fee, status, reversal_of
+FROM transfers
+WHERE id = $1
+LIMIT 1
+FOR NO KEY UPDATE
+`
+
+func (q *Queries) GetTransferForUpdate(ctx context.Context, id int64) (Transfer, error) {
+	row := q.db.QueryRowContext(ctx, getTransferForUpdate, id)
+	var i Transfer
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.CreatedAt,
+		&i.ToAmount,
+		&i.ExchangeRate,
+		&i.Spread,
+		&i.Currency,
+		&i.ToCurrency,
+		&i.Fee,
+		&i.Status,
+		&i.ReversalOf,
 	)
 	return i, err
 }
 
 const listTransfers = `-- name: ListTransfers :many
-SELECT id, from_account_id, to_account_id, amount, created_at
+SELECT id, from_account_id, to_account_id, amount, created_at, to_amount, exchange_rate, spread, currency, to_currency, fee, status, reversal_of
 FROM transfers
-WHERE from_account_id = $1
-   OR to_account_id = $2
+WHERE (($1::bool AND from_account_id = $2)
+    OR ($3::bool AND to_account_id = $2))
+  AND ($4::bigint IS NULL
+    OR from_account_id = $4
+    OR to_account_id = $4)
+  AND ($5::timestamptz IS NULL OR created_at >= $5)
+  AND ($6::timestamptz IS NULL OR created_at < $6)
+  AND ($7::bigint IS NULL OR amount >= $7)
+  AND ($8::bigint IS NULL OR amount <= $8)
 ORDER BY id
-LIMIT $3 OFFSET $4
+LIMIT $10 OFFSET $9
 `
 
 type ListTransfersParams struct {
-	FromAccountID int64 `json:"from_account_id"`
-	ToAccountID   int64 `json:"to_account_id"`
-	Limit         int32 `json:"limit"`
-	Offset        int32 `json:"offset"`
+	IncludeOutgoing bool          `json:"include_outgoing"`
+	AccountID       int64         `json:"account_id"`
+	IncludeIncoming bool          `json:"include_incoming"`
+	CounterpartyID  sql.NullInt64 `json:"counterparty_id"`
+	FromTime        sql.NullTime  `json:"from_time"`
+	ToTime          sql.NullTime  `json:"to_time"`
+	MinAmount       sql.NullInt64 `json:"min_amount"`
+	MaxAmount       sql.NullInt64 `json:"max_amount"`
+	Offset          int32         `json:"offset"`
+	Limit           int32         `json:"limit"`
 }
 
+// lists the transfers of an account in the directions enabled by include_outgoing and include_incoming;
+// every other filter is skipped when its argument is null
 func (q *Queries) ListTransfers(ctx context.Context, arg ListTransfersParams) ([]Transfer, error) {
 	rows, err := q.db.QueryContext(ctx, listTransfers,
-		arg.FromAccountID,
-		arg.ToAccountID,
-		arg.Limit,
+		arg.IncludeOutgoing,
+		arg.AccountID,
+		arg.IncludeIncoming,
+		arg.CounterpartyID,
+		arg.FromTime,
+		arg.ToTime,
+		arg.MinAmount,
+		arg.MaxAmount,
 		arg.Offset,
+		arg.Limit,
 	)
 	if err != nil {
 		return nil, err
@@ -90,6 +224,14 @@ func (q *Queries) ListTransfers(ctx context.Context, arg ListTransfersParams) ([
 			&i.ToAccountID,
 			&i.Amount,
 			&i.CreatedAt,
+			&i.ToAmount,
+			&i.ExchangeRate,
+			&i.Spread,
+			&i.Currency,
+			&i.ToCurrency,
+			&i.Fee,
+			&i.Status,
+			&i.ReversalOf,
 		); err != nil {
 			return nil, err
 		}
@@ -103,3 +245,201 @@ func (q *Queries) ListTransfers(ctx context.Context, arg ListTransfersParams) ([
 	}
 	return items, nil
 }
+
+const listTransfersAfter = `-- name: ListTransfersAfter :many
+SELECT id, from_account_id, to_account_id, amount, created_at, to_amount, exchange_rate, spread, currency, to_currency, fee, status, reversal_of
+FROM transfers
+WHERE (($1::bool AND from_account_id = $2)
+    OR ($3::bool AND to_account_id = $2))
+  AND ($4::bigint IS NULL
+    OR from_account_id = $4
+    OR to_account_id = $4)
+  AND ($5::timestamptz IS NULL OR created_at >= $5)
+  AND ($6::timestamptz IS NULL OR created_at < $6)
+  AND ($7::bigint IS NULL OR amount >= $7)
+  AND ($8::bigint IS NULL OR amount <= $8)
+  AND (created_at, id) > ($9::timestamptz, $10::bigint)
+ORDER BY created_at, id
+LIMIT $11
+`
+
+type ListTransfersAfterParams struct {
+	IncludeOutgoing bool          `json:"include_outgoing"`
+	AccountID       int64         `json:"account_id"`
+	IncludeIncoming bool          `json:"include_incoming"`
+	CounterpartyID  sql.NullInt64 `json:"counterparty_id"`
+	FromTime        sql.NullTime  `json:"from_time"`
+	ToTime          sql.NullTime  `json:"to_time"`
+	MinAmount       sql.NullInt64 `json:"min_amount"`
+	MaxAmount       sql.NullInt64 `json:"max_amount"`
+	CursorCreatedAt time.Time     `json:"cursor_created_at"`
+	CursorID        int64         `json:"cursor_id"`
+	Limit           int32         `json:"limit"`
+}
+
+// keyset page of the transfers that come after the cursor, filtered like ListTransfers
+func (q *Queries) ListTransfersAfter(ctx context.Context, arg ListTransfersAfterParams) ([]Transfer, error) {
+	rows, err := q.db.QueryContext(ctx, listTransfersAfter,
+		arg.IncludeOutgoing,
+		arg.AccountID,
+		arg.IncludeIncoming,
+		arg.CounterpartyID,
+		arg.FromTime,
+		arg.ToTime,
+		arg.MinAmount,
+		arg.MaxAmount,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Transfer{}
+	for rows.Next() {
+		var i Transfer
+		if err := rows.Scan(
+			&i.ID,
+			&i.FromAccountID,
+			&i.ToAccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.ToAmount,
+			&i.ExchangeRate,
+			&i.Spread,
+			&i.Currency,
+			&i.ToCurrency,
+			&i.Fee,
+			&i.Status,
+			&i.ReversalOf,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTransfersBefore = `-- name: ListTransfersBefore :many
+SELECT id, from_account_id, to_account_id, amount, created_at, to_amount, exchange_rate, spread, currency, to_currency, fee, status, reversal_of
+FROM transfers
+WHERE (($1::bool AND from_account_id = $2)
+    OR ($3::bool AND to_account_id = $2))
+  AND ($4::bigint IS NULL
+    OR from_account_id = $4
+    OR to_account_id = $4)
+  AND ($5::timestamptz IS NULL OR created_at >= $5)
+  AND ($6::timestamptz IS NULL OR created_at < $6)
+  AND ($7::bigint IS NULL OR amount >= $7)
+  AND ($8::bigint IS NULL OR amount <= $8)
+  AND (created_at, id) < ($9::timestamptz, $10::bigint)
+ORDER BY created_at DESC, id DESC
+LIMIT $11
+`
+
+type ListTransfersBeforeParams struct {
+	IncludeOutgoing bool          `json:"include_outgoing"`
+	AccountID       int64         `json:"account_id"`
+	IncludeIncoming bool          `json:"include_incoming"`
+	CounterpartyID  sql.NullInt64 `json:"counterparty_id"`
+	FromTime        sql.NullTime  `json:"from_time"`
+	ToTime          sql.NullTime  `json:"to_time"`
+	MinAmount       sql.NullInt64 `json:"min_amount"`
+	MaxAmount       sql.NullInt64 `json:"max_amount"`
+	CursorCreatedAt time.Time     `json:"cursor_created_at"`
+	CursorID        int64         `json:"cursor_id"`
+	Limit           int32         `json:"limit"`
+}
+
+// keyset page of the transfers that come before the cursor, closest first
+func (q *Queries) ListTransfersBefore(ctx context.Context, arg ListTransfersBeforeParams) ([]Transfer, error) {
+	rows, err := q.db.QueryContext(ctx, listTransfersBefore,
+		arg.IncludeOutgoing,
+		arg.AccountID,
+		arg.IncludeIncoming,
+		arg.CounterpartyID,
+		arg.FromTime,
+		arg.ToTime,
+		arg.MinAmount,
+		arg.MaxAmount,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Transfer{}
+	for rows.Next() {
+		var i Transfer
+		if err := rows.Scan(
+			&i.ID,
+			&i.FromAccountID,
+			&i.ToAccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.ToAmount,
+			&i.ExchangeRate,
+			&i.Spread,
+			&i.Currency,
+			&i.ToCurrency,
+			&i.Fee,
+			&i.Status,
+			&i.ReversalOf,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateTransferStatus = `-- name: UpdateTransferStatus :one
+UPDATE transfers
+SET status = $1
+WHERE id = $2
+  AND status = $3
+RETURNING id, from_account_id, to_account_id, amount, created_at, to_amount, exchange_rate, spread, currency, to_currency, fee, status, reversal_of
+`
+
+type UpdateTransferStatusParams struct {
+	Status     TransferStatus `json:"status"`
+	ID         int64          `json:"id"`
+	FromStatus TransferStatus `json:"from_status"`
+}
+
+// moves the transfer to status only if it is still in from_status, so concurrent changes cannot be lost
+func (q *Queries) UpdateTransferStatus(ctx context.Context, arg UpdateTransferStatusParams) (Transfer, error) {
+	row := q.db.QueryRowContext(ctx, updateTransferStatus, arg.Status, arg.ID, arg.FromStatus)
+	var i Transfer
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.CreatedAt,
+		&i.ToAmount,
+		&i.ExchangeRate,
+		&i.Spread,
+		&i.Currency,
+		&i.ToCurrency,
+		&i.Fee,
+		&i.Status,
+		&i.ReversalOf,
+	)
+	return i, err
+}
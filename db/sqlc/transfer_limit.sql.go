@@ -0,0 +1,226 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.26.0
+// source: transfer_limit.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+)
+
+const getAccountLimits = `-- name: GetAccountLimits :one
+SELECT account_id, max_amount, daily_amount, hourly_count, updated_at
+FROM account_limits
+WHERE account_id = $1
+LIMIT 1
+`
+
+func (q *Queries) GetAccountLimits(ctx context.Context, accountID int64) (AccountLimit, error) {
+	row := q.db.QueryRowContext(ctx, getAccountLimits, accountID)
+	var i AccountLimit
+	err := row.Scan(
+		&i.AccountID,
+		&i.MaxAmount,
+		&i.DailyAmount,
+		&i.HourlyCount,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getAccountTransferVelocity = `-- name: GetAccountTransferVelocity :one
+SELECT COALESCE(SUM(amount), 0)::bigint AS daily_amount,
+       COUNT(*) FILTER (WHERE created_at > now() - interval '1 hour')::bigint AS hourly_count
+FROM transfers
+WHERE from_account_id = $1
+  AND reversal_of IS NULL
+  AND created_at > now() - interval '24 hours'
+`
+
+type GetAccountTransferVelocityRow struct {
+	DailyAmount int64 `json:"daily_amount"`
+	HourlyCount int64 `json:"hourly_count"`
+}
+
+// sums up the transfers out of the account in the last 24 hours and counts those of the last hour;
+// reversals are left out, since they give money back rather than spend it
+func (q *Queries) GetAccountTransferVelocity(ctx context.Context, fromAccountID int64) (GetAccountTransferVelocityRow, error) {
+	row := q.db.QueryRowContext(ctx, getAccountTransferVelocity, fromAccountID)
+	var i GetAccountTransferVelocityRow
+	err := row.Scan(&i.DailyAmount, &i.HourlyCount)
+	return i, err
+}
+
+const getUserLimitsForUpdate = `-- name: GetUserLimitsForUpdate :one
+SELECT username, currency, max_amount, daily_amount, hourly_count, updated_at
+FROM user_limits
+WHERE username = $1
+  AND currency = $2
+LIMIT 1
+FOR NO KEY UPDATE
+`
+
+type GetUserLimitsForUpdateParams struct {
+	Username string `json:"username"`
+	Currency string `json:"currency"`
+}
+
+// locks the limits of the user, so transfers out of different accounts of the user are checked one at a time
+func (q *Queries) GetUserLimitsForUpdate(ctx context.Context, arg GetUserLimitsForUpdateParams) (UserLimit, error) {
+	row := q.db.QueryRowContext(ctx, getUserLimitsForUpdate, arg.Username, arg.Currency)
+	var i UserLimit
+	err := row.Scan(
+		&i.Username,
+		&i.Currency,
+		&i.MaxAmount,
+		&i.DailyAmount,
+		&i.HourlyCount,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getUserTransferVelocity = `-- name: GetUserTransferVelocity :one
+SELECT COALESCE(SUM(transfers.amount), 0)::bigint AS daily_amount,
+       COUNT(*) FILTER (WHERE transfers.created_at > now() - interval '1 hour')::bigint AS hourly_count
+FROM transfers
+         JOIN accounts ON accounts.id = transfers.from_account_id
+WHERE accounts.owner = $1
+  AND accounts.currency = $2
+  AND transfers.reversal_of IS NULL
+  AND transfers.created_at > now() - interval '24 hours'
+`
+
+type GetUserTransferVelocityParams struct {
+	Owner    string `json:"owner"`
+	Currency string `json:"currency"`
+}
+
+type GetUserTransferVelocityRow struct {
+	DailyAmount int64 `json:"daily_amount"`
+	HourlyCount int64 `json:"hourly_count"`
+}
+
+// sums up the transfers out of the accounts of the user in the currency, like GetAccountTransferVelocity
+func (q *Queries) GetUserTransferVelocity(ctx context.Context, arg GetUserTransferVelocityParams) (GetUserTransferVelocityRow, error) {
+	row := q.db.QueryRowContext(ctx, getUserTransferVelocity, arg.Owner, arg.Currency)
+	var i GetUserTransferVelocityRow
+	err := row.Scan(&i.DailyAmount, &i.HourlyCount)
+	return i, err
+}
+
+const listUserLimits = `-- name: ListUserLimits :many
+SELECT username, currency, max_amount, daily_amount, hourly_count, updated_at
+FROM user_limits
+WHERE username = $1
+ORDER BY currency
+`
+
+func (q *Queries) ListUserLimits(ctx context.Context, username string) ([]UserLimit, error) {
+	rows, err := q.db.QueryContext(ctx, listUserLimits, username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []UserLimit{}
+	for rows.Next() {
+		var i UserLimit
+		if err := rows.Scan(
+			&i.Username,
+			&i.Currency,
+			&i.MaxAmount,
+			&i.DailyAmount,
+			&i.HourlyCount,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const upsertAccountLimits = `-- name: UpsertAccountLimits :one
+INSERT INTO account_limits (account_id, max_amount, daily_amount, hourly_count)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (account_id) DO UPDATE
+    SET max_amount   = excluded.max_amount,
+        daily_amount = excluded.daily_amount,
+        hourly_count = excluded.hourly_count,
+        updated_at   = now()
+RETURNING account_id, max_amount, daily_amount, hourly_count, updated_at
+`
+
+type UpsertAccountLimitsParams struct {
+	AccountID   int64         `json:"account_id"`
+	MaxAmount   sql.NullInt64 `json:"max_amount"`
+	DailyAmount sql.NullInt64 `json:"daily_amount"`
+	HourlyCount sql.NullInt64 `json:"hourly_count"`
+}
+
+// replaces every cap of the account, a null clears it
+func (q *Queries) UpsertAccountLimits(ctx context.Context, arg UpsertAccountLimitsParams) (AccountLimit, error) {
+	row := q.db.QueryRowContext(ctx, upsertAccountLimits,
+		arg.AccountID,
+		arg.MaxAmount,
+		arg.DailyAmount,
+		arg.HourlyCount,
+	)
+	var i AccountLimit
+	err := row.Scan(
+		&i.AccountID,
+		&i.MaxAmount,
+		&i.DailyAmount,
+		&i.HourlyCount,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const upsertUserLimits = `-- name: UpsertUserLimits :one
+INSERT INTO user_limits (username, currency, max_amount, daily_amount, hourly_count)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (username, currency) DO UPDATE
+    SET max_amount   = excluded.max_amount,
+        daily_amount = excluded.daily_amount,
+        hourly_count = excluded.hourly_count,
+        updated_at   = now()
+RETURNING username, currency, max_amount, daily_amount, hourly_count, updated_at
+`
+
+type UpsertUserLimitsParams struct {
+	Username    string        `json:"username"`
+	Currency    string        `json:"currency"`
+	MaxAmount   sql.NullInt64 `json:"max_amount"`
+	DailyAmount sql.NullInt64 `json:"daily_amount"`
+	HourlyCount sql.NullInt64 `json:"hourly_count"`
+}
+
+// replaces every cap of the user in the currency, a null clears it
+func (q *Queries) UpsertUserLimits(ctx context.Context, arg UpsertUserLimitsParams) (UserLimit, error) {
+	row := q.db.QueryRowContext(ctx, upsertUserLimits,
+		arg.Username,
+		arg.Currency,
+		arg.MaxAmount,
+		arg.DailyAmount,
+		arg.HourlyCount,
+	)
+	var i UserLimit
+	err := row.Scan(
+		&i.Username,
+		&i.Currency,
+		&i.MaxAmount,
+		&i.DailyAmount,
+		&i.HourlyCount,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
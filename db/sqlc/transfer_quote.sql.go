@@ -0,0 +1,146 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.26.0
+// source: transfer_quote.sql
+
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const createTransferQuote = `-- name: CreateTransferQuote :one
+INSERT INTO transfer_quotes (id,
+                             username,
+                             from_account_id,
+                             to_account_id,
+                             amount,
+                             currency,
+                             to_amount,
+                             to_currency,
+                             exchange_rate,
+                             spread,
+                             fee,
+                             expires_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+RETURNING id, username, from_account_id, to_account_id, amount, currency, to_amount, to_currency, exchange_rate, spread, transfer_id, expires_at, created_at, fee
+`
+
+type CreateTransferQuoteParams struct {
+	ID            uuid.UUID `json:"id"`
+	Username      string    `json:"username"`
+	FromAccountID int64     `json:"from_account_id"`
+	ToAccountID   int64     `json:"to_account_id"`
+	Amount        int64     `json:"amount"`
+	Currency      string    `json:"currency"`
+	ToAmount      int64     `json:"to_amount"`
+	ToCurrency    string    `json:"to_currency"`
+	ExchangeRate  string    `json:"exchange_rate"`
+	Spread        string    `json:"spread"`
+	Fee           int64     `json:"fee"`
+	ExpiresAt     time.Time `json:"expires_at"`
+}
+
+func (q *Queries) CreateTransferQuote(ctx context.Context, arg CreateTransferQuoteParams) (TransferQuote, error) {
+	row := q.db.QueryRowContext(ctx, createTransferQuote,
+		arg.ID,
+		arg.Username,
+		arg.FromAccountID,
+		arg.ToAccountID,
+		arg.Amount,
+		arg.Currency,
+		arg.ToAmount,
+		arg.ToCurrency,
+		arg.ExchangeRate,
+		arg.Spread,
+		arg.Fee,
+		arg.ExpiresAt,
+	)
+	var i TransferQuote
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.Currency,
+		&i.ToAmount,
+		&i.ToCurrency,
+		&i.ExchangeRate,
+		&i.Spread,
+		&i.TransferID,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.Fee,
+	)
+	return i, err
+}
+
+const getTransferQuote = `-- name: GetTransferQuote :one
+SELECT id, username, from_account_id, to_account_id, amount, currency, to_amount, to_currency, exchange_rate, spread, transfer_id, expires_at, created_at, fee
+FROM transfer_quotes
+WHERE id = $1
+LIMIT 1
+`
+
+func (q *Queries) GetTransferQuote(ctx context.Context, id uuid.UUID) (TransferQuote, error) {
+	row := q.db.QueryRowContext(ctx, getTransferQuote, id)
+	var i TransferQuote
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.Currency,
+		&i.ToAmount,
+		&i.ToCurrency,
+		&i.ExchangeRate,
+		&i.Spread,
+		&i.TransferID,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.Fee,
+	)
+	return i, err
+}
+
+const useTransferQuote = `-- name: UseTransferQuote :one
+UPDATE transfer_quotes
+SET transfer_id = $1::bigint
+WHERE id = $2
+  AND transfer_id IS NULL
+  AND expires_at > now()
+RETURNING id, username, from_account_id, to_account_id, amount, currency, to_amount, to_currency, exchange_rate, spread, transfer_id, expires_at, created_at, fee
+`
+
+type UseTransferQuoteParams struct {
+	TransferID int64     `json:"transfer_id"`
+	ID         uuid.UUID `json:"id"`
+}
+
+// links the quote to the transfer that used it, unless it expired or was used already
+func (q *Queries) UseTransferQuote(ctx context.Context, arg UseTransferQuoteParams) (TransferQuote, error) {
+	row := q.db.QueryRowContext(ctx, useTransferQuote, arg.TransferID, arg.ID)
+	var i TransferQuote
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.Currency,
+		&i.ToAmount,
+		&i.ToCurrency,
+		&i.ExchangeRate,
+		&i.Spread,
+		&i.TransferID,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.Fee,
+	)
+	return i, err
+}
@@ -0,0 +1,220 @@
+package db
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func createRandomTransferQuote(t *testing.T, fromAccount Account, toAccount Account, expiresAt time.Time) TransferQuote {
+	arg := CreateTransferQuoteParams{
+		ID:            uuid.New(),
+		Username:      fromAccount.Owner,
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        10,
+		Currency:      fromAccount.Currency,
+		ToAmount:      10,
+		ToCurrency:    toAccount.Currency,
+		ExchangeRate:  "1",
+		Spread:        "0",
+		ExpiresAt:     expiresAt,
+	}
+	quote, err := testQueries.CreateTransferQuote(
+		context.Background(),
+		arg,
+	)
+	require.NoError(
+		t,
+		err,
+	)
+	require.Equal(
+		t,
+		arg.ID,
+		quote.ID,
+	)
+	require.Equal(
+		t,
+		arg.ToAmount,
+		quote.ToAmount,
+	)
+	require.False(
+		t,
+		quote.TransferID.Valid,
+	)
+	return quote
+}
+
+func TestTransferTxWithQuote(t *testing.T) {
+	store := NewStore(testDB)
+	fromAccount := createAccountWithBalance(
+		t,
+		100,
+		0,
+	)
+	toAccount, err := testQueries.CreateAccount(
+		context.Background(),
+		CreateAccountParams{
+			Owner:    createRandomUser(t).Username,
+			Balance:  0,
+			Currency: fromAccount.Currency,
+		},
+	)
+	require.NoError(
+		t,
+		err,
+	)
+
+	quote := createRandomTransferQuote(
+		t,
+		fromAccount,
+		toAccount,
+		time.Now().Add(time.Minute),
+	)
+	arg := TransferTxParams{
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        quote.Amount,
+		QuoteID:       &quote.ID,
+	}
+
+	result, err := store.TransferTx(
+		context.Background(),
+		arg,
+	)
+	require.NoError(
+		t,
+		err,
+	)
+
+	usedQuote, err := testQueries.GetTransferQuote(
+		context.Background(),
+		quote.ID,
+	)
+	require.NoError(
+		t,
+		err,
+	)
+	require.Equal(
+		t,
+		result.Transfer.ID,
+		usedQuote.TransferID.Int64,
+	)
+
+	// a quote only guarantees its terms once
+	_, err = store.TransferTx(
+		context.Background(),
+		arg,
+	)
+	require.ErrorIs(
+		t,
+		err,
+		ErrQuoteUnavailable,
+	)
+
+	expired := createRandomTransferQuote(
+		t,
+		fromAccount,
+		toAccount,
+		time.Now().Add(-time.Second),
+	)
+	arg.QuoteID = &expired.ID
+	_, err = store.TransferTx(
+		context.Background(),
+		arg,
+	)
+	require.ErrorIs(
+		t,
+		err,
+		ErrQuoteUnavailable,
+	)
+
+	// the failed transfers were rolled back
+	account, err := testQueries.GetAccount(
+		context.Background(),
+		fromAccount.ID,
+	)
+	require.NoError(
+		t,
+		err,
+	)
+	require.Equal(
+		t,
+		fromAccount.Balance-quote.Amount,
+		account.Balance,
+	)
+}
+
+func TestTransferTxQuotedFee(t *testing.T) {
+	store := NewStore(testDB)
+	fromAccount := createSavingsAccount(
+		t,
+		100,
+	)
+	toAccount, err := testQueries.CreateAccount(
+		context.Background(),
+		CreateAccountParams{
+			Owner:    createRandomUser(t).Username,
+			Balance:  0,
+			Currency: fromAccount.Currency,
+		},
+	)
+	require.NoError(
+		t,
+		err,
+	)
+
+	// the savings schedule has no tiers now, so only the quote can charge a fee
+	quote, err := testQueries.CreateTransferQuote(
+		context.Background(),
+		CreateTransferQuoteParams{
+			ID:            uuid.New(),
+			Username:      fromAccount.Owner,
+			FromAccountID: fromAccount.ID,
+			ToAccountID:   toAccount.ID,
+			Amount:        10,
+			Currency:      fromAccount.Currency,
+			ToAmount:      10,
+			ToCurrency:    toAccount.Currency,
+			ExchangeRate:  "1",
+			Spread:        "0",
+			Fee:           7,
+			ExpiresAt:     time.Now().Add(time.Minute),
+		},
+	)
+	require.NoError(
+		t,
+		err,
+	)
+
+	result, err := store.TransferTx(
+		context.Background(),
+		TransferTxParams{
+			FromAccountID: fromAccount.ID,
+			ToAccountID:   toAccount.ID,
+			Amount:        quote.Amount,
+			QuoteID:       &quote.ID,
+		},
+	)
+	require.NoError(
+		t,
+		err,
+	)
+	require.Equal(
+		t,
+		quote.Fee,
+		result.Fee,
+	)
+	require.Equal(
+		t,
+		quote.Fee,
+		result.Transfer.Fee,
+	)
+	require.Equal(
+		t,
+		int64(83),
+		result.FromAccount.Balance,
+	)
+}
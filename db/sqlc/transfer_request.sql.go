@@ -0,0 +1,343 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.26.0
+// source: transfer_request.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+const approveTransferRequest = `-- name: ApproveTransferRequest :one
+UPDATE transfer_requests
+SET status      = 'approved',
+    reviewed_by = $1,
+    reviewed_at = now(),
+    transfer_id = $2::bigint
+WHERE id = $3
+  AND status = 'pending_approval'
+  AND expires_at > now()
+RETURNING id, requested_by, from_account_id, to_account_id, amount, currency, status, reviewed_by, reviewed_at, transfer_id, expires_at, created_at
+`
+
+type ApproveTransferRequestParams struct {
+	ReviewedBy sql.NullString `json:"reviewed_by"`
+	TransferID int64          `json:"transfer_id"`
+	ID         int64          `json:"id"`
+}
+
+// records the approval and the transfer it executed, unless the request was reviewed already or expired
+func (q *Queries) ApproveTransferRequest(ctx context.Context, arg ApproveTransferRequestParams) (TransferRequest, error) {
+	row := q.db.QueryRowContext(ctx, approveTransferRequest, arg.ReviewedBy, arg.TransferID, arg.ID)
+	var i TransferRequest
+	err := row.Scan(
+		&i.ID,
+		&i.RequestedBy,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.Currency,
+		&i.Status,
+		&i.ReviewedBy,
+		&i.ReviewedAt,
+		&i.TransferID,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createTransferRequest = `-- name: CreateTransferRequest :one
+INSERT INTO transfer_requests (requested_by,
+                               from_account_id,
+                               to_account_id,
+                               amount,
+                               currency,
+                               expires_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, requested_by, from_account_id, to_account_id, amount, currency, status, reviewed_by, reviewed_at, transfer_id, expires_at, created_at
+`
+
+type CreateTransferRequestParams struct {
+	RequestedBy   string    `json:"requested_by"`
+	FromAccountID int64     `json:"from_account_id"`
+	ToAccountID   int64     `json:"to_account_id"`
+	Amount        int64     `json:"amount"`
+	Currency      string    `json:"currency"`
+	ExpiresAt     time.Time `json:"expires_at"`
+}
+
+func (q *Queries) CreateTransferRequest(ctx context.Context, arg CreateTransferRequestParams) (TransferRequest, error) {
+	row := q.db.QueryRowContext(ctx, createTransferRequest,
+		arg.RequestedBy,
+		arg.FromAccountID,
+		arg.ToAccountID,
+		arg.Amount,
+		arg.Currency,
+		arg.ExpiresAt,
+	)
+	var i TransferRequest
+	err := row.Scan(
+		&i.ID,
+		&i.RequestedBy,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.Currency,
+		&i.Status,
+		&i.ReviewedBy,
+		&i.ReviewedAt,
+		&i.TransferID,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const expireTransferRequests = `-- name: ExpireTransferRequests :execrows
+UPDATE transfer_requests
+SET status = 'expired'
+WHERE status = 'pending_approval'
+  AND expires_at <= now()
+`
+
+// moves the requests that were not reviewed in time to expired
+func (q *Queries) ExpireTransferRequests(ctx context.Context) (int64, error) {
+	result, err := q.db.ExecContext(ctx, expireTransferRequests)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getTransferRequest = `-- name: GetTransferRequest :one
+SELECT id, requested_by, from_account_id, to_account_id, amount, currency, status, reviewed_by, reviewed_at, transfer_id, expires_at, created_at
+FROM transfer_requests
+WHERE id = $1
+LIMIT 1
+`
+
+func (q *Queries) GetTransferRequest(ctx context.Context, id int64) (TransferRequest, error) {
+	row := q.db.QueryRowContext(ctx, getTransferRequest, id)
+	var i TransferRequest
+	err := row.Scan(
+		&i.ID,
+		&i.RequestedBy,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.Currency,
+		&i.Status,
+		&i.ReviewedBy,
+		&i.ReviewedAt,
+		&i.TransferID,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listTransferRequests = `-- name: ListTransferRequests :many
+SELECT id, requested_by, from_account_id, to_account_id, amount, currency, status, reviewed_by, reviewed_at, transfer_id, expires_at, created_at
+FROM transfer_requests
+WHERE status = $1
+ORDER BY created_at, id
+LIMIT $2 OFFSET $3
+`
+
+type ListTransferRequestsParams struct {
+	Status TransferRequestStatus `json:"status"`
+	Limit  int32                 `json:"limit"`
+	Offset int32                 `json:"offset"`
+}
+
+func (q *Queries) ListTransferRequests(ctx context.Context, arg ListTransferRequestsParams) ([]TransferRequest, error) {
+	rows, err := q.db.QueryContext(ctx, listTransferRequests, arg.Status, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []TransferRequest{}
+	for rows.Next() {
+		var i TransferRequest
+		if err := rows.Scan(
+			&i.ID,
+			&i.RequestedBy,
+			&i.FromAccountID,
+			&i.ToAccountID,
+			&i.Amount,
+			&i.Currency,
+			&i.Status,
+			&i.ReviewedBy,
+			&i.ReviewedAt,
+			&i.TransferID,
+			&i.ExpiresAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTransferRequestsAfter = `-- name: ListTransferRequestsAfter :many
+SELECT id, requested_by, from_account_id, to_account_id, amount, currency, status, reviewed_by, reviewed_at, transfer_id, expires_at, created_at
+FROM transfer_requests
+WHERE status = $1
+  AND (created_at, id) > ($2::timestamptz, $3::bigint)
+ORDER BY created_at, id
+LIMIT $4
+`
+
+type ListTransferRequestsAfterParams struct {
+	Status          TransferRequestStatus `json:"status"`
+	CursorCreatedAt time.Time             `json:"cursor_created_at"`
+	CursorID        int64                 `json:"cursor_id"`
+	Limit           int32                 `json:"limit"`
+}
+
+// keyset page of the requests in a status that come after the cursor
+func (q *Queries) ListTransferRequestsAfter(ctx context.Context, arg ListTransferRequestsAfterParams) ([]TransferRequest, error) {
+	rows, err := q.db.QueryContext(ctx, listTransferRequestsAfter,
+		arg.Status,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []TransferRequest{}
+	for rows.Next() {
+		var i TransferRequest
+		if err := rows.Scan(
+			&i.ID,
+			&i.RequestedBy,
+			&i.FromAccountID,
+			&i.ToAccountID,
+			&i.Amount,
+			&i.Currency,
+			&i.Status,
+			&i.ReviewedBy,
+			&i.ReviewedAt,
+			&i.TransferID,
+			&i.ExpiresAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTransferRequestsBefore = `-- name: ListTransferRequestsBefore :many
+SELECT id, requested_by, from_account_id, to_account_id, amount, currency, status, reviewed_by, reviewed_at, transfer_id, expires_at, created_at
+FROM transfer_requests
+WHERE status = $1
+  AND (created_at, id) < ($2::timestamptz, $3::bigint)
+ORDER BY created_at DESC, id DESC
+LIMIT $4
+`
+
+type ListTransferRequestsBeforeParams struct {
+	Status          TransferRequestStatus `json:"status"`
+	CursorCreatedAt time.Time             `json:"cursor_created_at"`
+	CursorID        int64                 `json:"cursor_id"`
+	Limit           int32                 `json:"limit"`
+}
+
+// keyset page of the requests in a status that come before the cursor, closest first
+func (q *Queries) ListTransferRequestsBefore(ctx context.Context, arg ListTransferRequestsBeforeParams) ([]TransferRequest, error) {
+	rows, err := q.db.QueryContext(ctx, listTransferRequestsBefore,
+		arg.Status,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []TransferRequest{}
+	for rows.Next() {
+		var i TransferRequest
+		if err := rows.Scan(
+			&i.ID,
+			&i.RequestedBy,
+			&i.FromAccountID,
+			&i.ToAccountID,
+			&i.Amount,
+			&i.Currency,
+			&i.Status,
+			&i.ReviewedBy,
+			&i.ReviewedAt,
+			&i.TransferID,
+			&i.ExpiresAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const rejectTransferRequest = `-- name: RejectTransferRequest :one
+UPDATE transfer_requests
+SET status      = 'rejected',
+    reviewed_by = $1,
+    reviewed_at = now()
+WHERE id = $2
+  AND status = 'pending_approval'
+  AND expires_at > now()
+RETURNING id, requested_by, from_account_id, to_account_id, amount, currency, status, reviewed_by, reviewed_at, transfer_id, expires_at, created_at
+`
+
+type RejectTransferRequestParams struct {
+	ReviewedBy sql.NullString `json:"reviewed_by"`
+	ID         int64          `json:"id"`
+}
+
+func (q *Queries) RejectTransferRequest(ctx context.Context, arg RejectTransferRequestParams) (TransferRequest, error) {
+	row := q.db.QueryRowContext(ctx, rejectTransferRequest, arg.ReviewedBy, arg.ID)
+	var i TransferRequest
+	err := row.Scan(
+		&i.ID,
+		&i.RequestedBy,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.Currency,
+		&i.Status,
+		&i.ReviewedBy,
+		&i.ReviewedAt,
+		&i.TransferID,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
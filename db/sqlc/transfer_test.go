@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"database/sql"
 	"github.com/PFefe/simplebank/util"
 	"github.com/stretchr/testify/require"
 	"testing"
@@ -9,10 +10,14 @@ import (
 )
 
 func createRandomTransfer(t *testing.T, account1, account2 Account) Transfer {
+	amount := util.RandomMoney()
 	arg := CreateTransferParams{
 		FromAccountID: account1.ID,
 		ToAccountID:   account2.ID,
-		Amount:        util.RandomMoney(),
+		Amount:        amount,
+		ToAmount:      amount,
+		ExchangeRate:  "1",
+		Spread:        "0",
 	}
 	transfer, err := testQueries.CreateTransfer(
 		context.Background(),
@@ -41,6 +46,21 @@ func createRandomTransfer(t *testing.T, account1, account2 Account) Transfer {
 		arg.Amount,
 		transfer.Amount,
 	)
+	require.Equal(
+		t,
+		arg.ToAmount,
+		transfer.ToAmount,
+	)
+	require.Equal(
+		t,
+		account1.Currency,
+		transfer.Currency,
+	)
+	require.Equal(
+		t,
+		account2.Currency,
+		transfer.ToCurrency,
+	)
 	require.NotZero(
 		t,
 		transfer.ID,
@@ -113,6 +133,7 @@ func TestGetTransfer(t *testing.T) {
 func TestListTransfer(t *testing.T) {
 	account1 := createRandomAccount(t)
 	account2 := createRandomAccount(t)
+	account3 := createRandomAccount(t)
 	for i := 0; i < 10; i++ {
 		createRandomTransfer(
 			t,
@@ -124,12 +145,141 @@ func TestListTransfer(t *testing.T) {
 			account2,
 			account1,
 		)
+		createRandomTransfer(
+			t,
+			account3,
+			account1,
+		)
+	}
+
+	testCases := []struct {
+		name  string
+		arg   ListTransfersParams
+		check func(t *testing.T, transfer Transfer)
+	}{
+		{
+			name: "Both",
+			arg: ListTransfersParams{
+				AccountID:       account1.ID,
+				IncludeOutgoing: true,
+				IncludeIncoming: true,
+			},
+			check: func(t *testing.T, transfer Transfer) {
+				require.True(
+					t,
+					transfer.FromAccountID == account1.ID || transfer.ToAccountID == account1.ID,
+				)
+			},
+		},
+		{
+			name: "Outgoing",
+			arg: ListTransfersParams{
+				AccountID:       account1.ID,
+				IncludeOutgoing: true,
+			},
+			check: func(t *testing.T, transfer Transfer) {
+				require.Equal(
+					t,
+					account1.ID,
+					transfer.FromAccountID,
+				)
+			},
+		},
+		{
+			name: "Incoming",
+			arg: ListTransfersParams{
+				AccountID:       account1.ID,
+				IncludeIncoming: true,
+			},
+			check: func(t *testing.T, transfer Transfer) {
+				require.Equal(
+					t,
+					account1.ID,
+					transfer.ToAccountID,
+				)
+			},
+		},
+		{
+			name: "Counterparty",
+			arg: ListTransfersParams{
+				AccountID:       account1.ID,
+				IncludeOutgoing: true,
+				IncludeIncoming: true,
+				CounterpartyID: sql.NullInt64{
+					Int64: account3.ID,
+					Valid: true,
+				},
+			},
+			check: func(t *testing.T, transfer Transfer) {
+				require.Equal(
+					t,
+					account3.ID,
+					transfer.FromAccountID,
+				)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(
+			tc.name,
+			func(t *testing.T) {
+				tc.arg.Limit = 5
+				tc.arg.Offset = 5
+				transfers, err := testQueries.ListTransfers(
+					context.Background(),
+					tc.arg,
+				)
+				require.NoError(
+					t,
+					err,
+				)
+				require.Len(
+					t,
+					transfers,
+					5,
+				)
+				for _, transfer := range transfers {
+					require.NotEmpty(
+						t,
+						transfer,
+					)
+					tc.check(
+						t,
+						transfer,
+					)
+				}
+			},
+		)
+	}
+}
+
+func TestListTransferAmountRange(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+	for i := 0; i < 10; i++ {
+		createRandomTransfer(
+			t,
+			account1,
+			account2,
+		)
 	}
+
 	arg := ListTransfersParams{
-		FromAccountID: account1.ID,
-		ToAccountID:   account2.ID,
-		Limit:         5,
-		Offset:        5,
+		AccountID:       account1.ID,
+		IncludeOutgoing: true,
+		IncludeIncoming: true,
+		MinAmount: sql.NullInt64{
+			Int64: 250,
+			Valid: true,
+		},
+		MaxAmount: sql.NullInt64{
+			Int64: 750,
+			Valid: true,
+		},
+		Limit:  10,
+		Offset: 0,
 	}
 	transfers, err := testQueries.ListTransfers(
 		context.Background(),
@@ -139,19 +289,16 @@ func TestListTransfer(t *testing.T) {
 		t,
 		err,
 	)
-	require.Len(
-		t,
-		transfers,
-		5,
-	)
 	for _, transfer := range transfers {
-		require.NotEmpty(
+		require.GreaterOrEqual(
 			t,
-			transfer,
+			transfer.Amount,
+			arg.MinAmount.Int64,
 		)
-		require.True(
+		require.LessOrEqual(
 			t,
-			transfer.FromAccountID == account1.ID || transfer.ToAccountID == account1.ID,
+			transfer.Amount,
+			arg.MaxAmount.Int64,
 		)
 	}
 }
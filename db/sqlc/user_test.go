@@ -0,0 +1,133 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/PFefe/simplebank/util"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// createRandomUser is a helper function to create a random user
+func createRandomUser(t *testing.T) User {
+	hashedPassword, err := util.HashPassword(
+		util.RandomString(6),
+		bcrypt.MinCost,
+	)
+	require.NoError(
+		t,
+		err,
+	)
+
+	arg := CreateUserParams{
+		Username:       util.RandomOwner(),
+		HashedPassword: hashedPassword,
+		FullName:       util.RandomOwner(),
+		Email:          util.RandomEmail(),
+	}
+	user, err := testQueries.CreateUser(
+		context.Background(),
+		arg,
+	)
+	require.NoError(
+		t,
+		err,
+	)
+	require.NotEmpty(
+		t,
+		user,
+	)
+
+	require.Equal(
+		t,
+		arg.Username,
+		user.Username,
+	)
+	require.Equal(
+		t,
+		arg.HashedPassword,
+		user.HashedPassword,
+	)
+	require.Equal(
+		t,
+		arg.FullName,
+		user.FullName,
+	)
+	require.Equal(
+		t,
+		arg.Email,
+		user.Email,
+	)
+	require.Equal(
+		t,
+		util.DepositorRole,
+		user.Role,
+	)
+	require.True(
+		t,
+		user.PasswordChangedAt.IsZero(),
+	)
+	require.NotZero(
+		t,
+		user.CreatedAt,
+	)
+
+	return user
+}
+
+// TestCreateUser tests the creation of a user
+func TestCreateUser(t *testing.T) {
+	createRandomUser(t)
+}
+
+// TestGetUser tests retrieving a user
+func TestGetUser(t *testing.T) {
+	user1 := createRandomUser(t)
+	user2, err := testQueries.GetUser(
+		context.Background(),
+		user1.Username,
+	)
+	require.NoError(
+		t,
+		err,
+	)
+	require.NotEmpty(
+		t,
+		user2,
+	)
+
+	require.Equal(
+		t,
+		user1.Username,
+		user2.Username,
+	)
+	require.Equal(
+		t,
+		user1.HashedPassword,
+		user2.HashedPassword,
+	)
+	require.Equal(
+		t,
+		user1.FullName,
+		user2.FullName,
+	)
+	require.Equal(
+		t,
+		user1.Email,
+		user2.Email,
+	)
+	require.WithinDuration(
+		t,
+		user1.PasswordChangedAt,
+		user2.PasswordChangedAt,
+		time.Second,
+	)
+	require.WithinDuration(
+		t,
+		user1.CreatedAt,
+		user2.CreatedAt,
+		time.Second,
+	)
+}
@@ -0,0 +1,125 @@
+package fx
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+)
+
+// rateScale is the number of decimal places rates are kept with, so the rate stored on a transfer
+// is exactly the one used to convert its amount
+const rateScale = 10
+
+// ErrRateNotFound is returned when a provider has no rate for a currency pair
+var ErrRateNotFound = errors.New("exchange rate not found")
+
+// RateProvider is an interface for looking up exchange rates
+type RateProvider interface {
+	// Rate returns the rate that converts an amount in the from currency into the to currency
+	Rate(ctx context.Context, from string, to string) (Rate, error)
+}
+
+// NewRateProvider creates the RateProvider backed by the given rates file.
+// Without a file there are no rates, so only transfers within a currency are possible.
+func NewRateProvider(ratesFile string) (RateProvider, error) {
+	if ratesFile == "" {
+		return NewStaticRateProvider(
+			nil,
+			"0",
+		)
+	}
+	return LoadStaticRateProvider(ratesFile)
+}
+
+// Rate is the price of one unit of the From currency in the To currency
+type Rate struct {
+	From string
+	To   string
+	// Mid is the market rate before the bank's margin
+	Mid *big.Rat
+	// Spread is the fraction of the converted amount kept by the bank
+	Spread *big.Rat
+}
+
+// Convert returns the amount credited in the To currency for an amount debited in the From currency,
+// rounded down in favour of the bank. Amounts are in minor units, so the result is scaled by the
+// difference of the minor-unit exponents of the two currencies.
+func (r Rate) Convert(amount int64, fromMinorUnits int, toMinorUnits int) int64 {
+	effective := new(big.Rat).Sub(
+		big.NewRat(
+			1,
+			1,
+		),
+		r.Spread,
+	)
+	effective.Mul(
+		effective,
+		r.Mid,
+	)
+	effective.Mul(
+		effective,
+		new(big.Rat).SetInt64(amount),
+	)
+
+	scale := new(big.Rat).SetInt(new(big.Int).Exp(
+		big.NewInt(10),
+		big.NewInt(int64(abs(toMinorUnits-fromMinorUnits))),
+		nil,
+	))
+	if toMinorUnits < fromMinorUnits {
+		scale.Inv(scale)
+	}
+	effective.Mul(
+		effective,
+		scale,
+	)
+
+	return new(big.Int).Quo(
+		effective.Num(),
+		effective.Denom(),
+	).Int64()
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// MidString formats the mid rate as a decimal, ready to be stored in a numeric column
+func (r Rate) MidString() string {
+	return decimalString(r.Mid)
+}
+
+// SpreadString formats the spread as a decimal, ready to be stored in a numeric column
+func (r Rate) SpreadString() string {
+	return decimalString(r.Spread)
+}
+
+// parseDecimal parses a decimal string and rounds it to rateScale places
+func parseDecimal(s string) (*big.Rat, bool) {
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return nil, false
+	}
+	return roundRat(r), true
+}
+
+func roundRat(r *big.Rat) *big.Rat {
+	rounded, _ := new(big.Rat).SetString(r.FloatString(rateScale))
+	return rounded
+}
+
+func decimalString(r *big.Rat) string {
+	s := r.FloatString(rateScale)
+	s = strings.TrimRight(
+		s,
+		"0",
+	)
+	return strings.TrimSuffix(
+		s,
+		".",
+	)
+}
@@ -0,0 +1,134 @@
+package fx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+)
+
+// StaticRateProvider serves fixed rates, meant for local development and tests
+type StaticRateProvider struct {
+	rates  map[string]*big.Rat
+	spread *big.Rat
+}
+
+// staticRatesFile is the layout of a rates file, e.g.
+//
+//	{"spread": "0.005", "rates": {"USD/EUR": "0.92", "USD/CAD": "1.36"}}
+type staticRatesFile struct {
+	Spread string            `json:"spread"`
+	Rates  map[string]string `json:"rates"`
+}
+
+// NewStaticRateProvider creates a StaticRateProvider from rates keyed by "FROM/TO" pairs.
+// The reverse of every pair is quoted too, unless it is listed on its own.
+func NewStaticRateProvider(rates map[string]string, spread string) (RateProvider, error) {
+	provider := &StaticRateProvider{
+		rates: make(map[string]*big.Rat),
+	}
+
+	var ok bool
+	provider.spread, ok = parseDecimal(spread)
+	if !ok || provider.spread.Sign() < 0 || provider.spread.Cmp(big.NewRat(1, 1)) >= 0 {
+		return nil, fmt.Errorf(
+			"invalid spread: %q",
+			spread,
+		)
+	}
+
+	for pair, value := range rates {
+		from, to, found := strings.Cut(
+			pair,
+			"/",
+		)
+		if !found || from == "" || to == "" || from == to {
+			return nil, fmt.Errorf(
+				"invalid currency pair: %q",
+				pair,
+			)
+		}
+
+		rate, ok := parseDecimal(value)
+		if !ok || rate.Sign() <= 0 {
+			return nil, fmt.Errorf(
+				"invalid rate for %s: %q",
+				pair,
+				value,
+			)
+		}
+		provider.rates[pair] = rate
+	}
+
+	for pair, rate := range provider.rates {
+		from, to, _ := strings.Cut(
+			pair,
+			"/",
+		)
+		reverse := to + "/" + from
+		if _, listed := provider.rates[reverse]; !listed {
+			provider.rates[reverse] = roundRat(new(big.Rat).Inv(rate))
+		}
+	}
+
+	return provider, nil
+}
+
+// LoadStaticRateProvider creates a StaticRateProvider from a JSON rates file
+func LoadStaticRateProvider(path string) (RateProvider, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file staticRatesFile
+	err = json.Unmarshal(
+		data,
+		&file,
+	)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"cannot parse rates file: %w",
+			err,
+		)
+	}
+
+	if file.Spread == "" {
+		file.Spread = "0"
+	}
+	return NewStaticRateProvider(
+		file.Rates,
+		file.Spread,
+	)
+}
+
+// Rate returns the configured rate of the pair, or 1 without a spread when both currencies are the same
+func (provider *StaticRateProvider) Rate(ctx context.Context, from string, to string) (Rate, error) {
+	if from == to {
+		return Rate{
+			From:   from,
+			To:     to,
+			Mid:    big.NewRat(1, 1),
+			Spread: new(big.Rat),
+		}, nil
+	}
+
+	mid, ok := provider.rates[from+"/"+to]
+	if !ok {
+		return Rate{}, fmt.Errorf(
+			"%w: %s/%s",
+			ErrRateNotFound,
+			from,
+			to,
+		)
+	}
+
+	return Rate{
+		From:   from,
+		To:     to,
+		Mid:    mid,
+		Spread: provider.spread,
+	}, nil
+}
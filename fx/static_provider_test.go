@@ -0,0 +1,278 @@
+package fx
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStaticRateProvider(t *testing.T) {
+	provider, err := NewStaticRateProvider(
+		map[string]string{
+			"USD/EUR": "0.8",
+		},
+		"0.01",
+	)
+	require.NoError(
+		t,
+		err,
+	)
+
+	rate, err := provider.Rate(
+		context.Background(),
+		"USD",
+		"EUR",
+	)
+	require.NoError(
+		t,
+		err,
+	)
+	require.Equal(
+		t,
+		"0.8",
+		rate.MidString(),
+	)
+	require.Equal(
+		t,
+		"0.01",
+		rate.SpreadString(),
+	)
+	// 1000 * 0.8 * 0.99
+	require.Equal(
+		t,
+		int64(792),
+		rate.Convert(
+			1000,
+			2,
+			2,
+		),
+	)
+
+	// the reverse pair is derived from the listed one
+	rate, err = provider.Rate(
+		context.Background(),
+		"EUR",
+		"USD",
+	)
+	require.NoError(
+		t,
+		err,
+	)
+	require.Equal(
+		t,
+		"1.25",
+		rate.MidString(),
+	)
+
+	rate, err = provider.Rate(
+		context.Background(),
+		"USD",
+		"USD",
+	)
+	require.NoError(
+		t,
+		err,
+	)
+	require.Equal(
+		t,
+		int64(1000),
+		rate.Convert(
+			1000,
+			2,
+			2,
+		),
+	)
+
+	_, err = provider.Rate(
+		context.Background(),
+		"USD",
+		"CAD",
+	)
+	require.ErrorIs(
+		t,
+		err,
+		ErrRateNotFound,
+	)
+}
+
+func TestConvertMinorUnits(t *testing.T) {
+	provider, err := NewStaticRateProvider(
+		map[string]string{
+			"USD/JPY": "150",
+			"USD/BHD": "0.376",
+		},
+		"0",
+	)
+	require.NoError(
+		t,
+		err,
+	)
+
+	testCases := []struct {
+		name           string
+		to             string
+		toMinorUnits   int
+		expectedAmount int64
+	}{
+		{
+			// 10.00 USD is 1500 JPY, which has no minor units
+			name:           "USDJPY",
+			to:             "JPY",
+			toMinorUnits:   0,
+			expectedAmount: 1500,
+		},
+		{
+			// 10.00 USD is 3.760 BHD, which has three decimal places
+			name:           "USDBHD",
+			to:             "BHD",
+			toMinorUnits:   3,
+			expectedAmount: 3760,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(
+			tc.name,
+			func(t *testing.T) {
+				rate, err := provider.Rate(
+					context.Background(),
+					"USD",
+					tc.to,
+				)
+				require.NoError(
+					t,
+					err,
+				)
+				require.Equal(
+					t,
+					tc.expectedAmount,
+					rate.Convert(
+						1000,
+						2,
+						tc.toMinorUnits,
+					),
+				)
+			},
+		)
+	}
+
+	// the reverse pair scales the other way: 1500 JPY is 10.00 USD
+	rate, err := provider.Rate(
+		context.Background(),
+		"JPY",
+		"USD",
+	)
+	require.NoError(
+		t,
+		err,
+	)
+	require.Equal(
+		t,
+		int64(1000),
+		rate.Convert(
+			1500,
+			0,
+			2,
+		),
+	)
+}
+
+func TestNewStaticRateProviderInvalid(t *testing.T) {
+	testCases := []struct {
+		name   string
+		rates  map[string]string
+		spread string
+	}{
+		{
+			name: "InvalidPair",
+			rates: map[string]string{
+				"USDEUR": "0.8",
+			},
+			spread: "0",
+		},
+		{
+			name: "SameCurrency",
+			rates: map[string]string{
+				"USD/USD": "1",
+			},
+			spread: "0",
+		},
+		{
+			name: "NegativeRate",
+			rates: map[string]string{
+				"USD/EUR": "-0.8",
+			},
+			spread: "0",
+		},
+		{
+			name: "InvalidSpread",
+			rates: map[string]string{
+				"USD/EUR": "0.8",
+			},
+			spread: "1",
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(
+			tc.name,
+			func(t *testing.T) {
+				_, err := NewStaticRateProvider(
+					tc.rates,
+					tc.spread,
+				)
+				require.Error(
+					t,
+					err,
+				)
+			},
+		)
+	}
+}
+
+func TestLoadStaticRateProvider(t *testing.T) {
+	path := filepath.Join(
+		t.TempDir(),
+		"rates.json",
+	)
+	err := os.WriteFile(
+		path,
+		[]byte(`{"spread": "0.005", "rates": {"USD/CAD": "1.36"}}`),
+		0o600,
+	)
+	require.NoError(
+		t,
+		err,
+	)
+
+	provider, err := LoadStaticRateProvider(path)
+	require.NoError(
+		t,
+		err,
+	)
+
+	rate, err := provider.Rate(
+		context.Background(),
+		"CAD",
+		"USD",
+	)
+	require.NoError(
+		t,
+		err,
+	)
+	require.Equal(
+		t,
+		"0.7352941176",
+		rate.MidString(),
+	)
+	require.Equal(
+		t,
+		"0.005",
+		rate.SpreadString(),
+	)
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"github.com/PFefe/simplebank/api"
 	db "github.com/PFefe/simplebank/db/sqlc"
@@ -28,7 +29,30 @@ func main() {
 		)
 	}
 	store := db.NewStore(conn)
-	server := api.NewServer(store)
+
+	// an enabled currency without system accounts would fail every deposit, fx transfer and fee
+	err = db.ProvisionSystemAccounts(
+		context.Background(),
+		store,
+		config.Currencies,
+	)
+	if err != nil {
+		log.Fatal(
+			"cannot create system accounts:",
+			err,
+		)
+	}
+
+	server, err := api.NewServer(
+		config,
+		store,
+	)
+	if err != nil {
+		log.Fatal(
+			"cannot create server:",
+			err,
+		)
+	}
 
 	err = server.Start(config.ServerAddress)
 	if err != nil {
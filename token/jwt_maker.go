@@ -0,0 +1,111 @@
+package token
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const minSecretKeySize = 32
+
+// JWTMaker is a JSON Web Token maker using the HS256 algorithm
+type JWTMaker struct {
+	secretKey string
+}
+
+// jwtClaims maps a Payload onto the registered JWT claims
+type jwtClaims struct {
+	Username string    `json:"username"`
+	Role     string    `json:"role"`
+	Type     TokenType `json:"type"`
+	jwt.RegisteredClaims
+}
+
+// NewJWTMaker creates a new JWTMaker
+func NewJWTMaker(secretKey string) (Maker, error) {
+	if len(secretKey) < minSecretKeySize {
+		return nil, fmt.Errorf(
+			"invalid key size: must be at least %d characters",
+			minSecretKeySize,
+		)
+	}
+	return &JWTMaker{secretKey}, nil
+}
+
+// CreateToken creates a new token for a specific username, role, type and duration
+func (maker *JWTMaker) CreateToken(username string, role string, tokenType TokenType, duration time.Duration) (string, *Payload, error) {
+	payload, err := NewPayload(
+		username,
+		role,
+		tokenType,
+		duration,
+	)
+	if err != nil {
+		return "", payload, err
+	}
+
+	claims := jwtClaims{
+		Username: payload.Username,
+		Role:     payload.Role,
+		Type:     payload.Type,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        payload.ID.String(),
+			IssuedAt:  jwt.NewNumericDate(payload.IssuedAt),
+			ExpiresAt: jwt.NewNumericDate(payload.ExpiredAt),
+		},
+	}
+
+	jwtToken := jwt.NewWithClaims(
+		jwt.SigningMethodHS256,
+		claims,
+	)
+	token, err := jwtToken.SignedString([]byte(maker.secretKey))
+	return token, payload, err
+}
+
+// VerifyToken checks if the token is valid or not
+func (maker *JWTMaker) VerifyToken(token string) (*Payload, error) {
+	keyFunc := func(token *jwt.Token) (interface{}, error) {
+		return []byte(maker.secretKey), nil
+	}
+
+	jwtToken, err := jwt.ParseWithClaims(
+		token,
+		&jwtClaims{},
+		keyFunc,
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuedAt(),
+	)
+	if err != nil {
+		if errors.Is(
+			err,
+			jwt.ErrTokenExpired,
+		) {
+			return nil, ErrExpiredToken
+		}
+		return nil, ErrInvalidToken
+	}
+
+	claims, ok := jwtToken.Claims.(*jwtClaims)
+	if !ok || claims.Type == "" || claims.IssuedAt == nil || claims.ExpiresAt == nil {
+		return nil, ErrInvalidToken
+	}
+
+	tokenID, err := uuid.Parse(claims.ID)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	payload := &Payload{
+		ID:        tokenID,
+		Username:  claims.Username,
+		Role:      claims.Role,
+		Type:      claims.Type,
+		IssuedAt:  claims.IssuedAt.Time,
+		ExpiredAt: claims.ExpiresAt.Time,
+	}
+	return payload, nil
+}
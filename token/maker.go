@@ -0,0 +1,35 @@
+package token
+
+import (
+	"fmt"
+	"time"
+)
+
+// Supported token types
+const (
+	TypePaseto = "paseto"
+	TypeJWT    = "jwt"
+)
+
+// Maker is an interface for managing tokens
+type Maker interface {
+	// CreateToken creates a new token for a specific username, role, type and duration
+	CreateToken(username string, role string, tokenType TokenType, duration time.Duration) (string, *Payload, error)
+
+	// VerifyToken checks if the token is valid or not
+	VerifyToken(token string) (*Payload, error)
+}
+
+// NewMaker creates a Maker of the given token type, defaulting to PASETO
+func NewMaker(tokenType string, symmetricKey string) (Maker, error) {
+	switch tokenType {
+	case TypePaseto, "":
+		return NewPasetoMaker(symmetricKey)
+	case TypeJWT:
+		return NewJWTMaker(symmetricKey)
+	}
+	return nil, fmt.Errorf(
+		"unsupported token type: %s",
+		tokenType,
+	)
+}
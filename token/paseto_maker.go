@@ -0,0 +1,133 @@
+package token
+
+import (
+	"fmt"
+	"time"
+
+	"aidanwoods.dev/go-paseto"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// PasetoMaker is a PASETO v4 local token maker
+type PasetoMaker struct {
+	symmetricKey paseto.V4SymmetricKey
+}
+
+// NewPasetoMaker creates a new PasetoMaker
+func NewPasetoMaker(symmetricKey string) (Maker, error) {
+	if len(symmetricKey) != chacha20poly1305.KeySize {
+		return nil, fmt.Errorf(
+			"invalid key size: must be exactly %d characters",
+			chacha20poly1305.KeySize,
+		)
+	}
+
+	key, err := paseto.V4SymmetricKeyFromBytes([]byte(symmetricKey))
+	if err != nil {
+		return nil, err
+	}
+	return &PasetoMaker{key}, nil
+}
+
+// CreateToken creates a new token for a specific username, role, type and duration
+func (maker *PasetoMaker) CreateToken(username string, role string, tokenType TokenType, duration time.Duration) (string, *Payload, error) {
+	payload, err := NewPayload(
+		username,
+		role,
+		tokenType,
+		duration,
+	)
+	if err != nil {
+		return "", payload, err
+	}
+
+	pasetoToken := paseto.NewToken()
+	pasetoToken.SetJti(payload.ID.String())
+	pasetoToken.SetString(
+		"username",
+		payload.Username,
+	)
+	pasetoToken.SetString(
+		"role",
+		payload.Role,
+	)
+	pasetoToken.SetString(
+		"type",
+		string(payload.Type),
+	)
+	pasetoToken.SetIssuedAt(payload.IssuedAt)
+	pasetoToken.SetExpiration(payload.ExpiredAt)
+
+	token := pasetoToken.V4Encrypt(
+		maker.symmetricKey,
+		nil,
+	)
+	return token, payload, nil
+}
+
+// VerifyToken checks if the token is valid or not
+func (maker *PasetoMaker) VerifyToken(token string) (*Payload, error) {
+	// expiry is checked on the payload so that it maps to ErrExpiredToken
+	parser := paseto.NewParserWithoutExpiryCheck()
+	pasetoToken, err := parser.ParseV4Local(
+		maker.symmetricKey,
+		token,
+		nil,
+	)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	payload, err := payloadFromPaseto(pasetoToken)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	err = payload.Valid()
+	if err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+func payloadFromPaseto(pasetoToken *paseto.Token) (*Payload, error) {
+	jti, err := pasetoToken.GetJti()
+	if err != nil {
+		return nil, err
+	}
+	tokenID, err := uuid.Parse(jti)
+	if err != nil {
+		return nil, err
+	}
+	username, err := pasetoToken.GetString("username")
+	if err != nil {
+		return nil, err
+	}
+	role, err := pasetoToken.GetString("role")
+	if err != nil {
+		return nil, err
+	}
+	tokenType, err := pasetoToken.GetString("type")
+	if err != nil {
+		return nil, err
+	}
+	issuedAt, err := pasetoToken.GetIssuedAt()
+	if err != nil {
+		return nil, err
+	}
+	expiredAt, err := pasetoToken.GetExpiration()
+	if err != nil {
+		return nil, err
+	}
+
+	payload := &Payload{
+		ID:        tokenID,
+		Username:  username,
+		Role:      role,
+		Type:      TokenType(tokenType),
+		IssuedAt:  issuedAt,
+		ExpiredAt: expiredAt,
+	}
+	return payload, nil
+}
@@ -0,0 +1,159 @@
+package token
+
+import (
+	"testing"
+	"time"
+
+	"github.com/PFefe/simplebank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPasetoMaker(t *testing.T) {
+	maker, err := NewPasetoMaker(util.RandomString(32))
+	require.NoError(
+		t,
+		err,
+	)
+
+	username := util.RandomOwner()
+	role := util.DepositorRole
+	duration := time.Minute
+
+	issuedAt := time.Now()
+	expiredAt := issuedAt.Add(duration)
+
+	token, payload, err := maker.CreateToken(
+		username,
+		role,
+		TokenTypeAccess,
+		duration,
+	)
+	require.NoError(
+		t,
+		err,
+	)
+	require.NotEmpty(
+		t,
+		token,
+	)
+	require.NotEmpty(
+		t,
+		payload,
+	)
+
+	verified, err := maker.VerifyToken(token)
+	require.NoError(
+		t,
+		err,
+	)
+	require.NotEmpty(
+		t,
+		verified,
+	)
+
+	require.Equal(
+		t,
+		payload.ID,
+		verified.ID,
+	)
+	require.Equal(
+		t,
+		username,
+		verified.Username,
+	)
+	require.Equal(
+		t,
+		role,
+		verified.Role,
+	)
+	require.Equal(
+		t,
+		TokenTypeAccess,
+		verified.Type,
+	)
+	require.WithinDuration(
+		t,
+		issuedAt,
+		verified.IssuedAt,
+		time.Second,
+	)
+	require.WithinDuration(
+		t,
+		expiredAt,
+		verified.ExpiredAt,
+		time.Second,
+	)
+}
+
+func TestExpiredPasetoToken(t *testing.T) {
+	maker, err := NewPasetoMaker(util.RandomString(32))
+	require.NoError(
+		t,
+		err,
+	)
+
+	token, _, err := maker.CreateToken(
+		util.RandomOwner(),
+		util.DepositorRole,
+		TokenTypeAccess,
+		-time.Minute,
+	)
+	require.NoError(
+		t,
+		err,
+	)
+	require.NotEmpty(
+		t,
+		token,
+	)
+
+	payload, err := maker.VerifyToken(token)
+	require.Error(
+		t,
+		err,
+	)
+	require.EqualError(
+		t,
+		err,
+		ErrExpiredToken.Error(),
+	)
+	require.Nil(
+		t,
+		payload,
+	)
+}
+
+func TestInvalidPasetoTokenKey(t *testing.T) {
+	maker1, err := NewPasetoMaker(util.RandomString(32))
+	require.NoError(
+		t,
+		err,
+	)
+	maker2, err := NewPasetoMaker(util.RandomString(32))
+	require.NoError(
+		t,
+		err,
+	)
+
+	token, _, err := maker1.CreateToken(
+		util.RandomOwner(),
+		util.DepositorRole,
+		TokenTypeAccess,
+		time.Minute,
+	)
+	require.NoError(
+		t,
+		err,
+	)
+
+	payload, err := maker2.VerifyToken(token)
+	require.EqualError(
+		t,
+		err,
+		ErrInvalidToken.Error(),
+	)
+	require.Nil(
+		t,
+		payload,
+	)
+}
@@ -0,0 +1,60 @@
+package token
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Different types of error returned by the VerifyToken function
+var (
+	ErrInvalidToken = errors.New("token is invalid")
+	ErrExpiredToken = errors.New("token has expired")
+)
+
+// TokenType tells the access tokens apart from the refresh tokens that can only renew them
+type TokenType string
+
+// Supported token uses
+const (
+	TokenTypeAccess  TokenType = "access"
+	TokenTypeRefresh TokenType = "refresh"
+)
+
+// Payload contains the payload data of the token
+type Payload struct {
+	ID        uuid.UUID `json:"id"`
+	Username  string    `json:"username"`
+	Role      string    `json:"role"`
+	Type      TokenType `json:"type"`
+	IssuedAt  time.Time `json:"issued_at"`
+	ExpiredAt time.Time `json:"expired_at"`
+}
+
+// NewPayload creates a new token payload with a specific username, role, type and duration
+func NewPayload(username string, role string, tokenType TokenType, duration time.Duration) (*Payload, error) {
+	tokenID, err := uuid.NewRandom()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	payload := &Payload{
+		ID:        tokenID,
+		Username:  username,
+		Role:      role,
+		Type:      tokenType,
+		IssuedAt:  now,
+		ExpiredAt: now.Add(duration),
+	}
+	return payload, nil
+}
+
+// Valid checks if the token payload is still valid
+func (payload *Payload) Valid() error {
+	if time.Now().After(payload.ExpiredAt) {
+		return ErrExpiredToken
+	}
+	return nil
+}
@@ -2,12 +2,26 @@ package util
 
 import (
 	"github.com/spf13/viper"
+	"time"
 )
 
 type Config struct {
-	DBDriver      string `mapstructure:"DB_DRIVER"`
-	DBSource      string `mapstructure:"DB_SOURCE"`
-	ServerAddress string `mapstructure:"SERVER_ADDRESS"`
+	DBDriver             string        `mapstructure:"DB_DRIVER"`
+	DBSource             string        `mapstructure:"DB_SOURCE"`
+	ServerAddress        string        `mapstructure:"SERVER_ADDRESS"`
+	PasswordHashCost     int           `mapstructure:"PASSWORD_HASH_COST"`
+	TokenType            string        `mapstructure:"TOKEN_TYPE"`
+	TokenSymmetricKey    string        `mapstructure:"TOKEN_SYMMETRIC_KEY"`
+	AccessTokenDuration  time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"`
+	RefreshTokenDuration time.Duration `mapstructure:"REFRESH_TOKEN_DURATION"`
+	IdempotencyKeyTTL    time.Duration `mapstructure:"IDEMPOTENCY_KEY_TTL"`
+	MaxPageSize          int32         `mapstructure:"MAX_PAGE_SIZE"`
+	FXRatesFile          string        `mapstructure:"FX_RATES_FILE"`
+	QuoteTTL             time.Duration `mapstructure:"QUOTE_TTL"`
+	Currencies           []string      `mapstructure:"CURRENCIES"`
+	ApprovalThresholds   []string      `mapstructure:"APPROVAL_THRESHOLDS"`
+	ApprovalTTL          time.Duration `mapstructure:"APPROVAL_TTL"`
+	HoldTTL              time.Duration `mapstructure:"HOLD_TTL"`
 }
 
 // LoadConfig returns a new Config struct
@@ -18,6 +32,10 @@ func LoadConfig(path string) (config Config, err error) {
 	viper.SetConfigType("env")
 
 	viper.AutomaticEnv()
+	viper.SetDefault(
+		"CURRENCIES",
+		DefaultCurrencies,
+	)
 
 	err = viper.ReadInConfig()
 	if err != nil {
@@ -1,17 +1,224 @@
 package util
 
-// Constants for all supported currencies
+import (
+	"bytes"
+	_ "embed"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Codes of the currencies the bank has settlement and fx accounts for
 const (
 	USD = "USD"
 	EUR = "EUR"
 	CAD = "CAD"
 )
 
-// IsSupportedCurrency returns true if the currency is supported
-func IsSupportedCurrency(currency string) bool {
-	switch currency {
-	case USD, EUR, CAD:
-		return true
+// DefaultCurrencies is the enabled subset when none is configured
+var DefaultCurrencies = []string{USD, EUR, CAD}
+
+// iso4217Table is the ISO 4217 list of active currencies. Codes without minor units,
+// like gold or the SDR, are left out, since no account can hold them.
+//
+//go:embed iso4217.csv
+var iso4217Table []byte
+
+// Currency is an ISO 4217 currency
+type Currency struct {
+	Code        string `json:"code"`
+	NumericCode string `json:"numeric_code"`
+	// MinorUnits is the number of decimal places, amounts are stored in units of 10^-MinorUnits
+	MinorUnits int    `json:"minor_units"`
+	Symbol     string `json:"symbol"`
+	Name       string `json:"name"`
+}
+
+// ErrInvalidAmount is returned for an amount that is not a plain decimal number
+var ErrInvalidAmount = errors.New("amount must be a decimal number")
+
+// ErrAmountPrecision is returned for an amount with more decimal places than the currency has minor units
+var ErrAmountPrecision = errors.New("amount has more decimal places than the currency allows")
+
+// ParseAmount converts a decimal string in the major unit, e.g. "12.34", to minor units without rounding.
+// Signs, exponents and more decimal places than MinorUnits are rejected.
+func (currency Currency) ParseAmount(s string) (int64, error) {
+	whole, fraction, hasPoint := strings.Cut(
+		s,
+		".",
+	)
+	if whole == "" || (hasPoint && fraction == "") || !isDigits(whole) || !isDigits(fraction) {
+		return 0, ErrInvalidAmount
+	}
+	if len(fraction) > currency.MinorUnits {
+		return 0, ErrAmountPrecision
+	}
+
+	fraction += strings.Repeat(
+		"0",
+		currency.MinorUnits-len(fraction),
+	)
+	amount, err := strconv.ParseInt(
+		whole+fraction,
+		10,
+		64,
+	)
+	if err != nil {
+		return 0, ErrInvalidAmount
+	}
+	return amount, nil
+}
+
+// DecimalString formats an amount in minor units as a decimal string in the major unit, e.g. 1234 as "12.34"
+func (currency Currency) DecimalString(amount int64) string {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	digits := strconv.FormatInt(
+		amount,
+		10,
+	)
+	if currency.MinorUnits > 0 {
+		if len(digits) <= currency.MinorUnits {
+			digits = strings.Repeat(
+				"0",
+				currency.MinorUnits-len(digits)+1,
+			) + digits
+		}
+		point := len(digits) - currency.MinorUnits
+		digits = digits[:point] + "." + digits[point:]
+	}
+	return sign + digits
+}
+
+// FormatAmount formats an amount in minor units for display, e.g. 123456 USD as $1234.56
+func (currency Currency) FormatAmount(amount int64) string {
+	if amount < 0 {
+		return "-" + currency.Symbol + currency.DecimalString(-amount)
+	}
+	return currency.Symbol + currency.DecimalString(amount)
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// CurrencyRegistry holds the ISO 4217 currencies and the subset that is enabled
+type CurrencyRegistry struct {
+	currencies map[string]Currency
+	// enabled keeps the configured order for listing
+	enabled []Currency
+}
+
+// NewCurrencyRegistry creates a CurrencyRegistry of the given ISO 4217 codes
+func NewCurrencyRegistry(codes []string) (*CurrencyRegistry, error) {
+	if len(codes) == 0 {
+		return nil, fmt.Errorf("no currencies enabled")
+	}
+
+	table, err := loadISO4217()
+	if err != nil {
+		return nil, err
+	}
+
+	registry := &CurrencyRegistry{
+		currencies: table,
+	}
+	for _, code := range codes {
+		currency, ok := table[code]
+		if !ok {
+			return nil, fmt.Errorf(
+				"unknown currency code: %q",
+				code,
+			)
+		}
+		if registry.IsSupported(code) {
+			continue
+		}
+		registry.enabled = append(
+			registry.enabled,
+			currency,
+		)
+	}
+	return registry, nil
+}
+
+// Lookup returns the ISO 4217 currency with the given code, enabled or not,
+// so amounts of accounts in a currency that was disabled later can still be read
+func (registry *CurrencyRegistry) Lookup(code string) (Currency, bool) {
+	currency, ok := registry.currencies[code]
+	return currency, ok
+}
+
+// IsSupported returns true if the currency is enabled
+func (registry *CurrencyRegistry) IsSupported(code string) bool {
+	for _, currency := range registry.enabled {
+		if currency.Code == code {
+			return true
+		}
 	}
 	return false
 }
+
+// List returns the enabled currencies in the configured order
+func (registry *CurrencyRegistry) List() []Currency {
+	return append(
+		[]Currency(nil),
+		registry.enabled...,
+	)
+}
+
+// FormatAmount formats an amount in minor units of the currency, falling back to the bare amount and code
+func (registry *CurrencyRegistry) FormatAmount(amount int64, code string) string {
+	currency, ok := registry.currencies[code]
+	if !ok {
+		return fmt.Sprintf(
+			"%d %s",
+			amount,
+			code,
+		)
+	}
+	return currency.FormatAmount(amount)
+}
+
+// loadISO4217 parses the embedded ISO 4217 table
+func loadISO4217() (map[string]Currency, error) {
+	records, err := csv.NewReader(bytes.NewReader(iso4217Table)).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf(
+			"cannot read currency table: %w",
+			err,
+		)
+	}
+
+	table := make(map[string]Currency, len(records))
+	// the first record is the header
+	for _, record := range records[1:] {
+		minorUnits, err := strconv.Atoi(record[2])
+		if err != nil {
+			return nil, fmt.Errorf(
+				"invalid minor units of %s: %w",
+				record[0],
+				err,
+			)
+		}
+		table[record[0]] = Currency{
+			Code:        record[0],
+			NumericCode: record[1],
+			MinorUnits:  minorUnits,
+			Symbol:      record[3],
+			Name:        record[4],
+		}
+	}
+	return table, nil
+}
@@ -0,0 +1,270 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCurrencyRegistry(t *testing.T) {
+	registry, err := NewCurrencyRegistry([]string{USD, "JPY", "KWD"})
+	require.NoError(
+		t,
+		err,
+	)
+
+	usd, ok := registry.Lookup(USD)
+	require.True(
+		t,
+		ok,
+	)
+	require.Equal(
+		t,
+		"840",
+		usd.NumericCode,
+	)
+	require.Equal(
+		t,
+		2,
+		usd.MinorUnits,
+	)
+
+	require.True(
+		t,
+		registry.IsSupported("JPY"),
+	)
+	// EUR is in the ISO table, but not enabled
+	require.False(
+		t,
+		registry.IsSupported(EUR),
+	)
+
+	codes := []string{}
+	for _, currency := range registry.List() {
+		codes = append(
+			codes,
+			currency.Code,
+		)
+	}
+	require.Equal(
+		t,
+		[]string{USD, "JPY", "KWD"},
+		codes,
+	)
+
+	// the whole ISO list can be looked up, with the minor units of each currency
+	for code, minorUnits := range map[string]int{
+		"CLF": 4,
+		"KZT": 2,
+		"UGX": 0,
+		"XOF": 0,
+	} {
+		currency, ok := registry.Lookup(code)
+		require.True(
+			t,
+			ok,
+		)
+		require.Equal(
+			t,
+			minorUnits,
+			currency.MinorUnits,
+		)
+	}
+
+	// gold has no minor units, so it is left out
+	_, ok = registry.Lookup("XAU")
+	require.False(
+		t,
+		ok,
+	)
+
+	_, err = NewCurrencyRegistry([]string{"XXY"})
+	require.Error(
+		t,
+		err,
+	)
+
+	_, err = NewCurrencyRegistry(nil)
+	require.Error(
+		t,
+		err,
+	)
+}
+
+func TestFormatAmount(t *testing.T) {
+	registry, err := NewCurrencyRegistry([]string{USD, "JPY", "KWD"})
+	require.NoError(
+		t,
+		err,
+	)
+
+	testCases := []struct {
+		amount   int64
+		currency string
+		want     string
+	}{
+		{
+			amount:   123456,
+			currency: USD,
+			want:     "$1234.56",
+		},
+		{
+			amount:   5,
+			currency: USD,
+			want:     "$0.05",
+		},
+		{
+			amount:   -250,
+			currency: USD,
+			want:     "-$2.50",
+		},
+		{
+			amount:   1500,
+			currency: "JPY",
+			want:     "¥1500",
+		},
+		{
+			amount:   1234,
+			currency: "KWD",
+			want:     "KWD1.234",
+		},
+		{
+			amount:   100,
+			currency: EUR,
+			want:     "€1.00",
+		},
+		{
+			amount:   100,
+			currency: "ABC",
+			want:     "100 ABC",
+		},
+	}
+
+	for _, tc := range testCases {
+		require.Equal(
+			t,
+			tc.want,
+			registry.FormatAmount(
+				tc.amount,
+				tc.currency,
+			),
+		)
+	}
+}
+
+func TestParseAmount(t *testing.T) {
+	registry, err := NewCurrencyRegistry([]string{USD, "JPY"})
+	require.NoError(
+		t,
+		err,
+	)
+	usd, _ := registry.Lookup(USD)
+	jpy, _ := registry.Lookup("JPY")
+
+	testCases := []struct {
+		name     string
+		currency Currency
+		input    string
+		want     int64
+		err      error
+	}{
+		{
+			name:     "Cents",
+			currency: usd,
+			input:    "12.34",
+			want:     1234,
+		},
+		{
+			name:     "ShortFraction",
+			currency: usd,
+			input:    "12.3",
+			want:     1230,
+		},
+		{
+			name:     "Whole",
+			currency: usd,
+			input:    "12",
+			want:     1200,
+		},
+		{
+			name:     "NoMinorUnits",
+			currency: jpy,
+			input:    "1500",
+			want:     1500,
+		},
+		{
+			name:     "ExcessPrecision",
+			currency: usd,
+			input:    "12.345",
+			err:      ErrAmountPrecision,
+		},
+		{
+			name:     "FractionOfYen",
+			currency: jpy,
+			input:    "1.5",
+			err:      ErrAmountPrecision,
+		},
+		{
+			name:     "Negative",
+			currency: usd,
+			input:    "-1.00",
+			err:      ErrInvalidAmount,
+		},
+		{
+			name:     "Exponent",
+			currency: usd,
+			input:    "1e3",
+			err:      ErrInvalidAmount,
+		},
+		{
+			name:     "TrailingPoint",
+			currency: usd,
+			input:    "12.",
+			err:      ErrInvalidAmount,
+		},
+		{
+			name:     "Overflow",
+			currency: usd,
+			input:    "92233720368547758.08",
+			err:      ErrInvalidAmount,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(
+			tc.name,
+			func(t *testing.T) {
+				amount, err := tc.currency.ParseAmount(tc.input)
+				if tc.err != nil {
+					require.ErrorIs(
+						t,
+						err,
+						tc.err,
+					)
+					return
+				}
+				require.NoError(
+					t,
+					err,
+				)
+				require.Equal(
+					t,
+					tc.want,
+					amount,
+				)
+				// formatting gives back the canonical form of the input
+				parsed, err := tc.currency.ParseAmount(tc.currency.DecimalString(amount))
+				require.NoError(
+					t,
+					err,
+				)
+				require.Equal(
+					t,
+					amount,
+					parsed,
+				)
+			},
+		)
+	}
+}
@@ -0,0 +1,46 @@
+package util
+
+import (
+	"fmt"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// normalizeCost falls back to the bcrypt default when cost is unset or out of range
+func normalizeCost(cost int) int {
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		return bcrypt.DefaultCost
+	}
+	return cost
+}
+
+// HashPassword returns the bcrypt hash of the password using the given cost
+func HashPassword(password string, cost int) (string, error) {
+	hashedPassword, err := bcrypt.GenerateFromPassword(
+		[]byte(password),
+		normalizeCost(cost),
+	)
+	if err != nil {
+		return "", fmt.Errorf(
+			"failed to hash password: %w",
+			err,
+		)
+	}
+	return string(hashedPassword), nil
+}
+
+// CheckPassword checks if the provided password matches the hashed password
+func CheckPassword(password string, hashedPassword string) error {
+	return bcrypt.CompareHashAndPassword(
+		[]byte(hashedPassword),
+		[]byte(password),
+	)
+}
+
+// PasswordNeedsRehash returns true if the hashed password was not produced with the given cost
+func PasswordNeedsRehash(hashedPassword string, cost int) bool {
+	hashCost, err := bcrypt.Cost([]byte(hashedPassword))
+	if err != nil {
+		return true
+	}
+	return hashCost != normalizeCost(cost)
+}
@@ -0,0 +1,93 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestPassword(t *testing.T) {
+	password := RandomString(6)
+
+	hashedPassword1, err := HashPassword(
+		password,
+		bcrypt.MinCost,
+	)
+	require.NoError(
+		t,
+		err,
+	)
+	require.NotEmpty(
+		t,
+		hashedPassword1,
+	)
+
+	err = CheckPassword(
+		password,
+		hashedPassword1,
+	)
+	require.NoError(
+		t,
+		err,
+	)
+
+	wrongPassword := RandomString(6)
+	err = CheckPassword(
+		wrongPassword,
+		hashedPassword1,
+	)
+	require.EqualError(
+		t,
+		err,
+		bcrypt.ErrMismatchedHashAndPassword.Error(),
+	)
+
+	// the same password must produce a different hash because of the salt
+	hashedPassword2, err := HashPassword(
+		password,
+		bcrypt.MinCost,
+	)
+	require.NoError(
+		t,
+		err,
+	)
+	require.NotEqual(
+		t,
+		hashedPassword1,
+		hashedPassword2,
+	)
+}
+
+func TestPasswordNeedsRehash(t *testing.T) {
+	hashedPassword, err := HashPassword(
+		RandomString(6),
+		bcrypt.MinCost,
+	)
+	require.NoError(
+		t,
+		err,
+	)
+
+	require.False(
+		t,
+		PasswordNeedsRehash(
+			hashedPassword,
+			bcrypt.MinCost,
+		),
+	)
+	require.True(
+		t,
+		PasswordNeedsRehash(
+			hashedPassword,
+			bcrypt.MinCost+1,
+		),
+	)
+	require.True(
+		t,
+		PasswordNeedsRehash(
+			"not-a-bcrypt-hash",
+			bcrypt.MinCost,
+		),
+	)
+}
@@ -36,11 +36,10 @@ func RandomMoney() int64 {
 	)
 }
 
-// RandomCurrency generates a random currency code
+// RandomCurrency generates a random code of the default currencies
 func RandomCurrency() string {
-	currencies := []string{"USD", "EUR", "CAD"}
-	n := len(currencies)
-	return currencies[rand.Intn(n)]
+	n := len(DefaultCurrencies)
+	return DefaultCurrencies[rand.Intn(n)]
 }
 
 // RandomEmail generates a random email address
@@ -0,0 +1,9 @@
+package util
+
+// Constants for all supported user roles
+const (
+	DepositorRole = "depositor"
+	TellerRole    = "teller"
+	BankerRole    = "banker"
+	AdminRole     = "admin"
+)